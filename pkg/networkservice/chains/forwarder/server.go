@@ -51,31 +51,76 @@ import (
 	"github.com/networkservicemesh/sdk/pkg/networkservice/common/roundrobin"
 	"github.com/networkservicemesh/sdk/pkg/networkservice/common/switchcase"
 	"github.com/networkservicemesh/sdk/pkg/networkservice/core/chain"
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
 	"github.com/networkservicemesh/sdk/pkg/tools/token"
 
+	"github.com/networkservicemesh/sdk-sriov/pkg/networkservice/common/altname"
+	"github.com/networkservicemesh/sdk-sriov/pkg/networkservice/common/linkstate"
 	"github.com/networkservicemesh/sdk-sriov/pkg/networkservice/common/mechanisms/noop"
 	"github.com/networkservicemesh/sdk-sriov/pkg/networkservice/common/mechanisms/vfio"
+	"github.com/networkservicemesh/sdk-sriov/pkg/networkservice/common/mtu"
+	"github.com/networkservicemesh/sdk-sriov/pkg/networkservice/common/nodelabel"
 	"github.com/networkservicemesh/sdk-sriov/pkg/networkservice/common/resetmechanism"
 	"github.com/networkservicemesh/sdk-sriov/pkg/networkservice/common/resourcepool"
+	"github.com/networkservicemesh/sdk-sriov/pkg/networkservice/common/spoofcheck"
+	"github.com/networkservicemesh/sdk-sriov/pkg/networkservice/common/trafficclass"
 	"github.com/networkservicemesh/sdk-sriov/pkg/sriov"
 	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/config"
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/vfnetlink"
 
 	registryclient "github.com/networkservicemesh/sdk/pkg/registry/chains/client"
 	registryrecvfd "github.com/networkservicemesh/sdk/pkg/registry/common/recvfd"
 	registrysendfd "github.com/networkservicemesh/sdk/pkg/registry/common/sendfd"
 )
 
+// Server is an Endpoint implementing the SR-IOV Forwarder networks service
+type Server interface {
+	endpoint.Endpoint
+
+	// Drain stops accepting new Requests and waits for in-flight VF selections to finish, should be called on
+	// shutdown before the server context is canceled
+	Drain(ctx context.Context) error
+	// ActiveConnections returns VF assignment details of the active connections by connection IDs for debugging
+	ActiveConnections() map[string]resourcepool.ConnectionInfo
+	// Ready returns a channel closed when the PCI and resource pools are ready, Requests fail with
+	// resourcepool.ErrNotReady before, e.g. for the health server
+	Ready() <-chan struct{}
+	// Expire is a resource.ExpireFunc, it gracefully closes the connection using the expired VF, so the client heals
+	// and requests a new one, e.g. it should be passed to resource.WithMaxVFLifetime
+	Expire(tokenID, vfPCIAddr string)
+	// Preempt is a resource.PreemptFunc, it cancels the Request reserving the preempted VF, so the VF is released for
+	// the preempting Request, e.g. it should be passed to resource.WithPreemption
+	Preempt(tokenID, vfPCIAddr string)
+}
+
+// pciWarmer is a pci.Pool interface
+type pciWarmer interface {
+	Warmup(ctx context.Context) error
+}
+
 type sriovServer struct {
 	endpoint.Endpoint
+
+	drainer           *resourcepool.Drainer
+	activeConnections *resourcepool.ActiveConnections
+	readiness         *resourcepool.Readiness
+	expirer           *resourcepool.Expirer
+	preemptor         *resourcepool.Preemptor
 }
 
-// NewServer - returns an Endpoint implementing the SR-IOV Forwarder networks service
+// NewServer - returns a Server implementing the SR-IOV Forwarder networks service
 //   - name - name of the Forwarder
 //   - authzServer - policy for allowing or rejecting requests
 //   - tokenGenerator - token.GeneratorFunc - generates tokens for use in Path
-//   - pciPool - provides PCI functions
-//   - resourcePool - provides SR-IOV resources
-//   - sriovConfig - SR-IOV PCI functions config
+//   - pciPool - provides PCI functions, Requests wait for it to be ready if it implements resourcepool.ReadyReporter,
+//     it is warmed up in background if it implements Warmup(ctx) error, e.g. pci.Pool is ready after the warmup
+//   - resourcePool - provides SR-IOV resources, Requests wait for it the same way as for pciPool, e.g. resource.Pool
+//     is ready when its token.Pool state is settled, Requests from the service domains not allowed to use the
+//     requested driver type fail if it implements resourcepool.TokenNameResourcePool, it is synchronized with the lock
+//     returned by its Locker if it implements resourcepool.LockingResourcePool, e.g. resource.Pool with the lock passed
+//     to resource.WithMaxVFLifetime, or with a forwarder own lock otherwise
+//   - sriovConfig - SR-IOV PCI functions config, the VFIO self-test runs in background when the pools get ready if
+//     VFIOSelfTest is set, VFIO Requests fail if it fails
 //   - vfioDir - host /dev/vfio directory mount location
 //   - cgroupBaseDir - host /sys/fs/cgroup/devices directory mount location
 //   - clientUrl - *url.URL for the talking to the NSMgr
@@ -93,7 +138,7 @@ func NewServer(
 	clientURL *url.URL,
 	dialTimeout time.Duration,
 	clientDialOptions ...grpc.DialOption,
-) endpoint.Endpoint {
+) Server {
 	nseClient := registryclient.NewNetworkServiceEndpointRegistryClient(ctx,
 		registryclient.WithClientURL(clientURL),
 		registryclient.WithNSEAdditionalFunctionality(
@@ -106,21 +151,67 @@ func NewServer(
 		registryclient.WithClientURL(clientURL),
 		registryclient.WithDialOptions(clientDialOptions...))
 
-	rv := new(sriovServer)
+	rv := &sriovServer{
+		drainer:           resourcepool.NewDrainer(),
+		activeConnections: resourcepool.NewActiveConnections(),
+		expirer:           resourcepool.NewExpirer(),
+		preemptor:         resourcepool.NewPreemptor(),
+		readiness: newPoolsReadiness(ctx, map[string]interface{}{
+			"pci":      pciPool,
+			"resource": resourcePool,
+		}),
+	}
 
-	resourceLock := &sync.Mutex{}
+	if warmer, ok := pciPool.(pciWarmer); ok {
+		go func() {
+			if err := warmer.Warmup(ctx); err != nil {
+				log.FromContext(ctx).WithField("sriovServer", "Warmup").Warnf("failed to warm up PCI pool: %v", err)
+			}
+		}()
+	}
+
+	resourceLock := resourcePoolLock(resourcePool)
+	vfConfigurator := vfnetlink.NewConfigurator()
+	resourcePoolOptions := []resourcepool.Option{
+		resourcepool.WithDrainer(rv.drainer),
+		resourcepool.WithActiveConnections(rv.activeConnections),
+		resourcepool.WithReadiness(rv.readiness),
+		resourcepool.WithVFMACSetter(vfConfigurator),
+		resourcepool.WithExpirer(rv.expirer),
+	}
+	// reserved VFs can be preempted by the resource pool until the connection is established
+	if _, ok := resourcePool.(resourcepool.ReservingResourcePool); ok {
+		resourcePoolOptions = append(resourcePoolOptions, resourcepool.WithReservation(),
+			resourcepool.WithPreemptor(rv.preemptor))
+	}
+	kernelServers := []networkservice.NetworkServiceServer{
+		resourcepool.NewServer(sriov.KernelDriver, resourceLock, pciPool, resourcePool, sriovConfig, resourcePoolOptions...),
+		trafficclass.NewServer(vfConfigurator),
+		mtu.NewServer(vfConfigurator),
+		altname.NewServer(vfConfigurator),
+		spoofcheck.NewServer(vfConfigurator, sriovConfig),
+	}
+	if operStateReader, ok := pciPool.(linkstate.OperStateReader); ok {
+		kernelServers = append(kernelServers, linkstate.NewServer(operStateReader))
+	}
+	var vfioOptions []vfio.ServerOption
+	if sriovConfig.VFIOSelfTest {
+		vfioOptions = append(vfioOptions, vfioSelfTestOptions(ctx, pciPool, resourcePool, resourceLock, sriovConfig,
+			rv.readiness.Ready())...)
+	}
 	additionalFunctionality := []networkservice.NetworkServiceServer{
 		recvfd.NewServer(),
 		discover.NewServer(nsClient, nseClient),
 		roundrobin.NewServer(),
+		nodelabel.NewServer("", name),
 		resetmechanism.NewServer(
 			mechanisms.NewServer(map[string]networkservice.NetworkServiceServer{
-				kernel.MECHANISM: chain.NewNetworkServiceServer(
-					resourcepool.NewServer(sriov.KernelDriver, resourceLock, pciPool, resourcePool, sriovConfig),
-				),
+				kernel.MECHANISM: chain.NewNetworkServiceServer(kernelServers...),
 				vfiomech.MECHANISM: chain.NewNetworkServiceServer(
-					resourcepool.NewServer(sriov.VFIOPCIDriver, resourceLock, pciPool, resourcePool, sriovConfig),
-					vfio.NewServer(vfioDir, cgroupBaseDir),
+					resourcepool.NewServer(sriov.VFIOPCIDriver, resourceLock, pciPool, resourcePool, sriovConfig,
+						resourcePoolOptions...),
+					trafficclass.NewServer(vfConfigurator),
+					vfio.NewServer(vfioDir, cgroupBaseDir, vfioOptions...),
 				),
 				noopmech.MECHANISM: null.NewServer(),
 			}),
@@ -162,3 +253,79 @@ func NewServer(
 
 	return rv
 }
+
+func (s *sriovServer) Drain(ctx context.Context) error {
+	return s.drainer.Drain(ctx)
+}
+
+func (s *sriovServer) ActiveConnections() map[string]resourcepool.ConnectionInfo {
+	return s.activeConnections.List()
+}
+
+func (s *sriovServer) Ready() <-chan struct{} {
+	return s.readiness.Ready()
+}
+
+func (s *sriovServer) Expire(tokenID, vfPCIAddr string) {
+	s.expirer.Expire(tokenID, vfPCIAddr)
+}
+
+func (s *sriovServer) Preempt(tokenID, vfPCIAddr string) {
+	s.preemptor.Preempt(tokenID, vfPCIAddr)
+}
+
+// resourcePoolLock returns the lock the resource pool is synchronized with in background if any, or a new lock
+func resourcePoolLock(resourcePool resourcepool.ResourcePool) sync.Locker {
+	if lockingPool, ok := resourcePool.(resourcepool.LockingResourcePool); ok {
+		if lock := lockingPool.Locker(); lock != nil {
+			return lock
+		}
+	}
+	return &sync.Mutex{}
+}
+
+// vfioSelfTestOptions returns vfio.WithVFIOSelfTest option running the self-test when the pools are ready, if the pools
+// support it
+func vfioSelfTestOptions(
+	ctx context.Context,
+	pciPool resourcepool.PCIPool,
+	resourcePool resourcepool.ResourcePool,
+	resourceLock sync.Locker,
+	sriovConfig *config.Config,
+	ready <-chan struct{},
+) []vfio.ServerOption {
+	selfTestPCIPool, ok := pciPool.(vfio.SelfTestPCIPool)
+	if !ok {
+		log.FromContext(ctx).WithField("sriovServer", "vfioSelfTestOptions").Warn("PCI pool doesn't support the VFIO self-test")
+		return nil
+	}
+	return []vfio.ServerOption{
+		vfio.WithVFIOSelfTest(ctx, sriovConfig, selfTestPCIPool, resourcePool, resourceLock, ready),
+	}
+}
+
+// newPoolsReadiness returns a Readiness waiting for the pools implementing resourcepool.ReadyReporter until the ctx is
+// done, the other pools are ready from the start
+func newPoolsReadiness(ctx context.Context, pools map[string]interface{}) *resourcepool.Readiness {
+	reporters := map[string]resourcepool.ReadyReporter{}
+	var names []string
+	for name, pool := range pools {
+		if reporter, ok := pool.(resourcepool.ReadyReporter); ok {
+			reporters[name] = reporter
+			names = append(names, name)
+		}
+	}
+
+	readiness := resourcepool.NewReadiness(names...)
+	for name, reporter := range reporters {
+		go func(name string, readyCh <-chan struct{}) {
+			select {
+			case <-readyCh:
+				readiness.SetReady(name)
+			case <-ctx.Done():
+			}
+		}(name, reporter.Ready())
+	}
+
+	return readiness
+}
@@ -0,0 +1,121 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+// Package altname provides server chain element adding VF net interface alternative name requested in the connection
+// context
+package altname
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/ptypes/empty"
+
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/networkservicemesh/sdk-kernel/pkg/kernel/networkservice/vfconfig"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/next"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/utils/metadata"
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+)
+
+// AltNameKey is a connection context extra context key for the VF net interface alternative name
+const AltNameKey = "sriovAltName"
+
+// VFConfigurator is a vfnetlink.Configurator interface
+type VFConfigurator interface {
+	SetVFAltName(ctx context.Context, vfIfName, altName string) error
+	DelVFAltName(ctx context.Context, vfIfName, altName string) error
+}
+
+type altNameKey struct{}
+
+type altNameServer struct {
+	vfConfigurator VFConfigurator
+}
+
+// NewServer returns a new alternative name server chain element, it should be placed after the resourcepool server
+// and before the VF net interface is moved to the client net namespace
+func NewServer(vfConfigurator VFConfigurator) networkservice.NetworkServiceServer {
+	return &altNameServer{
+		vfConfigurator: vfConfigurator,
+	}
+}
+
+func (s *altNameServer) Request(ctx context.Context, request *networkservice.NetworkServiceRequest) (*networkservice.Connection, error) {
+	vfConfig, ok := vfconfig.Load(ctx, false)
+	if !ok || vfConfig.VFInterfaceName == "" {
+		return next.Server(ctx).Request(ctx, request)
+	}
+
+	value, applied := metadata.Map(ctx, false).Load(altNameKey{})
+	appliedAltName, _ := value.(string)
+
+	switch altName, ok := request.GetConnection().GetContext().GetExtraContext()[AltNameKey]; {
+	case ok && (!applied || altName != appliedAltName):
+		if err := s.vfConfigurator.SetVFAltName(ctx, vfConfig.VFInterfaceName, altName); err != nil {
+			if !applied {
+				return nil, err
+			}
+			// established connection keeps the applied alternative name
+			log.FromContext(ctx).WithField("altNameServer", "Request").
+				Warnf("failed to change VF %v alternative name to %v: %v", vfConfig.VFInterfaceName, altName, err)
+			break
+		}
+		if applied {
+			s.del(ctx, vfConfig, appliedAltName)
+		}
+		metadata.Map(ctx, false).Store(altNameKey{}, altName)
+	case !ok && applied:
+		s.restore(ctx, vfConfig)
+	}
+
+	conn, err := next.Server(ctx).Request(ctx, request)
+	if err != nil {
+		// failed refresh doesn't close the connection, so the alternative name is kept
+		if !applied {
+			s.restore(ctx, vfConfig)
+		}
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+func (s *altNameServer) Close(ctx context.Context, conn *networkservice.Connection) (*empty.Empty, error) {
+	rv, err := next.Server(ctx).Close(ctx, conn)
+
+	// VF net interface should be returned to the host net namespace by the next chain elements
+	if vfConfig, ok := vfconfig.Load(ctx, false); ok && vfConfig.VFInterfaceName != "" {
+		s.restore(ctx, vfConfig)
+	}
+
+	return rv, err
+}
+
+func (s *altNameServer) restore(ctx context.Context, vfConfig *vfconfig.VFConfig) {
+	if value, ok := metadata.Map(ctx, false).LoadAndDelete(altNameKey{}); ok {
+		s.del(ctx, vfConfig, value.(string))
+	}
+}
+
+func (s *altNameServer) del(ctx context.Context, vfConfig *vfconfig.VFConfig, altName string) {
+	if err := s.vfConfigurator.DelVFAltName(ctx, vfConfig.VFInterfaceName, altName); err != nil {
+		log.FromContext(ctx).WithField("altNameServer", "del").
+			Warnf("failed to delete VF %v alternative name %v: %v", vfConfig.VFInterfaceName, altName, err)
+	}
+}
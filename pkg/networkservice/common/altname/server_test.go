@@ -0,0 +1,143 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package altname_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/networkservicemesh/sdk-kernel/pkg/kernel/networkservice/vfconfig"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/utils/inject/injecterror"
+
+	"github.com/networkservicemesh/sdk-sriov/pkg/networkservice/common/altname"
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/sriovtest"
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/vfnetlink"
+)
+
+const (
+	vfIfName = "vf"
+	altName  = "nsm-conn-1"
+)
+
+func newServer(t *testing.T, handle *sriovtest.NetlinkHandle, additionalFunctionality ...networkservice.NetworkServiceServer) networkservice.NetworkServiceServer {
+	return sriovtest.NewVFServer(t, &vfconfig.VFConfig{
+		VFInterfaceName: vfIfName,
+	}, altname.NewServer(vfnetlink.NewConfigurator(vfnetlink.WithHandle(handle))), additionalFunctionality...)
+}
+
+func newRequest(name string) *networkservice.NetworkServiceRequest {
+	return sriovtest.NewExtraContextRequest(map[string]string{
+		altname.AltNameKey: name,
+	})
+}
+
+func altNames(t *testing.T, handle *sriovtest.NetlinkHandle) []string {
+	link, err := handle.LinkByName(vfIfName)
+	require.NoError(t, err)
+	return link.Attrs().AltNames
+}
+
+func TestAltNameServer(t *testing.T) {
+	handle := sriovtest.NewNetlinkHandle()
+	handle.AddLink(vfIfName, sriovtest.DefaultMTU)
+
+	server := newServer(t, handle)
+
+	conn, err := server.Request(context.Background(), newRequest(altName))
+	require.NoError(t, err)
+	require.Equal(t, []string{altName}, altNames(t, handle))
+
+	_, err = server.Close(context.Background(), conn)
+	require.NoError(t, err)
+	require.Empty(t, altNames(t, handle))
+}
+
+func TestAltNameServer_RequestFailed(t *testing.T) {
+	handle := sriovtest.NewNetlinkHandle()
+	handle.AddLink(vfIfName, sriovtest.DefaultMTU)
+
+	server := newServer(t, handle, injecterror.NewServer(injecterror.WithError(errors.New("error"))))
+
+	_, err := server.Request(context.Background(), newRequest(altName))
+	require.Error(t, err)
+	require.Empty(t, altNames(t, handle))
+}
+
+func TestAltNameServer_InvalidAltName(t *testing.T) {
+	handle := sriovtest.NewNetlinkHandle()
+	handle.AddLink(vfIfName, sriovtest.DefaultMTU)
+
+	server := newServer(t, handle)
+
+	_, err := server.Request(context.Background(), newRequest("nsm/conn"))
+	require.ErrorIs(t, err, vfnetlink.ErrInvalidAltName)
+	require.Empty(t, altNames(t, handle))
+}
+
+func TestAltNameServer_Refresh(t *testing.T) {
+	handle := sriovtest.NewNetlinkHandle()
+	handle.AddLink(vfIfName, sriovtest.DefaultMTU)
+
+	server := newServer(t, handle)
+
+	_, err := server.Request(context.Background(), newRequest(altName))
+	require.NoError(t, err)
+
+	_, err = server.Request(context.Background(), newRequest(altName))
+	require.NoError(t, err)
+	require.Equal(t, []string{altName}, altNames(t, handle))
+
+	conn, err := server.Request(context.Background(), newRequest("nsm-conn-2"))
+	require.NoError(t, err)
+	require.Equal(t, []string{"nsm-conn-2"}, altNames(t, handle))
+
+	_, err = server.Close(context.Background(), conn)
+	require.NoError(t, err)
+	require.Empty(t, altNames(t, handle))
+}
+
+func TestAltNameServer_RefreshFailed(t *testing.T) {
+	handle := sriovtest.NewNetlinkHandle()
+	handle.AddLink(vfIfName, sriovtest.DefaultMTU)
+
+	server := newServer(t, handle, injecterror.NewServer(
+		injecterror.WithRequestErrorTimes(1),
+		injecterror.WithCloseErrorTimes(),
+	))
+
+	conn, err := server.Request(context.Background(), newRequest(altName))
+	require.NoError(t, err)
+
+	_, err = server.Request(context.Background(), newRequest(altName))
+	require.Error(t, err)
+	require.Equal(t, []string{altName}, altNames(t, handle))
+
+	_, err = server.Request(context.Background(), newRequest("nsm/conn"))
+	require.NoError(t, err)
+	require.Equal(t, []string{altName}, altNames(t, handle))
+
+	_, err = server.Close(context.Background(), conn)
+	require.NoError(t, err)
+	require.Empty(t, altNames(t, handle))
+}
@@ -0,0 +1,48 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package linkstate
+
+import "time"
+
+// Option is an option for NewServer
+type Option func(s *linkStateServer)
+
+// WithWaitUp makes server wait up to the timeout for the VF link to get up before reporting its state
+func WithWaitUp(timeout time.Duration) Option {
+	return func(s *linkStateServer) {
+		s.waitUpTimeout = timeout
+	}
+}
+
+// WithStrict makes server confirm that the VF link is up in the client net namespace within the timeout after the
+// Request has passed the rest of the chain, otherwise the connection is closed and the Request fails with ErrLinkNotUp
+func WithStrict(timeout time.Duration) Option {
+	return func(s *linkStateServer) {
+		s.strictTimeout = timeout
+	}
+}
+
+// WithNetNSOperStateReader sets reader for the VF link operational state in the client net namespace, netlink is
+// used by default
+func WithNetNSOperStateReader(reader NetNSOperStateReader) Option {
+	return func(s *linkStateServer) {
+		s.netNSOperStateReader = reader
+	}
+}
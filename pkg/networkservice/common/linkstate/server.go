@@ -0,0 +1,138 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+// Package linkstate provides server chain element reporting VF link state in the connection context
+package linkstate
+
+import (
+	"context"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/empty"
+
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/common"
+	"github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/kernel"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/next"
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+	"github.com/networkservicemesh/sdk/pkg/tools/postpone"
+)
+
+const (
+	// LinkStateKey is a connection context extra context key for the VF link operational state
+	LinkStateKey = "sriovLinkState"
+	// OperStateUp is the "up" link operational state
+	OperStateUp = "up"
+
+	waitUpChecks = 10
+)
+
+// OperStateReader is a pci.Pool interface
+type OperStateReader interface {
+	GetOperState(pciAddr string) (string, error)
+}
+
+type linkStateServer struct {
+	operStateReader      OperStateReader
+	waitUpTimeout        time.Duration
+	strictTimeout        time.Duration
+	netNSOperStateReader NetNSOperStateReader
+}
+
+// NewServer returns a new link state server chain element, it should be placed after the resourcepool server in the
+// kernel mechanism chain
+func NewServer(operStateReader OperStateReader, options ...Option) networkservice.NetworkServiceServer {
+	s := &linkStateServer{
+		operStateReader:      operStateReader,
+		netNSOperStateReader: netlinkOperStateReader{},
+	}
+	for _, opt := range options {
+		opt(s)
+	}
+	return s
+}
+
+func (s *linkStateServer) Request(ctx context.Context, request *networkservice.NetworkServiceRequest) (*networkservice.Connection, error) {
+	conn := request.GetConnection()
+	if mech := kernel.ToMechanism(conn.GetMechanism()); mech != nil {
+		if pciAddr, ok := mech.GetParameters()[common.PCIAddressKey]; ok {
+			s.reportLinkState(ctx, conn, pciAddr)
+		}
+	}
+
+	if s.strictTimeout == 0 {
+		return next.Server(ctx).Request(ctx, request)
+	}
+
+	postponeCtxFunc := postpone.ContextWithValues(ctx)
+
+	conn, err := next.Server(ctx).Request(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.confirmUp(ctx, conn, postponeCtxFunc); err != nil {
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+func (s *linkStateServer) Close(ctx context.Context, conn *networkservice.Connection) (*empty.Empty, error) {
+	return next.Server(ctx).Close(ctx, conn)
+}
+
+func (s *linkStateServer) reportLinkState(ctx context.Context, conn *networkservice.Connection, pciAddr string) {
+	logger := log.FromContext(ctx).WithField("linkStateServer", "reportLinkState")
+
+	operState, err := s.waitUp(ctx, pciAddr)
+	if err != nil {
+		logger.Warnf("failed to get VF %v link state: %v", pciAddr, err)
+		return
+	}
+	if operState != OperStateUp {
+		logger.Warnf("VF %v link is not up: %v", pciAddr, operState)
+	}
+
+	if conn.GetContext() == nil {
+		conn.Context = new(networkservice.ConnectionContext)
+	}
+	if conn.GetContext().GetExtraContext() == nil {
+		conn.GetContext().ExtraContext = map[string]string{}
+	}
+	conn.GetContext().GetExtraContext()[LinkStateKey] = operState
+}
+
+func (s *linkStateServer) waitUp(ctx context.Context, pciAddr string) (string, error) {
+	timeoutCh := time.After(s.waitUpTimeout)
+	for {
+		operState, err := s.operStateReader.GetOperState(pciAddr)
+		if err != nil || operState == OperStateUp || s.waitUpTimeout == 0 {
+			return operState, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return operState, nil
+		case <-timeoutCh:
+			return operState, nil
+		case <-time.After(s.waitUpTimeout / waitUpChecks):
+		}
+	}
+}
@@ -0,0 +1,158 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package linkstate_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/common"
+	"github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/kernel"
+	"github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/vfio"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/chain"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/next"
+
+	"github.com/networkservicemesh/sdk-sriov/pkg/networkservice/common/linkstate"
+)
+
+const vfPCIAddr = "0000:01:00.1"
+
+type operStateReaderStub struct {
+	lock   sync.Mutex
+	states []string
+	calls  int
+}
+
+func (r *operStateReaderStub) GetOperState(_ string) (string, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	state := r.states[r.calls]
+	if r.calls < len(r.states)-1 {
+		r.calls++
+	}
+	return state, nil
+}
+
+func newRequest(mechanism string) *networkservice.NetworkServiceRequest {
+	return &networkservice.NetworkServiceRequest{
+		Connection: &networkservice.Connection{
+			Id: "id",
+			Mechanism: &networkservice.Mechanism{
+				Type: mechanism,
+				Parameters: map[string]string{
+					common.PCIAddressKey: vfPCIAddr,
+				},
+			},
+		},
+	}
+}
+
+func TestLinkStateServer(t *testing.T) {
+	server := linkstate.NewServer(&operStateReaderStub{states: []string{"down"}})
+
+	conn, err := server.Request(context.Background(), newRequest(kernel.MECHANISM))
+	require.NoError(t, err)
+	require.Equal(t, "down", conn.GetContext().GetExtraContext()[linkstate.LinkStateKey])
+}
+
+func TestLinkStateServer_WaitUp(t *testing.T) {
+	reader := &operStateReaderStub{states: []string{"down", "down", linkstate.OperStateUp}}
+	server := linkstate.NewServer(reader, linkstate.WithWaitUp(time.Second))
+
+	conn, err := server.Request(context.Background(), newRequest(kernel.MECHANISM))
+	require.NoError(t, err)
+	require.Equal(t, linkstate.OperStateUp, conn.GetContext().GetExtraContext()[linkstate.LinkStateKey])
+	require.Equal(t, 2, reader.calls)
+}
+
+func TestLinkStateServer_WaitUp_Timeout(t *testing.T) {
+	server := linkstate.NewServer(&operStateReaderStub{states: []string{"down"}}, linkstate.WithWaitUp(50*time.Millisecond))
+
+	conn, err := server.Request(context.Background(), newRequest(kernel.MECHANISM))
+	require.NoError(t, err)
+	require.Equal(t, "down", conn.GetContext().GetExtraContext()[linkstate.LinkStateKey])
+}
+
+func TestLinkStateServer_VFIO(t *testing.T) {
+	server := linkstate.NewServer(&operStateReaderStub{states: []string{linkstate.OperStateUp}})
+
+	conn, err := server.Request(context.Background(), newRequest(vfio.MECHANISM))
+	require.NoError(t, err)
+	require.NotContains(t, conn.GetContext().GetExtraContext(), linkstate.LinkStateKey)
+}
+
+type netNSOperStateReaderStub struct {
+	operStateReaderStub
+}
+
+func (r *netNSOperStateReaderStub) GetNetNSOperState(_, _ string) (string, error) {
+	return r.GetOperState("")
+}
+
+type closeCounterServer struct {
+	closes int
+}
+
+func (s *closeCounterServer) Request(ctx context.Context, request *networkservice.NetworkServiceRequest) (*networkservice.Connection, error) {
+	return next.Server(ctx).Request(ctx, request)
+}
+
+func (s *closeCounterServer) Close(ctx context.Context, conn *networkservice.Connection) (*empty.Empty, error) {
+	s.closes++
+	return next.Server(ctx).Close(ctx, conn)
+}
+
+func TestLinkStateServer_Strict(t *testing.T) {
+	reader := &netNSOperStateReaderStub{operStateReaderStub{states: []string{"down", linkstate.OperStateUp}}}
+	counter := new(closeCounterServer)
+	server := chain.NewNetworkServiceServer(
+		linkstate.NewServer(&operStateReaderStub{states: []string{"down"}},
+			linkstate.WithStrict(time.Second),
+			linkstate.WithNetNSOperStateReader(reader)),
+		counter,
+	)
+
+	_, err := server.Request(context.Background(), newRequest(kernel.MECHANISM))
+	require.NoError(t, err)
+	require.Equal(t, 1, reader.calls)
+	require.Equal(t, 0, counter.closes)
+}
+
+func TestLinkStateServer_Strict_NeverUp(t *testing.T) {
+	reader := &netNSOperStateReaderStub{operStateReaderStub{states: []string{"down"}}}
+	counter := new(closeCounterServer)
+	server := chain.NewNetworkServiceServer(
+		linkstate.NewServer(&operStateReaderStub{states: []string{"down"}},
+			linkstate.WithStrict(50*time.Millisecond),
+			linkstate.WithNetNSOperStateReader(reader)),
+		counter,
+	)
+
+	_, err := server.Request(context.Background(), newRequest(kernel.MECHANISM))
+	require.ErrorIs(t, err, linkstate.ErrLinkNotUp)
+	require.Equal(t, 1, counter.closes)
+}
@@ -0,0 +1,110 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package linkstate
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/vishvananda/netlink"
+
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/kernel"
+	"github.com/networkservicemesh/sdk-kernel/pkg/kernel/tools/nshandle"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/next"
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+)
+
+// ErrLinkNotUp is returned by the strict server when the VF link doesn't get up in the client net namespace in time
+var ErrLinkNotUp = errors.New("VF link is not up")
+
+// NetNSOperStateReader reads net interface operational state in the net namespace
+type NetNSOperStateReader interface {
+	GetNetNSOperState(netNSURL, ifName string) (string, error)
+}
+
+type netlinkOperStateReader struct{}
+
+func (netlinkOperStateReader) GetNetNSOperState(netNSURL, ifName string) (string, error) {
+	nsHandle, err := nshandle.FromURL(netNSURL)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get net namespace: %v", netNSURL)
+	}
+	defer func() { _ = nsHandle.Close() }()
+
+	handle, err := netlink.NewHandleAt(nsHandle)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to create netlink handle for the net namespace: %v", netNSURL)
+	}
+	defer handle.Close()
+
+	link, err := handle.LinkByName(ifName)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get link %v in the net namespace: %v", ifName, netNSURL)
+	}
+
+	return link.Attrs().OperState.String(), nil
+}
+
+// confirmUp waits for the VF link to get up in the client net namespace, closes the connection and returns
+// ErrLinkNotUp if it doesn't get up in time
+func (s *linkStateServer) confirmUp(ctx context.Context, conn *networkservice.Connection, closeCtxFunc func() (context.Context, context.CancelFunc)) error {
+	mech := kernel.ToMechanism(conn.GetMechanism())
+	if mech == nil {
+		return nil
+	}
+
+	operState, err := s.waitNetNSUp(ctx, mech.GetNetNSURL(), mech.GetInterfaceName())
+	if err == nil && operState == OperStateUp {
+		return nil
+	}
+
+	closeCtx, cancelClose := closeCtxFunc()
+	defer cancelClose()
+
+	if _, closeErr := next.Server(ctx).Close(closeCtx, conn); closeErr != nil {
+		log.FromContext(ctx).WithField("linkStateServer", "confirmUp").
+			Warnf("failed to close connection: %v", closeErr)
+	}
+
+	if err != nil {
+		return errors.Wrapf(ErrLinkNotUp, "%v: %v", mech.GetInterfaceName(), err.Error())
+	}
+	return errors.Wrapf(ErrLinkNotUp, "%v: %v", mech.GetInterfaceName(), operState)
+}
+
+func (s *linkStateServer) waitNetNSUp(ctx context.Context, netNSURL, ifName string) (operState string, err error) {
+	timeoutCh := time.After(s.strictTimeout)
+	for {
+		operState, err = s.netNSOperStateReader.GetNetNSOperState(netNSURL, ifName)
+		if err == nil && operState == OperStateUp {
+			return operState, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return operState, err
+		case <-timeoutCh:
+			return operState, err
+		case <-time.After(s.strictTimeout / waitUpChecks):
+		}
+	}
+}
@@ -0,0 +1,47 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !windows
+// +build !windows
+
+package vfio
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// CleanupOrphanNodes removes IOMMU group device nodes from the vfioDir for the groups not present in liveGroups,
+// the VFIO control device node is never removed
+func CleanupOrphanNodes(vfioDir string, liveGroups map[string]bool) error {
+	entries, err := os.ReadDir(vfioDir)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read VFIO directory: %v", vfioDir)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || entry.Name() == vfioDevice || liveGroups[entry.Name()] {
+			continue
+		}
+		if err = os.Remove(filepath.Join(vfioDir, entry.Name())); err != nil && !os.IsNotExist(err) {
+			return errors.Wrapf(err, "failed to remove orphan VFIO device node: %v", entry.Name())
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,52 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !windows
+// +build !windows
+
+package vfio_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/sdk-sriov/pkg/networkservice/common/mechanisms/vfio"
+)
+
+func TestCleanupOrphanNodes(t *testing.T) {
+	vfioDir := t.TempDir()
+	for _, name := range []string{vfioDevice, "1", "2", "3"} {
+		require.NoError(t, os.WriteFile(filepath.Join(vfioDir, name), nil, 0o600))
+	}
+
+	require.NoError(t, vfio.CleanupOrphanNodes(vfioDir, map[string]bool{"2": true}))
+
+	entries, err := os.ReadDir(vfioDir)
+	require.NoError(t, err)
+
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	require.ElementsMatch(t, []string{vfioDevice, "2"}, names)
+}
+
+func TestCleanupOrphanNodes_NoDir(t *testing.T) {
+	require.Error(t, vfio.CleanupOrphanNodes(filepath.Join(t.TempDir(), "missing"), nil))
+}
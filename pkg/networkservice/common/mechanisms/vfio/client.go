@@ -41,8 +41,9 @@ import (
 )
 
 type vfioClient struct {
-	vfioDir   string
-	cgroupDir string
+	vfioDir        string
+	cgroupDir      string
+	groupNodeNamer func(group string) string
 }
 
 const (
@@ -54,6 +55,9 @@ const (
 func NewClient(options ...Option) networkservice.NetworkServiceClient {
 	c := &vfioClient{
 		vfioDir: "/dev/vfio",
+		groupNodeNamer: func(group string) string {
+			return group
+		},
 	}
 
 	for _, option := range options {
@@ -104,14 +108,14 @@ func (c *vfioClient) Request(ctx context.Context, request *networkservice.Networ
 			return nil, errors.Wrapf(err, "failed to mknod device: %v", vfioDevice)
 		}
 
-		igid := mech.GetParameters()[vfio.IommuGroupKey]
+		groupNode := c.groupNodeNamer(mech.GetParameters()[vfio.IommuGroupKey])
 		if err := unix.Mknod(
-			filepath.Join(c.vfioDir, igid),
+			filepath.Join(c.vfioDir, groupNode),
 			unix.S_IFCHR|mknodPerm,
 			int(unix.Mkdev(mech.GetDeviceMajor(), mech.GetDeviceMinor())),
 		); err != nil && !os.IsExist(err) {
-			logger.Errorf("failed to mknod device: %v", vfioDevice)
-			return nil, errors.Wrapf(err, "failed to mknod device: %v", vfioDevice)
+			logger.Errorf("failed to mknod device: %v", groupNode)
+			return nil, errors.Wrapf(err, "failed to mknod device: %v", groupNode)
 		}
 	}
 
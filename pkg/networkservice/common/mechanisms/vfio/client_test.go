@@ -116,6 +116,50 @@ func TestVFIOClient_RequestPerm(t *testing.T) {
 	require.NoError(t, ctx.Err())
 }
 
+func TestVFIOClient_GroupNodeNamerPerm(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 10*time.Second)
+	defer cancel()
+
+	tmpDir := filepath.Join(os.TempDir(), t.Name())
+	err := os.MkdirAll(tmpDir, 0o750)
+	require.NoError(t, err)
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	cc, err := testServer(ctx, tmpDir)
+	require.NoError(t, err)
+	defer func() { _ = cc.Close() }()
+
+	client := chain.NewNetworkServiceClient(
+		vfio.NewClient(
+			vfio.WithVFIODir(tmpDir),
+			vfio.WithCgroupDir(cgroupDir),
+			vfio.WithGroupNodeNamer(func(group string) string {
+				return "group-" + group
+			}),
+		),
+		networkservice.NewNetworkServiceClient(cc),
+	)
+
+	_, err = client.Request(ctx, &networkservice.NetworkServiceRequest{
+		Connection: &networkservice.Connection{},
+	})
+	require.NoError(t, err)
+
+	info := new(unix.Stat_t)
+
+	err = unix.Stat(filepath.Join(tmpDir, "group-"+iommuGroupString), info)
+	require.NoError(t, err)
+	require.Equal(t, uint32(3), vfio.Major(info.Rdev))
+	require.Equal(t, uint32(4), vfio.Minor(info.Rdev))
+
+	_, err = os.Stat(filepath.Join(tmpDir, iommuGroupString))
+	require.True(t, os.IsNotExist(err))
+
+	require.NoError(t, ctx.Err())
+}
+
 type vfioForwarderStub struct {
 	iommuGroup  uint
 	vfioMajor   uint32
@@ -19,6 +19,15 @@
 
 package vfio
 
+import (
+	"context"
+	"strconv"
+	"sync"
+
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/config"
+	"github.com/networkservicemesh/sdk-sriov/pkg/tools/cgroup"
+)
+
 // Option is an option for NewClient
 type Option func(c *vfioClient)
 
@@ -35,3 +44,64 @@ func WithCgroupDir(cgroupDir string) Option {
 		c.cgroupDir = cgroupDir
 	}
 }
+
+// WithGroupNodeNamer sets the function used by vfioClient to get the IOMMU group device node file name in vfioDir
+// from the IOMMU group, default is the IOMMU group itself
+func WithGroupNodeNamer(namer func(group string) string) Option {
+	return func(c *vfioClient) {
+		c.groupNodeNamer = namer
+	}
+}
+
+// ServerOption is an option for NewServer
+type ServerOption func(s *vfioServer)
+
+// WithDeviceAllowlist restricts device numbers vfioServer may allow for the client cgroups to the given ranges
+func WithDeviceAllowlist(ranges ...DeviceRange) ServerOption {
+	return func(s *vfioServer) {
+		s.deviceAllowlist = ranges
+	}
+}
+
+// WithEnsureGroupNode makes vfioServer create the IOMMU group device node in vfioDir if the kernel hasn't created it
+// yet, it is done only for the given IOMMU groups managed by the forwarder and only if the group is bound to vfio-pci
+func WithEnsureGroupNode(iommuGroups ...uint) ServerOption {
+	return func(s *vfioServer) {
+		s.managedGroups = map[string]struct{}{}
+		for _, iommuGroup := range iommuGroups {
+			s.managedGroups[strconv.FormatUint(uint64(iommuGroup), 10)] = struct{}{}
+		}
+	}
+}
+
+// WithDeviceModes sets the device modes vfioServer allows for the client cgroups, modes should be a non-empty subset of
+// "rwm", default is "rwm". NewServer returns a server failing all requests if modes are invalid.
+func WithDeviceModes(modes string) ServerOption {
+	return func(s *vfioServer) {
+		s.deviceModes = modes
+		s.deviceModesErr = cgroup.ValidateDeviceModes(modes)
+	}
+}
+
+// WithVFIOClassDir sets vfioServer vfioClassDir used to get the IOMMU group device numbers, default is /sys/class/vfio
+func WithVFIOClassDir(vfioClassDir string) ServerOption {
+	return func(s *vfioServer) {
+		s.vfioClassDir = vfioClassDir
+	}
+}
+
+// WithVFIOSelfTest makes NewServer run SelfTest for the VFs from cfg in background on startup, the VFIO mechanism
+// Requests fail if the self-test fails:
+//   - resourceLock - if set, it is held during the test, so the tested VF can't be selected by the resource pool
+//   - ready - if set, the test waits for it to be closed, e.g. for the pools to get ready
+func WithVFIOSelfTest(ctx context.Context, cfg *config.Config, pciPool SelfTestPCIPool, resourcePool SelfTestResourcePool,
+	resourceLock sync.Locker, ready <-chan struct{}) ServerOption {
+	return func(s *vfioServer) {
+		s.selfTestCtx = ctx
+		s.selfTestConfig = cfg
+		s.selfTestPCIPool = pciPool
+		s.selfTestResourcePool = resourcePool
+		s.selfTestLock = resourceLock
+		s.selfTestReady = ready
+	}
+}
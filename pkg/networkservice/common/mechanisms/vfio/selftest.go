@@ -0,0 +1,186 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !windows
+// +build !windows
+
+package vfio
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sys/unix"
+
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov"
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/config"
+	"github.com/networkservicemesh/sdk-sriov/pkg/tools/cgroup"
+)
+
+const (
+	// SelfTestCgroupName is a name of the throwaway cgroup created in the cgroup base directory by the self-test
+	SelfTestCgroupName = "nsm-vfio-self-test"
+
+	selfTestAllowTimeout = time.Second
+	selfTestAllowTick    = 10 * time.Millisecond
+)
+
+// ErrNoSpareVF is returned by SelfTest when there is no free VF to run the test on
+var ErrNoSpareVF = errors.New("no spare VF for the VFIO self-test")
+
+// SelfTestPCIPool is a PCI pool used by SelfTest to get and bind the drivers
+type SelfTestPCIPool interface {
+	GetBoundDriver(pciAddr string) (string, error)
+	BindDriver(ctx context.Context, iommuGroup uint, driverType sriov.DriverType) error
+}
+
+// SelfTestResourcePool is a resource pool used by SelfTest to find a spare VF
+type SelfTestResourcePool interface {
+	IsIOMMUGroupFree(iommuGroup uint) bool
+}
+
+// SelfTest verifies VFIO passthrough end-to-end: it picks a spare VF, binds its IOMMU group to the vfio-pci driver,
+// checks that the group device node appears in vfioDir and can be allowed in a throwaway cgroup created in
+// cgroupBaseDir, then binds the group back to the driver type it had before the test
+func SelfTest(ctx context.Context, cfg *config.Config, pciPool SelfTestPCIPool, resourcePool SelfTestResourcePool,
+	vfioDir, cgroupBaseDir string) (err error) {
+	vfPCIAddr, iommuGroup, ok := spareVF(cfg, resourcePool)
+	if !ok {
+		return errors.WithStack(ErrNoSpareVF)
+	}
+
+	prevDriver, err := pciPool.GetBoundDriver(vfPCIAddr)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get the bound driver for the VF %s", vfPCIAddr)
+	}
+	prevDriverType := sriov.KernelDriver
+	if prevDriver == string(sriov.VFIOPCIDriver) {
+		prevDriverType = sriov.VFIOPCIDriver
+	}
+
+	defer func() {
+		if bindErr := pciPool.BindDriver(ctx, iommuGroup, prevDriverType); bindErr != nil && err == nil {
+			err = errors.Wrapf(bindErr, "failed to restore the %v driver for the IOMMU group %d", prevDriverType, iommuGroup)
+		}
+	}()
+
+	if err = pciPool.BindDriver(ctx, iommuGroup, sriov.VFIOPCIDriver); err != nil {
+		return errors.Wrapf(err, "failed to bind the IOMMU group %d to the VFIO driver", iommuGroup)
+	}
+
+	groupNode := filepath.Join(vfioDir, strconv.FormatUint(uint64(iommuGroup), 10))
+	info := new(unix.Stat_t)
+	if err = unix.Stat(groupNode, info); err != nil {
+		return errors.Wrapf(err, "VFIO group node is not available: %s", groupNode)
+	}
+
+	return selfTestCgroup(ctx, filepath.Join(cgroupBaseDir, SelfTestCgroupName), Major(info.Rdev), Minor(info.Rdev))
+}
+
+func spareVF(cfg *config.Config, resourcePool SelfTestResourcePool) (vfPCIAddr string, iommuGroup uint, ok bool) {
+	var vfCfgs []*config.VirtualFunction
+	for _, pfCfg := range cfg.PhysicalFunctions {
+		vfCfgs = append(vfCfgs, pfCfg.VirtualFunctions...)
+	}
+	sort.Slice(vfCfgs, func(i, k int) bool { return vfCfgs[i].IOMMUGroup < vfCfgs[k].IOMMUGroup })
+
+	for _, vfCfg := range vfCfgs {
+		if resourcePool.IsIOMMUGroupFree(vfCfg.IOMMUGroup) {
+			return vfCfg.Address, vfCfg.IOMMUGroup, true
+		}
+	}
+	return "", 0, false
+}
+
+func selfTestCgroup(ctx context.Context, cgroupDir string, major, minor uint32) error {
+	switch err := os.Mkdir(cgroupDir, 0o750); {
+	case err == nil:
+		defer func() { _ = os.Remove(cgroupDir) }()
+	case !os.IsExist(err):
+		return errors.Wrapf(err, "failed to create the self-test cgroup: %s", cgroupDir)
+	}
+
+	cgroups, err := cgroup.NewCgroups(cgroupDir)
+	if err != nil || len(cgroups) == 0 {
+		return errors.Wrapf(err, "no cgroupDir found: %s", cgroupDir)
+	}
+	cg := cgroups[0]
+
+	if err := cg.Allow(major, minor); err != nil {
+		return err
+	}
+	defer func() { _ = cg.Deny(major, minor) }()
+
+	ctx, cancel := context.WithTimeout(ctx, selfTestAllowTimeout)
+	defer cancel()
+
+	for {
+		isAllowed, err := cg.IsAllowed(major, minor)
+		if err != nil {
+			return err
+		}
+		if isAllowed {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return errors.Errorf("VFIO group node %d:%d is not allowed in the self-test cgroup: %s", major, minor, cgroupDir)
+		case <-time.After(selfTestAllowTick):
+		}
+	}
+}
+
+func (s *vfioServer) runSelfTest() {
+	ctx := s.selfTestCtx
+	if s.selfTestReady != nil {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.selfTestReady:
+		}
+	}
+
+	if s.selfTestLock != nil {
+		s.selfTestLock.Lock()
+		defer s.selfTestLock.Unlock()
+	}
+
+	err := SelfTest(ctx, s.selfTestConfig, s.selfTestPCIPool, s.selfTestResourcePool, s.vfioDir, s.cgroupBaseDir)
+	if err != nil {
+		log.FromContext(ctx).Errorf("VFIO self-test failed: %+v", err)
+
+		s.lock.Lock()
+		s.selfTestErr = errors.Wrap(err, "VFIO self-test failed")
+		s.lock.Unlock()
+		return
+	}
+	log.FromContext(ctx).Info("VFIO self-test passed")
+}
+
+func (s *vfioServer) getSelfTestErr() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.selfTestErr
+}
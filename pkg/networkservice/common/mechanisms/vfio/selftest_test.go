@@ -0,0 +1,176 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package vfio_test
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/cls"
+	vfiomech "github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/vfio"
+
+	"github.com/networkservicemesh/sdk-sriov/pkg/networkservice/common/mechanisms/vfio"
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/config"
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/pci"
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/sriovtest"
+	"github.com/networkservicemesh/sdk-sriov/pkg/tools/cgroup"
+)
+
+const (
+	selfTestPFPCIAddr = "0000:00:01.0"
+	selfTestVFPCIAddr = "0000:00:01.1"
+	selfTestGroup     = 5
+)
+
+type freeGroupsStub map[uint]bool
+
+func (s freeGroupsStub) IsIOMMUGroupFree(iommuGroup uint) bool {
+	return s[iommuGroup]
+}
+
+func selfTestFunctions() (map[string]*sriovtest.PCIPhysicalFunction, *config.Config) {
+	pfs := map[string]*sriovtest.PCIPhysicalFunction{
+		selfTestPFPCIAddr: {
+			PCIFunction: sriovtest.PCIFunction{Addr: selfTestPFPCIAddr, IfName: "pf", IOMMUGroup: 1, Driver: "pf-driver"},
+			Vfs: []*sriovtest.PCIFunction{
+				{Addr: selfTestVFPCIAddr, IfName: "vf", IOMMUGroup: selfTestGroup, Driver: "vf-driver"},
+			},
+		},
+	}
+	cfg := &config.Config{
+		PhysicalFunctions: map[string]*config.PhysicalFunction{
+			selfTestPFPCIAddr: {
+				PFKernelDriver: "pf-driver",
+				VFKernelDriver: "vf-driver",
+				VirtualFunctions: []*config.VirtualFunction{
+					{Address: selfTestVFPCIAddr, IOMMUGroup: selfTestGroup},
+				},
+			},
+		},
+	}
+	return pfs, cfg
+}
+
+func TestSelfTest_Pass(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	vfioDir := t.TempDir()
+	require.NoError(t, unix.Mknod(filepath.Join(vfioDir, "5"), unix.S_IFCHR|0o666, int(unix.Mkdev(3, 4))))
+
+	cgroupBaseDir := t.TempDir()
+	_, err := cgroup.NewFakeCgroup(ctx, filepath.Join(cgroupBaseDir, vfio.SelfTestCgroupName))
+	require.NoError(t, err)
+
+	pfs, cfg := selfTestFunctions()
+	pciPool, err := pci.NewSimulatedPool(pfs, cfg)
+	require.NoError(t, err)
+
+	require.NoError(t, vfio.SelfTest(ctx, cfg, pciPool, freeGroupsStub{selfTestGroup: true}, vfioDir, cgroupBaseDir))
+
+	require.Equal(t, "vf-driver", pfs[selfTestPFPCIAddr].Vfs[0].Driver)
+}
+
+func TestSelfTest_Fail(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	pfs, cfg := selfTestFunctions()
+
+	// 1. No spare VF
+
+	pciPool, err := pci.NewSimulatedPool(pfs, cfg)
+	require.NoError(t, err)
+
+	err = vfio.SelfTest(ctx, cfg, pciPool, freeGroupsStub{}, t.TempDir(), t.TempDir())
+	require.ErrorIs(t, err, vfio.ErrNoSpareVF)
+
+	// 2. VFIO group node never appears, IOMMU group is bound back to the kernel driver
+
+	pciPool, err = pci.NewSimulatedPool(pfs, cfg, pci.WithoutVFIOGroupNode(selfTestGroup), pci.WithBindTimeout(50*time.Millisecond))
+	require.NoError(t, err)
+
+	err = vfio.SelfTest(ctx, cfg, pciPool, freeGroupsStub{selfTestGroup: true}, t.TempDir(), t.TempDir())
+	require.Error(t, err)
+
+	require.Equal(t, "vf-driver", pfs[selfTestPFPCIAddr].Vfs[0].Driver)
+}
+
+func TestSelfTest_RestoresVFIODriver(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	vfioDir := t.TempDir()
+	require.NoError(t, unix.Mknod(filepath.Join(vfioDir, "5"), unix.S_IFCHR|0o666, int(unix.Mkdev(3, 4))))
+
+	cgroupBaseDir := t.TempDir()
+	_, err := cgroup.NewFakeCgroup(ctx, filepath.Join(cgroupBaseDir, vfio.SelfTestCgroupName))
+	require.NoError(t, err)
+
+	pfs, cfg := selfTestFunctions()
+	pfs[selfTestPFPCIAddr].Vfs[0].Driver = "vfio-pci"
+
+	pciPool, err := pci.NewSimulatedPool(pfs, cfg)
+	require.NoError(t, err)
+
+	require.NoError(t, vfio.SelfTest(ctx, cfg, pciPool, freeGroupsStub{selfTestGroup: true}, vfioDir, cgroupBaseDir))
+
+	require.Equal(t, "vfio-pci", pfs[selfTestPFPCIAddr].Vfs[0].Driver)
+}
+
+func TestVFIOServer_SelfTestFailed(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	pfs, cfg := selfTestFunctions()
+	pciPool, err := pci.NewSimulatedPool(pfs, cfg)
+	require.NoError(t, err)
+
+	ready := make(chan struct{})
+	server := vfio.NewServer(t.TempDir(), t.TempDir(),
+		vfio.WithVFIOSelfTest(ctx, cfg, pciPool, freeGroupsStub{}, new(sync.Mutex), ready))
+
+	request := &networkservice.NetworkServiceRequest{
+		Connection: &networkservice.Connection{
+			Mechanism: &networkservice.Mechanism{
+				Cls:  cls.LOCAL,
+				Type: vfiomech.MECHANISM,
+			},
+		},
+	}
+
+	// Self-test waits for the pools to get ready
+	_, err = server.Request(ctx, request.Clone())
+	require.NotErrorIs(t, err, vfio.ErrNoSpareVF)
+
+	close(ready)
+	require.Eventually(t, func() bool {
+		_, err = server.Request(ctx, request.Clone())
+		return errors.Is(err, vfio.ErrNoSpareVF)
+	}, time.Second, 10*time.Millisecond)
+}
@@ -25,7 +25,9 @@ package vfio
 import (
 	"context"
 	"fmt"
+	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 
 	"github.com/golang/protobuf/ptypes/empty"
@@ -35,35 +37,101 @@ import (
 	"github.com/networkservicemesh/api/pkg/api/networkservice"
 	"github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/vfio"
 	"github.com/networkservicemesh/sdk/pkg/networkservice/core/next"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/utils/inject/injecterror"
 	"github.com/networkservicemesh/sdk/pkg/tools/log"
 
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/config"
 	"github.com/networkservicemesh/sdk-sriov/pkg/tools/cgroup"
 )
 
+// ErrInvalidCgroupDir is returned when the client cgroup directory escapes the cgroup base directory or can match
+// cgroups of other pods
+var ErrInvalidCgroupDir = errors.New("invalid cgroup directory")
+
+// DeviceRange is a range of device numbers with the given major and minor in [MinMinor, MaxMinor]
+type DeviceRange struct {
+	Major    uint32
+	MinMinor uint32
+	MaxMinor uint32
+}
+
+// Contains returns true if r contains the major:minor device number
+func (r DeviceRange) Contains(major, minor uint32) bool {
+	return major == r.Major && r.MinMinor <= minor && minor <= r.MaxMinor
+}
+
+// DeviceNotAllowedError is an error returned when the device number is not in the device allowlist
+type DeviceNotAllowedError struct {
+	Major uint32
+	Minor uint32
+}
+
+func (e *DeviceNotAllowedError) Error() string {
+	return fmt.Sprintf("device is not in the allowlist: %d:%d", e.Major, e.Minor)
+}
+
 type vfioServer struct {
-	vfioDir        string
-	cgroupBaseDir  string
-	deviceCounters map[string]int
-	lock           sync.Mutex
+	vfioDir         string
+	cgroupBaseDir   string
+	deviceAllowlist []DeviceRange
+	deviceCounters  map[string]int
+	managedGroups   map[string]struct{}
+	vfioClassDir    string
+	deviceModes     string
+	deviceModesErr  error
+	lock            sync.Mutex
+
+	selfTestCtx          context.Context
+	selfTestConfig       *config.Config
+	selfTestPCIPool      SelfTestPCIPool
+	selfTestResourcePool SelfTestResourcePool
+	selfTestLock         sync.Locker
+	selfTestReady        <-chan struct{}
+	selfTestErr          error // guarded by lock
 }
 
 // NewServer returns a new VFIO server chain element
-func NewServer(vfioDir, cgroupBaseDir string) networkservice.NetworkServiceServer {
-	return &vfioServer{
+func NewServer(vfioDir, cgroupBaseDir string, options ...ServerOption) networkservice.NetworkServiceServer {
+	s := &vfioServer{
 		vfioDir:        vfioDir,
 		cgroupBaseDir:  cgroupBaseDir,
 		deviceCounters: map[string]int{},
+		vfioClassDir:   "/sys/class/vfio",
+		deviceModes:    cgroup.AllDeviceModes,
+	}
+
+	for _, opt := range options {
+		opt(s)
+	}
+
+	if s.deviceModesErr != nil {
+		return injecterror.NewServer(injecterror.WithError(s.deviceModesErr))
 	}
+
+	if s.selfTestCtx != nil {
+		go s.runSelfTest()
+	}
+
+	return s
 }
 
 func (s *vfioServer) Request(ctx context.Context, request *networkservice.NetworkServiceRequest) (*networkservice.Connection, error) {
 	logger := log.FromContext(ctx).WithField("vfioServer", "Request")
 
 	if mech := vfio.ToMechanism(request.GetConnection().GetMechanism()); mech != nil {
+		if err := s.getSelfTestErr(); err != nil {
+			return nil, err
+		}
+
 		if mech.GetCgroupDir() == "" {
 			return nil, errors.New("expected client cgroup directory set")
 		}
 
+		cgroupDirPattern, err := s.cgroupDirPattern(mech.GetCgroupDir())
+		if err != nil {
+			return nil, err
+		}
+
 		vfioMajor, vfioMinor, err := s.getDeviceNumbers(filepath.Join(s.vfioDir, vfioDevice))
 		if err != nil {
 			logger.Errorf("failed to get device numbers for the device: %v", vfioDevice)
@@ -71,37 +139,32 @@ func (s *vfioServer) Request(ctx context.Context, request *networkservice.Networ
 		}
 
 		igid := mech.GetParameters()[vfio.IommuGroupKey]
+		if err = s.ensureGroupNode(igid); err != nil {
+			logger.Errorf("failed to ensure device node for the IOMMU group: %v", igid)
+			return nil, err
+		}
+
 		deviceMajor, deviceMinor, err := s.getDeviceNumbers(filepath.Join(s.vfioDir, igid))
 		if err != nil {
 			logger.Errorf("failed to get device numbers for the device: %v", igid)
 			return nil, err
 		}
 
-		cgroupDirPattern := filepath.Join(s.cgroupBaseDir, mech.GetCgroupDir())
-
 		if err := func() error {
 			s.lock.Lock()
 			defer s.lock.Unlock()
 
-			if err := s.deviceAllow(cgroupDirPattern, vfioMajor, vfioMinor); err != nil {
-				logger.Errorf("failed to allow device for the client: %v", vfioDevice)
-				return err
-			}
-			mech.SetVfioMajor(vfioMajor)
-			mech.SetVfioMinor(vfioMinor)
-
-			if err := s.deviceAllow(cgroupDirPattern, deviceMajor, deviceMinor); err != nil {
-				logger.Errorf("failed to allow device for the client: %v", igid)
-				_ = s.deviceDeny(cgroupDirPattern, vfioMajor, vfioMinor)
-				return err
-			}
-			mech.SetDeviceMajor(deviceMajor)
-			mech.SetDeviceMinor(deviceMinor)
-
-			return nil
+			return s.deviceAllow(cgroupDirPattern,
+				cgroup.DeviceID{Major: vfioMajor, Minor: vfioMinor},
+				cgroup.DeviceID{Major: deviceMajor, Minor: deviceMinor})
 		}(); err != nil {
+			logger.Errorf("failed to allow devices for the client: %v, %v", vfioDevice, igid)
 			return nil, err
 		}
+		mech.SetVfioMajor(vfioMajor)
+		mech.SetVfioMinor(vfioMinor)
+		mech.SetDeviceMajor(deviceMajor)
+		mech.SetDeviceMinor(deviceMinor)
 	}
 
 	conn, err := next.Server(ctx).Request(ctx, request)
@@ -113,6 +176,75 @@ func (s *vfioServer) Request(ctx context.Context, request *networkservice.Networ
 	return conn, nil
 }
 
+// cgroupDirPattern returns the client cgroup directory pattern anchored under the cgroup base directory. Pattern can't
+// contain ".." or be the base directory itself, it can have a wildcard only as the "*" last element under the pod
+// cgroup directory matching the client pod containers, e.g. "kubepods/pod-1/*" (see cgroup.DirPath).
+func (s *vfioServer) cgroupDirPattern(cgroupDir string) (string, error) {
+	elems := strings.Split(strings.Trim(filepath.ToSlash(cgroupDir), "/"), "/")
+	for i, elem := range elems {
+		switch {
+		case elem == "..":
+			return "", errors.Wrapf(ErrInvalidCgroupDir, "%s escapes cgroup base directory", cgroupDir)
+		case elem == "*" && i == len(elems)-1:
+			if i == 0 || !isPodDir(elems[i-1]) {
+				return "", errors.Wrapf(ErrInvalidCgroupDir, "%s wildcard is not under the pod cgroup directory", cgroupDir)
+			}
+		case strings.ContainsAny(elem, "*?[\\"):
+			return "", errors.Wrapf(ErrInvalidCgroupDir, "%s can match other pods cgroups", cgroupDir)
+		}
+	}
+	if cleanDir := filepath.Clean("/" + cgroupDir); cleanDir == "/" {
+		return "", errors.Wrapf(ErrInvalidCgroupDir, "%q is the cgroup base directory", cgroupDir)
+	}
+	return filepath.Join(s.cgroupBaseDir, cgroupDir), nil
+}
+
+// isPodDir returns if the cgroup directory name is a pod cgroup directory name, e.g. "pod<uid>" for the cgroupfs
+// driver or "kubepods-burstable-pod<uid>.slice" for the systemd one, but not "kubepods" or QoS class directories
+func isPodDir(name string) bool {
+	if strings.HasPrefix(name, "pod") {
+		return len(name) > len("pod")
+	}
+	name, ok := strings.CutSuffix(name, ".slice")
+	if !ok {
+		return false
+	}
+	_, podID, ok := strings.Cut(name, "-pod")
+	return ok && podID != ""
+}
+
+// ensureGroupNode creates vfioDir/igid device node for the managed IOMMU group if it doesn't exist, device numbers are
+// read from the vfio class sysfs directory, which exists only for the groups bound to vfio-pci
+func (s *vfioServer) ensureGroupNode(igid string) error {
+	if _, ok := s.managedGroups[igid]; !ok {
+		return nil
+	}
+
+	deviceFile := filepath.Join(s.vfioDir, igid)
+	if _, err := os.Stat(deviceFile); err == nil || !os.IsNotExist(err) {
+		return errors.Wrapf(err, "failed to check %s file status", deviceFile)
+	}
+
+	devFile := filepath.Join(s.vfioClassDir, igid, "dev")
+	data, err := os.ReadFile(filepath.Clean(devFile))
+	if err != nil {
+		return errors.Wrapf(err, "IOMMU group %s is not bound to vfio-pci", igid)
+	}
+
+	var major, minor uint32
+	if _, err = fmt.Sscanf(strings.TrimSpace(string(data)), "%d:%d", &major, &minor); err != nil {
+		return errors.Wrapf(err, "invalid device numbers in %s: %s", devFile, data)
+	}
+
+	if err = os.MkdirAll(s.vfioDir, mkdirPerm); err != nil {
+		return errors.Wrapf(err, "failed to create vfio directory %s", s.vfioDir)
+	}
+	if err = unix.Mknod(deviceFile, unix.S_IFCHR|mknodPerm, int(unix.Mkdev(major, minor))); err != nil && !os.IsExist(err) {
+		return errors.Wrapf(err, "failed to mknod device: %s", deviceFile)
+	}
+	return nil
+}
+
 func (s *vfioServer) getDeviceNumbers(deviceFile string) (major, minor uint32, err error) {
 	info := new(unix.Stat_t)
 	if err := unix.Stat(deviceFile, info); err != nil {
@@ -121,14 +253,49 @@ func (s *vfioServer) getDeviceNumbers(deviceFile string) (major, minor uint32, e
 	return Major(info.Rdev), Minor(info.Rdev), nil
 }
 
-func (s *vfioServer) deviceAllow(cgroupDirPattern string, major, minor uint32) error {
+func (s *vfioServer) isDeviceAllowlisted(major, minor uint32) bool {
+	if s.deviceAllowlist == nil {
+		return true
+	}
+	for _, r := range s.deviceAllowlist {
+		if r.Contains(major, minor) {
+			return true
+		}
+	}
+	return false
+}
+
+// deviceAllow allows all the devices for each cgroup matching the pattern in one pass, on failure the devices are
+// denied back for the already processed cgroups
+func (s *vfioServer) deviceAllow(cgroupDirPattern string, devices ...cgroup.DeviceID) error {
+	for _, dev := range devices {
+		if !s.isDeviceAllowlisted(dev.Major, dev.Minor) {
+			return errors.WithStack(&DeviceNotAllowedError{Major: dev.Major, Minor: dev.Minor})
+		}
+	}
+
 	cgroups, err := cgroup.NewCgroups(cgroupDirPattern)
 	if err != nil || len(cgroups) == 0 {
 		return errors.Wrapf(err, "no cgroupDir found: %s", cgroupDirPattern)
 	}
 
-	for _, cg := range cgroups {
-		isWider, err := cg.IsWiderThan(major, minor)
+	for i, cg := range cgroups {
+		if err := s.cgroupDeviceAllow(cg, devices); err != nil {
+			for _, allowedCg := range cgroups[:i] {
+				_ = s.cgroupDeviceDeny(allowedCg, devices)
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *vfioServer) cgroupDeviceAllow(cg *cgroup.Cgroup, devices []cgroup.DeviceID) error {
+	var keys []string
+	var toAllow []cgroup.DeviceID
+	for _, dev := range devices {
+		isWider, err := cg.IsWiderThan(dev.Major, dev.Minor)
 		if err != nil {
 			return err
 		}
@@ -136,17 +303,19 @@ func (s *vfioServer) deviceAllow(cgroupDirPattern string, major, minor uint32) e
 			continue
 		}
 
-		key := deviceKey(cg.Path, major, minor)
-		if counter, ok := s.deviceCounters[key]; ok && counter > 0 {
-			s.deviceCounters[key] = counter + 1
-			return nil
+		key := deviceKey(cg.Path, dev.Major, dev.Minor)
+		keys = append(keys, key)
+		if s.deviceCounters[key] == 0 {
+			toAllow = append(toAllow, dev)
 		}
+	}
 
-		if err := cg.Allow(major, minor); err != nil {
-			return err
-		}
+	if err := cg.AllowAllModes(toAllow, s.deviceModes); err != nil {
+		return err
+	}
 
-		s.deviceCounters[key] = 1
+	for _, key := range keys {
+		s.deviceCounters[key]++
 	}
 
 	return nil
@@ -165,37 +334,52 @@ func (s *vfioServer) close(ctx context.Context, conn *networkservice.Connection)
 	logger := log.FromContext(ctx).WithField("vfioServer", "close")
 
 	if mech := vfio.ToMechanism(conn.GetMechanism()); mech != nil {
-		cgroupDirPattern := filepath.Join(s.cgroupBaseDir, mech.GetCgroupDir())
+		cgroupDirPattern, err := s.cgroupDirPattern(mech.GetCgroupDir())
+		if err != nil {
+			logger.Warnf("failed to deny devices for the client: %v", err)
+			return
+		}
+
+		var devices []cgroup.DeviceID
+		if vfioMajor, vfioMinor := mech.GetVfioMajor(), mech.GetVfioMinor(); !(vfioMajor == 0 && vfioMinor == 0) {
+			devices = append(devices, cgroup.DeviceID{Major: vfioMajor, Minor: vfioMinor})
+		}
+		if deviceMajor, deviceMinor := mech.GetDeviceMajor(), mech.GetDeviceMinor(); !(deviceMajor == 0 && deviceMinor == 0) {
+			devices = append(devices, cgroup.DeviceID{Major: deviceMajor, Minor: deviceMinor})
+		}
+		if len(devices) == 0 {
+			return
+		}
 
 		s.lock.Lock()
 		defer s.lock.Unlock()
 
-		vfioMajor := mech.GetVfioMajor()
-		vfioMinor := mech.GetVfioMinor()
-		if !(vfioMajor == 0 && vfioMinor == 0) {
-			if err := s.deviceDeny(cgroupDirPattern, vfioMajor, vfioMinor); err != nil {
-				logger.Warnf("failed to deny device for the client: %v", vfioDevice)
-			}
-		}
-
-		deviceMajor := mech.GetDeviceMajor()
-		deviceMinor := mech.GetDeviceMinor()
-		if !(deviceMajor == 0 && deviceMinor == 0) {
-			if err := s.deviceDeny(cgroupDirPattern, deviceMajor, deviceMinor); err != nil {
-				logger.Warnf("failed to deny device for the client: %v", mech.GetIommuGroup())
-			}
+		if err = s.deviceDeny(cgroupDirPattern, devices...); err != nil {
+			logger.Warnf("failed to deny devices for the client: %v, %v", vfioDevice, mech.GetIommuGroup())
 		}
 	}
 }
 
-func (s *vfioServer) deviceDeny(cgroupDirPattern string, major, minor uint32) error {
+// deviceDeny denies all the devices no more used by any connection for each cgroup matching the pattern in one pass
+func (s *vfioServer) deviceDeny(cgroupDirPattern string, devices ...cgroup.DeviceID) error {
 	cgroups, err := cgroup.NewCgroups(cgroupDirPattern)
 	if err != nil || len(cgroups) == 0 {
 		return errors.Wrapf(err, "no cgroupDir found: %s", cgroupDirPattern)
 	}
 
 	for _, cg := range cgroups {
-		isWider, err := cg.IsWiderThan(major, minor)
+		if err := s.cgroupDeviceDeny(cg, devices); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *vfioServer) cgroupDeviceDeny(cg *cgroup.Cgroup, devices []cgroup.DeviceID) error {
+	var toDeny []cgroup.DeviceID
+	for _, dev := range devices {
+		isWider, err := cg.IsWiderThan(dev.Major, dev.Minor)
 		if err != nil {
 			return err
 		}
@@ -203,18 +387,16 @@ func (s *vfioServer) deviceDeny(cgroupDirPattern string, major, minor uint32) er
 			continue
 		}
 
-		key := deviceKey(cg.Path, major, minor)
-		s.deviceCounters[key]--
-		if s.deviceCounters[key] > 0 {
-			return nil
-		}
-
-		if err := cg.Deny(major, minor); err != nil {
-			return err
+		key := deviceKey(cg.Path, dev.Major, dev.Minor)
+		if s.deviceCounters[key]--; s.deviceCounters[key] > 0 {
+			continue
 		}
+		delete(s.deviceCounters, key)
+		toDeny = append(toDeny, dev)
 	}
 
-	return nil
+	// deny exactly the modes allowed by deviceAllow
+	return cg.DenyAllModes(toDeny, s.deviceModes)
 }
 
 func deviceKey(cgroupDir string, major, minor uint32) string {
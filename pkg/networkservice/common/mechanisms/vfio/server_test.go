@@ -32,6 +32,7 @@ import (
 	vfiomech "github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/vfio"
 	"github.com/networkservicemesh/sdk/pkg/networkservice/common/mechanisms"
 	"github.com/networkservicemesh/sdk/pkg/networkservice/core/chain"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/goleak"
 	"golang.org/x/sys/unix"
@@ -41,8 +42,9 @@ import (
 )
 
 const (
-	testWait = 100 * time.Millisecond
-	testTick = testWait / 100
+	testWait      = 100 * time.Millisecond
+	testTick      = testWait / 100
+	testCgroupDir = "kubepods/pod-1/container-1"
 )
 
 func eventuallyIsAllowed(t *testing.T, a1 *cgroup.Cgroup, major, minor uint32) bool {
@@ -217,3 +219,247 @@ func TestVFIOServer_Close(t *testing.T) {
 
 	require.NoError(t, ctx.Err())
 }
+
+func TestVFIOServer_Request_EnsureGroupNode(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 5*time.Second)
+	defer cancel()
+
+	tmpDir := t.TempDir()
+	vfioClassDir := t.TempDir()
+
+	if err := unix.Mknod(filepath.Join(tmpDir, vfioDevice), unix.S_IFCHR|0o666, int(unix.Mkdev(1, 2))); err != nil {
+		t.Skipf("failed to create device file: %v", err)
+	}
+	_, err := cgroup.NewFakeWideCgroup(ctx, filepath.Join(tmpDir, testCgroupDir))
+	require.NoError(t, err)
+
+	// IOMMU group 1 is not bound to vfio-pci yet
+	server := chain.NewNetworkServiceServer(
+		vfio.NewServer(tmpDir, tmpDir, vfio.WithEnsureGroupNode(1), vfio.WithVFIOClassDir(vfioClassDir)),
+	)
+
+	_, err = server.Request(ctx, testDeviceRequest(testCgroupDir))
+	require.Error(t, err)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(vfioClassDir, iommuGroupString), 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(vfioClassDir, iommuGroupString, "dev"), []byte("3:4\n"), 0o600))
+
+	conn, err := server.Request(ctx, testDeviceRequest(testCgroupDir))
+	require.NoError(t, err)
+
+	mech := vfiomech.ToMechanism(conn.GetMechanism())
+	require.NotNil(t, mech)
+	require.Equal(t, uint32(3), mech.GetDeviceMajor())
+	require.Equal(t, uint32(4), mech.GetDeviceMinor())
+
+	info := new(unix.Stat_t)
+	require.NoError(t, unix.Stat(filepath.Join(tmpDir, iommuGroupString), info))
+	require.Equal(t, unix.Mkdev(3, 4), info.Rdev)
+}
+
+func TestVFIOServer_Request_EnsureGroupNode_NotManaged(t *testing.T) {
+	tmpDir := t.TempDir()
+	vfioClassDir := t.TempDir()
+
+	if err := unix.Mknod(filepath.Join(tmpDir, vfioDevice), unix.S_IFCHR|0o666, int(unix.Mkdev(1, 2))); err != nil {
+		t.Skipf("failed to create device file: %v", err)
+	}
+	require.NoError(t, os.MkdirAll(filepath.Join(vfioClassDir, iommuGroupString), 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(vfioClassDir, iommuGroupString, "dev"), []byte("3:4\n"), 0o600))
+
+	server := chain.NewNetworkServiceServer(
+		vfio.NewServer(tmpDir, tmpDir, vfio.WithEnsureGroupNode(2), vfio.WithVFIOClassDir(vfioClassDir)),
+	)
+
+	_, err := server.Request(context.TODO(), testDeviceRequest(testCgroupDir))
+	require.Error(t, err)
+
+	_, err = os.Stat(filepath.Join(tmpDir, iommuGroupString))
+	require.True(t, os.IsNotExist(err))
+}
+
+func testDeviceServer(ctx context.Context, t *testing.T, cgroupDir string, options ...vfio.ServerOption) networkservice.NetworkServiceServer {
+	tmpDir := t.TempDir()
+
+	if err := unix.Mknod(filepath.Join(tmpDir, vfioDevice), unix.S_IFCHR|0o666, int(unix.Mkdev(1, 2))); err != nil {
+		t.Skipf("failed to create device file: %v", err)
+	}
+	require.NoError(t, unix.Mknod(filepath.Join(tmpDir, iommuGroupString), unix.S_IFCHR|0o666, int(unix.Mkdev(3, 4))))
+
+	_, err := cgroup.NewFakeWideCgroup(ctx, filepath.Join(tmpDir, cgroupDir))
+	require.NoError(t, err)
+
+	return chain.NewNetworkServiceServer(
+		vfio.NewServer(tmpDir, tmpDir, options...),
+	)
+}
+
+func testDeviceRequest(cgroupDir string) *networkservice.NetworkServiceRequest {
+	return &networkservice.NetworkServiceRequest{
+		Connection: &networkservice.Connection{
+			Mechanism: &networkservice.Mechanism{
+				Cls:  cls.LOCAL,
+				Type: vfiomech.MECHANISM,
+				Parameters: map[string]string{
+					vfiomech.CgroupDirKey:  cgroupDir,
+					vfiomech.IommuGroupKey: iommuGroupString,
+				},
+			},
+		},
+	}
+}
+
+func TestVFIOServer_Request_DeviceAllowlist(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 5*time.Second)
+	defer cancel()
+
+	server := testDeviceServer(ctx, t, testCgroupDir, vfio.WithDeviceAllowlist(
+		vfio.DeviceRange{Major: 1, MinMinor: 2, MaxMinor: 2},
+		vfio.DeviceRange{Major: 3, MinMinor: 0, MaxMinor: 255},
+	))
+
+	conn, err := server.Request(ctx, testDeviceRequest(testCgroupDir))
+	require.NoError(t, err)
+
+	mech := vfiomech.ToMechanism(conn.GetMechanism())
+	require.NotNil(t, mech)
+	require.Equal(t, uint32(3), mech.GetDeviceMajor())
+	require.Equal(t, uint32(4), mech.GetDeviceMinor())
+}
+
+func TestVFIOServer_Request_DeviceNotAllowed(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 5*time.Second)
+	defer cancel()
+
+	server := testDeviceServer(ctx, t, testCgroupDir, vfio.WithDeviceAllowlist(
+		vfio.DeviceRange{Major: 1, MinMinor: 2, MaxMinor: 2},
+		vfio.DeviceRange{Major: 3, MinMinor: 5, MaxMinor: 255},
+	))
+
+	_, err := server.Request(ctx, testDeviceRequest(testCgroupDir))
+	require.Error(t, err)
+
+	var notAllowedErr *vfio.DeviceNotAllowedError
+	require.True(t, errors.As(err, &notAllowedErr))
+	require.Equal(t, &vfio.DeviceNotAllowedError{Major: 3, Minor: 4}, notAllowedErr)
+}
+
+func TestVFIOServer_Request_AnchoredCgroupDir(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 5*time.Second)
+	defer cancel()
+
+	for _, sample := range []struct {
+		cgroupDir, pattern string
+	}{
+		{"kubepods/pod-1/container-1", "kubepods/pod-1/container-1"},
+		{"kubepods/pod-1/container-1", "kubepods/pod-1/*"},
+		{"kubepods/burstable/pod-1/container-1", "kubepods/burstable/pod-1/*"},
+		{
+			"kubepods.slice/kubepods-burstable.slice/kubepods-burstable-pod1.slice/container-1.scope",
+			"kubepods.slice/kubepods-burstable.slice/kubepods-burstable-pod1.slice/*",
+		},
+	} {
+		server := testDeviceServer(ctx, t, sample.cgroupDir)
+
+		conn, err := server.Request(ctx, testDeviceRequest(sample.pattern))
+		require.NoError(t, err, sample.pattern)
+		require.Equal(t, uint32(3), vfiomech.ToMechanism(conn.GetMechanism()).GetDeviceMajor())
+	}
+}
+
+func TestVFIOServer_Request_InvalidCgroupDir(t *testing.T) {
+	server := chain.NewNetworkServiceServer(
+		vfio.NewServer(t.TempDir(), t.TempDir()),
+	)
+
+	for _, cgroupDir := range []string{
+		"/",
+		"kubepods/..",
+		"../kubepods/pod-1/container-1",
+		"kubepods/pod-1/../../container-1",
+		"*",
+		"kubepods/*",
+		"kubepods/burstable/*",
+		"kubepods.slice/*",
+		"kubepods.slice/kubepods-burstable.slice/*",
+		"kubepods/pod-1/container-*",
+		"kubepods/pod-1/container-?",
+		"kubepods/pod-1/[a-z]*",
+		"kubepods/*/*",
+		"*/pod-1/container-1",
+	} {
+		_, err := server.Request(context.TODO(), testDeviceRequest(cgroupDir))
+		require.ErrorIs(t, err, vfio.ErrInvalidCgroupDir, cgroupDir)
+	}
+}
+
+func TestVFIOServer_DeviceModes(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	vfioDir := t.TempDir()
+	require.NoError(t, unix.Mknod(filepath.Join(vfioDir, vfioDevice), unix.S_IFCHR|0o666, int(unix.Mkdev(1, 2))))
+	require.NoError(t, unix.Mknod(filepath.Join(vfioDir, iommuGroupString), unix.S_IFCHR|0o666, int(unix.Mkdev(3, 4))))
+
+	cgroupBaseDir := t.TempDir()
+	cg, err := cgroup.NewFakeCgroup(ctx, filepath.Join(cgroupBaseDir, "pod"))
+	require.NoError(t, err)
+
+	server := chain.NewNetworkServiceServer(
+		mechanisms.NewServer(map[string]networkservice.NetworkServiceServer{
+			vfiomech.MECHANISM: vfio.NewServer(vfioDir, cgroupBaseDir, vfio.WithDeviceModes("rm")),
+		}),
+	)
+
+	isAllowed := func(major, minor uint32, modes string) func() bool {
+		return func() bool {
+			allowed, allowedErr := cg.IsAllowedModes(major, minor, modes)
+			return allowedErr == nil && allowed
+		}
+	}
+
+	conn, err := server.Request(ctx, &networkservice.NetworkServiceRequest{
+		Connection: &networkservice.Connection{},
+		MechanismPreferences: []*networkservice.Mechanism{
+			{
+				Cls:  cls.LOCAL,
+				Type: vfiomech.MECHANISM,
+				Parameters: map[string]string{
+					vfiomech.CgroupDirKey:  "pod",
+					vfiomech.IommuGroupKey: iommuGroupString,
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	for _, dev := range [][2]uint32{{1, 2}, {3, 4}} {
+		require.Eventually(t, isAllowed(dev[0], dev[1], "r"), testWait, testTick)
+		require.True(t, isAllowed(dev[0], dev[1], "m")())
+		require.False(t, isAllowed(dev[0], dev[1], "w")())
+	}
+
+	_, err = server.Close(ctx, conn)
+	require.NoError(t, err)
+
+	for _, dev := range [][2]uint32{{1, 2}, {3, 4}} {
+		require.Eventually(t, func() bool { return !isAllowed(dev[0], dev[1], "r")() }, testWait, testTick)
+	}
+}
+
+func TestVFIOServer_InvalidDeviceModes(t *testing.T) {
+	server := vfio.NewServer(t.TempDir(), t.TempDir(), vfio.WithDeviceModes("rx"))
+
+	_, err := server.Request(context.Background(), &networkservice.NetworkServiceRequest{
+		Connection: &networkservice.Connection{},
+	})
+	require.ErrorIs(t, err, cgroup.ErrInvalidDeviceModes)
+}
@@ -0,0 +1,113 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+// Package mtu provides server chain element checking VF net interface MTU requested in the connection context and
+// restoring the VF MTU on Close
+package mtu
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/ptypes/empty"
+
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/networkservicemesh/sdk-kernel/pkg/kernel/networkservice/vfconfig"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/next"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/utils/metadata"
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+)
+
+// VFConfigurator is a vfnetlink.Configurator interface
+type VFConfigurator interface {
+	GetVFMTU(vfIfName string) (int, error)
+	CheckVFMTU(pfIfName string, mtu int) error
+	SetVFMTU(ctx context.Context, pfIfName, vfIfName string, mtu int) error
+}
+
+type prevMTUKey struct{}
+
+type mtuServer struct {
+	vfConfigurator VFConfigurator
+}
+
+// NewServer returns a new MTU server chain element, it fails Requests for the MTU exceeding the PF MTU. The MTU is set
+// to the VF net interface by the connection context kernel server on every Request, so the MTU server only restores
+// the VF MTU on Close. It should be placed after the resourcepool server and before the VF net interface is moved to
+// the client net namespace.
+func NewServer(vfConfigurator VFConfigurator) networkservice.NetworkServiceServer {
+	return &mtuServer{
+		vfConfigurator: vfConfigurator,
+	}
+}
+
+func (s *mtuServer) Request(ctx context.Context, request *networkservice.NetworkServiceRequest) (*networkservice.Connection, error) {
+	mtu := int(request.GetConnection().GetContext().GetMTU())
+
+	vfConfig, ok := vfconfig.Load(ctx, false)
+	if mtu == 0 || !ok || vfConfig.VFInterfaceName == "" {
+		return next.Server(ctx).Request(ctx, request)
+	}
+
+	if err := s.vfConfigurator.CheckVFMTU(vfConfig.PFInterfaceName, mtu); err != nil {
+		return nil, err
+	}
+
+	_, established := metadata.Map(ctx, false).Load(prevMTUKey{})
+	if !established {
+		prevMTU, err := s.vfConfigurator.GetVFMTU(vfConfig.VFInterfaceName)
+		if err != nil {
+			return nil, err
+		}
+		metadata.Map(ctx, false).Store(prevMTUKey{}, prevMTU)
+	}
+
+	conn, err := next.Server(ctx).Request(ctx, request)
+	if err != nil {
+		// failed refresh doesn't close the connection, so the MTU is kept
+		if !established {
+			s.restore(ctx, vfConfig)
+		}
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+func (s *mtuServer) Close(ctx context.Context, conn *networkservice.Connection) (*empty.Empty, error) {
+	rv, err := next.Server(ctx).Close(ctx, conn)
+
+	// VF net interface should be returned to the host net namespace by the next chain elements
+	if vfConfig, ok := vfconfig.Load(ctx, false); ok {
+		s.restore(ctx, vfConfig)
+	}
+
+	return rv, err
+}
+
+func (s *mtuServer) restore(ctx context.Context, vfConfig *vfconfig.VFConfig) {
+	value, ok := metadata.Map(ctx, false).LoadAndDelete(prevMTUKey{})
+	if !ok {
+		return
+	}
+
+	if err := s.vfConfigurator.SetVFMTU(ctx, vfConfig.PFInterfaceName, vfConfig.VFInterfaceName, value.(int)); err != nil {
+		log.FromContext(ctx).WithField("mtuServer", "restore").
+			Warnf("failed to restore VF %v MTU: %v", vfConfig.VFInterfaceName, err)
+	}
+}
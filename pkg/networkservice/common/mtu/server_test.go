@@ -0,0 +1,168 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package mtu_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/networkservicemesh/sdk-kernel/pkg/kernel/networkservice/vfconfig"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/utils/checks/checkrequest"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/utils/inject/injecterror"
+
+	"github.com/networkservicemesh/sdk-sriov/pkg/networkservice/common/mtu"
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/sriovtest"
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/vfnetlink"
+)
+
+const (
+	pfIfName = "pf"
+	vfIfName = "vf"
+)
+
+func newServer(t *testing.T, handle *sriovtest.NetlinkHandle, additionalFunctionality ...networkservice.NetworkServiceServer) networkservice.NetworkServiceServer {
+	return sriovtest.NewVFServer(t, &vfconfig.VFConfig{
+		PFInterfaceName: pfIfName,
+		VFInterfaceName: vfIfName,
+	}, mtu.NewServer(vfnetlink.NewConfigurator(vfnetlink.WithHandle(handle))), additionalFunctionality...)
+}
+
+func newHandle() *sriovtest.NetlinkHandle {
+	handle := sriovtest.NewNetlinkHandle()
+	handle.AddPhysicalFunction(pfIfName, 1)
+	handle.AddLink(vfIfName, sriovtest.DefaultMTU)
+	return handle
+}
+
+func newRequest(mtu uint32) *networkservice.NetworkServiceRequest {
+	return &networkservice.NetworkServiceRequest{
+		Connection: &networkservice.Connection{
+			Id: sriovtest.ConnectionID,
+			Context: &networkservice.ConnectionContext{
+				MTU: mtu,
+			},
+		},
+	}
+}
+
+func getMTU(t *testing.T, handle *sriovtest.NetlinkHandle) int {
+	link, err := handle.LinkByName(vfIfName)
+	require.NoError(t, err)
+	return link.Attrs().MTU
+}
+
+// newMTUSetter returns a server setting the requested MTU to the VF net interface the same way the connection context
+// kernel server does
+func newMTUSetter(t *testing.T, handle *sriovtest.NetlinkHandle) networkservice.NetworkServiceServer {
+	return checkrequest.NewServer(t, func(t *testing.T, request *networkservice.NetworkServiceRequest) {
+		link, err := handle.LinkByName(vfIfName)
+		require.NoError(t, err)
+		require.NoError(t, handle.LinkSetMTU(link, int(request.GetConnection().GetContext().GetMTU())))
+	})
+}
+
+func TestMTUServer(t *testing.T) {
+	handle := newHandle()
+
+	server := newServer(t, handle, newMTUSetter(t, handle))
+
+	conn, err := server.Request(context.Background(), newRequest(1400))
+	require.NoError(t, err)
+	require.Equal(t, 1400, getMTU(t, handle))
+
+	_, err = server.Close(context.Background(), conn)
+	require.NoError(t, err)
+	require.Equal(t, sriovtest.DefaultMTU, getMTU(t, handle))
+}
+
+func TestMTUServer_NoMTU(t *testing.T) {
+	handle := newHandle()
+
+	server := newServer(t, handle)
+
+	_, err := server.Request(context.Background(), newRequest(0))
+	require.NoError(t, err)
+	require.Equal(t, sriovtest.DefaultMTU, getMTU(t, handle))
+}
+
+func TestMTUServer_TooLarge(t *testing.T) {
+	handle := newHandle()
+
+	server := newServer(t, handle)
+
+	_, err := server.Request(context.Background(), newRequest(sriovtest.DefaultMTU+1))
+	require.ErrorIs(t, err, vfnetlink.ErrMTUTooLarge)
+	require.Equal(t, sriovtest.DefaultMTU, getMTU(t, handle))
+}
+
+func TestMTUServer_RequestFailed(t *testing.T) {
+	handle := newHandle()
+
+	server := newServer(t, handle, newMTUSetter(t, handle), injecterror.NewServer(injecterror.WithError(errors.New("error"))))
+
+	_, err := server.Request(context.Background(), newRequest(1400))
+	require.Error(t, err)
+	require.Equal(t, sriovtest.DefaultMTU, getMTU(t, handle))
+}
+
+func TestMTUServer_RefreshFailed(t *testing.T) {
+	handle := newHandle()
+
+	server := newServer(t, handle, newMTUSetter(t, handle), injecterror.NewServer(
+		injecterror.WithRequestErrorTimes(1),
+		injecterror.WithCloseErrorTimes(),
+	))
+
+	conn, err := server.Request(context.Background(), newRequest(1400))
+	require.NoError(t, err)
+
+	_, err = server.Request(context.Background(), newRequest(1400))
+	require.Error(t, err)
+	require.Equal(t, 1400, getMTU(t, handle))
+
+	_, err = server.Close(context.Background(), conn)
+	require.NoError(t, err)
+	require.Equal(t, sriovtest.DefaultMTU, getMTU(t, handle))
+}
+
+func TestMTUServer_Refresh(t *testing.T) {
+	handle := newHandle()
+
+	server := newServer(t, handle, newMTUSetter(t, handle))
+
+	_, err := server.Request(context.Background(), newRequest(1400))
+	require.NoError(t, err)
+
+	conn, err := server.Request(context.Background(), newRequest(1300))
+	require.NoError(t, err)
+	require.Equal(t, 1300, getMTU(t, handle))
+
+	_, err = server.Request(context.Background(), newRequest(sriovtest.DefaultMTU+1))
+	require.ErrorIs(t, err, vfnetlink.ErrMTUTooLarge)
+	require.Equal(t, 1300, getMTU(t, handle))
+
+	_, err = server.Close(context.Background(), conn)
+	require.NoError(t, err)
+	require.Equal(t, sriovtest.DefaultMTU, getMTU(t, handle))
+}
@@ -0,0 +1,99 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+// Package nodelabel provides server chain element stamping node and forwarder names into the connection labels
+package nodelabel
+
+import (
+	"context"
+	"os"
+
+	"github.com/golang/protobuf/ptypes/empty"
+
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/next"
+)
+
+const (
+	// NodeNameLabel is a connection label for the name of the node serving the connection
+	NodeNameLabel = "sriovNodeName"
+	// ForwarderNameLabel is a connection label for the name of the forwarder serving the connection
+	ForwarderNameLabel = "sriovForwarderName"
+
+	nodeNameEnv = "NODE_NAME"
+)
+
+type nodeLabelServer struct {
+	nodeName      string
+	forwarderName string
+}
+
+// NewServer returns a new node label server chain element, it should be placed after the discover server so the labels
+// don't affect the NSE selection. Labels are added to the resulting connection and don't override the existing ones.
+// If nodeName is empty, it is taken from the NODE_NAME env or from the host name.
+func NewServer(nodeName, forwarderName string) networkservice.NetworkServiceServer {
+	if nodeName == "" {
+		nodeName = defaultNodeName()
+	}
+	return &nodeLabelServer{
+		nodeName:      nodeName,
+		forwarderName: forwarderName,
+	}
+}
+
+func (s *nodeLabelServer) Request(ctx context.Context, request *networkservice.NetworkServiceRequest) (*networkservice.Connection, error) {
+	conn, err := next.Server(ctx).Request(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	s.setLabels(conn)
+
+	return conn, nil
+}
+
+func (s *nodeLabelServer) Close(ctx context.Context, conn *networkservice.Connection) (*empty.Empty, error) {
+	return next.Server(ctx).Close(ctx, conn)
+}
+
+func (s *nodeLabelServer) setLabels(conn *networkservice.Connection) {
+	if conn == nil {
+		return
+	}
+	if conn.Labels == nil {
+		conn.Labels = map[string]string{}
+	}
+	setLabel(conn.Labels, NodeNameLabel, s.nodeName)
+	setLabel(conn.Labels, ForwarderNameLabel, s.forwarderName)
+}
+
+func setLabel(labels map[string]string, key, value string) {
+	if _, ok := labels[key]; ok || value == "" {
+		return
+	}
+	labels[key] = value
+}
+
+func defaultNodeName() string {
+	if nodeName := os.Getenv(nodeNameEnv); nodeName != "" {
+		return nodeName
+	}
+	hostName, _ := os.Hostname()
+	return hostName
+}
@@ -0,0 +1,105 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package nodelabel_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/chain"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/utils/checks/checkrequest"
+
+	"github.com/networkservicemesh/sdk-sriov/pkg/networkservice/common/nodelabel"
+)
+
+func TestNodeLabelServer_Request(t *testing.T) {
+	server := nodelabel.NewServer("node-1", "forwarder-1")
+
+	conn, err := server.Request(context.Background(), &networkservice.NetworkServiceRequest{
+		Connection: &networkservice.Connection{
+			Id: "id",
+			Labels: map[string]string{
+				"app": "nsc",
+			},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"app":                        "nsc",
+		nodelabel.NodeNameLabel:      "node-1",
+		nodelabel.ForwarderNameLabel: "forwarder-1",
+	}, conn.GetLabels())
+}
+
+func TestNodeLabelServer_Request_NodeNameEnv(t *testing.T) {
+	t.Setenv("NODE_NAME", "node-env")
+
+	conn, err := nodelabel.NewServer("", "forwarder-1").Request(context.Background(), &networkservice.NetworkServiceRequest{
+		Connection: &networkservice.Connection{
+			Id: "id",
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "node-env", conn.GetLabels()[nodelabel.NodeNameLabel])
+}
+
+func TestNodeLabelServer_Request_HostName(t *testing.T) {
+	t.Setenv("NODE_NAME", "")
+
+	hostName, err := os.Hostname()
+	require.NoError(t, err)
+
+	conn, err := nodelabel.NewServer("", "forwarder-1").Request(context.Background(), &networkservice.NetworkServiceRequest{
+		Connection: &networkservice.Connection{
+			Id: "id",
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, hostName, conn.GetLabels()[nodelabel.NodeNameLabel])
+}
+
+func TestNodeLabelServer_Request_ExistingLabels(t *testing.T) {
+	request := &networkservice.NetworkServiceRequest{
+		Connection: &networkservice.Connection{
+			Id: "id",
+			Labels: map[string]string{
+				nodelabel.NodeNameLabel: "client-node",
+			},
+		},
+	}
+
+	conn, err := chain.NewNetworkServiceServer(
+		nodelabel.NewServer("node-1", "forwarder-1"),
+		checkrequest.NewServer(t, func(t *testing.T, request *networkservice.NetworkServiceRequest) {
+			require.Equal(t, map[string]string{
+				nodelabel.NodeNameLabel: "client-node",
+			}, request.GetConnection().GetLabels())
+		}),
+	).Request(context.Background(), request)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		nodelabel.NodeNameLabel:      "client-node",
+		nodelabel.ForwarderNameLabel: "forwarder-1",
+	}, conn.GetLabels())
+}
@@ -0,0 +1,41 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resetmechanism
+
+import (
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+)
+
+// AssignmentInProgressKey is a mechanism parameter marking the Request as a part of the two-phase VF assignment, reset
+// mechanism server doesn't reset the wrapped server for such Requests because it would free the assigned VF. It is
+// carried in the Request itself, so it reaches the reset mechanism server over gRPC.
+const AssignmentInProgressKey = "sriovAssignmentInProgress"
+
+// SetAssignmentInProgress marks the Request with mech as a part of the two-phase VF assignment
+func SetAssignmentInProgress(mech *networkservice.Mechanism) {
+	if mech.GetParameters() == nil {
+		mech.Parameters = map[string]string{}
+	}
+	mech.GetParameters()[AssignmentInProgressKey] = "true"
+}
+
+// ClearAssignmentInProgress removes the two-phase VF assignment mark from mech, returns if it has been set
+func ClearAssignmentInProgress(mech *networkservice.Mechanism) bool {
+	_, ok := mech.GetParameters()[AssignmentInProgressKey]
+	delete(mech.GetParameters(), AssignmentInProgressKey)
+	return ok
+}
@@ -43,11 +43,14 @@ func NewServer(wrappedServer networkservice.NetworkServiceServer) networkservice
 
 func (s *resetMechanismServer) Request(ctx context.Context, request *networkservice.NetworkServiceRequest) (*networkservice.Connection, error) {
 	connID := request.GetConnection().GetId()
+	assignmentInProgress := ClearAssignmentInProgress(request.GetConnection().GetMechanism())
 
 	if storedMech, ok := s.mechanisms.Load(connID); ok {
 		mech := request.GetConnection().GetMechanism()
-		if mech.GetType() == storedMech.GetType() {
-			// mechanism is the same, there is no need to request the wrapped server
+		if mech.GetType() == storedMech.GetType() || assignmentInProgress {
+			// mechanism is the same, there is no need to request the wrapped server. Reset in the middle of the
+			// two-phase VF assignment would free the assigned VF, so it is postponed until the next Request: the
+			// wrapped server keeps the stored mechanism until then.
 			return next.Server(ctx).Request(ctx, request)
 		}
 
@@ -63,13 +66,24 @@ func (s *resetMechanismServer) Request(ctx context.Context, request *networkserv
 
 	conn, err := s.wrappedServer.Request(ctx, request)
 	if mech := conn.GetMechanism(); err == nil && mech != nil {
-		s.mechanisms.LoadOrStore(connID, mech.Clone())
+		s.mechanisms.Store(connID, mech.Clone())
 	}
 	return conn, err
 }
 
 func (s *resetMechanismServer) Close(ctx context.Context, conn *networkservice.Connection) (*empty.Empty, error) {
-	s.mechanisms.Delete(conn.GetId())
+	if storedMech, ok := s.mechanisms.LoadAndDelete(conn.GetId()); ok && conn.GetMechanism().GetType() != storedMech.GetType() {
+		// reset has been postponed, the wrapped server still uses the stored mechanism
+		storedConn := conn.Clone()
+		storedConn.Mechanism = storedMech
+
+		closeServer := next.NewNetworkServiceServer(s.wrappedServer, &tailServer{})
+		_, err := closeServer.Close(ctx, storedConn)
+		if _, nextErr := next.Server(ctx).Close(ctx, conn); nextErr != nil {
+			return nil, nextErr
+		}
+		return &empty.Empty{}, err
+	}
 
 	return s.wrappedServer.Close(ctx, conn)
 }
@@ -147,6 +147,49 @@ func TestResetMechanismServer_Request_Change(t *testing.T) {
 	mockElement.mock.AssertNumberOfCalls(t, "Close", 1)
 }
 
+func TestResetMechanismServer_Request_AssignmentInProgress(t *testing.T) {
+	mechElement := newMechChainElement()
+	mockElement := newMockChainElement()
+
+	server := chain.NewNetworkServiceServer(
+		resetmechanism.NewServer(mechElement),
+		mockElement,
+	)
+
+	// 1. Request with mech1 mechanism
+
+	_, err := server.Request(context.TODO(), testRequest(mech1))
+	require.NoError(t, err)
+
+	// 2. Request with mech2 mechanism during the two-phase VF assignment doesn't reset the wrapped server
+
+	request := testRequest(mech2)
+	request.GetConnection().Mechanism = &networkservice.Mechanism{Type: mech2}
+	resetmechanism.SetAssignmentInProgress(request.GetConnection().GetMechanism())
+
+	conn, err := server.Request(context.TODO(), request)
+	require.NoError(t, err)
+	require.Equal(t, mech2, conn.Mechanism.Type)
+	require.NotContains(t, conn.GetMechanism().GetParameters(), resetmechanism.AssignmentInProgressKey)
+
+	require.True(t, mechElement.mechs[mech1])
+	require.False(t, mechElement.mechs[mech2])
+
+	mechElement.mock.AssertNumberOfCalls(t, "Request", 1)
+	mechElement.mock.AssertNumberOfCalls(t, "Close", 0)
+	mockElement.mock.AssertNumberOfCalls(t, "Request", 2)
+
+	// 3. Close closes the wrapped server with the mechanism it has been requested with
+
+	_, err = server.Close(context.TODO(), conn)
+	require.NoError(t, err)
+
+	require.False(t, mechElement.mechs[mech1])
+
+	mechElement.mock.AssertNumberOfCalls(t, "Close", 1)
+	mockElement.mock.AssertNumberOfCalls(t, "Close", 1)
+}
+
 type mechChainElement struct {
 	mock  mock.Mock
 	mechs map[string]bool
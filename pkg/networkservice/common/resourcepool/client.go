@@ -39,13 +39,15 @@ import (
 	"github.com/networkservicemesh/sdk/pkg/tools/log"
 	"github.com/networkservicemesh/sdk/pkg/tools/postpone"
 
+	"github.com/networkservicemesh/sdk-sriov/pkg/networkservice/common/resetmechanism"
 	"github.com/networkservicemesh/sdk-sriov/pkg/sriov"
 	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/config"
 	"github.com/networkservicemesh/sdk-sriov/pkg/tools/tokens"
 )
 
 type resourcePoolClient struct {
-	resourcePool *resourcePoolConfig
+	resourcePool     *resourcePoolConfig
+	strictMechanisms map[string]struct{}
 }
 
 // NewClient returns a new resource pool client chain element
@@ -55,15 +57,23 @@ func NewClient(
 	pciPool PCIPool,
 	resourcePool ResourcePool,
 	cfg *config.Config,
+	options ...ClientOption,
 ) networkservice.NetworkServiceClient {
-	return &resourcePoolClient{resourcePool: &resourcePoolConfig{
-		driverType:   driverType,
-		resourceLock: resourceLock,
-		pciPool:      pciPool,
-		resourcePool: resourcePool,
-		config:       cfg,
-		selectedVFs:  map[string]string{},
-	}}
+	c := &resourcePoolClient{
+		resourcePool: &resourcePoolConfig{
+			driverType:   driverType,
+			resourceLock: resourceLock,
+			pciPool:      pciPool,
+			resourcePool: resourcePool,
+			config:       cfg,
+			selectedVFs:  map[string]string{},
+		},
+		strictMechanisms: map[string]struct{}{},
+	}
+	for _, opt := range options {
+		opt(c)
+	}
+	return c
 }
 
 func (i *resourcePoolClient) Request(
@@ -88,6 +98,10 @@ func (i *resourcePoolClient) Request(
 
 	tokenID, ok := conn.GetMechanism().GetParameters()[common.DeviceTokenIDKey]
 	if !ok {
+		if _, strict := i.strictMechanisms[conn.GetMechanism().GetType()]; strict {
+			err = errors.Wrapf(ErrMissingTokenID, "connection %s with %s mechanism", conn.GetId(), conn.GetMechanism().GetType())
+			return nil, i.closeOnError(postponeCtxFunc, conn, err, opts...)
+		}
 		logger.Infof("no token ID present for the connection: %v", conn)
 		return conn, nil
 	}
@@ -96,16 +110,9 @@ func (i *resourcePoolClient) Request(
 		return conn, nil
 	}
 
-	err = assignVF(ctx, logger, conn, tokenID, i.resourcePool, metadata.IsClient(i))
+	err = assignVF(ctx, logger, conn, tokenID, i.resourcePool, metadata.IsClient(i), nil)
 	if err != nil {
-		closeCtx, cancelClose := postponeCtxFunc()
-		defer cancelClose()
-
-		if _, closeErr := i.Close(closeCtx, conn, opts...); closeErr != nil {
-			err = errors.Wrapf(err, "connection closed with error: %s", closeErr.Error())
-		}
-
-		return nil, err
+		return nil, i.closeOnError(postponeCtxFunc, conn, err, opts...)
 	}
 
 	// Don't make second request if PCI address, token id weren't changed
@@ -116,18 +123,43 @@ func (i *resourcePoolClient) Request(
 	// communicate assigned VF's pci address to endpoint by making another Request.
 	// this would also need subsequent chain elements to ignore handling of response
 	// for 2nd Request.
+	// resetmechanism shouldn't reset the connection on this Request, it would free the assigned VF.
 	request.Connection = conn.Clone()
+	resetmechanism.SetAssignmentInProgress(request.GetConnection().GetMechanism())
 	if conn, err = next.Client(ctx).Request(ctx, request); err != nil {
 		// Perform local cleanup in case of second Request failed
-		_ = i.resourcePool.close(request.Connection)
+		_ = i.resourcePool.close(ctx, request.Connection)
+		return nil, err
 	}
+	// the mark should be removed by the reset mechanism server, but there can be no such server in the chain
+	resetmechanism.ClearAssignmentInProgress(conn.GetMechanism())
 
-	return conn, err
+	return conn, nil
+}
+
+func (i *resourcePoolClient) closeOnError(
+	postponeCtxFunc func() (context.Context, context.CancelFunc),
+	conn *networkservice.Connection,
+	err error,
+	opts ...grpc.CallOption,
+) error {
+	closeCtx, cancelClose := postponeCtxFunc()
+	defer cancelClose()
+
+	if _, closeErr := i.Close(closeCtx, conn, opts...); closeErr != nil {
+		err = errors.Wrapf(err, "connection closed with error: %s", closeErr.Error())
+	}
+
+	return err
 }
 
 func (i *resourcePoolClient) Close(ctx context.Context, conn *networkservice.Connection, opts ...grpc.CallOption) (*empty.Empty, error) {
+	if vfConfig, ok := vfconfig.Load(ctx, metadata.IsClient(i)); ok {
+		i.resourcePool.refreshVFInterfaceName(ctx, conn.GetId(), vfConfig)
+	}
+
 	rv, err := next.Client(ctx).Close(ctx, conn, opts...)
-	closeErr := i.resourcePool.close(conn)
+	closeErr := i.resourcePool.close(ctx, conn)
 
 	if err != nil && closeErr != nil {
 		return nil, errors.Wrapf(err, "failed to free VF: %v", closeErr)
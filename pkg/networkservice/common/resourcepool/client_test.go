@@ -0,0 +1,251 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package resourcepool_test
+
+import (
+	"context"
+	"net/url"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/common"
+	"github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/kernel"
+	"github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/vfio"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/common/mechanisms"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/adapters"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/chain"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/next"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/utils/metadata"
+	"github.com/networkservicemesh/sdk/pkg/tools/grpcutils"
+
+	"github.com/networkservicemesh/sdk-sriov/pkg/networkservice/common/resetmechanism"
+	"github.com/networkservicemesh/sdk-sriov/pkg/networkservice/common/resourcepool"
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov"
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/config"
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/pci"
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/sriovtest"
+	"github.com/networkservicemesh/sdk-sriov/pkg/tools/yamlhelper"
+)
+
+// mechanismChangeClient changes the connection mechanism on the second Request
+type mechanismChangeClient struct {
+	requests int
+}
+
+func (c *mechanismChangeClient) Request(ctx context.Context, request *networkservice.NetworkServiceRequest, opts ...grpc.CallOption) (*networkservice.Connection, error) {
+	c.requests++
+	if c.requests == 2 {
+		request.GetConnection().GetMechanism().Type = vfio.MECHANISM
+	}
+	return next.Client(ctx).Request(ctx, request, opts...)
+}
+
+func (c *mechanismChangeClient) Close(ctx context.Context, conn *networkservice.Connection, opts ...grpc.CallOption) (*empty.Empty, error) {
+	return next.Client(ctx).Close(ctx, conn, opts...)
+}
+
+type closeCountServer struct {
+	closes int
+}
+
+func (s *closeCountServer) Request(ctx context.Context, request *networkservice.NetworkServiceRequest) (*networkservice.Connection, error) {
+	return next.Server(ctx).Request(ctx, request)
+}
+
+func (s *closeCountServer) Close(ctx context.Context, conn *networkservice.Connection) (*empty.Empty, error) {
+	s.closes++
+	return next.Server(ctx).Close(ctx, conn)
+}
+
+// testForwarder serves the forwarder mechanisms chain with resourcepool servers wrapped into resetmechanism over gRPC
+func testForwarder(ctx context.Context, t *testing.T, pciPool resourcepool.PCIPool, resourcePool resourcepool.ResourcePool,
+	conf *config.Config, kernelServer, vfioServer networkservice.NetworkServiceServer) *grpc.ClientConn {
+	resourceLock := new(sync.Mutex)
+	server := grpc.NewServer()
+	networkservice.RegisterNetworkServiceServer(server, chain.NewNetworkServiceServer(
+		metadata.NewServer(),
+		resetmechanism.NewServer(
+			mechanisms.NewServer(map[string]networkservice.NetworkServiceServer{
+				kernel.MECHANISM: chain.NewNetworkServiceServer(
+					resourcepool.NewServer(sriov.KernelDriver, resourceLock, pciPool, resourcePool, conf),
+					kernelServer,
+				),
+				vfio.MECHANISM: chain.NewNetworkServiceServer(
+					resourcepool.NewServer(sriov.VFIOPCIDriver, resourceLock, pciPool, resourcePool, conf),
+					vfioServer,
+				),
+			}),
+		),
+	))
+
+	socketURL := &url.URL{
+		Scheme: "unix",
+		Path:   filepath.Join(t.TempDir(), "forwarder.socket"),
+	}
+	select {
+	case err := <-grpcutils.ListenAndServe(ctx, socketURL, server):
+		require.NoError(t, err)
+	default:
+	}
+
+	cc, err := grpc.DialContext(ctx, grpcutils.URLToTarget(socketURL),
+		grpc.WithTransportCredentials(insecure.NewCredentials()), grpc.WithBlock())
+	require.NoError(t, err)
+
+	return cc
+}
+
+func TestResourcePoolClient_Request_ResetMechanism(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.TODO(), 10*time.Second)
+	defer cancel()
+
+	var pfs map[string]*sriovtest.PCIPhysicalFunction
+	_ = yamlhelper.UnmarshalFile(physicalFunctionsFilename, &pfs)
+
+	conf, err := config.ReadConfig(ctx, configFileName)
+	require.NoError(t, err)
+
+	forwarderPCIPool, err := pci.NewTestPool(pfs, conf)
+	require.NoError(t, err)
+
+	forwarderResourcePool := new(resourcePoolMock)
+	forwarderResourcePool.mock.On("Select", tokenID, sriov.KernelDriver).
+		Return(pfs[pf2PciAddr].Vfs[0].Addr, nil)
+	forwarderResourcePool.mock.On("Free", pfs[pf2PciAddr].Vfs[0].Addr).
+		Return(nil)
+
+	kernelServer, vfioServer := new(closeCountServer), new(closeCountServer)
+	cc := testForwarder(ctx, t, forwarderPCIPool, forwarderResourcePool, conf, kernelServer, vfioServer)
+	defer func() { _ = cc.Close() }()
+
+	pciPool, err := pci.NewTestPool(pfs, conf)
+	require.NoError(t, err)
+
+	resourcePool := new(resourcePoolMock)
+	resourcePool.mock.On("Select", tokenID, sriov.KernelDriver).
+		Return(pfs[pf2PciAddr].Vfs[1].Addr, nil)
+	resourcePool.mock.On("Free", pfs[pf2PciAddr].Vfs[1].Addr).
+		Return(nil)
+
+	client := chain.NewNetworkServiceClient(
+		metadata.NewClient(),
+		resourcepool.NewClient(sriov.KernelDriver, new(sync.Mutex), pciPool, resourcePool, conf),
+		new(mechanismChangeClient),
+		networkservice.NewNetworkServiceClient(cc),
+	)
+
+	conn, err := client.Request(ctx, &networkservice.NetworkServiceRequest{
+		Connection: &networkservice.Connection{
+			Id: "id",
+			Mechanism: &networkservice.Mechanism{
+				Type: kernel.MECHANISM,
+				Parameters: map[string]string{
+					common.DeviceTokenIDKey: tokenID,
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	// Mechanism has been changed on the second Request, but the forwarder hasn't been reset and both VFs are still
+	// assigned
+	require.Equal(t, vfio.MECHANISM, conn.GetMechanism().GetType())
+	require.Equal(t, pfs[pf2PciAddr].Vfs[1].Addr, conn.GetMechanism().GetParameters()[common.PCIAddressKey])
+	require.NotContains(t, conn.GetMechanism().GetParameters(), resetmechanism.AssignmentInProgressKey)
+	require.Zero(t, kernelServer.closes)
+	forwarderResourcePool.mock.AssertNumberOfCalls(t, "Free", 0)
+	resourcePool.mock.AssertNumberOfCalls(t, "Free", 0)
+
+	// Close closes the forwarder chain for the mechanism it has been requested with
+
+	_, err = client.Close(ctx, conn)
+	require.NoError(t, err)
+
+	require.Equal(t, 1, kernelServer.closes)
+	require.Zero(t, vfioServer.closes)
+	forwarderResourcePool.mock.AssertNumberOfCalls(t, "Free", 1)
+	resourcePool.mock.AssertNumberOfCalls(t, "Free", 1)
+}
+
+func TestResourcePoolClient_Request_MissingTokenID(t *testing.T) {
+	conf, err := config.ReadConfig(context.TODO(), configFileName)
+	require.NoError(t, err)
+
+	resourcePool := new(resourcePoolMock)
+
+	request := func(client networkservice.NetworkServiceClient, mechanismType string) (*networkservice.Connection, error) {
+		return client.Request(context.TODO(), &networkservice.NetworkServiceRequest{
+			Connection: &networkservice.Connection{
+				Id: "id",
+				Mechanism: &networkservice.Mechanism{
+					Type:       mechanismType,
+					Parameters: map[string]string{},
+				},
+			},
+		})
+	}
+
+	// Lenient client passes the connection through unchanged
+
+	wrappedServer := new(closeCountServer)
+	client := chain.NewNetworkServiceClient(
+		metadata.NewClient(),
+		resourcepool.NewClient(sriov.KernelDriver, new(sync.Mutex), nil, resourcePool, conf),
+		adapters.NewServerToClient(wrappedServer),
+	)
+
+	conn, err := request(client, vfio.MECHANISM)
+	require.NoError(t, err)
+	require.Empty(t, conn.GetMechanism().GetParameters()[common.PCIAddressKey])
+	require.Zero(t, wrappedServer.closes)
+
+	// Strict client fails and closes the connection with the SR-IOV mechanism
+
+	wrappedServer = new(closeCountServer)
+	client = chain.NewNetworkServiceClient(
+		metadata.NewClient(),
+		resourcepool.NewClient(sriov.KernelDriver, new(sync.Mutex), nil, resourcePool, conf,
+			resourcepool.WithStrictTokenID(vfio.MECHANISM)),
+		adapters.NewServerToClient(wrappedServer),
+	)
+
+	_, err = request(client, vfio.MECHANISM)
+	require.Error(t, err)
+	require.True(t, errors.Is(err, resourcepool.ErrMissingTokenID))
+	require.Equal(t, 1, wrappedServer.closes)
+
+	// Strict client passes through the connection with the other mechanism
+
+	conn, err = request(client, kernel.MECHANISM)
+	require.NoError(t, err)
+	require.Equal(t, kernel.MECHANISM, conn.GetMechanism().GetType())
+	require.Equal(t, 1, wrappedServer.closes)
+
+	resourcePool.mock.AssertNumberOfCalls(t, "Select", 0)
+}
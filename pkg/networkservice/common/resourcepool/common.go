@@ -23,7 +23,10 @@ package resourcepool
 
 import (
 	"context"
+	"net"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/pkg/errors"
 
@@ -35,8 +38,31 @@ import (
 
 	"github.com/networkservicemesh/sdk-sriov/pkg/sriov"
 	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/config"
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/pci"
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/vfnetlink"
+	"github.com/networkservicemesh/sdk-sriov/pkg/tools/tokens"
 )
 
+const (
+	// RepresentorKey is a connection context extra context key for the selected VF representor net interface name, it
+	// is set only if the VF PF is in switchdev mode
+	RepresentorKey = "sriovVFRepresentor"
+
+	bindRetries    = 10
+	bindRetryDelay = 50 * time.Millisecond
+)
+
+// ErrUnknownToken is returned when the connection token doesn't belong to any service domain, capability served by
+// the SR-IOV config
+var ErrUnknownToken = errors.New("unknown SR-IOV token")
+
+// ErrDriverTypeNotAllowed is returned when the connection service domain is not allowed to use the server driver type
+var ErrDriverTypeNotAllowed = errors.New("driver type is not allowed for the service domain")
+
+// ErrMissingTokenID is returned by the strict client when the endpoint didn't assign a token ID to the connection
+// with the mechanism expecting a SR-IOV VF
+var ErrMissingTokenID = errors.New("no SR-IOV token ID assigned by the endpoint")
+
 // PCIPool is a pci.Pool interface
 type PCIPool interface {
 	GetPCIFunction(pciAddr string) (sriov.PCIFunction, error)
@@ -47,6 +73,66 @@ type PCIPool interface {
 type ResourcePool interface {
 	Select(tokenID string, driverType sriov.DriverType) (string, error)
 	Free(vfPCIAddr string) error
+	IsIOMMUGroupFree(iommuGroup uint) bool
+}
+
+// VFStatsReader is a vfnetlink.Configurator interface
+type VFStatsReader interface {
+	GetVFStats(pfIfName string, vfIndex int) (*vfnetlink.VFStats, error)
+}
+
+// RepresentorLookup is a vfnetlink.Configurator interface
+type RepresentorLookup interface {
+	IsSwitchdev(pfPCIAddr string) (bool, error)
+	GetVFRepresentor(pfIfName string, vfIndex int) (string, error)
+}
+
+// VFStatsReportFunc is called on Close with the VF traffic counters increase since the VF has been assigned to the
+// connection, it is called before the VF is freed
+type VFStatsReportFunc func(ctx context.Context, conn *networkservice.Connection, vfPCIAddr string, stats *vfnetlink.VFStats)
+
+// ExcludingResourcePool is a resource.Pool interface for selecting VFs other than the ones failed to get assigned, ctx
+// is passed to the resource.Allocator
+type ExcludingResourcePool interface {
+	SelectExcluding(ctx context.Context, tokenID string, driverType sriov.DriverType, excludedVFs ...string) (string, error)
+}
+
+// ReservingResourcePool is a resource.Pool interface for reserving VFs until the connection is established, the
+// reserved VFs can be preempted by the resource pool (see resource.WithPreemption)
+type ReservingResourcePool interface {
+	ReserveExcluding(ctx context.Context, tokenID string, driverType sriov.DriverType, excludedVFs ...string) (string, error)
+	Commit(vfPCIAddr string) error
+}
+
+// LockingResourcePool is a resource.Pool interface for getting the lock used to synchronize it in background
+type LockingResourcePool interface {
+	Locker() sync.Locker
+}
+
+// TokenResourcePool is a resource.Pool interface for checking which token the VF is selected for
+type TokenResourcePool interface {
+	GetTokenID(vfPCIAddr string) (string, error)
+}
+
+// TokenNameResourcePool is a resource.Pool interface for finding the token names, it is used to find the connection
+// service domain if there is no TokenPool set
+type TokenNameResourcePool interface {
+	FindTokenName(tokenID string) (string, error)
+}
+
+// HardwareAddrResourcePool is a resource.Pool interface for getting the MAC address allocated for the selected VF
+type HardwareAddrResourcePool interface {
+	GetHardwareAddr(vfPCIAddr string) (net.HardwareAddr, error)
+}
+
+// VFMACSetter is a vfnetlink.Configurator interface
+type VFMACSetter interface {
+	SetVFMACAddress(ctx context.Context, pfIfName string, vfIndex int, mac net.HardwareAddr) error
+}
+
+// TokenPool is a token.Pool interface
+type TokenPool interface {
+	Find(id string) (string, error)
 }
 
 type resourcePoolConfig struct {
@@ -56,10 +142,119 @@ type resourcePoolConfig struct {
 	resourcePool ResourcePool
 	config       *config.Config
 	selectedVFs  map[string]string
+	tokenPool    TokenPool
+	waitQueue    *WaitQueue
+	// rebindToKernel makes close rebind freed VFIO IOMMU groups back to the kernel driver
+	rebindToKernel bool
+	vfStatsReader  VFStatsReader
+	reportVFStats  VFStatsReportFunc
+	// vfStatsBase contains the VF traffic counters read on the VF assignment by the connection IDs
+	vfStatsBase map[string]*vfnetlink.VFStats
+	// representorLookup makes assignVF set the VF representor name to the connection context for switchdev PFs
+	representorLookup RepresentorLookup
+	// vfMACSetter makes assignVF set the MAC address allocated by the resource pool to the VF
+	vfMACSetter VFMACSetter
+	// assignAttempts is a number of attempts to assign a VF on transient failures, each attempt selects another VF
+	assignAttempts int
+	assignBackoff  time.Duration
+	// activeConnections tracks the connections VF assignment details
+	activeConnections *ActiveConnections
+	// expirer closes the connections using the expired VFs
+	expirer *Expirer
+	// reservingPool makes selectVF reserve the VF, it is committed after the connection is established
+	reservingPool ReservingResourcePool
+	// preemptor cancels the Requests which reserved VFs are preempted
+	preemptor *Preemptor
 }
 
-func (s *resourcePoolConfig) selectVF(connID string, vfConfig *vfconfig.VFConfig, tokenID string) (vf sriov.PCIFunction, err error) {
-	vfPCIAddr, err := s.resourcePool.Select(tokenID, s.driverType)
+// checkToken returns ErrUnknownToken if the token name is not served by any PF in the config, token names are built
+// the same way as the resource and token pools do
+func (s *resourcePoolConfig) checkToken(tokenID string) error {
+	if s.tokenPool == nil {
+		return nil
+	}
+
+	tokenName, err := s.tokenPool.Find(tokenID)
+	if err != nil {
+		return errors.Wrapf(ErrUnknownToken, "%v: %v", tokenID, err)
+	}
+
+	for _, pfCfg := range s.config.PhysicalFunctions {
+		qosClasses := map[string]struct{}{}
+		for _, vfCfg := range pfCfg.VirtualFunctions {
+			qosClasses[vfCfg.QoSClass] = struct{}{}
+		}
+		for _, name := range tokens.Names(s.config.AllowedServiceDomains(pfCfg), pfCfg.Capabilities) {
+			for qosClass := range qosClasses {
+				if tokens.QoSClassName(name, qosClass) == tokenName {
+					return nil
+				}
+			}
+		}
+	}
+
+	return errors.Wrapf(ErrUnknownToken, "%v: no PF serves %v", tokenID, tokenName)
+}
+
+// checkDriverType returns ErrDriverTypeNotAllowed if the token service domain doesn't allow the driver type, token name
+// is found with the TokenPool if set or with the resource pool otherwise
+func (s *resourcePoolConfig) checkDriverType(tokenID string) error {
+	if !s.hasDriverTypeRestrictions() {
+		return nil
+	}
+
+	tokenName, err := s.findTokenName(tokenID)
+	if err != nil {
+		return errors.Wrapf(ErrDriverTypeNotAllowed, "%v: failed to find the service domain: %v", tokenID, err)
+	}
+
+	// token name is a "serviceDomain/capability" path
+	serviceDomain := strings.SplitN(tokenName, "/", 2)[0]
+	if !s.config.ServiceDomains[serviceDomain].IsDriverTypeAllowed(s.driverType) {
+		return errors.Wrapf(ErrDriverTypeNotAllowed, "%v: %v, allowed: %v",
+			serviceDomain, s.driverType, s.config.ServiceDomains[serviceDomain].AllowedDriverTypes)
+	}
+	return nil
+}
+
+func (s *resourcePoolConfig) hasDriverTypeRestrictions() bool {
+	for _, serviceDomain := range s.config.ServiceDomains {
+		if serviceDomain != nil && len(serviceDomain.AllowedDriverTypes) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *resourcePoolConfig) findTokenName(tokenID string) (string, error) {
+	if s.tokenPool != nil {
+		return s.tokenPool.Find(tokenID)
+	}
+	if tokenNames, ok := s.resourcePool.(TokenNameResourcePool); ok {
+		return tokenNames.FindTokenName(tokenID)
+	}
+	return "", errors.New("no token pool to find the token name")
+}
+
+func (s *resourcePoolConfig) selectVF(ctx context.Context, connID string, vfConfig *vfconfig.VFConfig, tokenID string,
+	excludedVFs []string) (vf sriov.PCIFunction, err error) {
+	selectFunc := func() (string, error) {
+		return s.resourcePool.Select(tokenID, s.driverType)
+	}
+	if s.reservingPool != nil {
+		selectFunc = func() (string, error) {
+			return s.reservingPool.ReserveExcluding(ctx, tokenID, s.driverType, excludedVFs...)
+		}
+	} else if excludingResourcePool, ok := s.resourcePool.(ExcludingResourcePool); ok {
+		selectFunc = func() (string, error) {
+			return excludingResourcePool.SelectExcluding(ctx, tokenID, s.driverType, excludedVFs...)
+		}
+	}
+
+	vfPCIAddr, err := selectFunc()
+	if isAllInUse(err) && s.waitQueue != nil {
+		vfPCIAddr, err = s.waitQueue.wait(ctx, s.resourceLock, selectFunc, s.resourcePool.Free)
+	}
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to select VF for: %v", s.driverType)
 	}
@@ -94,27 +289,335 @@ func (s *resourcePoolConfig) selectVF(connID string, vfConfig *vfconfig.VFConfig
 	return nil, errors.Errorf("no VF with selected PCI address exists: %v", s.selectedVFs[connID])
 }
 
-func (s *resourcePoolConfig) close(conn *networkservice.Connection) error {
+// setVFHardwareAddr sets the MAC address allocated by the resource pool to the VF, VFs with no MAC address allocated
+// are left as is
+func (s *resourcePoolConfig) setVFHardwareAddr(ctx context.Context, vfPCIAddr string, vfConfig *vfconfig.VFConfig) error {
+	if s.vfMACSetter == nil {
+		return nil
+	}
+	hardwareAddrPool, ok := s.resourcePool.(HardwareAddrResourcePool)
+	if !ok {
+		return nil
+	}
+
+	hardwareAddr, err := hardwareAddrPool.GetHardwareAddr(vfPCIAddr)
+	if err != nil || hardwareAddr == nil {
+		return err
+	}
+	return s.vfMACSetter.SetVFMACAddress(ctx, vfConfig.PFInterfaceName, vfConfig.VFNum, hardwareAddr)
+}
+
+// setVFRepresentor sets the VF representor name to the connection context if the VF PF is in switchdev mode, PFs not
+// supporting devlink are considered to be in legacy mode
+func (s *resourcePoolConfig) setVFRepresentor(conn *networkservice.Connection, vfPCIAddr string, vfConfig *vfconfig.VFConfig) error {
+	if s.representorLookup == nil {
+		return nil
+	}
+
+	for pfPCIAddr, pfCfg := range s.config.PhysicalFunctions {
+		for _, vfCfg := range pfCfg.VirtualFunctions {
+			if vfCfg.Address != vfPCIAddr {
+				continue
+			}
+
+			switchdev, err := s.representorLookup.IsSwitchdev(pfPCIAddr)
+			if err != nil && !errors.Is(err, sriov.ErrNotSupported) {
+				return errors.Wrapf(err, "failed to get eswitch mode for the PF: %v", pfPCIAddr)
+			}
+			if !switchdev {
+				delete(conn.GetContext().GetExtraContext(), RepresentorKey)
+				return nil
+			}
+
+			representor, err := s.representorLookup.GetVFRepresentor(vfConfig.PFInterfaceName, vfConfig.VFNum)
+			if err != nil {
+				return errors.Wrapf(err, "failed to get VF representor: %v", vfPCIAddr)
+			}
+
+			if conn.GetContext() == nil {
+				conn.Context = new(networkservice.ConnectionContext)
+			}
+			if conn.GetContext().GetExtraContext() == nil {
+				conn.GetContext().ExtraContext = map[string]string{}
+			}
+			conn.GetContext().GetExtraContext()[RepresentorKey] = representor
+
+			return nil
+		}
+	}
+
+	return errors.Errorf("no VF with selected PCI address exists: %v", vfPCIAddr)
+}
+
+// commit commits the VF reserved for the connection, it fails if the VF has been preempted, so the VF should be freed
+func (s *resourcePoolConfig) commit(conn *networkservice.Connection) error {
+	if s.reservingPool == nil {
+		return nil
+	}
+
+	s.resourceLock.Lock()
+	defer s.resourceLock.Unlock()
+
 	vfPCIAddr, ok := s.selectedVFs[conn.GetId()]
 	if !ok {
 		return nil
 	}
+
+	if err := s.reservingPool.Commit(vfPCIAddr); err != nil {
+		return errors.Wrapf(err, "failed to commit VF: %v", vfPCIAddr)
+	}
+	return nil
+}
+
+func (s *resourcePoolConfig) close(ctx context.Context, conn *networkservice.Connection) error {
+	s.resourceLock.Lock()
+	defer s.resourceLock.Unlock()
+
+	vfPCIAddr, ok := s.selectedVFs[conn.GetId()]
+	if !ok {
+		return nil
+	}
+
+	if !s.isSelectedBy(ctx, vfPCIAddr, conn) {
+		return nil
+	}
 	delete(s.selectedVFs, conn.GetId())
+	delete(s.vfStatsBase, conn.GetId())
+	if s.activeConnections != nil {
+		s.activeConnections.delete(conn.GetId())
+	}
+	if s.expirer != nil {
+		s.expirer.delete(conn.GetId())
+	}
+
+	if err := s.resourcePool.Free(vfPCIAddr); err != nil {
+		return err
+	}
+	if s.rebindToKernel && s.driverType == sriov.VFIOPCIDriver {
+		s.rebindFreeIOMMUGroup(ctx, vfPCIAddr)
+	}
+	if s.waitQueue != nil {
+		s.waitQueue.dispatch()
+	}
+
+	return nil
+}
+
+// isSelectedBy returns false if the VF is selected for another token than the connection one, e.g. when the connection
+// ID has been reused by another connection, such VF must not be freed on the connection close
+func (s *resourcePoolConfig) isSelectedBy(ctx context.Context, vfPCIAddr string, conn *networkservice.Connection) bool {
+	tokenResourcePool, ok := s.resourcePool.(TokenResourcePool)
+	if !ok {
+		return true
+	}
+	connTokenID, ok := conn.GetMechanism().GetParameters()[common.DeviceTokenIDKey]
+	if !ok {
+		return true
+	}
+
+	vfTokenID, err := tokenResourcePool.GetTokenID(vfPCIAddr)
+	if err != nil || vfTokenID == "" || vfTokenID == connTokenID {
+		return true
+	}
+
+	log.FromContext(ctx).WithField("resourcePool", "close").
+		Warnf("VF %v is selected for the token %v, not for the connection %v token %v, skip freeing it",
+			vfPCIAddr, vfTokenID, conn.GetId(), connTokenID)
+	return false
+}
+
+// refreshVFInterfaceName looks the VF up by its PCI address and updates the cached VF interface name, because the
+// kernel can rename the VF interface (e.g. after it has been moved to another net namespace and back)
+func (s *resourcePoolConfig) refreshVFInterfaceName(ctx context.Context, connID string, vfConfig *vfconfig.VFConfig) {
+	if s.driverType != sriov.KernelDriver {
+		return
+	}
+
+	logger := log.FromContext(ctx).WithField("resourcePool", "refreshVFInterfaceName")
+
+	s.resourceLock.Lock()
+	vfPCIAddr, ok := s.selectedVFs[connID]
+	s.resourceLock.Unlock()
+	if !ok {
+		return
+	}
+
+	vf, err := s.pciPool.GetPCIFunction(vfPCIAddr)
+	if err != nil {
+		logger.Warnf("failed to get VF: %v", err)
+		return
+	}
+	// VF interface is not visible in the host net namespace while it is moved to the client net namespace, so the
+	// cached name is kept if the lookup fails
+	ifName, err := vf.GetNetInterfaceName()
+	if err != nil {
+		logger.Debugf("failed to get VF net interface name, using cached name %v: %v", vfConfig.VFInterfaceName, err)
+		return
+	}
+
+	if ifName != vfConfig.VFInterfaceName {
+		logger.Infof("VF %v net interface has been renamed: %v -> %v", vfPCIAddr, vfConfig.VFInterfaceName, ifName)
+		vfConfig.VFInterfaceName = ifName
+	}
+}
+
+// snapshotStats reads the VF traffic counters on the VF assignment to report the increase on Close, failures are only
+// logged because drivers are not required to support VF stats
+func (s *resourcePoolConfig) snapshotStats(ctx context.Context, connID string, vfConfig *vfconfig.VFConfig) {
+	if s.vfStatsReader == nil || vfConfig == nil {
+		return
+	}
+
+	stats, err := s.vfStatsReader.GetVFStats(vfConfig.PFInterfaceName, vfConfig.VFNum)
+	if err != nil {
+		log.FromContext(ctx).WithField("resourcePool", "snapshotStats").Warnf("failed to get VF stats: %v", err)
+		return
+	}
 
 	s.resourceLock.Lock()
 	defer s.resourceLock.Unlock()
 
-	return s.resourcePool.Free(vfPCIAddr)
+	if s.vfStatsBase == nil {
+		s.vfStatsBase = map[string]*vfnetlink.VFStats{}
+	}
+	s.vfStatsBase[connID] = stats
+}
+
+// reportStats reads the VF traffic counters and reports their increase since the VF assignment, failures are only
+// logged because drivers are not required to support VF stats
+func (s *resourcePoolConfig) reportStats(ctx context.Context, conn *networkservice.Connection, vfConfig *vfconfig.VFConfig) {
+	if s.vfStatsReader == nil || vfConfig == nil {
+		return
+	}
+
+	s.resourceLock.Lock()
+	vfPCIAddr, ok := s.selectedVFs[conn.GetId()]
+	base, hasBase := s.vfStatsBase[conn.GetId()]
+	s.resourceLock.Unlock()
+	if !ok {
+		return
+	}
+
+	logger := log.FromContext(ctx).WithField("resourcePool", "reportStats")
+	if !hasBase {
+		logger.Warnf("no VF %v stats have been read on the assignment", vfPCIAddr)
+		return
+	}
+
+	stats, err := s.vfStatsReader.GetVFStats(vfConfig.PFInterfaceName, vfConfig.VFNum)
+	if err != nil {
+		logger.Warnf("failed to get VF %v stats: %v", vfPCIAddr, err)
+		return
+	}
+
+	s.reportVFStats(ctx, conn, vfPCIAddr, stats.Sub(base))
+}
+
+func (s *resourcePoolConfig) rebindFreeIOMMUGroup(ctx context.Context, vfPCIAddr string) {
+	logger := log.FromContext(ctx).WithField("resourcePool", "rebindFreeIOMMUGroup")
+
+	vf, err := s.pciPool.GetPCIFunction(vfPCIAddr)
+	if err != nil {
+		logger.Warnf("failed to get VF: %v", err)
+		return
+	}
+	iommuGroup, err := vf.GetIOMMUGroup()
+	if err != nil {
+		logger.Warnf("failed to get VF IOMMU group: %v", err)
+		return
+	}
+
+	// resource pool marks IOMMU group as free only when there are no more selected VFs in it
+	if !s.resourcePool.IsIOMMUGroupFree(iommuGroup) {
+		return
+	}
+
+	logger.Infof("rebinding IOMMU group %v to the kernel driver", iommuGroup)
+	if err = s.pciPool.BindDriver(ctx, iommuGroup, sriov.KernelDriver); err != nil {
+		logger.Warnf("failed to rebind IOMMU group %v to the kernel driver: %v", iommuGroup, err)
+	}
+}
+
+// isTransientAssignError returns true if the VF assignment can succeed with another VF
+func isTransientAssignError(err error) bool {
+	var bindTimeoutErr *pci.BindTimeoutError
+	return errors.As(err, &bindTimeoutErr) || errors.Is(err, pci.ErrDeviceResetting)
+}
+
+// iommuGroupVFs returns all configured VFs sharing the IOMMU group with the given VF, driver is bound to the whole
+// IOMMU group, so if it fails for the VF it fails for all of them
+func (s *resourcePoolConfig) iommuGroupVFs(vfPCIAddr string) []string {
+	vfPCIAddrs := []string{vfPCIAddr}
+	for _, pfCfg := range s.config.PhysicalFunctions {
+		for _, vfCfg := range pfCfg.VirtualFunctions {
+			if vfCfg.Address != vfPCIAddr {
+				continue
+			}
+			for _, cfg := range s.config.PhysicalFunctions {
+				for _, groupVFCfg := range cfg.VirtualFunctions {
+					if groupVFCfg.IOMMUGroup == vfCfg.IOMMUGroup && groupVFCfg.Address != vfPCIAddr {
+						vfPCIAddrs = append(vfPCIAddrs, groupVFCfg.Address)
+					}
+				}
+			}
+			return vfPCIAddrs
+		}
+	}
+	return vfPCIAddrs
+}
+
+// assignVFWithRetry assigns a VF retrying with another VF on transient failures, the VFs of the IOMMU group selected
+// by the failed attempt are freed and excluded from the next attempts. Assignment failed on a resetting PF is retried
+// up to bindRetries times with the same VFs allowed, the VF is freed and the resource lock is released while waiting,
+// so the other Requests and Closes are not blocked by the resetting PF.
+func assignVFWithRetry(ctx context.Context, logger log.Logger, conn *networkservice.Connection, tokenID string, resourcePool *resourcePoolConfig, isClient bool) error {
+	var excludedVFs []string
+	resets := 0
+	for attempt := 1; ; attempt++ {
+		err := assignVF(ctx, logger, conn, tokenID, resourcePool, isClient, excludedVFs)
+		resetting := errors.Is(err, pci.ErrDeviceResetting) && resets < bindRetries
+		if err == nil || !resetting && (attempt >= resourcePool.assignAttempts || !isTransientAssignError(err)) {
+			return err
+		}
+
+		resourcePool.resourceLock.Lock()
+		vfPCIAddr, ok := resourcePool.selectedVFs[conn.GetId()]
+		resourcePool.resourceLock.Unlock()
+		if ok && !resetting {
+			excludedVFs = append(excludedVFs, resourcePool.iommuGroupVFs(vfPCIAddr)...)
+		}
+		if closeErr := resourcePool.close(ctx, conn); closeErr != nil {
+			return errors.Wrapf(err, "failed to free VF before retry: %v", closeErr)
+		}
+
+		delay := resourcePool.assignBackoff
+		if resetting {
+			resets++
+			// resetting PF retries don't use the attempts to select another VF
+			attempt--
+			delay = bindRetryDelay
+			logger.Infof("device is resetting, retrying to assign VF in %v: %v", delay, err)
+		} else {
+			logger.Warnf("failed to assign VF, retrying with another VF in %v: %v", delay, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return errors.Wrapf(err, "provided context is done: %v", ctx.Err())
+		case <-time.After(delay):
+		}
+	}
 }
 
-func assignVF(ctx context.Context, logger log.Logger, conn *networkservice.Connection, tokenID string, resourcePool *resourcePoolConfig, isClient bool) error {
+func assignVF(ctx context.Context, logger log.Logger, conn *networkservice.Connection, tokenID string, resourcePool *resourcePoolConfig, isClient bool,
+	excludedVFs []string) error {
 	resourcePool.resourceLock.Lock()
 	defer resourcePool.resourceLock.Unlock()
 
 	vfConfig := &vfconfig.VFConfig{}
 
 	logger.Infof("trying to select VF for %v", resourcePool.driverType)
-	vf, err := resourcePool.selectVF(conn.GetId(), vfConfig, tokenID)
+	vf, err := resourcePool.selectVF(ctx, conn.GetId(), vfConfig, tokenID, excludedVFs)
 	if err != nil {
 		return err
 	}
@@ -129,6 +632,10 @@ func assignVF(ctx context.Context, logger log.Logger, conn *networkservice.Conne
 		return err
 	}
 
+	if err = resourcePool.setVFHardwareAddr(ctx, vf.GetPCIAddress(), vfConfig); err != nil {
+		return errors.Wrapf(err, "failed to set VF MAC address: %v", vf.GetPCIAddress())
+	}
+
 	switch resourcePool.driverType {
 	case sriov.KernelDriver:
 		vfConfig.VFInterfaceName, err = vf.GetNetInterfaceName()
@@ -140,6 +647,10 @@ func assignVF(ctx context.Context, logger log.Logger, conn *networkservice.Conne
 	}
 	conn.GetMechanism().GetParameters()[common.PCIAddressKey] = vf.GetPCIAddress()
 
+	if err = resourcePool.setVFRepresentor(conn, vf.GetPCIAddress(), vfConfig); err != nil {
+		return err
+	}
+
 	vfconfig.Store(ctx, isClient, vfConfig)
 
 	return nil
@@ -0,0 +1,117 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package resourcepool
+
+import (
+	"sync"
+
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/vfio"
+
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov"
+)
+
+// ConnectionInfo is an active connection VF assignment details
+type ConnectionInfo struct {
+	TokenID    string
+	VFPCIAddr  string
+	DriverType sriov.DriverType
+	IOMMUGroup uint
+
+	// CgroupDir and the device numbers allowed for the client cgroup are set only for the VFIO connections
+	CgroupDir   string
+	VfioMajor   uint32
+	VfioMinor   uint32
+	DeviceMajor uint32
+	DeviceMinor uint32
+}
+
+// ActiveConnections tracks VF assignment details of the active resource pool server connections for debugging, it
+// can be shared by several resource pool servers
+type ActiveConnections struct {
+	lock  sync.Mutex
+	conns map[string]ConnectionInfo
+}
+
+// NewActiveConnections returns a new ActiveConnections
+func NewActiveConnections() *ActiveConnections {
+	return &ActiveConnections{
+		conns: map[string]ConnectionInfo{},
+	}
+}
+
+// List returns a copy of the active connections details map: connection ID -> details
+func (a *ActiveConnections) List() map[string]ConnectionInfo {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	conns := make(map[string]ConnectionInfo, len(a.conns))
+	for connID, info := range a.conns {
+		conns[connID] = info
+	}
+	return conns
+}
+
+func (a *ActiveConnections) store(connID string, info ConnectionInfo) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	a.conns[connID] = info
+}
+
+func (a *ActiveConnections) delete(connID string) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	delete(a.conns, connID)
+}
+
+// trackConnection stores the connection VF assignment details to activeConnections, VFIO details are taken from the
+// connection mechanism set by the VFIO server
+func (s *resourcePoolConfig) trackConnection(conn *networkservice.Connection, tokenID string) {
+	if s.activeConnections == nil {
+		return
+	}
+
+	s.resourceLock.Lock()
+	vfPCIAddr, ok := s.selectedVFs[conn.GetId()]
+	s.resourceLock.Unlock()
+	if !ok {
+		return
+	}
+
+	info := ConnectionInfo{
+		TokenID:    tokenID,
+		VFPCIAddr:  vfPCIAddr,
+		DriverType: s.driverType,
+	}
+	if vf, err := s.pciPool.GetPCIFunction(vfPCIAddr); err == nil {
+		info.IOMMUGroup, _ = vf.GetIOMMUGroup()
+	}
+	if mech := vfio.ToMechanism(conn.GetMechanism()); mech != nil {
+		info.CgroupDir = mech.GetCgroupDir()
+		info.VfioMajor = mech.GetVfioMajor()
+		info.VfioMinor = mech.GetVfioMinor()
+		info.DeviceMajor = mech.GetDeviceMajor()
+		info.DeviceMinor = mech.GetDeviceMinor()
+	}
+
+	s.activeConnections.store(conn.GetId(), info)
+}
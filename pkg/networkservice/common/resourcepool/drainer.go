@@ -0,0 +1,90 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package resourcepool
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// ErrDraining is returned by the resource pool server on Request after Drain has been called
+var ErrDraining = errors.New("resource pool server is draining")
+
+// Drainer tracks in-flight resource pool server Requests, Closes and allows to wait for them on shutdown
+type Drainer struct {
+	lock     sync.Mutex
+	draining bool
+	inFlight int
+	drainCh  chan struct{}
+}
+
+// NewDrainer returns a new Drainer
+func NewDrainer() *Drainer {
+	return &Drainer{
+		drainCh: make(chan struct{}),
+	}
+}
+
+// Drain stops accepting new Requests and waits for all in-flight Requests, Closes to finish, returns an error if
+// ctx is done before
+func (d *Drainer) Drain(ctx context.Context) error {
+	d.lock.Lock()
+	if !d.draining {
+		d.draining = true
+		if d.inFlight == 0 {
+			close(d.drainCh)
+		}
+	}
+	d.lock.Unlock()
+
+	select {
+	case <-d.drainCh:
+		return nil
+	case <-ctx.Done():
+		return errors.Wrap(ctx.Err(), "failed to wait for in-flight requests")
+	}
+}
+
+// start marks a new in-flight Request or Close, returned func should be called on its finish. Requests are rejected
+// after Drain has been called, Closes are not tracked after all in-flight actions have finished.
+func (d *Drainer) start(isRequest bool) (done func(), err error) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	switch {
+	case d.draining && isRequest:
+		return nil, ErrDraining
+	case d.draining && d.inFlight == 0:
+		return func() {}, nil
+	}
+	d.inFlight++
+
+	return func() {
+		d.lock.Lock()
+		defer d.lock.Unlock()
+
+		d.inFlight--
+		if d.draining && d.inFlight == 0 {
+			close(d.drainCh)
+		}
+	}, nil
+}
@@ -0,0 +1,105 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package resourcepool
+
+import (
+	"context"
+	"sync"
+
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/common/begin"
+)
+
+// Expirer closes the resource pool server connections using the VFs expired by the resource pool (see
+// resource.WithMaxVFLifetime), so the clients heal and request them again landing on the possibly different VFs, it
+// can be shared by several resource pool servers
+type Expirer struct {
+	lock  sync.Mutex
+	conns map[string]*expiringConnection
+}
+
+type expiringConnection struct {
+	tokenID      string
+	vfPCIAddr    string
+	eventFactory begin.EventFactory
+}
+
+// NewExpirer returns a new Expirer
+func NewExpirer() *Expirer {
+	return &Expirer{
+		conns: map[string]*expiringConnection{},
+	}
+}
+
+// Expire is a resource.ExpireFunc, it gracefully closes the connection using the VF selected for the token
+func (e *Expirer) Expire(tokenID, vfPCIAddr string) {
+	e.lock.Lock()
+	var eventFactory begin.EventFactory
+	for _, conn := range e.conns {
+		if conn.tokenID == tokenID && conn.vfPCIAddr == vfPCIAddr {
+			eventFactory = conn.eventFactory
+			break
+		}
+	}
+	e.lock.Unlock()
+
+	if eventFactory != nil {
+		eventFactory.Close()
+	}
+}
+
+func (e *Expirer) store(connID string, conn *expiringConnection) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	e.conns[connID] = conn
+}
+
+func (e *Expirer) delete(connID string) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	delete(e.conns, connID)
+}
+
+// trackExpiry stores the connection begin event factory to expirer to close the connection on the VF expiry
+func (s *resourcePoolConfig) trackExpiry(ctx context.Context, conn *networkservice.Connection, tokenID string) {
+	if s.expirer == nil {
+		return
+	}
+
+	eventFactory := begin.FromContext(ctx)
+	if eventFactory == nil {
+		return
+	}
+
+	s.resourceLock.Lock()
+	vfPCIAddr, ok := s.selectedVFs[conn.GetId()]
+	s.resourceLock.Unlock()
+	if !ok {
+		return
+	}
+
+	s.expirer.store(conn.GetId(), &expiringConnection{
+		tokenID:      tokenID,
+		vfPCIAddr:    vfPCIAddr,
+		eventFactory: eventFactory,
+	})
+}
@@ -0,0 +1,160 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package resourcepool
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Option is an option for NewServer
+type Option func(s *resourcePoolServer)
+
+// WithDrainer sets Drainer to track in-flight Requests, Closes
+func WithDrainer(drainer *Drainer) Option {
+	return func(s *resourcePoolServer) {
+		s.drainer = drainer
+	}
+}
+
+// WithReadiness makes server reject Requests with ErrNotReady until all the readiness pools are ready
+func WithReadiness(readiness *Readiness) Option {
+	return func(s *resourcePoolServer) {
+		s.readiness = readiness
+	}
+}
+
+// WithWaitQueue sets WaitQueue to make Requests wait in FIFO order for a VF to be freed if there is no free VF, the
+// VFs freed not by the servers sharing the WaitQueue should be reported with WaitQueue.Dispatch
+func WithWaitQueue(waitQueue *WaitQueue) Option {
+	return func(s *resourcePoolServer) {
+		s.resourcePool.waitQueue = waitQueue
+	}
+}
+
+// WithRebindToKernel makes VFIO server rebind IOMMU group back to the kernel driver on Close if there are no more
+// connections using the group
+func WithRebindToKernel() Option {
+	return func(s *resourcePoolServer) {
+		s.resourcePool.rebindToKernel = true
+	}
+}
+
+// WithTokenPool makes server check that the connection token belongs to the config before selecting a VF, and fail
+// early with ErrUnknownToken if it doesn't. Token pool is also used to find the token service domain for the allowed
+// driver types check, which is done without the option as well if the resource pool implements TokenNameResourcePool.
+func WithTokenPool(tokenPool TokenPool) Option {
+	return func(s *resourcePoolServer) {
+		s.resourcePool.tokenPool = tokenPool
+	}
+}
+
+// WithVFStatsReporter makes server read the VF traffic counters on the VF assignment and on Close and pass their
+// increase to report before the VF is freed, failures to read the counters are only logged. Both reader and report are
+// required, server fails all Requests otherwise.
+func WithVFStatsReporter(reader VFStatsReader, report VFStatsReportFunc) Option {
+	return func(s *resourcePoolServer) {
+		if reader == nil || report == nil {
+			s.optionErr = errors.New("both VF stats reader and report func are required")
+			return
+		}
+		s.resourcePool.vfStatsReader = reader
+		s.resourcePool.reportVFStats = report
+	}
+}
+
+// WithAssignRetry makes server retry the whole VF assignment up to attempts times on transient failures (the driver
+// binding timeout or the device reset), each retry waits for backoff, frees the previously selected VF and selects
+// another one, other failures (e.g. no free VF) are returned immediately
+func WithAssignRetry(attempts int, backoff time.Duration) Option {
+	return func(s *resourcePoolServer) {
+		s.resourcePool.assignAttempts = attempts
+		s.resourcePool.assignBackoff = backoff
+	}
+}
+
+// WithActiveConnections makes server track VF assignment details of the active connections in activeConnections
+func WithActiveConnections(activeConnections *ActiveConnections) Option {
+	return func(s *resourcePoolServer) {
+		s.resourcePool.activeConnections = activeConnections
+	}
+}
+
+// WithExpirer makes server close the connections using the VFs expired by the resource pool with expirer, expirer.Expire
+// should be passed to resource.WithMaxVFLifetime
+func WithExpirer(expirer *Expirer) Option {
+	return func(s *resourcePoolServer) {
+		s.resourcePool.expirer = expirer
+	}
+}
+
+// WithReservation makes server reserve the VF on the VF assignment and commit it after the rest of the chain has
+// established the connection, until then the VF can be preempted by the resource pool for a token of a higher
+// priority service domain (see resource.WithPreemption) and the Request fails. Resource pool should implement
+// ReservingResourcePool, server fails all Requests otherwise.
+func WithReservation() Option {
+	return func(s *resourcePoolServer) {
+		reservingPool, ok := s.resourcePool.resourcePool.(ReservingResourcePool)
+		if !ok {
+			s.optionErr = errors.New("resource pool doesn't support VF reservation")
+			return
+		}
+		s.resourcePool.reservingPool = reservingPool
+	}
+}
+
+// WithPreemptor makes server cancel the Requests which reserved VFs are preempted by the resource pool with preemptor,
+// preemptor.Preempt should be passed to resource.WithPreemption. It takes effect only with WithReservation.
+func WithPreemptor(preemptor *Preemptor) Option {
+	return func(s *resourcePoolServer) {
+		s.resourcePool.preemptor = preemptor
+	}
+}
+
+// WithRepresentorLookup makes server set the selected VF representor net interface name to the connection context
+// with RepresentorKey if the VF PF is in switchdev mode
+func WithRepresentorLookup(lookup RepresentorLookup) Option {
+	return func(s *resourcePoolServer) {
+		s.resourcePool.representorLookup = lookup
+	}
+}
+
+// WithVFMACSetter makes server set the MAC address allocated for the selected VF by the resource pool (see
+// resource.WithMACPool) to the VF, it does nothing if the resource pool doesn't implement HardwareAddrResourcePool
+func WithVFMACSetter(setter VFMACSetter) Option {
+	return func(s *resourcePoolServer) {
+		s.resourcePool.vfMACSetter = setter
+	}
+}
+
+// ClientOption is an option for NewClient
+type ClientOption func(c *resourcePoolClient)
+
+// WithStrictTokenID makes client fail the Request with ErrMissingTokenID and close the connection if the endpoint
+// didn't assign a token ID to the connection with one of the mechanismTypes, connections with the other mechanisms
+// are passed through unchanged
+func WithStrictTokenID(mechanismTypes ...string) ClientOption {
+	return func(c *resourcePoolClient) {
+		for _, mechanismType := range mechanismTypes {
+			c.strictMechanisms[mechanismType] = struct{}{}
+		}
+	}
+}
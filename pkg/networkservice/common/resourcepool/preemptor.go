@@ -0,0 +1,91 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package resourcepool
+
+import (
+	"context"
+	"sync"
+)
+
+// Preemptor cancels the resource pool server Requests which VFs are preempted by the resource pool (see
+// resource.WithPreemption), so the Requests fail and release the VFs to the preempting Requests, it can be shared by
+// several resource pool servers
+type Preemptor struct {
+	lock     sync.Mutex
+	requests map[string]*preemptibleRequest
+}
+
+type preemptibleRequest struct {
+	tokenID string
+	cancel  context.CancelFunc
+}
+
+// NewPreemptor returns a new Preemptor
+func NewPreemptor() *Preemptor {
+	return &Preemptor{
+		requests: map[string]*preemptibleRequest{},
+	}
+}
+
+// Preempt is a resource.PreemptFunc, it cancels the Request reserving the VF for the token, a token reserves at most one
+// VF, so the VF address is not checked
+func (p *Preemptor) Preempt(tokenID, _ string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for _, request := range p.requests {
+		if request.tokenID == tokenID {
+			request.cancel()
+		}
+	}
+}
+
+func (p *Preemptor) store(connID string, request *preemptibleRequest) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.requests[connID] = request
+}
+
+func (p *Preemptor) delete(connID string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	delete(p.requests, connID)
+}
+
+// trackPreemption returns ctx canceled when the VF reserved for the connection token gets preempted, it is tracked
+// from before the VF reservation, so the preemption cannot be missed, until the returned done is called
+func (s *resourcePoolConfig) trackPreemption(ctx context.Context, connID, tokenID string) (context.Context, func()) {
+	if s.preemptor == nil || s.reservingPool == nil {
+		return ctx, func() {}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	s.preemptor.store(connID, &preemptibleRequest{
+		tokenID: tokenID,
+		cancel:  cancel,
+	})
+
+	return ctx, func() {
+		s.preemptor.delete(connID)
+		cancel()
+	}
+}
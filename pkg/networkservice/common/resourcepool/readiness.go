@@ -0,0 +1,120 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package resourcepool
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrNotReady is returned by the resource pool server on Request until all the pools are ready, the Request can be
+// retried later. It is sent to the gRPC clients with the codes.Unavailable status code.
+var ErrNotReady = errors.New("SR-IOV pools are not ready")
+
+type notReadyError struct {
+	pools []string
+}
+
+func (e *notReadyError) Error() string {
+	return fmt.Sprintf("%v: waiting for: %v", ErrNotReady, e.pools)
+}
+
+func (e *notReadyError) Unwrap() error {
+	return ErrNotReady
+}
+
+// GRPCStatus makes gRPC send the error with the retryable status code
+func (e *notReadyError) GRPCStatus() *status.Status {
+	return status.New(codes.Unavailable, e.Error())
+}
+
+// ReadyReporter is implemented by the pools completing their initialization asynchronously
+type ReadyReporter interface {
+	// Ready returns a channel closed when the pool is ready
+	Ready() <-chan struct{}
+}
+
+// Readiness is an initialization barrier for the resource pool servers, it rejects Requests with ErrNotReady until all
+// the pools report ready
+type Readiness struct {
+	lock    sync.Mutex
+	pending map[string]struct{}
+	readyCh chan struct{}
+}
+
+// NewReadiness returns a new Readiness waiting for the pools with the given names, e.g. "pci", "resource", "token",
+// Readiness with no pools is ready from the start
+func NewReadiness(pools ...string) *Readiness {
+	r := &Readiness{
+		pending: map[string]struct{}{},
+		readyCh: make(chan struct{}),
+	}
+	for _, pool := range pools {
+		r.pending[pool] = struct{}{}
+	}
+	if len(r.pending) == 0 {
+		close(r.readyCh)
+	}
+	return r
+}
+
+// SetReady marks the pool as ready, it does nothing if the pool is not waited or has already been marked ready
+func (r *Readiness) SetReady(pool string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if _, ok := r.pending[pool]; !ok {
+		return
+	}
+	delete(r.pending, pool)
+	if len(r.pending) == 0 {
+		close(r.readyCh)
+	}
+}
+
+// Ready returns a channel closed when all the pools are ready, e.g. for the health server
+func (r *Readiness) Ready() <-chan struct{} {
+	return r.readyCh
+}
+
+// check returns ErrNotReady with the not ready pools names if there are any
+func (r *Readiness) check() error {
+	select {
+	case <-r.readyCh:
+		return nil
+	default:
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	pools := make([]string, 0, len(r.pending))
+	for pool := range r.pending {
+		pools = append(pools, pool)
+	}
+	sort.Strings(pools)
+
+	return &notReadyError{pools: pools}
+}
@@ -33,6 +33,7 @@ import (
 	"github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/common"
 	"github.com/networkservicemesh/sdk-kernel/pkg/kernel/networkservice/vfconfig"
 	"github.com/networkservicemesh/sdk/pkg/networkservice/core/next"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/utils/inject/injecterror"
 	"github.com/networkservicemesh/sdk/pkg/networkservice/utils/metadata"
 	"github.com/networkservicemesh/sdk/pkg/tools/log"
 
@@ -43,6 +44,9 @@ import (
 
 type resourcePoolServer struct {
 	resourcePool *resourcePoolConfig
+	drainer      *Drainer
+	readiness    *Readiness
+	optionErr    error
 }
 
 // NewServer returns a new resource pool server chain element
@@ -52,19 +56,44 @@ func NewServer(
 	pciPool PCIPool,
 	resourcePool ResourcePool,
 	cfg *config.Config,
+	options ...Option,
 ) networkservice.NetworkServiceServer {
-	return &resourcePoolServer{resourcePool: &resourcePoolConfig{
-		driverType:   driverType,
-		resourceLock: resourceLock,
-		pciPool:      pciPool,
-		resourcePool: resourcePool,
-		config:       cfg,
-		selectedVFs:  map[string]string{},
-	}}
+	s := &resourcePoolServer{
+		resourcePool: &resourcePoolConfig{
+			driverType:   driverType,
+			resourceLock: resourceLock,
+			pciPool:      pciPool,
+			resourcePool: resourcePool,
+			config:       cfg,
+			selectedVFs:  map[string]string{},
+		},
+		drainer:   NewDrainer(),
+		readiness: NewReadiness(),
+	}
+
+	for _, option := range options {
+		option(s)
+	}
+	if s.optionErr != nil {
+		return injecterror.NewServer(injecterror.WithError(s.optionErr))
+	}
+
+	return s
 }
 
 func (s *resourcePoolServer) Request(ctx context.Context, request *networkservice.NetworkServiceRequest) (*networkservice.Connection, error) {
 	logger := log.FromContext(ctx).WithField("resourcePoolServer", "Request")
+
+	if err := s.readiness.check(); err != nil {
+		return nil, err
+	}
+
+	done, err := s.drainer.start(true)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
 	conn := request.GetConnection()
 	tokenID, ok := conn.GetMechanism().GetParameters()[common.DeviceTokenIDKey]
 	if !ok {
@@ -73,34 +102,72 @@ func (s *resourcePoolServer) Request(ctx context.Context, request *networkservic
 	if !tokens.IsTokenID(tokenID) {
 		return nil, errors.Errorf("no SR-IOV token ID provided, got: %s", tokenID)
 	}
+	if err = s.resourcePool.checkToken(tokenID); err != nil {
+		return nil, err
+	}
+	if err = s.resourcePool.checkDriverType(tokenID); err != nil {
+		return nil, err
+	}
 
 	_, vfExists := vfconfig.Load(ctx, metadata.IsClient(s))
 
+	// requestCtx is canceled when the reserved VF gets preempted, the VF is released with ctx
+	requestCtx := ctx
 	if !vfExists {
-		err := assignVF(ctx, logger, conn, tokenID, s.resourcePool, metadata.IsClient(s))
+		var preemptionDone func()
+		requestCtx, preemptionDone = s.resourcePool.trackPreemption(ctx, conn.GetId(), tokenID)
+		defer preemptionDone()
+
+		err = assignVFWithRetry(requestCtx, logger, conn, tokenID, s.resourcePool, metadata.IsClient(s))
 		if err != nil {
-			_ = s.resourcePool.close(conn)
+			_ = s.resourcePool.close(ctx, conn)
 			return nil, err
 		}
+		vfConfig, _ := vfconfig.Load(ctx, metadata.IsClient(s))
+		s.resourcePool.snapshotStats(ctx, conn.GetId(), vfConfig)
 	}
 
-	conn, err := next.Server(ctx).Request(ctx, request)
+	conn, err = next.Server(ctx).Request(requestCtx, request)
 	if err != nil && !vfExists {
 		vfconfig.Delete(ctx, metadata.IsClient(s))
-		if closeErr := s.resourcePool.close(conn); closeErr != nil {
+		// conn is nil on error, so the VF is freed for the requested connection
+		if closeErr := s.resourcePool.close(ctx, request.GetConnection()); closeErr != nil {
 			err = errors.Wrapf(err, "connection closed with error: %s", closeErr.Error())
 		}
 		return nil, err
 	}
+	if err != nil {
+		return nil, err
+	}
+	if !vfExists {
+		if err = s.resourcePool.commit(conn); err != nil {
+			_, _ = next.Server(ctx).Close(ctx, conn)
+			vfconfig.Delete(ctx, metadata.IsClient(s))
+			_ = s.resourcePool.close(ctx, conn)
+			return nil, err
+		}
+	}
+	s.resourcePool.trackConnection(conn, tokenID)
+	s.resourcePool.trackExpiry(ctx, conn, tokenID)
 
-	return conn, err
+	return conn, nil
 }
 
 func (s *resourcePoolServer) Close(ctx context.Context, conn *networkservice.Connection) (*empty.Empty, error) {
+	done, _ := s.drainer.start(false)
+	defer done()
+
+	vfConfig, ok := vfconfig.Load(ctx, metadata.IsClient(s))
+	if ok {
+		s.resourcePool.refreshVFInterfaceName(ctx, conn.GetId(), vfConfig)
+	}
+
 	_, err := next.Server(ctx).Close(ctx, conn)
 
+	s.resourcePool.reportStats(ctx, conn, vfConfig)
+
 	vfconfig.Delete(ctx, metadata.IsClient(s))
-	closeErr := s.resourcePool.close(conn)
+	closeErr := s.resourcePool.close(ctx, conn)
 
 	if err != nil && closeErr != nil {
 		return nil, errors.Wrapf(err, "failed to free VF: %v", closeErr)
@@ -23,33 +23,49 @@ package resourcepool_test
 
 import (
 	"context"
+	"net"
+	"os"
+	"path/filepath"
 	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"github.com/vishvananda/netlink"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"github.com/networkservicemesh/api/pkg/api/networkservice"
 	"github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/common"
 	"github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/kernel"
 	"github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/vfio"
 	"github.com/networkservicemesh/sdk-kernel/pkg/kernel/networkservice/vfconfig"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/common/begin"
 	"github.com/networkservicemesh/sdk/pkg/networkservice/core/chain"
 	"github.com/networkservicemesh/sdk/pkg/networkservice/core/next"
 	"github.com/networkservicemesh/sdk/pkg/networkservice/utils/metadata"
 
+	"github.com/networkservicemesh/sdk-sriov/pkg/networkservice/common/linkstate"
 	"github.com/networkservicemesh/sdk-sriov/pkg/networkservice/common/resourcepool"
 	"github.com/networkservicemesh/sdk-sriov/pkg/sriov"
 	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/config"
 	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/pci"
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/resource"
 	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/sriovtest"
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/vfnetlink"
+	"github.com/networkservicemesh/sdk-sriov/pkg/tools/tokens"
 	"github.com/networkservicemesh/sdk-sriov/pkg/tools/yamlhelper"
 )
 
 const (
 	physicalFunctionsFilename = "physical_functions.yml"
 	configFileName            = "config.yml"
+	preemptionConfigFileName  = "preemption_config.yml"
 	pf2PciAddr                = "0000:00:02.0"
 	vf2KernelDriver           = "vf-2-driver"
 	tokenID                   = "sriov-xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx"
@@ -94,12 +110,14 @@ var samples = []*sample{
 }
 
 type vfResource struct {
-	vfConfig *vfconfig.VFConfig
+	vfConfig      *vfconfig.VFConfig
+	closeVFConfig *vfconfig.VFConfig
 }
 
 type vfResourceServer interface {
 	networkservice.NetworkServiceServer
 	getVFConfig() *vfconfig.VFConfig
+	getCloseVFConfig() *vfconfig.VFConfig
 }
 
 func newVFResourceServer() vfResourceServer {
@@ -112,6 +130,10 @@ func (s *vfResource) Request(ctx context.Context, request *networkservice.Networ
 }
 
 func (s *vfResource) Close(ctx context.Context, conn *networkservice.Connection) (*empty.Empty, error) {
+	if vfConfig, ok := vfconfig.Load(ctx, false); ok {
+		closeVFConfig := *vfConfig
+		s.closeVFConfig = &closeVFConfig
+	}
 	return next.Server(ctx).Close(ctx, conn)
 }
 
@@ -119,6 +141,10 @@ func (s *vfResource) getVFConfig() *vfconfig.VFConfig {
 	return s.vfConfig
 }
 
+func (s *vfResource) getCloseVFConfig() *vfconfig.VFConfig {
+	return s.closeVFConfig
+}
+
 func TestResourcePoolServer_Request(t *testing.T) {
 	for i := range samples {
 		sample := samples[i]
@@ -175,18 +201,1800 @@ func TestResourcePoolServer_Request(t *testing.T) {
 	}
 }
 
-type resourcePoolMock struct {
-	mock mock.Mock
+func TestResourcePoolServer_Drain(t *testing.T) {
+	var pfs map[string]*sriovtest.PCIPhysicalFunction
+	_ = yamlhelper.UnmarshalFile(physicalFunctionsFilename, &pfs)
 
-	sync.Mutex
+	conf, err := config.ReadConfig(context.TODO(), configFileName)
+	require.NoError(t, err)
+
+	testPCIPool, err := pci.NewTestPool(pfs, conf)
+	require.NoError(t, err)
+
+	pciPool := &slowPCIPool{
+		PCIPool:   testPCIPool,
+		startedCh: make(chan struct{}),
+		bindCh:    make(chan struct{}),
+	}
+
+	resourcePool := new(resourcePoolMock)
+	resourcePool.mock.On("Select", tokenID, sriov.KernelDriver).
+		Return(pfs[pf2PciAddr].Vfs[1].Addr, nil)
+	resourcePool.mock.On("Free", pfs[pf2PciAddr].Vfs[1].Addr).
+		Return(nil)
+
+	drainer := resourcepool.NewDrainer()
+	server := chain.NewNetworkServiceServer(
+		metadata.NewServer(),
+		resourcepool.NewServer(sriov.KernelDriver, new(sync.Mutex), pciPool, resourcePool, conf, resourcepool.WithDrainer(drainer)),
+	)
+
+	request := func(connID string) (*networkservice.Connection, error) {
+		return server.Request(context.TODO(), &networkservice.NetworkServiceRequest{
+			Connection: &networkservice.Connection{
+				Id: connID,
+				Mechanism: &networkservice.Mechanism{
+					Type: kernel.MECHANISM,
+					Parameters: map[string]string{
+						common.DeviceTokenIDKey: tokenID,
+					},
+				},
+			},
+		})
+	}
+
+	// 1. Slow Request
+
+	var conn *networkservice.Connection
+	requestErrCh := make(chan error, 1)
+	go func() {
+		var requestErr error
+		conn, requestErr = request("id-1")
+		requestErrCh <- requestErr
+	}()
+	<-pciPool.startedCh
+
+	// 2. Drain concurrently with the Request
+
+	drainErrCh := make(chan error, 1)
+	go func() {
+		drainErrCh <- drainer.Drain(context.TODO())
+	}()
+
+	require.Eventually(t, func() bool {
+		// Request without token fails fast if the server is not draining yet
+		_, newRequestErr := server.Request(context.TODO(), &networkservice.NetworkServiceRequest{
+			Connection: &networkservice.Connection{Id: "id-2"},
+		})
+		return errors.Is(newRequestErr, resourcepool.ErrDraining)
+	}, time.Second, 10*time.Millisecond)
+
+	_, err = request("id-2")
+	require.ErrorIs(t, err, resourcepool.ErrDraining)
+
+	select {
+	case <-drainErrCh:
+		require.FailNow(t, "Drain should wait for the in-flight Request")
+	default:
+	}
+
+	// 3. Finish the Request
+
+	close(pciPool.bindCh)
+
+	require.NoError(t, <-requestErrCh)
+	require.NoError(t, <-drainErrCh)
+
+	require.Equal(t, vf2KernelDriver, pfs[pf2PciAddr].Vfs[1].Driver)
+	require.Equal(t, pfs[pf2PciAddr].Vfs[1].Addr, conn.GetMechanism().GetParameters()[common.PCIAddressKey])
+	resourcePool.mock.AssertNumberOfCalls(t, "Select", 1)
+
+	// 4. Close is still allowed after Drain
+
+	_, err = server.Close(context.TODO(), conn)
+	require.NoError(t, err)
+
+	resourcePool.mock.AssertNumberOfCalls(t, "Free", 1)
 }
 
-func (rp *resourcePoolMock) Select(tokenID string, driverType sriov.DriverType) (string, error) {
-	rv := rp.mock.Called(tokenID, driverType)
-	return rv.String(0), rv.Error(1)
+func TestResourcePoolServer_Readiness(t *testing.T) {
+	var pfs map[string]*sriovtest.PCIPhysicalFunction
+	_ = yamlhelper.UnmarshalFile(physicalFunctionsFilename, &pfs)
+
+	conf, err := config.ReadConfig(context.TODO(), configFileName)
+	require.NoError(t, err)
+
+	pciPool, err := pci.NewTestPool(pfs, conf)
+	require.NoError(t, err)
+
+	resourcePool := new(resourcePoolMock)
+	resourcePool.mock.On("Select", tokenID, sriov.KernelDriver).
+		Return(pfs[pf2PciAddr].Vfs[1].Addr, nil)
+
+	readiness := resourcepool.NewReadiness("pci", "resource")
+	server := chain.NewNetworkServiceServer(
+		metadata.NewServer(),
+		resourcepool.NewServer(sriov.KernelDriver, new(sync.Mutex), pciPool, resourcePool, conf,
+			resourcepool.WithReadiness(readiness)),
+	)
+
+	request := func() (*networkservice.Connection, error) {
+		return server.Request(context.TODO(), &networkservice.NetworkServiceRequest{
+			Connection: &networkservice.Connection{
+				Id: "id",
+				Mechanism: &networkservice.Mechanism{
+					Type: kernel.MECHANISM,
+					Parameters: map[string]string{
+						common.DeviceTokenIDKey: tokenID,
+					},
+				},
+			},
+		})
+	}
+
+	// 1. Early Requests are rejected until all the pools are ready
+
+	_, err = request()
+	require.ErrorIs(t, err, resourcepool.ErrNotReady)
+	require.Equal(t, codes.Unavailable, status.Code(err))
+
+	readiness.SetReady("pci")
+
+	_, err = request()
+	require.ErrorIs(t, err, resourcepool.ErrNotReady)
+	require.Contains(t, err.Error(), "resource")
+
+	select {
+	case <-readiness.Ready():
+		require.FailNow(t, "Readiness should wait for all the pools")
+	default:
+	}
+	resourcePool.mock.AssertNumberOfCalls(t, "Select", 0)
+
+	// 2. Requests succeed after readiness fires
+
+	readiness.SetReady("resource")
+	<-readiness.Ready()
+
+	conn, err := request()
+	require.NoError(t, err)
+	require.Equal(t, pfs[pf2PciAddr].Vfs[1].Addr, conn.GetMechanism().GetParameters()[common.PCIAddressKey])
 }
 
-func (rp *resourcePoolMock) Free(vfPCIAddr string) error {
-	rv := rp.mock.Called(vfPCIAddr)
-	return rv.Error(0)
+func TestDrainer_Drain_Timeout(t *testing.T) {
+	var pfs map[string]*sriovtest.PCIPhysicalFunction
+	_ = yamlhelper.UnmarshalFile(physicalFunctionsFilename, &pfs)
+
+	conf, err := config.ReadConfig(context.TODO(), configFileName)
+	require.NoError(t, err)
+
+	testPCIPool, err := pci.NewTestPool(pfs, conf)
+	require.NoError(t, err)
+
+	pciPool := &slowPCIPool{
+		PCIPool:   testPCIPool,
+		startedCh: make(chan struct{}),
+		bindCh:    make(chan struct{}),
+	}
+	defer close(pciPool.bindCh)
+
+	resourcePool := new(resourcePoolMock)
+	resourcePool.mock.On("Select", tokenID, sriov.KernelDriver).
+		Return(pfs[pf2PciAddr].Vfs[1].Addr, nil)
+
+	drainer := resourcepool.NewDrainer()
+	server := chain.NewNetworkServiceServer(
+		metadata.NewServer(),
+		resourcepool.NewServer(sriov.KernelDriver, new(sync.Mutex), pciPool, resourcePool, conf, resourcepool.WithDrainer(drainer)),
+	)
+
+	go func() {
+		_, _ = server.Request(context.TODO(), &networkservice.NetworkServiceRequest{
+			Connection: &networkservice.Connection{
+				Id: "id",
+				Mechanism: &networkservice.Mechanism{
+					Type: kernel.MECHANISM,
+					Parameters: map[string]string{
+						common.DeviceTokenIDKey: tokenID,
+					},
+				},
+			},
+		})
+	}()
+	<-pciPool.startedCh
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 10*time.Millisecond)
+	defer cancel()
+
+	require.ErrorIs(t, drainer.Drain(ctx), context.DeadlineExceeded)
+}
+
+func TestResourcePoolServer_Request_BindFailed(t *testing.T) {
+	var pfs map[string]*sriovtest.PCIPhysicalFunction
+	_ = yamlhelper.UnmarshalFile(physicalFunctionsFilename, &pfs)
+
+	vfPCIAddr := pfs[pf2PciAddr].Vfs[1].Addr
+	iommuGroup := pfs[pf2PciAddr].Vfs[1].IOMMUGroup
+
+	for _, sample := range []struct {
+		name       string
+		driverType sriov.DriverType
+		mechanism  string
+		options    []pci.SimulationOption
+		attempts   int
+	}{
+		{
+			name:       "bind error",
+			driverType: sriov.KernelDriver,
+			mechanism:  kernel.MECHANISM,
+			options:    []pci.SimulationOption{pci.WithBindError(vfPCIAddr, errors.New("error"))},
+			attempts:   1,
+		},
+		{
+			name:       "bind timeout",
+			driverType: sriov.KernelDriver,
+			mechanism:  kernel.MECHANISM,
+			options:    []pci.SimulationOption{pci.WithBindLatency(time.Hour), pci.WithBindTimeout(50 * time.Millisecond)},
+			attempts:   1,
+		},
+		{
+			name:       "no VFIO group node",
+			driverType: sriov.VFIOPCIDriver,
+			mechanism:  vfio.MECHANISM,
+			options:    []pci.SimulationOption{pci.WithoutVFIOGroupNode(iommuGroup), pci.WithBindTimeout(50 * time.Millisecond)},
+			attempts:   1,
+		},
+		{
+			name:       "PF resetting",
+			driverType: sriov.KernelDriver,
+			mechanism:  kernel.MECHANISM,
+			options:    []pci.SimulationOption{pci.WithResetting(pf2PciAddr, time.Hour)},
+			// VF is freed and selected again on each of the 10 resetting PF retries
+			attempts: 11,
+		},
+	} {
+		sample := sample
+		t.Run(sample.name, func(t *testing.T) {
+			conf, err := config.ReadConfig(context.TODO(), configFileName)
+			require.NoError(t, err)
+
+			pciPool, err := pci.NewSimulatedPool(pfs, conf, sample.options...)
+			require.NoError(t, err)
+
+			resourcePool := new(resourcePoolMock)
+			resourcePool.mock.On("Select", tokenID, sample.driverType).
+				Return(vfPCIAddr, nil)
+			resourcePool.mock.On("Free", vfPCIAddr).
+				Return(nil)
+
+			resourceServerChainElem := newVFResourceServer()
+
+			server := chain.NewNetworkServiceServer(
+				metadata.NewServer(),
+				resourcepool.NewServer(sample.driverType, new(sync.Mutex), pciPool, resourcePool, conf),
+				resourceServerChainElem)
+
+			_, err = server.Request(context.TODO(), &networkservice.NetworkServiceRequest{
+				Connection: &networkservice.Connection{
+					Id: "id",
+					Mechanism: &networkservice.Mechanism{
+						Type: sample.mechanism,
+						Parameters: map[string]string{
+							common.DeviceTokenIDKey: tokenID,
+						},
+					},
+				},
+			})
+			require.Error(t, err)
+
+			resourcePool.mock.AssertNumberOfCalls(t, "Select", sample.attempts)
+			resourcePool.mock.AssertNumberOfCalls(t, "Free", sample.attempts)
+			require.Nil(t, resourceServerChainElem.getVFConfig())
+		})
+	}
+}
+
+func TestResourcePoolServer_Request_PFReset(t *testing.T) {
+	var pfs map[string]*sriovtest.PCIPhysicalFunction
+	_ = yamlhelper.UnmarshalFile(physicalFunctionsFilename, &pfs)
+
+	conf, err := config.ReadConfig(context.TODO(), configFileName)
+	require.NoError(t, err)
+
+	pciPool, err := pci.NewSimulatedPool(pfs, conf, pci.WithResetting(pf2PciAddr, 100*time.Millisecond))
+	require.NoError(t, err)
+
+	resourcePool := new(resourcePoolMock)
+	resourcePool.mock.On("Select", tokenID, sriov.KernelDriver).
+		Return(pfs[pf2PciAddr].Vfs[1].Addr, nil)
+	resourcePool.mock.On("Free", pfs[pf2PciAddr].Vfs[1].Addr).
+		Return(nil)
+
+	server := chain.NewNetworkServiceServer(
+		metadata.NewServer(),
+		resourcepool.NewServer(sriov.KernelDriver, new(sync.Mutex), pciPool, resourcePool, conf))
+
+	_, err = server.Request(context.TODO(), &networkservice.NetworkServiceRequest{
+		Connection: &networkservice.Connection{
+			Id: "id",
+			Mechanism: &networkservice.Mechanism{
+				Type: kernel.MECHANISM,
+				Parameters: map[string]string{
+					common.DeviceTokenIDKey: tokenID,
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, vf2KernelDriver, pfs[pf2PciAddr].Vfs[1].Driver)
+}
+
+func TestResourcePoolServer_Request_PFReset_Unlocked(t *testing.T) {
+	var pfs map[string]*sriovtest.PCIPhysicalFunction
+	_ = yamlhelper.UnmarshalFile(physicalFunctionsFilename, &pfs)
+
+	conf, err := config.ReadConfig(context.TODO(), configFileName)
+	require.NoError(t, err)
+
+	simulatedPool, err := pci.NewSimulatedPool(pfs, conf, pci.WithResetting(pf2PciAddr, time.Hour))
+	require.NoError(t, err)
+	pciPool := &countingPCIPool{PCIPool: simulatedPool}
+
+	resourcePool := new(resourcePoolMock)
+	resourcePool.mock.On("Select", tokenID, sriov.KernelDriver).
+		Return(pfs[pf2PciAddr].Vfs[1].Addr, nil)
+	resourcePool.mock.On("Free", pfs[pf2PciAddr].Vfs[1].Addr).
+		Return(nil)
+
+	resourceLock := new(sync.Mutex)
+	server := chain.NewNetworkServiceServer(
+		metadata.NewServer(),
+		resourcepool.NewServer(sriov.KernelDriver, resourceLock, pciPool, resourcePool, conf))
+
+	doneCh := make(chan struct{})
+	go func() {
+		defer close(doneCh)
+		_, requestErr := server.Request(context.TODO(), &networkservice.NetworkServiceRequest{
+			Connection: &networkservice.Connection{
+				Id: "id",
+				Mechanism: &networkservice.Mechanism{
+					Type: kernel.MECHANISM,
+					Parameters: map[string]string{
+						common.DeviceTokenIDKey: tokenID,
+					},
+				},
+			},
+		})
+		assert.ErrorIs(t, requestErr, pci.ErrDeviceResetting)
+	}()
+
+	// resource lock is available and the VF is freed while the Request waits to retry binding the resetting PF
+	require.Eventually(t, func() bool {
+		if atomic.LoadInt32(&pciPool.bindCalls) == 0 || !resourceLock.TryLock() {
+			return false
+		}
+		defer resourceLock.Unlock()
+
+		var selects, frees int
+		for _, call := range resourcePool.mock.Calls {
+			switch call.Method {
+			case "Select":
+				selects++
+			case "Free":
+				frees++
+			}
+		}
+		if selects != frees {
+			return false
+		}
+
+		select {
+		case <-doneCh:
+			return false
+		default:
+			return true
+		}
+	}, time.Second, 5*time.Millisecond)
+
+	<-doneCh
+}
+
+func TestResourcePoolServer_Request_BindLatency(t *testing.T) {
+	var pfs map[string]*sriovtest.PCIPhysicalFunction
+	_ = yamlhelper.UnmarshalFile(physicalFunctionsFilename, &pfs)
+
+	conf, err := config.ReadConfig(context.TODO(), configFileName)
+	require.NoError(t, err)
+
+	pciPool, err := pci.NewSimulatedPool(pfs, conf, pci.WithBindLatency(20*time.Millisecond))
+	require.NoError(t, err)
+
+	resourcePool := new(resourcePoolMock)
+	resourcePool.mock.On("Select", tokenID, sriov.KernelDriver).
+		Return(pfs[pf2PciAddr].Vfs[1].Addr, nil)
+
+	resourceServerChainElem := newVFResourceServer()
+
+	server := chain.NewNetworkServiceServer(
+		metadata.NewServer(),
+		resourcepool.NewServer(sriov.KernelDriver, new(sync.Mutex), pciPool, resourcePool, conf),
+		resourceServerChainElem)
+
+	_, err = server.Request(context.TODO(), &networkservice.NetworkServiceRequest{
+		Connection: &networkservice.Connection{
+			Id: "id",
+			Mechanism: &networkservice.Mechanism{
+				Type: kernel.MECHANISM,
+				Parameters: map[string]string{
+					common.DeviceTokenIDKey: tokenID,
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, vf2KernelDriver, pfs[pf2PciAddr].Vfs[1].Driver)
+	require.Equal(t, pfs[pf2PciAddr].Vfs[1].IfName, resourceServerChainElem.getVFConfig().VFInterfaceName)
+}
+
+func TestResourcePoolServer_Request_AssignRetry(t *testing.T) {
+	var pfs map[string]*sriovtest.PCIPhysicalFunction
+	_ = yamlhelper.UnmarshalFile(physicalFunctionsFilename, &pfs)
+
+	conf, err := config.ReadConfig(context.TODO(), configFileName)
+	require.NoError(t, err)
+
+	// VFs of the IOMMU group 1 never get bound to the VFIO driver
+	pciPool, err := pci.NewSimulatedPool(pfs, conf, pci.WithoutVFIOGroupNode(1), pci.WithBindTimeout(50*time.Millisecond))
+	require.NoError(t, err)
+
+	resourcePool := resource.NewPool(&tokenPoolStub{name: "service.domain.1/intel"}, conf, resource.WithOrderedSelection())
+
+	server := chain.NewNetworkServiceServer(
+		metadata.NewServer(),
+		resourcepool.NewServer(sriov.VFIOPCIDriver, new(sync.Mutex), pciPool, resourcePool, conf,
+			resourcepool.WithAssignRetry(2, time.Millisecond)))
+
+	// 1. First attempt times out binding the IOMMU group 1, retry selects a VF from the IOMMU group 2
+
+	conn, err := server.Request(context.TODO(), &networkservice.NetworkServiceRequest{
+		Connection: &networkservice.Connection{
+			Id: "id",
+			Mechanism: &networkservice.Mechanism{
+				Type: vfio.MECHANISM,
+				Parameters: map[string]string{
+					common.DeviceTokenIDKey: tokenID,
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, pfs[pf2PciAddr].Vfs[0].Addr, conn.GetMechanism().GetParameters()[common.PCIAddressKey])
+	require.Equal(t, pfs[pf2PciAddr].Vfs[0].IOMMUGroup, vfio.ToMechanism(conn.GetMechanism()).GetIommuGroup())
+
+	// 2. VFs failed to get bound are freed
+
+	for _, vf := range pfs["0000:00:01.0"].Vfs {
+		tokenID, getErr := resourcePool.GetTokenID(vf.Addr)
+		require.NoError(t, getErr)
+		require.Empty(t, tokenID)
+	}
+}
+
+func TestResourcePoolServer_Request_VFMACSetter(t *testing.T) {
+	var pfs map[string]*sriovtest.PCIPhysicalFunction
+	_ = yamlhelper.UnmarshalFile(physicalFunctionsFilename, &pfs)
+
+	conf, err := config.ReadConfig(context.TODO(), configFileName)
+	require.NoError(t, err)
+
+	pciPool, err := pci.NewTestPool(pfs, conf)
+	require.NoError(t, err)
+
+	macPool, err := sriov.NewMACPool("02:00:00", 0)
+	require.NoError(t, err)
+
+	resourcePool := resource.NewPool(&tokenPoolStub{name: "service.domain.1/intel"}, conf, resource.WithMACPool(macPool))
+	macSetter := &vfMACSetterStub{}
+
+	server := chain.NewNetworkServiceServer(
+		metadata.NewServer(),
+		resourcepool.NewServer(sriov.KernelDriver, new(sync.Mutex), pciPool, resourcePool, conf,
+			resourcepool.WithVFMACSetter(macSetter)))
+
+	conn, err := server.Request(context.TODO(), &networkservice.NetworkServiceRequest{
+		Connection: &networkservice.Connection{
+			Id: "id",
+			Mechanism: &networkservice.Mechanism{
+				Type: kernel.MECHANISM,
+				Parameters: map[string]string{
+					common.DeviceTokenIDKey: tokenID,
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	mac, err := resourcePool.GetHardwareAddr(conn.GetMechanism().GetParameters()[common.PCIAddressKey])
+	require.NoError(t, err)
+	require.NotNil(t, mac)
+	require.Equal(t, []net.HardwareAddr{mac}, macSetter.macs)
+}
+
+func TestResourcePoolServer_Close_VFRenamed(t *testing.T) {
+	var pfs map[string]*sriovtest.PCIPhysicalFunction
+	_ = yamlhelper.UnmarshalFile(physicalFunctionsFilename, &pfs)
+
+	conf, err := config.ReadConfig(context.TODO(), configFileName)
+	require.NoError(t, err)
+
+	pciPool, err := pci.NewTestPool(pfs, conf)
+	require.NoError(t, err)
+
+	resourcePool := new(resourcePoolMock)
+	resourcePool.mock.On("Select", tokenID, sriov.KernelDriver).
+		Return(pfs[pf2PciAddr].Vfs[1].Addr, nil)
+	resourcePool.mock.On("Free", pfs[pf2PciAddr].Vfs[1].Addr).
+		Return(nil)
+
+	resourceServerChainElem := newVFResourceServer()
+
+	server := chain.NewNetworkServiceServer(
+		metadata.NewServer(),
+		resourcepool.NewServer(sriov.KernelDriver, new(sync.Mutex), pciPool, resourcePool, conf),
+		resourceServerChainElem)
+
+	conn, err := server.Request(context.TODO(), &networkservice.NetworkServiceRequest{
+		Connection: &networkservice.Connection{
+			Id: "id",
+			Mechanism: &networkservice.Mechanism{
+				Type: kernel.MECHANISM,
+				Parameters: map[string]string{
+					common.DeviceTokenIDKey: tokenID,
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, pfs[pf2PciAddr].Vfs[1].IfName, resourceServerChainElem.getVFConfig().VFInterfaceName)
+
+	// Kernel renames the VF interface
+
+	pfs[pf2PciAddr].Vfs[1].IfName = "enp2s0v1"
+
+	_, err = server.Close(context.TODO(), conn)
+	require.NoError(t, err)
+
+	require.Equal(t, "enp2s0v1", resourceServerChainElem.getCloseVFConfig().VFInterfaceName)
+	resourcePool.mock.AssertCalled(t, "Free", pfs[pf2PciAddr].Vfs[1].Addr)
+}
+
+func TestResourcePoolServer_Close_VFStats(t *testing.T) {
+	var pfs map[string]*sriovtest.PCIPhysicalFunction
+	_ = yamlhelper.UnmarshalFile(physicalFunctionsFilename, &pfs)
+
+	conf, err := config.ReadConfig(context.TODO(), configFileName)
+	require.NoError(t, err)
+
+	pciPool, err := pci.NewTestPool(pfs, conf)
+	require.NoError(t, err)
+
+	resourcePool := new(resourcePoolMock)
+	resourcePool.mock.On("Select", tokenID, sriov.KernelDriver).
+		Return(pfs[pf2PciAddr].Vfs[1].Addr, nil)
+	resourcePool.mock.On("Free", pfs[pf2PciAddr].Vfs[1].Addr).
+		Return(nil)
+
+	handle := sriovtest.NewNetlinkHandle()
+	handle.AddPhysicalFunction(pfs[pf2PciAddr].IfName, len(pfs[pf2PciAddr].Vfs))
+	handle.UpdateVF(pfs[pf2PciAddr].IfName, 1, func(vfInfo *netlink.VfInfo) {
+		vfInfo.RxBytes = 300
+		vfInfo.TxBytes = 500
+	})
+
+	var reportedStats *vfnetlink.VFStats
+	server := chain.NewNetworkServiceServer(
+		metadata.NewServer(),
+		resourcepool.NewServer(sriov.KernelDriver, new(sync.Mutex), pciPool, resourcePool, conf,
+			resourcepool.WithVFStatsReporter(vfnetlink.NewConfigurator(vfnetlink.WithHandle(handle)),
+				func(_ context.Context, _ *networkservice.Connection, vfPCIAddr string, stats *vfnetlink.VFStats) {
+					require.Equal(t, pfs[pf2PciAddr].Vfs[1].Addr, vfPCIAddr)
+					resourcePool.mock.AssertNotCalled(t, "Free", vfPCIAddr)
+					reportedStats = stats
+				})))
+
+	conn, err := server.Request(context.TODO(), &networkservice.NetworkServiceRequest{
+		Connection: &networkservice.Connection{
+			Id: "id",
+			Mechanism: &networkservice.Mechanism{
+				Type: kernel.MECHANISM,
+				Parameters: map[string]string{
+					common.DeviceTokenIDKey: tokenID,
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	handle.UpdateVF(pfs[pf2PciAddr].IfName, 1, func(vfInfo *netlink.VfInfo) {
+		vfInfo.RxBytes = 1000
+		vfInfo.TxBytes = 2000
+	})
+
+	_, err = server.Close(context.TODO(), conn)
+	require.NoError(t, err)
+
+	// Only the counters increase since the VF assignment is reported
+	require.Equal(t, &vfnetlink.VFStats{RxBytes: 700, TxBytes: 1500}, reportedStats)
+	resourcePool.mock.AssertCalled(t, "Free", pfs[pf2PciAddr].Vfs[1].Addr)
+}
+
+func TestResourcePoolServer_VFStatsReporter_Invalid(t *testing.T) {
+	conf, err := config.ReadConfig(context.TODO(), configFileName)
+	require.NoError(t, err)
+
+	resourcePool := new(resourcePoolMock)
+
+	server := resourcepool.NewServer(sriov.KernelDriver, new(sync.Mutex), nil, resourcePool, conf,
+		resourcepool.WithVFStatsReporter(vfnetlink.NewConfigurator(vfnetlink.WithHandle(sriovtest.NewNetlinkHandle())), nil))
+
+	_, err = server.Request(context.TODO(), &networkservice.NetworkServiceRequest{
+		Connection: &networkservice.Connection{
+			Id: "id",
+			Mechanism: &networkservice.Mechanism{
+				Type: kernel.MECHANISM,
+				Parameters: map[string]string{
+					common.DeviceTokenIDKey: tokenID,
+				},
+			},
+		},
+	})
+	require.Error(t, err)
+	resourcePool.mock.AssertNotCalled(t, "Select", tokenID, sriov.KernelDriver)
+}
+
+func TestResourcePoolServer_Request_VFRepresentor(t *testing.T) {
+	for _, switchdev := range []bool{true, false} {
+		t.Run(map[bool]string{true: "switchdev", false: "legacy"}[switchdev], func(t *testing.T) {
+			var pfs map[string]*sriovtest.PCIPhysicalFunction
+			_ = yamlhelper.UnmarshalFile(physicalFunctionsFilename, &pfs)
+
+			conf, err := config.ReadConfig(context.TODO(), configFileName)
+			require.NoError(t, err)
+
+			pciPool, err := pci.NewTestPool(pfs, conf)
+			require.NoError(t, err)
+
+			resourcePool := new(resourcePoolMock)
+			resourcePool.mock.On("Select", tokenID, sriov.KernelDriver).
+				Return(pfs[pf2PciAddr].Vfs[1].Addr, nil)
+
+			handle := sriovtest.NewNetlinkHandle()
+			if switchdev {
+				handle.SetEswitchMode(pf2PciAddr, vfnetlink.EswitchModeSwitchdev)
+			}
+
+			netClassPath := t.TempDir()
+			for ifName, portName := range map[string]string{pfs[pf2PciAddr].IfName: "p0", "pf0vf0-rep": "pf0vf0", "pf0vf1-rep": "pf0vf1"} {
+				require.NoError(t, os.MkdirAll(filepath.Join(netClassPath, ifName), 0o750))
+				require.NoError(t, os.WriteFile(filepath.Join(netClassPath, ifName, "phys_switch_id"), []byte("abcd"), 0o600))
+				require.NoError(t, os.WriteFile(filepath.Join(netClassPath, ifName, "phys_port_name"), []byte(portName), 0o600))
+			}
+
+			server := chain.NewNetworkServiceServer(
+				metadata.NewServer(),
+				resourcepool.NewServer(sriov.KernelDriver, new(sync.Mutex), pciPool, resourcePool, conf,
+					resourcepool.WithRepresentorLookup(vfnetlink.NewConfigurator(
+						vfnetlink.WithHandle(handle), vfnetlink.WithNetClassPath(netClassPath)))))
+
+			conn, err := server.Request(context.TODO(), &networkservice.NetworkServiceRequest{
+				Connection: &networkservice.Connection{
+					Id: "id",
+					Mechanism: &networkservice.Mechanism{
+						Type: kernel.MECHANISM,
+						Parameters: map[string]string{
+							common.DeviceTokenIDKey: tokenID,
+						},
+					},
+				},
+			})
+			require.NoError(t, err)
+
+			representor, ok := conn.GetContext().GetExtraContext()[resourcepool.RepresentorKey]
+			require.Equal(t, switchdev, ok)
+			if switchdev {
+				require.Equal(t, "pf0vf1-rep", representor)
+			}
+		})
+	}
+}
+
+func TestResourcePoolServer_Close_VFStatsNotSupported(t *testing.T) {
+	var pfs map[string]*sriovtest.PCIPhysicalFunction
+	_ = yamlhelper.UnmarshalFile(physicalFunctionsFilename, &pfs)
+
+	conf, err := config.ReadConfig(context.TODO(), configFileName)
+	require.NoError(t, err)
+
+	pciPool, err := pci.NewTestPool(pfs, conf)
+	require.NoError(t, err)
+
+	resourcePool := new(resourcePoolMock)
+	resourcePool.mock.On("Select", tokenID, sriov.KernelDriver).
+		Return(pfs[pf2PciAddr].Vfs[1].Addr, nil)
+	resourcePool.mock.On("Free", pfs[pf2PciAddr].Vfs[1].Addr).
+		Return(nil)
+
+	// PF link is missing, so VF stats can't be read
+	server := chain.NewNetworkServiceServer(
+		metadata.NewServer(),
+		resourcepool.NewServer(sriov.KernelDriver, new(sync.Mutex), pciPool, resourcePool, conf,
+			resourcepool.WithVFStatsReporter(vfnetlink.NewConfigurator(vfnetlink.WithHandle(sriovtest.NewNetlinkHandle())),
+				func(context.Context, *networkservice.Connection, string, *vfnetlink.VFStats) {
+					require.FailNow(t, "stats should not be reported")
+				})))
+
+	conn, err := server.Request(context.TODO(), &networkservice.NetworkServiceRequest{
+		Connection: &networkservice.Connection{
+			Id: "id",
+			Mechanism: &networkservice.Mechanism{
+				Type: kernel.MECHANISM,
+				Parameters: map[string]string{
+					common.DeviceTokenIDKey: tokenID,
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	_, err = server.Close(context.TODO(), conn)
+	require.NoError(t, err)
+	resourcePool.mock.AssertCalled(t, "Free", pfs[pf2PciAddr].Vfs[1].Addr)
+}
+
+func TestResourcePoolServer_Request_UnknownToken(t *testing.T) {
+	var pfs map[string]*sriovtest.PCIPhysicalFunction
+	_ = yamlhelper.UnmarshalFile(physicalFunctionsFilename, &pfs)
+
+	conf, err := config.ReadConfig(context.TODO(), configFileName)
+	require.NoError(t, err)
+
+	pciPool, err := pci.NewTestPool(pfs, conf)
+	require.NoError(t, err)
+
+	request := func() *networkservice.NetworkServiceRequest {
+		return &networkservice.NetworkServiceRequest{
+			Connection: &networkservice.Connection{
+				Id: "id",
+				Mechanism: &networkservice.Mechanism{
+					Type: kernel.MECHANISM,
+					Parameters: map[string]string{
+						common.DeviceTokenIDKey: tokenID,
+					},
+				},
+			},
+		}
+	}
+
+	// Token name is not served by this config
+
+	resourcePool := new(resourcePoolMock)
+	server := chain.NewNetworkServiceServer(
+		metadata.NewServer(),
+		resourcepool.NewServer(sriov.KernelDriver, new(sync.Mutex), pciPool, resourcePool, conf,
+			resourcepool.WithTokenPool(&tokenPoolStub{name: "service.domain.2/intel"})),
+	)
+
+	_, err = server.Request(context.TODO(), request())
+	require.ErrorIs(t, err, resourcepool.ErrUnknownToken)
+	resourcePool.mock.AssertNotCalled(t, "Select", tokenID, sriov.KernelDriver)
+
+	// Token name is served by this config
+
+	resourcePool.mock.On("Select", tokenID, sriov.KernelDriver).
+		Return(pfs[pf2PciAddr].Vfs[1].Addr, nil)
+
+	server = chain.NewNetworkServiceServer(
+		metadata.NewServer(),
+		resourcepool.NewServer(sriov.KernelDriver, new(sync.Mutex), pciPool, resourcePool, conf,
+			resourcepool.WithTokenPool(&tokenPoolStub{name: "service.domain.1/intel"})),
+	)
+
+	_, err = server.Request(context.TODO(), request())
+	require.NoError(t, err)
+	resourcePool.mock.AssertNumberOfCalls(t, "Select", 1)
+}
+
+func TestResourcePoolServer_Request_QoSClassToken(t *testing.T) {
+	var pfs map[string]*sriovtest.PCIPhysicalFunction
+	_ = yamlhelper.UnmarshalFile(physicalFunctionsFilename, &pfs)
+
+	conf, err := config.ReadConfig(context.TODO(), configFileName)
+	require.NoError(t, err)
+	conf.PhysicalFunctions[pf2PciAddr].VirtualFunctions[1].QoSClass = "guaranteed"
+
+	pciPool, err := pci.NewTestPool(pfs, conf)
+	require.NoError(t, err)
+
+	request := func() *networkservice.NetworkServiceRequest {
+		return &networkservice.NetworkServiceRequest{
+			Connection: &networkservice.Connection{
+				Id: "id",
+				Mechanism: &networkservice.Mechanism{
+					Type: kernel.MECHANISM,
+					Parameters: map[string]string{
+						common.DeviceTokenIDKey: tokenID,
+					},
+				},
+			},
+		}
+	}
+
+	// QoS class token is served by the PF with the QoS class VFs
+
+	resourcePool := new(resourcePoolMock)
+	resourcePool.mock.On("Select", tokenID, sriov.KernelDriver).
+		Return(pfs[pf2PciAddr].Vfs[1].Addr, nil)
+
+	server := chain.NewNetworkServiceServer(
+		metadata.NewServer(),
+		resourcepool.NewServer(sriov.KernelDriver, new(sync.Mutex), pciPool, resourcePool, conf,
+			resourcepool.WithTokenPool(&tokenPoolStub{name: "service.domain.1/intel.guaranteed"})),
+	)
+
+	_, err = server.Request(context.TODO(), request())
+	require.NoError(t, err)
+	resourcePool.mock.AssertNumberOfCalls(t, "Select", 1)
+
+	// Service domain is not allowed on the PFs NUMA node
+
+	numaNode := 0
+	for _, pfCfg := range conf.PhysicalFunctions {
+		pfCfg.NUMANode = &numaNode
+	}
+	conf.ServiceDomains = map[string]*config.ServiceDomain{
+		"service.domain.1": {AllowedNUMANodes: []int{1}},
+	}
+
+	server = chain.NewNetworkServiceServer(
+		metadata.NewServer(),
+		resourcepool.NewServer(sriov.KernelDriver, new(sync.Mutex), pciPool, resourcePool, conf,
+			resourcepool.WithTokenPool(&tokenPoolStub{name: "service.domain.1/intel"})),
+	)
+
+	_, err = server.Request(context.TODO(), request())
+	require.ErrorIs(t, err, resourcepool.ErrUnknownToken)
+	resourcePool.mock.AssertNumberOfCalls(t, "Select", 1)
+}
+
+func TestResourcePoolServer_Request_DriverTypeNotAllowed(t *testing.T) {
+	var pfs map[string]*sriovtest.PCIPhysicalFunction
+	_ = yamlhelper.UnmarshalFile(physicalFunctionsFilename, &pfs)
+
+	conf, err := config.ReadConfig(context.TODO(), configFileName)
+	require.NoError(t, err)
+	conf.ServiceDomains = map[string]*config.ServiceDomain{
+		"service.domain.1": {AllowedDriverTypes: []string{string(sriov.VFIOPCIDriver)}},
+	}
+
+	pciPool, err := pci.NewTestPool(pfs, conf)
+	require.NoError(t, err)
+
+	resourcePool := new(resourcePoolMock)
+	resourcePool.mock.On("Select", tokenID, sriov.VFIOPCIDriver).
+		Return(pfs[pf2PciAddr].Vfs[1].Addr, nil)
+
+	request := func(mechanism string) *networkservice.NetworkServiceRequest {
+		return &networkservice.NetworkServiceRequest{
+			Connection: &networkservice.Connection{
+				Id: "id",
+				Mechanism: &networkservice.Mechanism{
+					Type: mechanism,
+					Parameters: map[string]string{
+						common.DeviceTokenIDKey: tokenID,
+					},
+				},
+			},
+		}
+	}
+
+	// Kernel driver is not allowed
+
+	server := chain.NewNetworkServiceServer(
+		metadata.NewServer(),
+		resourcepool.NewServer(sriov.KernelDriver, new(sync.Mutex), pciPool, resourcePool, conf,
+			resourcepool.WithTokenPool(&tokenPoolStub{name: "service.domain.1/intel"})),
+	)
+
+	_, err = server.Request(context.TODO(), request(kernel.MECHANISM))
+	require.ErrorIs(t, err, resourcepool.ErrDriverTypeNotAllowed)
+	resourcePool.mock.AssertNotCalled(t, "Select", tokenID, sriov.KernelDriver)
+
+	// VFIO driver is allowed
+
+	server = chain.NewNetworkServiceServer(
+		metadata.NewServer(),
+		resourcepool.NewServer(sriov.VFIOPCIDriver, new(sync.Mutex), pciPool, resourcePool, conf,
+			resourcepool.WithTokenPool(&tokenPoolStub{name: "service.domain.1/intel"})),
+	)
+
+	_, err = server.Request(context.TODO(), request(vfio.MECHANISM))
+	require.NoError(t, err)
+	resourcePool.mock.AssertNumberOfCalls(t, "Select", 1)
+
+	// Service domain can't be found without token pool
+
+	server = chain.NewNetworkServiceServer(
+		metadata.NewServer(),
+		resourcepool.NewServer(sriov.VFIOPCIDriver, new(sync.Mutex), pciPool, resourcePool, conf),
+	)
+
+	_, err = server.Request(context.TODO(), request(vfio.MECHANISM))
+	require.ErrorIs(t, err, resourcepool.ErrDriverTypeNotAllowed)
+	resourcePool.mock.AssertNumberOfCalls(t, "Select", 1)
+}
+
+func TestResourcePoolServer_Request_DriverTypeNotAllowed_ResourcePool(t *testing.T) {
+	var pfs map[string]*sriovtest.PCIPhysicalFunction
+	_ = yamlhelper.UnmarshalFile(physicalFunctionsFilename, &pfs)
+
+	conf, err := config.ReadConfig(context.TODO(), configFileName)
+	require.NoError(t, err)
+	conf.ServiceDomains = map[string]*config.ServiceDomain{
+		"service.domain.1": {AllowedDriverTypes: []string{string(sriov.VFIOPCIDriver)}},
+	}
+
+	pciPool, err := pci.NewTestPool(pfs, conf)
+	require.NoError(t, err)
+
+	// Service domain is found with the resource pool the same way as in the forwarder, there is no token pool set
+
+	resourcePool := resource.NewPool(&tokenPoolStub{name: "service.domain.1/intel"}, conf)
+	server := chain.NewNetworkServiceServer(
+		metadata.NewServer(),
+		resourcepool.NewServer(sriov.KernelDriver, new(sync.Mutex), pciPool, resourcePool, conf),
+	)
+
+	_, err = server.Request(context.TODO(), &networkservice.NetworkServiceRequest{
+		Connection: &networkservice.Connection{
+			Id: "id",
+			Mechanism: &networkservice.Mechanism{
+				Type: kernel.MECHANISM,
+				Parameters: map[string]string{
+					common.DeviceTokenIDKey: tokenID,
+				},
+			},
+		},
+	})
+	require.ErrorIs(t, err, resourcepool.ErrDriverTypeNotAllowed)
+	require.Empty(t, resourcePool.TokenToVF())
+}
+
+func TestResourcePoolServer_WaitQueue(t *testing.T) {
+	var pfs map[string]*sriovtest.PCIPhysicalFunction
+	_ = yamlhelper.UnmarshalFile(physicalFunctionsFilename, &pfs)
+
+	conf := &config.Config{
+		PhysicalFunctions: map[string]*config.PhysicalFunction{
+			pf2PciAddr: {
+				PFKernelDriver: "pf-2-driver",
+				VFKernelDriver: vf2KernelDriver,
+				Capabilities:   []string{"intel"},
+				ServiceDomains: []string{"service.domain.1"},
+				VirtualFunctions: []*config.VirtualFunction{
+					{
+						Address:    pfs[pf2PciAddr].Vfs[0].Addr,
+						IOMMUGroup: pfs[pf2PciAddr].Vfs[0].IOMMUGroup,
+					},
+				},
+			},
+		},
+	}
+
+	pciPool, err := pci.NewTestPool(pfs, conf)
+	require.NoError(t, err)
+
+	resourceLock := new(sync.Mutex)
+	waitQueue := resourcepool.NewWaitQueue()
+	server := chain.NewNetworkServiceServer(
+		metadata.NewServer(),
+		resourcepool.NewServer(sriov.KernelDriver, resourceLock, pciPool,
+			resource.NewPool(&tokenPoolStub{name: "service.domain.1/intel"}, conf), conf,
+			resourcepool.WithWaitQueue(waitQueue)),
+	)
+
+	request := func(ctx context.Context, connID string) (*networkservice.Connection, error) {
+		return server.Request(ctx, &networkservice.NetworkServiceRequest{
+			Connection: &networkservice.Connection{
+				Id: connID,
+				Mechanism: &networkservice.Mechanism{
+					Type: kernel.MECHANISM,
+					Parameters: map[string]string{
+						common.DeviceTokenIDKey: tokens.NewTokenID(),
+					},
+				},
+			},
+		})
+	}
+	requestAsync := func(connID string) <-chan *networkservice.Connection {
+		connCh := make(chan *networkservice.Connection, 1)
+		go func() {
+			conn, requestErr := request(context.TODO(), connID)
+			assert.NoError(t, requestErr)
+			connCh <- conn
+		}()
+		return connCh
+	}
+	queueLen := func() int {
+		resourceLock.Lock()
+		defer resourceLock.Unlock()
+		return waitQueue.Len()
+	}
+
+	// 1. Select the only VF
+
+	conn, err := request(context.TODO(), "id-0")
+	require.NoError(t, err)
+
+	// 2. Make 2 Requests wait in order
+
+	connCh1 := requestAsync("id-1")
+	require.Eventually(t, func() bool { return queueLen() == 1 }, time.Second, 10*time.Millisecond)
+
+	connCh2 := requestAsync("id-2")
+	require.Eventually(t, func() bool { return queueLen() == 2 }, time.Second, 10*time.Millisecond)
+
+	// 3. Waiting Request with canceled context leaves the queue
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err = request(ctx, "id-3")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Equal(t, 2, queueLen())
+
+	// 4. Freed VF goes to the longest waiting Request
+
+	_, err = server.Close(context.TODO(), conn)
+	require.NoError(t, err)
+
+	select {
+	case conn = <-connCh1:
+		require.Equal(t, pfs[pf2PciAddr].Vfs[0].Addr, conn.GetMechanism().GetParameters()[common.PCIAddressKey])
+	case <-connCh2:
+		require.FailNow(t, "VF should be selected for the first waiting Request")
+	case <-time.After(time.Second):
+		require.FailNow(t, "VF should be selected for the first waiting Request")
+	}
+	require.Equal(t, 1, queueLen())
+
+	_, err = server.Close(context.TODO(), conn)
+	require.NoError(t, err)
+
+	select {
+	case conn = <-connCh2:
+		require.Equal(t, pfs[pf2PciAddr].Vfs[0].Addr, conn.GetMechanism().GetParameters()[common.PCIAddressKey])
+	case <-time.After(time.Second):
+		require.FailNow(t, "VF should be selected for the second waiting Request")
+	}
+	require.Equal(t, 0, queueLen())
+
+	_, err = server.Close(context.TODO(), conn)
+	require.NoError(t, err)
+}
+
+func TestResourcePoolServer_WaitQueue_Expiry(t *testing.T) {
+	var pfs map[string]*sriovtest.PCIPhysicalFunction
+	_ = yamlhelper.UnmarshalFile(physicalFunctionsFilename, &pfs)
+
+	conf := &config.Config{
+		PhysicalFunctions: map[string]*config.PhysicalFunction{
+			pf2PciAddr: {
+				PFKernelDriver: "pf-2-driver",
+				VFKernelDriver: vf2KernelDriver,
+				Capabilities:   []string{"intel"},
+				ServiceDomains: []string{"service.domain.1"},
+				VirtualFunctions: []*config.VirtualFunction{
+					{
+						Address:    pfs[pf2PciAddr].Vfs[0].Addr,
+						IOMMUGroup: pfs[pf2PciAddr].Vfs[0].IOMMUGroup,
+					},
+				},
+			},
+		},
+	}
+
+	pciPool, err := pci.NewTestPool(pfs, conf)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	resourceLock := new(sync.Mutex)
+	waitQueue := resourcepool.NewWaitQueue()
+	expirer := resourcepool.NewExpirer()
+	resourcePool := resource.NewPool(&tokenPoolStub{name: "service.domain.1/intel"}, conf,
+		resource.WithMaxVFLifetime(ctx, 100*time.Millisecond, resourceLock, expirer.Expire),
+		resource.WithReleaseListener(func() { waitQueue.Dispatch(resourceLock) }))
+
+	// expiringServer doesn't use the WaitQueue, VF freed on its connection expiry is reported by the resource pool
+	expiringServer := chain.NewNetworkServiceServer(
+		begin.NewServer(),
+		metadata.NewServer(),
+		resourcepool.NewServer(sriov.KernelDriver, resourceLock, pciPool, resourcePool, conf,
+			resourcepool.WithExpirer(expirer)),
+	)
+	waitingServer := chain.NewNetworkServiceServer(
+		metadata.NewServer(),
+		resourcepool.NewServer(sriov.KernelDriver, resourceLock, pciPool, resourcePool, conf,
+			resourcepool.WithWaitQueue(waitQueue)),
+	)
+
+	request := func(server networkservice.NetworkServiceServer, connID string) (*networkservice.Connection, error) {
+		return server.Request(context.TODO(), &networkservice.NetworkServiceRequest{
+			Connection: &networkservice.Connection{
+				Id: connID,
+				Mechanism: &networkservice.Mechanism{
+					Type: kernel.MECHANISM,
+					Parameters: map[string]string{
+						common.DeviceTokenIDKey: tokens.NewTokenID(),
+					},
+				},
+			},
+		})
+	}
+
+	_, err = request(expiringServer, "id-1")
+	require.NoError(t, err)
+
+	connCh := make(chan *networkservice.Connection, 1)
+	go func() {
+		conn, requestErr := request(waitingServer, "id-2")
+		assert.NoError(t, requestErr)
+		connCh <- conn
+	}()
+
+	// Waiting Request gets the VF freed on the expiry
+
+	select {
+	case conn := <-connCh:
+		require.Equal(t, pfs[pf2PciAddr].Vfs[0].Addr, conn.GetMechanism().GetParameters()[common.PCIAddressKey])
+
+		_, err = waitingServer.Close(context.TODO(), conn)
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		require.FailNow(t, "VF freed on the expiry should be selected for the waiting Request")
+	}
+}
+
+func TestResourcePoolServer_WaitQueue_DriverConflict(t *testing.T) {
+	var pfs map[string]*sriovtest.PCIPhysicalFunction
+	_ = yamlhelper.UnmarshalFile(physicalFunctionsFilename, &pfs)
+
+	conf := &config.Config{
+		PhysicalFunctions: map[string]*config.PhysicalFunction{
+			pf2PciAddr: {
+				PFKernelDriver: "pf-2-driver",
+				VFKernelDriver: vf2KernelDriver,
+				Capabilities:   []string{"intel"},
+				ServiceDomains: []string{"service.domain.1"},
+				VirtualFunctions: []*config.VirtualFunction{
+					{
+						Address:    pfs[pf2PciAddr].Vfs[0].Addr,
+						IOMMUGroup: pfs[pf2PciAddr].Vfs[0].IOMMUGroup,
+					},
+					{
+						Address:    pfs[pf2PciAddr].Vfs[1].Addr,
+						IOMMUGroup: pfs[pf2PciAddr].Vfs[1].IOMMUGroup,
+					},
+				},
+			},
+		},
+	}
+
+	pciPool, err := pci.NewTestPool(pfs, conf)
+	require.NoError(t, err)
+
+	resourceLock := new(sync.Mutex)
+	resourcePool := resource.NewPool(&tokenPoolStub{name: "service.domain.1/intel"}, conf)
+	waitQueue := resourcepool.NewWaitQueue()
+	newServer := func(driverType sriov.DriverType) networkservice.NetworkServiceServer {
+		return chain.NewNetworkServiceServer(
+			metadata.NewServer(),
+			resourcepool.NewServer(driverType, resourceLock, pciPool, resourcePool, conf,
+				resourcepool.WithWaitQueue(waitQueue)),
+		)
+	}
+	request := func(connID string) *networkservice.NetworkServiceRequest {
+		return &networkservice.NetworkServiceRequest{
+			Connection: &networkservice.Connection{
+				Id: connID,
+				Mechanism: &networkservice.Mechanism{
+					Type: kernel.MECHANISM,
+					Parameters: map[string]string{
+						common.DeviceTokenIDKey: tokens.NewTokenID(),
+					},
+				},
+			},
+		}
+	}
+
+	_, err = newServer(sriov.KernelDriver).Request(context.TODO(), request("id-1"))
+	require.NoError(t, err)
+
+	// Free VF is in the IOMMU group used by the kernel driver, freeing a VF doesn't resolve it, so Request doesn't wait
+
+	ctx, cancel := context.WithTimeout(context.TODO(), time.Second)
+	defer cancel()
+
+	_, err = newServer(sriov.VFIOPCIDriver).Request(ctx, request("id-2"))
+	require.ErrorIs(t, err, resource.ErrNoFreeVF)
+	require.NoError(t, ctx.Err())
+
+	resourceLock.Lock()
+	defer resourceLock.Unlock()
+	require.Equal(t, 0, waitQueue.Len())
+}
+
+func TestResourcePoolServer_Close_RebindToKernel(t *testing.T) {
+	var pfs map[string]*sriovtest.PCIPhysicalFunction
+	_ = yamlhelper.UnmarshalFile(physicalFunctionsFilename, &pfs)
+
+	conf, err := config.ReadConfig(context.TODO(), configFileName)
+	require.NoError(t, err)
+
+	pciPool, err := pci.NewTestPool(pfs, conf)
+	require.NoError(t, err)
+
+	server := chain.NewNetworkServiceServer(
+		metadata.NewServer(),
+		resourcepool.NewServer(sriov.VFIOPCIDriver, new(sync.Mutex), pciPool,
+			resource.NewPool(&tokenPoolStub{name: "service.domain.1/intel"}, conf, resource.WithOrderedSelection()), conf,
+			resourcepool.WithRebindToKernel()),
+	)
+
+	request := func(connID string) *networkservice.Connection {
+		conn, requestErr := server.Request(context.TODO(), &networkservice.NetworkServiceRequest{
+			Connection: &networkservice.Connection{
+				Id: connID,
+				Mechanism: &networkservice.Mechanism{
+					Type: vfio.MECHANISM,
+					Parameters: map[string]string{
+						common.DeviceTokenIDKey: tokens.NewTokenID(),
+					},
+				},
+			},
+		})
+		require.NoError(t, requestErr)
+		return conn
+	}
+
+	pf1 := pfs["0000:00:01.0"]
+
+	// 1. Select both VFs from the IOMMU group 1
+
+	conn1 := request("id-1")
+	conn2 := request("id-2")
+	require.Equal(t, pf1.Vfs[0].Addr, conn1.GetMechanism().GetParameters()[common.PCIAddressKey])
+	require.Equal(t, pf1.Vfs[1].Addr, conn2.GetMechanism().GetParameters()[common.PCIAddressKey])
+	require.Equal(t, string(sriov.VFIOPCIDriver), pf1.Vfs[0].Driver)
+	require.Equal(t, string(sriov.VFIOPCIDriver), pf1.Vfs[1].Driver)
+
+	// 2. IOMMU group is still used by the second connection
+
+	_, err = server.Close(context.TODO(), conn1)
+	require.NoError(t, err)
+	require.Equal(t, string(sriov.VFIOPCIDriver), pf1.Vfs[0].Driver)
+	require.Equal(t, string(sriov.VFIOPCIDriver), pf1.Vfs[1].Driver)
+
+	// 3. IOMMU group is free
+
+	_, err = server.Close(context.TODO(), conn2)
+	require.NoError(t, err)
+	require.Equal(t, "vf-1-driver", pf1.Vfs[0].Driver)
+	require.Equal(t, "vf-1-driver", pf1.Vfs[1].Driver)
+}
+
+func TestResourcePoolServer_ActiveConnections(t *testing.T) {
+	var pfs map[string]*sriovtest.PCIPhysicalFunction
+	_ = yamlhelper.UnmarshalFile(physicalFunctionsFilename, &pfs)
+
+	conf, err := config.ReadConfig(context.TODO(), configFileName)
+	require.NoError(t, err)
+
+	pciPool, err := pci.NewTestPool(pfs, conf)
+	require.NoError(t, err)
+
+	resourcePool := new(resourcePoolMock)
+	activeConnections := resourcepool.NewActiveConnections()
+
+	server := chain.NewNetworkServiceServer(
+		metadata.NewServer(),
+		resourcepool.NewServer(sriov.VFIOPCIDriver, new(sync.Mutex), pciPool, resourcePool, conf,
+			resourcepool.WithActiveConnections(activeConnections)),
+		&vfioServerStub{},
+	)
+
+	vf := pfs[pf2PciAddr].Vfs[1]
+	resourcePool.mock.On("Select", tokenID, sriov.VFIOPCIDriver).Return(vf.Addr, nil)
+	resourcePool.mock.On("Free", vf.Addr).Return(nil)
+
+	conn, err := server.Request(context.TODO(), &networkservice.NetworkServiceRequest{
+		Connection: &networkservice.Connection{
+			Id: "id",
+			Mechanism: &networkservice.Mechanism{
+				Type: vfio.MECHANISM,
+				Parameters: map[string]string{
+					common.DeviceTokenIDKey: tokenID,
+					vfio.CgroupDirKey:       "pod",
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, map[string]resourcepool.ConnectionInfo{
+		"id": {
+			TokenID:     tokenID,
+			VFPCIAddr:   vf.Addr,
+			DriverType:  sriov.VFIOPCIDriver,
+			IOMMUGroup:  vf.IOMMUGroup,
+			CgroupDir:   "pod",
+			VfioMajor:   1,
+			VfioMinor:   2,
+			DeviceMajor: 3,
+			DeviceMinor: 4,
+		},
+	}, activeConnections.List())
+
+	_, err = server.Close(context.TODO(), conn)
+	require.NoError(t, err)
+	require.Empty(t, activeConnections.List())
+}
+
+func TestResourcePoolServer_Expirer(t *testing.T) {
+	var pfs map[string]*sriovtest.PCIPhysicalFunction
+	_ = yamlhelper.UnmarshalFile(physicalFunctionsFilename, &pfs)
+
+	conf, err := config.ReadConfig(context.TODO(), configFileName)
+	require.NoError(t, err)
+
+	pciPool, err := pci.NewTestPool(pfs, conf)
+	require.NoError(t, err)
+
+	resourcePool := new(resourcePoolMock)
+	activeConnections := resourcepool.NewActiveConnections()
+	expirer := resourcepool.NewExpirer()
+
+	server := chain.NewNetworkServiceServer(
+		begin.NewServer(),
+		metadata.NewServer(),
+		resourcepool.NewServer(sriov.VFIOPCIDriver, new(sync.Mutex), pciPool, resourcePool, conf,
+			resourcepool.WithActiveConnections(activeConnections), resourcepool.WithExpirer(expirer)),
+		&vfioServerStub{},
+	)
+
+	vf := pfs[pf2PciAddr].Vfs[1]
+	resourcePool.mock.On("Select", tokenID, sriov.VFIOPCIDriver).Return(vf.Addr, nil)
+	resourcePool.mock.On("Free", vf.Addr).Return(nil)
+
+	_, err = server.Request(context.TODO(), &networkservice.NetworkServiceRequest{
+		Connection: &networkservice.Connection{
+			Id: "id",
+			Mechanism: &networkservice.Mechanism{
+				Type: vfio.MECHANISM,
+				Parameters: map[string]string{
+					common.DeviceTokenIDKey: tokenID,
+					vfio.CgroupDirKey:       "pod",
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, activeConnections.List(), 1)
+
+	// VF selected for another token is not closed
+
+	expirer.Expire(tokens.NewTokenID(), vf.Addr)
+	require.Never(t, func() bool {
+		return len(activeConnections.List()) == 0
+	}, 50*time.Millisecond, 10*time.Millisecond)
+
+	// Connection using the expired VF is closed
+
+	expirer.Expire(tokenID, vf.Addr)
+	require.Eventually(t, func() bool {
+		return len(activeConnections.List()) == 0
+	}, time.Second, 10*time.Millisecond)
+	resourcePool.mock.AssertCalled(t, "Free", vf.Addr)
+}
+
+func TestResourcePoolServer_Request_Reservation(t *testing.T) {
+	var pfs map[string]*sriovtest.PCIPhysicalFunction
+	_ = yamlhelper.UnmarshalFile(physicalFunctionsFilename, &pfs)
+
+	conf, err := config.ReadConfig(context.TODO(), configFileName)
+	require.NoError(t, err)
+
+	pciPool, err := pci.NewTestPool(pfs, conf)
+	require.NoError(t, err)
+
+	resourcePool := new(reservingResourcePoolMock)
+	resourceServerChainElem := newVFResourceServer()
+
+	server := chain.NewNetworkServiceServer(
+		metadata.NewServer(),
+		resourcepool.NewServer(sriov.KernelDriver, new(sync.Mutex), pciPool, resourcePool, conf,
+			resourcepool.WithReservation()),
+		resourceServerChainElem,
+	)
+
+	request := func(connID string) (*networkservice.Connection, error) {
+		return server.Request(context.TODO(), &networkservice.NetworkServiceRequest{
+			Connection: &networkservice.Connection{
+				Id: connID,
+				Mechanism: &networkservice.Mechanism{
+					Type: kernel.MECHANISM,
+					Parameters: map[string]string{
+						common.DeviceTokenIDKey: tokenID,
+					},
+				},
+			},
+		})
+	}
+
+	vf := pfs[pf2PciAddr].Vfs[1]
+
+	// 1. Reserved VF is committed after the connection is established
+
+	resourcePool.mock.On("ReserveExcluding", tokenID, sriov.KernelDriver).Return(vf.Addr, nil)
+	resourcePool.mock.On("Commit", vf.Addr).Return(nil).Once()
+
+	conn, err := request("id-1")
+	require.NoError(t, err)
+	resourcePool.mock.AssertNumberOfCalls(t, "Select", 0)
+	resourcePool.mock.AssertNumberOfCalls(t, "Commit", 1)
+
+	resourcePool.mock.On("GetTokenID", vf.Addr).Return(tokenID, nil).Once()
+	resourcePool.mock.On("Free", vf.Addr).Return(nil).Once()
+
+	_, err = server.Close(context.TODO(), conn)
+	require.NoError(t, err)
+	resourcePool.mock.AssertNumberOfCalls(t, "Free", 1)
+
+	// 2. Preempted VF is not committed, the connection is closed and the VF is freed
+
+	resourcePool.mock.On("Commit", vf.Addr).Return(resource.ErrPreempted).Once()
+	resourcePool.mock.On("GetTokenID", vf.Addr).Return(tokenID, nil).Once()
+	resourcePool.mock.On("Free", vf.Addr).Return(nil).Once()
+
+	_, err = request("id-2")
+	require.ErrorIs(t, err, resource.ErrPreempted)
+	require.NotNil(t, resourceServerChainElem.getCloseVFConfig())
+	resourcePool.mock.AssertNumberOfCalls(t, "Free", 2)
+
+	// 3. Server fails if the resource pool doesn't support the reservation
+
+	server = resourcepool.NewServer(sriov.KernelDriver, new(sync.Mutex), pciPool, new(resourcePoolMock), conf,
+		resourcepool.WithReservation())
+	_, err = server.Request(context.TODO(), &networkservice.NetworkServiceRequest{})
+	require.Error(t, err)
+}
+
+type blockingServer struct {
+	connID    string
+	blockedCh chan struct{}
+}
+
+// Request blocks the Request for the connID connection until ctx is done
+func (s *blockingServer) Request(ctx context.Context, request *networkservice.NetworkServiceRequest) (*networkservice.Connection, error) {
+	if request.GetConnection().GetId() == s.connID {
+		close(s.blockedCh)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return next.Server(ctx).Request(ctx, request)
+}
+
+func (s *blockingServer) Close(ctx context.Context, conn *networkservice.Connection) (*empty.Empty, error) {
+	return next.Server(ctx).Close(ctx, conn)
+}
+
+type namedTokenPoolStub map[string]string
+
+func (tp namedTokenPoolStub) Find(id string) (string, error) {
+	return tp[id], nil
+}
+
+func (tp namedTokenPoolStub) Use(_ string, _ []string) error {
+	return nil
+}
+
+func (tp namedTokenPoolStub) StopUsing(_ string) error {
+	return nil
+}
+
+func (tp namedTokenPoolStub) Free(_ string) error {
+	return nil
+}
+
+func TestResourcePoolServer_Request_Preemption(t *testing.T) {
+	var pfs map[string]*sriovtest.PCIPhysicalFunction
+	_ = yamlhelper.UnmarshalFile(physicalFunctionsFilename, &pfs)
+
+	conf, err := config.ReadConfig(context.TODO(), preemptionConfigFileName)
+	require.NoError(t, err)
+
+	pciPool, err := pci.NewTestPool(pfs, conf)
+	require.NoError(t, err)
+
+	lowTokenID, highTokenID := tokens.NewTokenID(), tokens.NewTokenID()
+	preemptor := resourcepool.NewPreemptor()
+	resourcePool := resource.NewPool(namedTokenPoolStub{
+		lowTokenID:  "service.domain.2/intel",
+		highTokenID: "service.domain.1/intel",
+	}, conf, resource.WithPreemption(preemptor.Preempt))
+
+	blocking := &blockingServer{
+		connID:    "low",
+		blockedCh: make(chan struct{}),
+	}
+	server := chain.NewNetworkServiceServer(
+		metadata.NewServer(),
+		resourcepool.NewServer(sriov.KernelDriver, new(sync.Mutex), pciPool, resourcePool, conf,
+			resourcepool.WithReservation(),
+			resourcepool.WithPreemptor(preemptor),
+			resourcepool.WithWaitQueue(resourcepool.NewWaitQueue())),
+		blocking,
+	)
+
+	request := func(connID, tokenID string) (*networkservice.Connection, error) {
+		return server.Request(context.TODO(), &networkservice.NetworkServiceRequest{
+			Connection: &networkservice.Connection{
+				Id: connID,
+				Mechanism: &networkservice.Mechanism{
+					Type: kernel.MECHANISM,
+					Parameters: map[string]string{
+						common.DeviceTokenIDKey: tokenID,
+					},
+				},
+			},
+		})
+	}
+
+	lowErrCh := make(chan error, 1)
+	go func() {
+		_, lowErr := request("low", lowTokenID)
+		lowErrCh <- lowErr
+	}()
+	<-blocking.blockedCh
+
+	// Victim Request is canceled, the VF is handed over after the victim releases it
+
+	conn, err := request("high", highTokenID)
+	require.NoError(t, err)
+	require.ErrorIs(t, <-lowErrCh, context.Canceled)
+
+	vf := pfs["0000:00:01.0"].Vfs[0]
+	require.Equal(t, map[string]string{highTokenID: vf.Addr}, resourcePool.TokenToVF())
+
+	_, err = server.Close(context.TODO(), conn)
+	require.NoError(t, err)
+	require.Empty(t, resourcePool.TokenToVF())
+}
+
+type operStateReaderStub struct {
+	operState string
+}
+
+func (r *operStateReaderStub) GetOperState(_ string) (string, error) {
+	return r.operState, nil
+}
+
+func (r *operStateReaderStub) GetNetNSOperState(_, _ string) (string, error) {
+	return r.operState, nil
+}
+
+func TestResourcePoolServer_Request_LinkNotUp(t *testing.T) {
+	var pfs map[string]*sriovtest.PCIPhysicalFunction
+	_ = yamlhelper.UnmarshalFile(physicalFunctionsFilename, &pfs)
+
+	conf, err := config.ReadConfig(context.TODO(), configFileName)
+	require.NoError(t, err)
+
+	pciPool, err := pci.NewTestPool(pfs, conf)
+	require.NoError(t, err)
+
+	resourcePool := resource.NewPool(&tokenPoolStub{name: "service.domain.1/intel"}, conf)
+	operStateReader := &operStateReaderStub{operState: "down"}
+	server := chain.NewNetworkServiceServer(
+		metadata.NewServer(),
+		resourcepool.NewServer(sriov.KernelDriver, new(sync.Mutex), pciPool, resourcePool, conf),
+		linkstate.NewServer(operStateReader,
+			linkstate.WithStrict(10*time.Millisecond),
+			linkstate.WithNetNSOperStateReader(operStateReader)),
+	)
+
+	request := &networkservice.NetworkServiceRequest{
+		Connection: &networkservice.Connection{
+			Id: "id",
+			Mechanism: &networkservice.Mechanism{
+				Type: kernel.MECHANISM,
+				Parameters: map[string]string{
+					common.DeviceTokenIDKey: tokenID,
+				},
+			},
+		},
+	}
+
+	// VF link doesn't get up, so the VF is freed
+
+	_, err = server.Request(context.TODO(), request.Clone())
+	require.ErrorIs(t, err, linkstate.ErrLinkNotUp)
+	require.Empty(t, resourcePool.TokenToVF())
+
+	// VF link gets up
+
+	operStateReader.operState = linkstate.OperStateUp
+
+	_, err = server.Request(context.TODO(), request.Clone())
+	require.NoError(t, err)
+	require.Len(t, resourcePool.TokenToVF(), 1)
+}
+
+type vfioServerStub struct{}
+
+func (s *vfioServerStub) Request(ctx context.Context, request *networkservice.NetworkServiceRequest) (*networkservice.Connection, error) {
+	if mech := vfio.ToMechanism(request.GetConnection().GetMechanism()); mech != nil {
+		mech.SetVfioMajor(1)
+		mech.SetVfioMinor(2)
+		mech.SetDeviceMajor(3)
+		mech.SetDeviceMinor(4)
+	}
+	return next.Server(ctx).Request(ctx, request)
+}
+
+func (s *vfioServerStub) Close(ctx context.Context, conn *networkservice.Connection) (*empty.Empty, error) {
+	return next.Server(ctx).Close(ctx, conn)
+}
+
+func TestResourcePoolServer_Close_ReusedConnectionID(t *testing.T) {
+	var pfs map[string]*sriovtest.PCIPhysicalFunction
+	_ = yamlhelper.UnmarshalFile(physicalFunctionsFilename, &pfs)
+
+	conf, err := config.ReadConfig(context.TODO(), configFileName)
+	require.NoError(t, err)
+
+	pciPool, err := pci.NewTestPool(pfs, conf)
+	require.NoError(t, err)
+
+	resourcePool := resource.NewPool(&tokenPoolStub{name: "service.domain.1/intel"}, conf)
+
+	server := chain.NewNetworkServiceServer(
+		metadata.NewServer(),
+		resourcepool.NewServer(sriov.KernelDriver, new(sync.Mutex), pciPool, resourcePool, conf),
+	)
+
+	newConn := func(tokenID string) *networkservice.Connection {
+		return &networkservice.Connection{
+			Id: "id",
+			Mechanism: &networkservice.Mechanism{
+				Type: kernel.MECHANISM,
+				Parameters: map[string]string{
+					common.DeviceTokenIDKey: tokenID,
+				},
+			},
+		}
+	}
+
+	tokenID1, tokenID2 := tokens.NewTokenID(), tokens.NewTokenID()
+
+	conn, err := server.Request(context.TODO(), &networkservice.NetworkServiceRequest{
+		Connection: newConn(tokenID1),
+	})
+	require.NoError(t, err)
+	vfPCIAddr := conn.GetMechanism().GetParameters()[common.PCIAddressKey]
+
+	// Another connection with the same ID closes
+
+	_, err = server.Close(context.TODO(), newConn(tokenID2))
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{tokenID1: vfPCIAddr}, resourcePool.TokenToVF())
+
+	_, err = server.Close(context.TODO(), conn)
+	require.NoError(t, err)
+	require.Empty(t, resourcePool.TokenToVF())
+}
+
+type slowPCIPool struct {
+	resourcepool.PCIPool
+
+	startedCh chan struct{}
+	bindCh    chan struct{}
+}
+
+func (p *slowPCIPool) BindDriver(ctx context.Context, iommuGroup uint, driverType sriov.DriverType) error {
+	close(p.startedCh)
+	<-p.bindCh
+	return p.PCIPool.BindDriver(ctx, iommuGroup, driverType)
+}
+
+type vfMACSetterStub struct {
+	macs []net.HardwareAddr
+}
+
+func (s *vfMACSetterStub) SetVFMACAddress(_ context.Context, _ string, _ int, mac net.HardwareAddr) error {
+	s.macs = append(s.macs, mac)
+	return nil
+}
+
+type countingPCIPool struct {
+	resourcepool.PCIPool
+
+	bindCalls int32
+}
+
+func (p *countingPCIPool) BindDriver(ctx context.Context, iommuGroup uint, driverType sriov.DriverType) error {
+	atomic.AddInt32(&p.bindCalls, 1)
+	return p.PCIPool.BindDriver(ctx, iommuGroup, driverType)
+}
+
+type resourcePoolMock struct {
+	mock mock.Mock
+
+	sync.Mutex
+}
+
+func (rp *resourcePoolMock) Select(tokenID string, driverType sriov.DriverType) (string, error) {
+	rv := rp.mock.Called(tokenID, driverType)
+	return rv.String(0), rv.Error(1)
+}
+
+func (rp *resourcePoolMock) Free(vfPCIAddr string) error {
+	rv := rp.mock.Called(vfPCIAddr)
+	return rv.Error(0)
+}
+
+func (rp *resourcePoolMock) IsIOMMUGroupFree(iommuGroup uint) bool {
+	rv := rp.mock.Called(iommuGroup)
+	return rv.Bool(0)
+}
+
+type reservingResourcePoolMock struct {
+	resourcePoolMock
+}
+
+func (rp *reservingResourcePoolMock) ReserveExcluding(_ context.Context, tokenID string, driverType sriov.DriverType, _ ...string) (string, error) {
+	rv := rp.mock.Called(tokenID, driverType)
+	return rv.String(0), rv.Error(1)
+}
+
+func (rp *reservingResourcePoolMock) Commit(vfPCIAddr string) error {
+	rv := rp.mock.Called(vfPCIAddr)
+	return rv.Error(0)
+}
+
+func (rp *reservingResourcePoolMock) GetTokenID(vfPCIAddr string) (string, error) {
+	rv := rp.mock.Called(vfPCIAddr)
+	return rv.String(0), rv.Error(1)
+}
+
+type tokenPoolStub struct {
+	name string
+}
+
+func (tp *tokenPoolStub) Find(_ string) (string, error) {
+	return tp.name, nil
+}
+
+func (tp *tokenPoolStub) Use(_ string, _ []string) error {
+	return nil
+}
+
+func (tp *tokenPoolStub) StopUsing(_ string) error {
+	return nil
+}
+
+func (tp *tokenPoolStub) Free(_ string) error {
+	return nil
 }
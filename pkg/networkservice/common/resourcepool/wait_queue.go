@@ -0,0 +1,134 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package resourcepool
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/resource"
+)
+
+// WaitQueue is a FIFO queue of Requests waiting for a VF to be freed: when some VF gets freed, the longest waiting
+// Request able to use it is served first, so newer Requests cannot take the freed VF away. WaitQueue is guarded by
+// the resource lock, so it should be shared between all servers sharing the resource lock.
+type WaitQueue struct {
+	waiters []*waiter
+}
+
+type waiter struct {
+	selectVF func() (string, error)
+	resultCh chan *waitResult
+}
+
+type waitResult struct {
+	vfPCIAddr string
+	err       error
+}
+
+// NewWaitQueue returns a new WaitQueue
+func NewWaitQueue() *WaitQueue {
+	return &WaitQueue{}
+}
+
+// Len returns a number of waiting Requests, it should be called under the resource lock
+func (q *WaitQueue) Len() int {
+	return len(q.waiters)
+}
+
+// wait enqueues selectVF and waits for it to be served, resourceLock should be locked
+func (q *WaitQueue) wait(ctx context.Context, resourceLock sync.Locker, selectVF func() (string, error), freeVF func(string) error) (string, error) {
+	w := &waiter{
+		selectVF: selectVF,
+		resultCh: make(chan *waitResult, 1),
+	}
+	q.waiters = append(q.waiters, w)
+
+	var result *waitResult
+	resourceLock.Unlock()
+	select {
+	case <-ctx.Done():
+	case result = <-w.resultCh:
+	}
+	resourceLock.Lock()
+
+	if result != nil {
+		return result.vfPCIAddr, result.err
+	}
+
+	// w can be served at the same time with the ctx getting done, so we should check the result under the lock
+	select {
+	case result = <-w.resultCh:
+		if result.err == nil && freeVF(result.vfPCIAddr) == nil {
+			q.dispatch()
+		}
+	default:
+		q.remove(w)
+	}
+
+	return "", errors.Wrap(ctx.Err(), "no VF has been freed while waiting")
+}
+
+// Dispatch serves waiting Requests in FIFO order, resourceLock should not be locked. Servers using the WaitQueue
+// dispatch it on their own Closes, so it should be called for the VFs freed in the other way, e.g. by the servers not
+// using the WaitQueue, it can be passed to resource.WithReleaseListener for this.
+func (q *WaitQueue) Dispatch(resourceLock sync.Locker) {
+	resourceLock.Lock()
+	defer resourceLock.Unlock()
+
+	q.dispatch()
+}
+
+// dispatch serves waiting Requests in FIFO order, resourceLock should be locked
+func (q *WaitQueue) dispatch() {
+	for i := 0; i < len(q.waiters); {
+		w := q.waiters[i]
+
+		vfPCIAddr, err := w.selectVF()
+		if isAllInUse(err) {
+			i++
+			continue
+		}
+
+		q.waiters = append(q.waiters[:i], q.waiters[i+1:]...)
+		w.resultCh <- &waitResult{
+			vfPCIAddr: vfPCIAddr,
+			err:       err,
+		}
+	}
+}
+
+// isAllInUse returns if err is a resource.SelectError caused by all matching VFs being in use, only such Requests can
+// be served by freeing another VF
+func isAllInUse(err error) bool {
+	var selectErr *resource.SelectError
+	return errors.As(err, &selectErr) && selectErr.Reason == resource.AllInUse
+}
+
+func (q *WaitQueue) remove(w *waiter) {
+	for i := range q.waiters {
+		if q.waiters[i] == w {
+			q.waiters = append(q.waiters[:i], q.waiters[i+1:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,180 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+// Package spoofcheck provides server chain element setting VF spoof check requested in the kernel mechanism parameters
+package spoofcheck
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/pkg/errors"
+
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/kernel"
+	"github.com/networkservicemesh/sdk-kernel/pkg/kernel/networkservice/vfconfig"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/next"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/utils/metadata"
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/config"
+)
+
+// SpoofCheckKey is a kernel mechanism parameter key for the VF spoof check, value is parsed with strconv.ParseBool
+const SpoofCheckKey = "spoofcheck"
+
+// ErrSpoofCheckNotAllowed is returned when the client is not allowed to disable VF spoof check
+var ErrSpoofCheckNotAllowed = errors.New("disabling VF spoof check is not allowed")
+
+// VFConfigurator is a vfnetlink.Configurator interface
+type VFConfigurator interface {
+	GetVFSpoofCheck(pfIfName string, vfIndex int) (bool, error)
+	SetVFSpoofCheck(ctx context.Context, pfIfName string, vfIndex int, enabled bool) error
+}
+
+type prevSpoofCheckKey struct{}
+
+type spoofCheckServer struct {
+	vfConfigurator VFConfigurator
+	allowlist      map[string]struct{}
+}
+
+// NewServer returns a new spoof check server chain element, it should be placed after the resourcepool server. Only
+// clients with SPIFFE IDs from the cfg.SpoofCheckAllowlist are allowed to disable VF spoof check. Client SPIFFE ID is
+// taken from the first path segment token, so the token should be validated by the authorize chain element.
+func NewServer(vfConfigurator VFConfigurator, cfg *config.Config) networkservice.NetworkServiceServer {
+	s := &spoofCheckServer{
+		vfConfigurator: vfConfigurator,
+		allowlist:      map[string]struct{}{},
+	}
+	for _, spiffeID := range cfg.SpoofCheckAllowlist {
+		s.allowlist[spiffeID] = struct{}{}
+	}
+	return s
+}
+
+func (s *spoofCheckServer) Request(ctx context.Context, request *networkservice.NetworkServiceRequest) (*networkservice.Connection, error) {
+	conn := request.GetConnection()
+
+	enabled, ok, err := getSpoofCheck(conn)
+	if err != nil {
+		return nil, err
+	}
+
+	vfConfig, vfOk := vfconfig.Load(ctx, false)
+	if !vfOk {
+		return next.Server(ctx).Request(ctx, request)
+	}
+
+	if !ok {
+		// spoof check can be removed from the mechanism parameters on refresh
+		s.restore(ctx, vfConfig)
+		return next.Server(ctx).Request(ctx, request)
+	}
+
+	if !enabled {
+		clientID := getClientID(conn)
+		if _, allowed := s.allowlist[clientID]; !allowed {
+			return nil, errors.Wrapf(ErrSpoofCheckNotAllowed, "client: %v", clientID)
+		}
+	}
+
+	_, established := metadata.Map(ctx, false).Load(prevSpoofCheckKey{})
+	if !established {
+		prevEnabled, getErr := s.vfConfigurator.GetVFSpoofCheck(vfConfig.PFInterfaceName, vfConfig.VFNum)
+		if getErr != nil {
+			return nil, getErr
+		}
+		metadata.Map(ctx, false).Store(prevSpoofCheckKey{}, prevEnabled)
+	}
+
+	if err = s.vfConfigurator.SetVFSpoofCheck(ctx, vfConfig.PFInterfaceName, vfConfig.VFNum, enabled); err != nil {
+		if !established {
+			s.restore(ctx, vfConfig)
+		}
+		return nil, err
+	}
+
+	conn, err = next.Server(ctx).Request(ctx, request)
+	if err != nil {
+		// failed refresh doesn't close the connection, so the spoof check is kept
+		if !established {
+			s.restore(ctx, vfConfig)
+		}
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+func (s *spoofCheckServer) Close(ctx context.Context, conn *networkservice.Connection) (*empty.Empty, error) {
+	rv, err := next.Server(ctx).Close(ctx, conn)
+
+	if vfConfig, ok := vfconfig.Load(ctx, false); ok {
+		s.restore(ctx, vfConfig)
+	}
+
+	return rv, err
+}
+
+func (s *spoofCheckServer) restore(ctx context.Context, vfConfig *vfconfig.VFConfig) {
+	value, ok := metadata.Map(ctx, false).LoadAndDelete(prevSpoofCheckKey{})
+	if !ok {
+		return
+	}
+
+	if err := s.vfConfigurator.SetVFSpoofCheck(ctx, vfConfig.PFInterfaceName, vfConfig.VFNum, value.(bool)); err != nil {
+		log.FromContext(ctx).WithField("spoofCheckServer", "restore").
+			Warnf("failed to restore VF %v spoof check for the PF %v: %v", vfConfig.VFNum, vfConfig.PFInterfaceName, err)
+	}
+}
+
+func getSpoofCheck(conn *networkservice.Connection) (enabled, ok bool, err error) {
+	mech := kernel.ToMechanism(conn.GetMechanism())
+	if mech == nil {
+		return false, false, nil
+	}
+
+	value, ok := mech.GetParameters()[SpoofCheckKey]
+	if !ok {
+		return false, false, nil
+	}
+
+	if enabled, err = strconv.ParseBool(value); err != nil {
+		return false, false, errors.Wrapf(err, "invalid spoof check value: %v", value)
+	}
+
+	return enabled, true, nil
+}
+
+func getClientID(conn *networkservice.Connection) string {
+	pathSegments := conn.GetPath().GetPathSegments()
+	if len(pathSegments) == 0 {
+		return ""
+	}
+
+	claims := new(jwt.RegisteredClaims)
+	if _, _, err := jwt.NewParser().ParseUnverified(pathSegments[0].GetToken(), claims); err != nil {
+		return ""
+	}
+
+	return claims.Subject
+}
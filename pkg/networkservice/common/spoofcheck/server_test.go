@@ -0,0 +1,167 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package spoofcheck_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/kernel"
+	"github.com/networkservicemesh/sdk-kernel/pkg/kernel/networkservice/vfconfig"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/utils/inject/injecterror"
+
+	"github.com/networkservicemesh/sdk-sriov/pkg/networkservice/common/spoofcheck"
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/config"
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/sriovtest"
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/vfnetlink"
+)
+
+const (
+	pfIfName        = "pf"
+	vfNum           = 1
+	trustedClient   = "spiffe://example.org/trusted-nsc"
+	untrustedClient = "spiffe://example.org/untrusted-nsc"
+)
+
+func newServer(t *testing.T, additionalFunctionality ...networkservice.NetworkServiceServer) (networkservice.NetworkServiceServer, *sriovtest.NetlinkHandle) {
+	handle := sriovtest.NewNetlinkHandle()
+	handle.AddPhysicalFunction(pfIfName, 2)
+
+	link, err := handle.LinkByName(pfIfName)
+	require.NoError(t, err)
+	require.NoError(t, handle.LinkSetVfSpoofchk(link, vfNum, true))
+
+	cfg := &config.Config{
+		SpoofCheckAllowlist: []string{trustedClient},
+	}
+
+	return sriovtest.NewVFServer(t, &vfconfig.VFConfig{
+		PFInterfaceName: pfIfName,
+		VFNum:           vfNum,
+	}, spoofcheck.NewServer(vfnetlink.NewConfigurator(vfnetlink.WithHandle(handle)), cfg), additionalFunctionality...), handle
+}
+
+func newRequest(t *testing.T, clientID, spoofCheck string) *networkservice.NetworkServiceRequest {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, &jwt.RegisteredClaims{
+		Subject: clientID,
+	}).SignedString([]byte("secret"))
+	require.NoError(t, err)
+
+	request := &networkservice.NetworkServiceRequest{
+		Connection: &networkservice.Connection{
+			Id:             sriovtest.ConnectionID,
+			NetworkService: "ns",
+			Path: &networkservice.Path{
+				PathSegments: []*networkservice.PathSegment{
+					{Token: token},
+				},
+			},
+			Mechanism: &networkservice.Mechanism{
+				Type:       kernel.MECHANISM,
+				Parameters: map[string]string{},
+			},
+		},
+	}
+	if spoofCheck != "" {
+		request.GetConnection().GetMechanism().GetParameters()[spoofcheck.SpoofCheckKey] = spoofCheck
+	}
+	return request
+}
+
+func TestSpoofCheckServer_Disable(t *testing.T) {
+	server, handle := newServer(t)
+
+	conn, err := server.Request(context.Background(), newRequest(t, trustedClient, "false"))
+	require.NoError(t, err)
+	require.False(t, handle.GetVF(pfIfName, vfNum).Spoofchk)
+
+	_, err = server.Close(context.Background(), conn)
+	require.NoError(t, err)
+	require.True(t, handle.GetVF(pfIfName, vfNum).Spoofchk)
+}
+
+func TestSpoofCheckServer_DisableNotAllowed(t *testing.T) {
+	server, handle := newServer(t)
+
+	_, err := server.Request(context.Background(), newRequest(t, untrustedClient, "false"))
+	require.ErrorIs(t, err, spoofcheck.ErrSpoofCheckNotAllowed)
+	require.True(t, handle.GetVF(pfIfName, vfNum).Spoofchk)
+
+	// Network service is not a client identity
+	request := newRequest(t, untrustedClient, "false")
+	request.GetConnection().NetworkService = trustedClient
+	_, err = server.Request(context.Background(), request)
+	require.ErrorIs(t, err, spoofcheck.ErrSpoofCheckNotAllowed)
+
+	// Enabling is always allowed
+	_, err = server.Request(context.Background(), newRequest(t, untrustedClient, "true"))
+	require.NoError(t, err)
+	require.True(t, handle.GetVF(pfIfName, vfNum).Spoofchk)
+}
+
+func TestSpoofCheckServer_RequestFailed(t *testing.T) {
+	server, handle := newServer(t, injecterror.NewServer(injecterror.WithError(errors.New("error"))))
+
+	_, err := server.Request(context.Background(), newRequest(t, trustedClient, "false"))
+	require.Error(t, err)
+	require.True(t, handle.GetVF(pfIfName, vfNum).Spoofchk)
+}
+
+func TestSpoofCheckServer_InvalidValue(t *testing.T) {
+	server, _ := newServer(t)
+
+	_, err := server.Request(context.Background(), newRequest(t, trustedClient, "maybe"))
+	require.Error(t, err)
+}
+
+func TestSpoofCheckServer_RefreshFailed(t *testing.T) {
+	server, handle := newServer(t, injecterror.NewServer(
+		injecterror.WithRequestErrorTimes(1),
+		injecterror.WithCloseErrorTimes(),
+	))
+
+	conn, err := server.Request(context.Background(), newRequest(t, trustedClient, "false"))
+	require.NoError(t, err)
+
+	_, err = server.Request(context.Background(), newRequest(t, trustedClient, "false"))
+	require.Error(t, err)
+	require.False(t, handle.GetVF(pfIfName, vfNum).Spoofchk)
+
+	_, err = server.Close(context.Background(), conn)
+	require.NoError(t, err)
+	require.True(t, handle.GetVF(pfIfName, vfNum).Spoofchk)
+}
+
+func TestSpoofCheckServer_RefreshRemoved(t *testing.T) {
+	server, handle := newServer(t)
+
+	_, err := server.Request(context.Background(), newRequest(t, trustedClient, "false"))
+	require.NoError(t, err)
+	require.False(t, handle.GetVF(pfIfName, vfNum).Spoofchk)
+
+	_, err = server.Request(context.Background(), newRequest(t, trustedClient, ""))
+	require.NoError(t, err)
+	require.True(t, handle.GetVF(pfIfName, vfNum).Spoofchk)
+}
@@ -0,0 +1,127 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+// Package trafficclass provides server chain element setting VF traffic class requested in the connection context
+package trafficclass
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/pkg/errors"
+
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/networkservicemesh/sdk-kernel/pkg/kernel/networkservice/vfconfig"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/next"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/utils/metadata"
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+)
+
+// TrafficClassKey is a connection context extra context key for the VF traffic class
+const TrafficClassKey = "sriovTrafficClass"
+
+// VFConfigurator is a vfnetlink.Configurator interface
+type VFConfigurator interface {
+	SetVFTrafficClass(ctx context.Context, pfIfName string, vfIndex int, tc uint8) error
+	ClearVFTrafficClass(ctx context.Context, pfIfName string, vfIndex int) error
+}
+
+type trafficClassKey struct{}
+
+type trafficClassServer struct {
+	vfConfigurator VFConfigurator
+}
+
+// NewServer returns a new traffic class server chain element, it should be placed after the resourcepool server
+func NewServer(vfConfigurator VFConfigurator) networkservice.NetworkServiceServer {
+	return &trafficClassServer{
+		vfConfigurator: vfConfigurator,
+	}
+}
+
+func (s *trafficClassServer) Request(ctx context.Context, request *networkservice.NetworkServiceRequest) (*networkservice.Connection, error) {
+	tc, ok, err := getTrafficClass(request.GetConnection())
+	if err != nil {
+		return nil, err
+	}
+
+	vfConfig, vfOk := vfconfig.Load(ctx, false)
+	if !vfOk {
+		return next.Server(ctx).Request(ctx, request)
+	}
+
+	value, established := metadata.Map(ctx, false).Load(trafficClassKey{})
+	switch {
+	case ok && (!established || tc != value.(uint8)):
+		if err = s.vfConfigurator.SetVFTrafficClass(ctx, vfConfig.PFInterfaceName, vfConfig.VFNum, tc); err != nil {
+			return nil, err
+		}
+		metadata.Map(ctx, false).Store(trafficClassKey{}, tc)
+	case !ok:
+		// traffic class can be removed from the connection context on refresh
+		s.clear(ctx, vfConfig)
+	}
+
+	conn, err := next.Server(ctx).Request(ctx, request)
+	if err != nil {
+		// failed refresh doesn't close the connection, so the traffic class is kept
+		if !established {
+			s.clear(ctx, vfConfig)
+		}
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+func (s *trafficClassServer) Close(ctx context.Context, conn *networkservice.Connection) (*empty.Empty, error) {
+	rv, err := next.Server(ctx).Close(ctx, conn)
+
+	if vfConfig, ok := vfconfig.Load(ctx, false); ok {
+		s.clear(ctx, vfConfig)
+	}
+
+	return rv, err
+}
+
+func (s *trafficClassServer) clear(ctx context.Context, vfConfig *vfconfig.VFConfig) {
+	if _, ok := metadata.Map(ctx, false).LoadAndDelete(trafficClassKey{}); !ok {
+		return
+	}
+
+	if err := s.vfConfigurator.ClearVFTrafficClass(ctx, vfConfig.PFInterfaceName, vfConfig.VFNum); err != nil {
+		log.FromContext(ctx).WithField("trafficClassServer", "clear").
+			Warnf("failed to clear VF %v traffic class for the PF %v: %v", vfConfig.VFNum, vfConfig.PFInterfaceName, err)
+	}
+}
+
+func getTrafficClass(conn *networkservice.Connection) (tc uint8, ok bool, err error) {
+	value, ok := conn.GetContext().GetExtraContext()[TrafficClassKey]
+	if !ok {
+		return 0, false, nil
+	}
+
+	parsed, err := strconv.ParseUint(value, 10, 8)
+	if err != nil {
+		return 0, false, errors.Wrapf(err, "invalid traffic class: %v", value)
+	}
+
+	return uint8(parsed), true, nil
+}
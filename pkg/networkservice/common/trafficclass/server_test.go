@@ -0,0 +1,114 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package trafficclass_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/networkservicemesh/sdk-kernel/pkg/kernel/networkservice/vfconfig"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/utils/inject/injecterror"
+
+	"github.com/networkservicemesh/sdk-sriov/pkg/networkservice/common/trafficclass"
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/sriovtest"
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/vfnetlink"
+)
+
+const (
+	pfIfName = "pf"
+	vfNum    = 1
+)
+
+func newServer(t *testing.T, handle *sriovtest.NetlinkHandle, additionalFunctionality ...networkservice.NetworkServiceServer) networkservice.NetworkServiceServer {
+	return sriovtest.NewVFServer(t, &vfconfig.VFConfig{
+		PFInterfaceName: pfIfName,
+		VFNum:           vfNum,
+	}, trafficclass.NewServer(vfnetlink.NewConfigurator(vfnetlink.WithHandle(handle))), additionalFunctionality...)
+}
+
+func newRequest(tc string) *networkservice.NetworkServiceRequest {
+	return sriovtest.NewExtraContextRequest(map[string]string{
+		trafficclass.TrafficClassKey: tc,
+	})
+}
+
+func TestTrafficClassServer(t *testing.T) {
+	handle := sriovtest.NewNetlinkHandle()
+	handle.AddPhysicalFunction(pfIfName, 2)
+
+	server := newServer(t, handle)
+
+	conn, err := server.Request(context.Background(), newRequest("3"))
+	require.NoError(t, err)
+	require.Equal(t, 3, handle.GetVF(pfIfName, vfNum).Qos)
+
+	_, err = server.Close(context.Background(), conn)
+	require.NoError(t, err)
+	require.Equal(t, 0, handle.GetVF(pfIfName, vfNum).Qos)
+}
+
+func TestTrafficClassServer_RequestFailed(t *testing.T) {
+	handle := sriovtest.NewNetlinkHandle()
+	handle.AddPhysicalFunction(pfIfName, 2)
+
+	server := newServer(t, handle, injecterror.NewServer(injecterror.WithError(errors.New("error"))))
+
+	_, err := server.Request(context.Background(), newRequest("3"))
+	require.Error(t, err)
+	require.Equal(t, 0, handle.GetVF(pfIfName, vfNum).Qos)
+}
+
+func TestTrafficClassServer_InvalidTrafficClass(t *testing.T) {
+	handle := sriovtest.NewNetlinkHandle()
+	handle.AddPhysicalFunction(pfIfName, 2)
+
+	server := newServer(t, handle)
+
+	_, err := server.Request(context.Background(), newRequest("8"))
+	require.Error(t, err)
+
+	_, err = server.Request(context.Background(), newRequest("high"))
+	require.Error(t, err)
+}
+
+func TestTrafficClassServer_RefreshFailed(t *testing.T) {
+	handle := sriovtest.NewNetlinkHandle()
+	handle.AddPhysicalFunction(pfIfName, 2)
+
+	server := newServer(t, handle, injecterror.NewServer(
+		injecterror.WithRequestErrorTimes(1),
+		injecterror.WithCloseErrorTimes(),
+	))
+
+	conn, err := server.Request(context.Background(), newRequest("3"))
+	require.NoError(t, err)
+
+	_, err = server.Request(context.Background(), newRequest("3"))
+	require.Error(t, err)
+	require.Equal(t, 3, handle.GetVF(pfIfName, vfNum).Qos)
+
+	_, err = server.Close(context.Background(), conn)
+	require.NoError(t, err)
+	require.Equal(t, 0, handle.GetVF(pfIfName, vfNum).Qos)
+}
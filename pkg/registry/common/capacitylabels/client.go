@@ -0,0 +1,159 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package capacitylabels provides a registry client chain element setting the forwarder free SR-IOV tokens counts into
+// the NSE registration labels
+package capacitylabels
+
+import (
+	"context"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"google.golang.org/grpc"
+
+	"github.com/networkservicemesh/api/pkg/api/registry"
+	"github.com/networkservicemesh/sdk/pkg/registry/common/begin"
+	"github.com/networkservicemesh/sdk/pkg/registry/core/next"
+	"github.com/networkservicemesh/sdk/pkg/tools/clock"
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+)
+
+const (
+	// LabelPrefix is a prefix of the capacity label keys, the full key is LabelPrefix + token name
+	LabelPrefix = "sriov.capacity/"
+
+	defaultDebounce = time.Second
+)
+
+// CapacityPool provides free tokens counts by token names, token.Pool implements it
+type CapacityPool interface {
+	Capacity() map[string]int
+	AddCapacityListener(listener func())
+}
+
+type capacityLabelsNSEClient struct {
+	ctx      context.Context
+	pool     CapacityPool
+	debounce time.Duration
+
+	factories map[string]begin.EventFactory
+	capacity  map[string]int
+	timer     clock.Timer
+	lock      sync.Mutex
+}
+
+// NewNetworkServiceEndpointRegistryClient returns a new NSE registry client chain element setting the free tokens
+// counts into the NSE labels for every NSE network service. Registered NSEs are registered again on the capacity
+// change, the updates are debounced. It should be placed after the begin chain element.
+func NewNetworkServiceEndpointRegistryClient(ctx context.Context, pool CapacityPool, options ...Option) registry.NetworkServiceEndpointRegistryClient {
+	c := &capacityLabelsNSEClient{
+		ctx:       ctx,
+		pool:      pool,
+		debounce:  defaultDebounce,
+		factories: map[string]begin.EventFactory{},
+	}
+	for _, opt := range options {
+		opt(c)
+	}
+
+	pool.AddCapacityListener(c.onCapacityChange)
+
+	return c
+}
+
+func (c *capacityLabelsNSEClient) Register(ctx context.Context, nse *registry.NetworkServiceEndpoint, opts ...grpc.CallOption) (*registry.NetworkServiceEndpoint, error) {
+	capacity := c.pool.Capacity()
+	setLabels(nse, capacity)
+
+	resp, err := next.NetworkServiceEndpointRegistryClient(ctx).Register(ctx, nse, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.capacity = capacity
+	if factory := begin.FromContext(ctx); factory != nil {
+		c.factories[nse.GetName()] = factory
+	}
+
+	return resp, nil
+}
+
+func (c *capacityLabelsNSEClient) Find(ctx context.Context, query *registry.NetworkServiceEndpointQuery, opts ...grpc.CallOption) (registry.NetworkServiceEndpointRegistry_FindClient, error) {
+	return next.NetworkServiceEndpointRegistryClient(ctx).Find(ctx, query, opts...)
+}
+
+func (c *capacityLabelsNSEClient) Unregister(ctx context.Context, nse *registry.NetworkServiceEndpoint, opts ...grpc.CallOption) (*empty.Empty, error) {
+	c.lock.Lock()
+	delete(c.factories, nse.GetName())
+	c.lock.Unlock()
+
+	return next.NetworkServiceEndpointRegistryClient(ctx).Unregister(ctx, nse, opts...)
+}
+
+func (c *capacityLabelsNSEClient) onCapacityChange() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.timer == nil {
+		c.timer = clock.FromContext(c.ctx).AfterFunc(c.debounce, c.update)
+	}
+}
+
+func (c *capacityLabelsNSEClient) update() {
+	c.lock.Lock()
+	c.timer = nil
+	if reflect.DeepEqual(c.pool.Capacity(), c.capacity) {
+		c.lock.Unlock()
+		return
+	}
+	var factories []begin.EventFactory
+	for _, factory := range c.factories {
+		factories = append(factories, factory)
+	}
+	c.lock.Unlock()
+
+	for _, factory := range factories {
+		if err := <-factory.Register(begin.CancelContext(c.ctx)); err != nil {
+			log.FromContext(c.ctx).Warnf("failed to update NSE capacity labels: %s", err.Error())
+		}
+	}
+}
+
+func setLabels(nse *registry.NetworkServiceEndpoint, capacity map[string]int) {
+	if nse.NetworkServiceLabels == nil {
+		nse.NetworkServiceLabels = map[string]*registry.NetworkServiceLabels{}
+	}
+	for _, nsName := range nse.GetNetworkServiceNames() {
+		nsLabels, ok := nse.NetworkServiceLabels[nsName]
+		if !ok {
+			nsLabels = new(registry.NetworkServiceLabels)
+			nse.NetworkServiceLabels[nsName] = nsLabels
+		}
+		if nsLabels.Labels == nil {
+			nsLabels.Labels = map[string]string{}
+		}
+		for tokenName, count := range capacity {
+			nsLabels.Labels[LabelPrefix+tokenName] = strconv.Itoa(count)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacitylabels_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+	"google.golang.org/grpc"
+
+	"github.com/networkservicemesh/api/pkg/api/registry"
+	"github.com/networkservicemesh/sdk/pkg/registry/common/begin"
+	"github.com/networkservicemesh/sdk/pkg/registry/core/chain"
+	"github.com/networkservicemesh/sdk/pkg/registry/core/next"
+
+	"github.com/networkservicemesh/sdk-sriov/pkg/registry/common/capacitylabels"
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/config"
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/token"
+)
+
+const (
+	nsName    = "ns"
+	tokenName = "service.domain.1/intel"
+	labelKey  = capacitylabels.LabelPrefix + tokenName
+	debounce  = 50 * time.Millisecond
+)
+
+type registrationsClient struct {
+	registrations []*registry.NetworkServiceEndpoint
+	lock          sync.Mutex
+}
+
+func (c *registrationsClient) Register(ctx context.Context, nse *registry.NetworkServiceEndpoint, opts ...grpc.CallOption) (*registry.NetworkServiceEndpoint, error) {
+	c.lock.Lock()
+	c.registrations = append(c.registrations, nse.Clone())
+	c.lock.Unlock()
+
+	return next.NetworkServiceEndpointRegistryClient(ctx).Register(ctx, nse, opts...)
+}
+
+func (c *registrationsClient) Find(ctx context.Context, query *registry.NetworkServiceEndpointQuery, opts ...grpc.CallOption) (registry.NetworkServiceEndpointRegistry_FindClient, error) {
+	return next.NetworkServiceEndpointRegistryClient(ctx).Find(ctx, query, opts...)
+}
+
+func (c *registrationsClient) Unregister(ctx context.Context, nse *registry.NetworkServiceEndpoint, opts ...grpc.CallOption) (*empty.Empty, error) {
+	return next.NetworkServiceEndpointRegistryClient(ctx).Unregister(ctx, nse, opts...)
+}
+
+func (c *registrationsClient) labels() []string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	var labels []string
+	for _, nse := range c.registrations {
+		labels = append(labels, nse.GetNetworkServiceLabels()[nsName].GetLabels()[labelKey])
+	}
+	return labels
+}
+
+func testConfig() *config.Config {
+	return &config.Config{
+		PhysicalFunctions: map[string]*config.PhysicalFunction{
+			"0000:01:00.0": {
+				Capabilities:   []string{"intel"},
+				ServiceDomains: []string{"service.domain.1"},
+				VirtualFunctions: []*config.VirtualFunction{
+					{Address: "0000:01:00.1", IOMMUGroup: 1},
+					{Address: "0000:01:00.2", IOMMUGroup: 2},
+					{Address: "0000:01:00.3", IOMMUGroup: 3},
+				},
+			},
+		},
+	}
+}
+
+func TestCapacityLabelsNSEClient(t *testing.T) {
+	t.Cleanup(func() { goleak.VerifyNone(t) })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	tokenPool := token.NewPool(testConfig())
+	defer tokenPool.Close()
+
+	registrations := new(registrationsClient)
+	client := chain.NewNetworkServiceEndpointRegistryClient(
+		begin.NewNetworkServiceEndpointRegistryClient(),
+		capacitylabels.NewNetworkServiceEndpointRegistryClient(ctx, tokenPool, capacitylabels.WithDebounce(debounce)),
+		registrations,
+	)
+
+	// 1. Registration has the capacity labels
+
+	_, err := client.Register(ctx, &registry.NetworkServiceEndpoint{
+		Name:                "nse",
+		NetworkServiceNames: []string{nsName},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"3"}, registrations.labels())
+
+	// 2. Rapid allocations are coalesced into a single registration update
+
+	var ids []string
+	for id := range tokenPool.Tokens()[tokenName] {
+		ids = append(ids, id)
+	}
+	require.NoError(t, tokenPool.Allocate(ids[0]))
+	require.NoError(t, tokenPool.Allocate(ids[1]))
+
+	require.Eventually(t, func() bool {
+		return len(registrations.labels()) == 2
+	}, time.Second, 10*time.Millisecond)
+	require.Equal(t, []string{"3", "1"}, registrations.labels())
+
+	// 3. No update if the capacity has been changed back
+
+	require.NoError(t, tokenPool.Allocate(ids[2]))
+	require.NoError(t, tokenPool.Free(ids[2]))
+
+	time.Sleep(2 * debounce)
+	require.Len(t, registrations.labels(), 2)
+
+	_, err = client.Unregister(ctx, &registry.NetworkServiceEndpoint{Name: "nse"})
+	require.NoError(t, err)
+}
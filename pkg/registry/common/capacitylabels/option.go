@@ -0,0 +1,29 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacitylabels
+
+import "time"
+
+// Option is an option for NewNetworkServiceEndpointRegistryClient
+type Option func(c *capacityLabelsNSEClient)
+
+// WithDebounce sets a delay to coalesce the capacity changes into a single NSE registration update, default is 1s
+func WithDebounce(debounce time.Duration) Option {
+	return func(c *capacityLabelsNSEClient) {
+		c.debounce = debounce
+	}
+}
@@ -19,25 +19,51 @@ package config
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/networkservicemesh/sdk/pkg/tools/log/logruslogger"
 	"github.com/pkg/errors"
 
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov"
+	"github.com/networkservicemesh/sdk-sriov/pkg/tools/tokens"
 	"github.com/networkservicemesh/sdk-sriov/pkg/tools/yamlhelper"
 )
 
+const (
+	envPrefix               = "NSM_SRIOV_PF_"
+	pfKernelDriverEnvSuffix = "_KERNEL_DRIVER"
+	vfKernelDriverEnvSuffix = "_VF_KERNEL_DRIVER"
+)
+
 // Config contains list of available physical functions
 type Config struct {
-	PhysicalFunctions map[string]*PhysicalFunction `yaml:"physicalFunctions"`
+	// MaxAllocatableVFs limits number of VFs that can be handed out node-wide, 0 means no limit
+	MaxAllocatableVFs uint                         `yaml:"maxAllocatableVFs" json:"maxAllocatableVFs"`
+	PhysicalFunctions map[string]*PhysicalFunction `yaml:"physicalFunctions" json:"physicalFunctions"`
+	ServiceDomains    map[string]*ServiceDomain    `yaml:"serviceDomains" json:"serviceDomains"`
+	// SpoofCheckAllowlist contains SPIFFE IDs of the clients allowed to disable VF spoof check for their connections
+	SpoofCheckAllowlist []string `yaml:"spoofCheckAllowlist" json:"spoofCheckAllowlist"`
+	// QoSClasses contains VF QoS classes, VFs of a class back only the separate "<name>.<class>" tokens of the class
+	QoSClasses []string `yaml:"qosClasses" json:"qosClasses"`
+	// VFIOSelfTest enables the VFIO passthrough self-test on a spare VF on the forwarder startup
+	VFIOSelfTest bool `yaml:"vfioSelfTest" json:"vfioSelfTest"`
 }
 
 func (c *Config) String() string {
 	sb := &strings.Builder{}
 	_, _ = sb.WriteString("&{")
 
-	_, _ = sb.WriteString("PhysicalFunctions:map[")
+	_, _ = sb.WriteString("MaxAllocatableVFs:")
+	_, _ = sb.WriteString(strconv.FormatUint(uint64(c.MaxAllocatableVFs), 10))
+
+	_, _ = sb.WriteString(" PhysicalFunctions:map[")
 	var strs []string
 	for k, physicalFunction := range c.PhysicalFunctions {
 		strs = append(strs, fmt.Sprintf("%s:%+v", k, physicalFunction))
@@ -45,17 +71,166 @@ func (c *Config) String() string {
 	_, _ = sb.WriteString(strings.Join(strs, " "))
 	_, _ = sb.WriteString("]")
 
+	_, _ = sb.WriteString(" ServiceDomains:map[")
+	strs = nil
+	for k, serviceDomain := range c.ServiceDomains {
+		strs = append(strs, fmt.Sprintf("%s:%+v", k, serviceDomain))
+	}
+	_, _ = sb.WriteString(strings.Join(strs, " "))
+	_, _ = sb.WriteString("]")
+
+	_, _ = sb.WriteString(" SpoofCheckAllowlist:[")
+	_, _ = sb.WriteString(strings.Join(c.SpoofCheckAllowlist, " "))
+	_, _ = sb.WriteString("]")
+
+	_, _ = sb.WriteString(" QoSClasses:[")
+	_, _ = sb.WriteString(strings.Join(c.QoSClasses, " "))
+	_, _ = sb.WriteString("]")
+
+	_, _ = sb.WriteString(" VFIOSelfTest:")
+	_, _ = sb.WriteString(strconv.FormatBool(c.VFIOSelfTest))
+
 	_, _ = sb.WriteString("}")
 	return sb.String()
 }
 
+// Clone returns a deep copy of the Config
+func (c *Config) Clone() *Config {
+	if c == nil {
+		return nil
+	}
+
+	clone := &Config{
+		MaxAllocatableVFs:   c.MaxAllocatableVFs,
+		SpoofCheckAllowlist: slices.Clone(c.SpoofCheckAllowlist),
+		QoSClasses:          slices.Clone(c.QoSClasses),
+		VFIOSelfTest:        c.VFIOSelfTest,
+	}
+	if c.PhysicalFunctions != nil {
+		clone.PhysicalFunctions = make(map[string]*PhysicalFunction, len(c.PhysicalFunctions))
+		for pciAddr, pfCfg := range c.PhysicalFunctions {
+			clone.PhysicalFunctions[pciAddr] = pfCfg.Clone()
+		}
+	}
+	if c.ServiceDomains != nil {
+		clone.ServiceDomains = make(map[string]*ServiceDomain, len(c.ServiceDomains))
+		for serviceDomain, sdCfg := range c.ServiceDomains {
+			clone.ServiceDomains[serviceDomain] = sdCfg.Clone()
+		}
+	}
+	return clone
+}
+
+// ServiceDomain contains service domain VF selection restrictions
+type ServiceDomain struct {
+	// AllowedNUMANodes limits VF selection to the PFs on the NUMA nodes, empty means no restriction
+	AllowedNUMANodes []int `yaml:"allowedNumaNodes" json:"allowedNumaNodes"`
+	// AllowedDriverTypes limits VF driver types the service domain connections can request, e.g. "vfio-pci", empty
+	// means no restriction
+	AllowedDriverTypes []string `yaml:"allowedDriverTypes" json:"allowedDriverTypes"`
+	// Priority is used by the VF preemption, VFs reserved by the service domain connections can be reclaimed for the
+	// connections of the service domains with higher priority, 0 is the default
+	Priority int `yaml:"priority" json:"priority"`
+}
+
+// Clone returns a deep copy of the ServiceDomain
+func (sd *ServiceDomain) Clone() *ServiceDomain {
+	if sd == nil {
+		return nil
+	}
+	return &ServiceDomain{
+		AllowedNUMANodes:   slices.Clone(sd.AllowedNUMANodes),
+		AllowedDriverTypes: slices.Clone(sd.AllowedDriverTypes),
+		Priority:           sd.Priority,
+	}
+}
+
+// IsNUMANodeAllowed returns true if the service domain connections can get VFs of the PFs on the NUMA node
+func (sd *ServiceDomain) IsNUMANodeAllowed(numaNode int) bool {
+	if sd == nil || len(sd.AllowedNUMANodes) == 0 {
+		return true
+	}
+	return slices.Contains(sd.AllowedNUMANodes, numaNode)
+}
+
+// IsDriverTypeAllowed returns true if the service domain connections can request VFs with the driver type
+func (sd *ServiceDomain) IsDriverTypeAllowed(driverType sriov.DriverType) bool {
+	if sd == nil || len(sd.AllowedDriverTypes) == 0 {
+		return true
+	}
+	for _, allowed := range sd.AllowedDriverTypes {
+		if allowed == string(driverType) {
+			return true
+		}
+	}
+	return false
+}
+
 // PhysicalFunction contains physical function capabilities, available services domains and virtual functions
 type PhysicalFunction struct {
-	PFKernelDriver   string             `yaml:"pfKernelDriver"`
-	VFKernelDriver   string             `yaml:"vfKernelDriver"`
-	Capabilities     []string           `yaml:"capabilities"`
-	ServiceDomains   []string           `yaml:"serviceDomains"`
-	VirtualFunctions []*VirtualFunction `yaml:"virtualFunctions"`
+	PFKernelDriver string   `yaml:"pfKernelDriver" json:"pfKernelDriver"`
+	VFKernelDriver string   `yaml:"vfKernelDriver" json:"vfKernelDriver"`
+	Capabilities   []string `yaml:"capabilities" json:"capabilities"`
+	ServiceDomains []string `yaml:"serviceDomains" json:"serviceDomains"`
+	// NUMANode is a PF NUMA node, -1 means no NUMA node, unset means unknown and is treated as no NUMA node, it is
+	// read from sysfs by pci.UpdateConfig if unset
+	NUMANode *int `yaml:"numaNode" json:"numaNode"`
+	// TargetConcurrency is a number of concurrent connections each service domain × capability combination should
+	// be able to get, 0 means 1
+	TargetConcurrency uint `yaml:"targetConcurrency" json:"targetConcurrency"`
+	// ExpectedVendorID, ExpectedDeviceID are PF PCI vendor and device IDs, e.g. "8086", "1572", "0x" prefix is optional,
+	// empty means no check
+	ExpectedVendorID string `yaml:"expectedVendorID" json:"expectedVendorID"`
+	ExpectedDeviceID string `yaml:"expectedDeviceID" json:"expectedDeviceID"`
+	// BringPFUp makes the PF net interface administratively up on the PCI pool init, some NICs need it for the VFs
+	// to get carrier
+	BringPFUp        bool               `yaml:"bringPFUp" json:"bringPFUp"`
+	VirtualFunctions []*VirtualFunction `yaml:"virtualFunctions" json:"virtualFunctions"`
+}
+
+// GetNUMANode returns the PF NUMA node, -1 if it is not set
+func (pf *PhysicalFunction) GetNUMANode() int {
+	if pf.NUMANode == nil {
+		return -1
+	}
+	return *pf.NUMANode
+}
+
+// AllowedServiceDomains returns the PF service domains allowed to get the PF VFs by their AllowedNUMANodes, the PF
+// VFs back only the tokens of these service domains
+func (c *Config) AllowedServiceDomains(pfCfg *PhysicalFunction) []string {
+	var serviceDomains []string
+	for _, serviceDomain := range pfCfg.ServiceDomains {
+		if c.ServiceDomains[serviceDomain].IsNUMANodeAllowed(pfCfg.GetNUMANode()) {
+			serviceDomains = append(serviceDomains, serviceDomain)
+		}
+	}
+	return serviceDomains
+}
+
+// Clone returns a deep copy of the PhysicalFunction
+func (pf *PhysicalFunction) Clone() *PhysicalFunction {
+	if pf == nil {
+		return nil
+	}
+
+	clone := *pf
+	if pf.NUMANode != nil {
+		numaNode := *pf.NUMANode
+		clone.NUMANode = &numaNode
+	}
+	clone.Capabilities = slices.Clone(pf.Capabilities)
+	clone.ServiceDomains = slices.Clone(pf.ServiceDomains)
+	if pf.VirtualFunctions != nil {
+		clone.VirtualFunctions = make([]*VirtualFunction, len(pf.VirtualFunctions))
+		for i, vfCfg := range pf.VirtualFunctions {
+			if vfCfg != nil {
+				vfClone := *vfCfg
+				clone.VirtualFunctions[i] = &vfClone
+			}
+		}
+	}
+	return &clone
 }
 
 func (pf *PhysicalFunction) String() string {
@@ -76,6 +251,25 @@ func (pf *PhysicalFunction) String() string {
 	_, _ = sb.WriteString(strings.Join(pf.ServiceDomains, " "))
 	_, _ = sb.WriteString("]")
 
+	_, _ = sb.WriteString(" NUMANode:")
+	if pf.NUMANode != nil {
+		_, _ = sb.WriteString(strconv.Itoa(*pf.NUMANode))
+	} else {
+		_, _ = sb.WriteString("<nil>")
+	}
+
+	_, _ = sb.WriteString(" TargetConcurrency:")
+	_, _ = sb.WriteString(strconv.FormatUint(uint64(pf.TargetConcurrency), 10))
+
+	_, _ = sb.WriteString(" ExpectedVendorID:")
+	_, _ = sb.WriteString(pf.ExpectedVendorID)
+
+	_, _ = sb.WriteString(" ExpectedDeviceID:")
+	_, _ = sb.WriteString(pf.ExpectedDeviceID)
+
+	_, _ = sb.WriteString(" BringPFUp:")
+	_, _ = sb.WriteString(strconv.FormatBool(pf.BringPFUp))
+
 	_, _ = sb.WriteString(" VirtualFunctions:[")
 	var strs []string
 	for _, virtualFunction := range pf.VirtualFunctions {
@@ -90,19 +284,39 @@ func (pf *PhysicalFunction) String() string {
 
 // VirtualFunction contains
 type VirtualFunction struct {
-	Address    string `yaml:"address"`
-	IOMMUGroup uint   `yaml:"iommuGroup"`
+	Address    string `yaml:"address" json:"address"`
+	IOMMUGroup uint   `yaml:"iommuGroup" json:"iommuGroup"`
+	// QoSClass is a VF QoS class from the Config.QoSClasses, empty means the default class
+	QoSClass string `yaml:"qosClass" json:"qosClass"`
 }
 
 // ReadConfig reads configuration from file
 func ReadConfig(ctx context.Context, configFile string) (*Config, error) {
+	return readConfig(ctx, configFile, false)
+}
+
+// ReadConfigWithEnv reads configuration from file same as ReadConfig, but PF kernel drivers can be overridden with
+// the environment variables:
+//   - NSM_SRIOV_PF_<bdf>_KERNEL_DRIVER - PF kernel driver
+//   - NSM_SRIOV_PF_<bdf>_VF_KERNEL_DRIVER - VF kernel driver
+//
+// where <bdf> is the PF PCI address with colons and dots replaced by underscores, e.g. 0000_01_00_0.
+func ReadConfigWithEnv(ctx context.Context, configFile string) (*Config, error) {
+	return readConfig(ctx, configFile, true)
+}
+
+func readConfig(ctx context.Context, configFile string, withEnv bool) (*Config, error) {
 	logger := logruslogger.New(ctx)
 
 	cfg := &Config{}
-	if err := yamlhelper.UnmarshalFile(configFile, cfg); err != nil {
+	if err := unmarshalFile(configFile, cfg); err != nil {
 		return nil, err
 	}
 
+	if withEnv {
+		applyEnvOverrides(ctx, cfg)
+	}
+
 	for pciAddr, pfCfg := range cfg.PhysicalFunctions {
 		if pfCfg.PFKernelDriver == "" {
 			return nil, errors.Errorf("%s has no PFKernelDriver set", pciAddr)
@@ -116,9 +330,166 @@ func ReadConfig(ctx context.Context, configFile string) (*Config, error) {
 		if len(pfCfg.ServiceDomains) == 0 {
 			return nil, errors.Errorf("%s has no ServiceDomains set", pciAddr)
 		}
+		if err := validateQoSClasses(cfg, pfCfg); err != nil {
+			return nil, errors.Wrapf(err, "%s", pciAddr)
+		}
+	}
+
+	if err := validateVFAddresses(cfg); err != nil {
+		return nil, err
+	}
+
+	if err := validateServiceDomains(cfg); err != nil {
+		return nil, err
+	}
+
+	if err := ValidateVFCapacity(cfg); err != nil {
+		logger.WithField("Config", "ReadConfig").Warnf("%v", err)
 	}
 
 	logger.WithField("Config", "ReadConfig").Infof("unmarshalled Config: %+v", cfg)
 
 	return cfg, nil
 }
+
+// unmarshalFile unmarshals JSON config file if it has ".json" extension, YAML otherwise
+func unmarshalFile(configFile string, cfg *Config) error {
+	if !strings.EqualFold(filepath.Ext(configFile), ".json") {
+		return yamlhelper.UnmarshalFile(configFile, cfg)
+	}
+
+	bytes, err := os.ReadFile(filepath.Clean(configFile))
+	if err != nil {
+		return errors.Wrapf(err, "error reading file: %v", configFile)
+	}
+	if err = json.Unmarshal(bytes, cfg); err != nil {
+		return errors.Wrapf(err, "error unmarshalling json: %s", bytes)
+	}
+	return nil
+}
+
+func applyEnvOverrides(ctx context.Context, cfg *Config) {
+	logger := logruslogger.New(ctx).WithField("Config", "ReadConfigWithEnv")
+
+	for pciAddr, pfCfg := range cfg.PhysicalFunctions {
+		prefix := envPrefix + strings.NewReplacer(":", "_", ".", "_").Replace(pciAddr)
+
+		if driver, ok := os.LookupEnv(prefix + pfKernelDriverEnvSuffix); ok {
+			logger.Infof("%s PFKernelDriver is overridden from %s: %s", pciAddr, prefix+pfKernelDriverEnvSuffix, driver)
+			pfCfg.PFKernelDriver = driver
+		}
+		if driver, ok := os.LookupEnv(prefix + vfKernelDriverEnvSuffix); ok {
+			logger.Infof("%s VFKernelDriver is overridden from %s: %s", pciAddr, prefix+vfKernelDriverEnvSuffix, driver)
+			pfCfg.VFKernelDriver = driver
+		}
+	}
+}
+
+func validateQoSClasses(cfg *Config, pfCfg *PhysicalFunction) error {
+	for _, vfCfg := range pfCfg.VirtualFunctions {
+		if vfCfg.QoSClass == "" {
+			continue
+		}
+		var ok bool
+		for _, qosClass := range cfg.QoSClasses {
+			ok = ok || qosClass == vfCfg.QoSClass
+		}
+		if !ok {
+			return errors.Errorf("VF %s has unknown QoS class: %s", vfCfg.Address, vfCfg.QoSClass)
+		}
+	}
+	return nil
+}
+
+func validateVFAddresses(cfg *Config) error {
+	var pfPCIAddrs []string
+	for pfPCIAddr := range cfg.PhysicalFunctions {
+		pfPCIAddrs = append(pfPCIAddrs, pfPCIAddr)
+	}
+	sort.Strings(pfPCIAddrs)
+
+	vfPCIAddrs := map[string]string{}
+	for _, pfPCIAddr := range pfPCIAddrs {
+		for _, vfCfg := range cfg.PhysicalFunctions[pfPCIAddr].VirtualFunctions {
+			if _, ok := cfg.PhysicalFunctions[vfCfg.Address]; ok {
+				return errors.Errorf("VF %s of %s is configured as a PF", vfCfg.Address, pfPCIAddr)
+			}
+			if otherPFPCIAddr, ok := vfPCIAddrs[vfCfg.Address]; ok {
+				return errors.Errorf("VF %s is configured more than once: %s, %s", vfCfg.Address, otherPFPCIAddr, pfPCIAddr)
+			}
+			vfPCIAddrs[vfCfg.Address] = pfPCIAddr
+		}
+	}
+	return nil
+}
+
+func validateServiceDomains(cfg *Config) error {
+	for serviceDomain, sdCfg := range cfg.ServiceDomains {
+		for _, driverType := range sdCfg.AllowedDriverTypes {
+			switch sriov.DriverType(driverType) {
+			case sriov.KernelDriver, sriov.VFIOPCIDriver:
+			default:
+				return errors.Errorf("service domain %s has unknown allowed driver type: %s", serviceDomain, driverType)
+			}
+		}
+	}
+	return nil
+}
+
+// Validate checks VF IOMMU groups: VFs of one PF should either share a single IOMMU group or have a separate IOMMU
+// group each, mixed layout is logged as a warning. IOMMU group 0 is almost always a misconfiguration, it is logged as
+// a warning or returned as an error if strict is set.
+func (c *Config) Validate(ctx context.Context, strict bool) error {
+	logger := logruslogger.New(ctx).WithField("Config", "Validate")
+
+	var pfPCIAddrs []string
+	for pfPCIAddr := range c.PhysicalFunctions {
+		pfPCIAddrs = append(pfPCIAddrs, pfPCIAddr)
+	}
+	sort.Strings(pfPCIAddrs)
+
+	for _, pfPCIAddr := range pfPCIAddrs {
+		pfCfg := c.PhysicalFunctions[pfPCIAddr]
+
+		iommuGroups := map[uint]struct{}{}
+		for _, vfCfg := range pfCfg.VirtualFunctions {
+			if vfCfg.IOMMUGroup == 0 {
+				if strict {
+					return errors.Errorf("VF %s of %s has IOMMU group 0", vfCfg.Address, pfPCIAddr)
+				}
+				logger.Warnf("VF %s of %s has IOMMU group 0", vfCfg.Address, pfPCIAddr)
+			}
+			iommuGroups[vfCfg.IOMMUGroup] = struct{}{}
+		}
+
+		if count := len(iommuGroups); count > 1 && count < len(pfCfg.VirtualFunctions) {
+			logger.Warnf("VFs of %s span %d IOMMU groups, expected 1 or %d", pfPCIAddr, count, len(pfCfg.VirtualFunctions))
+		}
+	}
+	return nil
+}
+
+// RequiredVFCount returns a minimum number of VFs needed to back all declared token names at the PF target
+// concurrency, hierarchical capabilities produce a token name for each of their parents (see tokens.Names)
+func RequiredVFCount(pfCfg *PhysicalFunction) int {
+	concurrency := int(pfCfg.TargetConcurrency)
+	if concurrency == 0 {
+		concurrency = 1
+	}
+	return len(tokens.Names(pfCfg.ServiceDomains, pfCfg.Capabilities)) * concurrency
+}
+
+// ValidateVFCapacity returns an error if some PF has not enough VFs to back its declared tokens
+func ValidateVFCapacity(cfg *Config) error {
+	var strs []string
+	for pciAddr, pfCfg := range cfg.PhysicalFunctions {
+		if required := RequiredVFCount(pfCfg); len(pfCfg.VirtualFunctions) < required {
+			strs = append(strs, fmt.Sprintf("%s: %d < %d", pciAddr, len(pfCfg.VirtualFunctions), required))
+		}
+	}
+	if len(strs) > 0 {
+		sort.Strings(strs)
+		return errors.Errorf("VF count is not enough to back declared tokens: %s", strings.Join(strs, ", "))
+	}
+	return nil
+}
@@ -18,15 +18,19 @@ package config_test
 
 import (
 	"context"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov"
 	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/config"
 )
 
 const (
 	configFileName  = "config.yml"
+	jsonConfigFile  = "config.json"
 	pf1PciAddr      = "0000:01:00.0"
 	pf2PciAddr      = "0000:02:00.0"
 	pfKernelDriver  = "pf-driver"
@@ -98,3 +102,264 @@ func TestReadConfigFile(t *testing.T) {
 		},
 	}, cfg)
 }
+
+func TestReadConfigFile_JSON(t *testing.T) {
+	yamlCfg, err := config.ReadConfig(context.Background(), configFileName)
+	require.NoError(t, err)
+
+	jsonCfg, err := config.ReadConfig(context.Background(), jsonConfigFile)
+	require.NoError(t, err)
+	require.Equal(t, yamlCfg, jsonCfg)
+}
+
+func TestRequiredVFCount(t *testing.T) {
+	cfg, err := config.ReadConfig(context.Background(), configFileName)
+	require.NoError(t, err)
+
+	require.Equal(t, 2, config.RequiredVFCount(cfg.PhysicalFunctions[pf1PciAddr]))
+	require.Equal(t, 4, config.RequiredVFCount(cfg.PhysicalFunctions[pf2PciAddr]))
+
+	cfg.PhysicalFunctions[pf1PciAddr].TargetConcurrency = 2
+	require.Equal(t, 4, config.RequiredVFCount(cfg.PhysicalFunctions[pf1PciAddr]))
+
+	// "net/10G" capability needs VFs for both "net/10G" and "net" token names
+	require.Equal(t, 3, config.RequiredVFCount(&config.PhysicalFunction{
+		Capabilities:   []string{"net/10G", "net/20G"},
+		ServiceDomains: []string{"service.domain.1"},
+	}))
+}
+
+func TestValidateVFCapacity(t *testing.T) {
+	cfg, err := config.ReadConfig(context.Background(), configFileName)
+	require.NoError(t, err)
+
+	// pf2 has 3 VFs for 2 service domains × 2 capabilities
+	err = config.ValidateVFCapacity(cfg)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), pf2PciAddr)
+	require.NotContains(t, err.Error(), pf1PciAddr)
+
+	delete(cfg.PhysicalFunctions, pf2PciAddr)
+	require.NoError(t, config.ValidateVFCapacity(cfg))
+}
+
+func TestReadConfig_UnknownQoSClass(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), configFileName)
+	require.NoError(t, os.WriteFile(configFile, []byte(`---
+qosClasses:
+  - guaranteed
+physicalFunctions:
+  0000:01:00.0:
+    pfKernelDriver: pf-driver
+    vfKernelDriver: vf-driver
+    capabilities:
+      - intel
+    serviceDomains:
+      - service.domain.1
+    virtualFunctions:
+      - address: 0000:01:00.1
+        iommuGroup: 1
+        qosClass: guaranteed
+      - address: 0000:01:00.2
+        iommuGroup: 2
+        qosClass: burstable
+`), 0o600))
+
+	_, err := config.ReadConfig(context.Background(), configFile)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "0000:01:00.2")
+}
+
+func TestReadConfig_AllowedDriverTypes(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), configFileName)
+	writeConfig := func(driverType string) {
+		require.NoError(t, os.WriteFile(configFile, []byte(`---
+serviceDomains:
+  service.domain.1:
+    allowedDriverTypes:
+      - `+driverType+`
+physicalFunctions:
+  0000:01:00.0:
+    pfKernelDriver: pf-driver
+    vfKernelDriver: vf-driver
+    capabilities:
+      - intel
+    serviceDomains:
+      - service.domain.1
+    virtualFunctions:
+      - address: 0000:01:00.1
+        iommuGroup: 1
+`), 0o600))
+	}
+
+	writeConfig("vfio-pci")
+	cfg, err := config.ReadConfig(context.Background(), configFile)
+	require.NoError(t, err)
+	require.True(t, cfg.ServiceDomains["service.domain.1"].IsDriverTypeAllowed(sriov.VFIOPCIDriver))
+	require.False(t, cfg.ServiceDomains["service.domain.1"].IsDriverTypeAllowed(sriov.KernelDriver))
+	require.True(t, cfg.ServiceDomains["service.domain.2"].IsDriverTypeAllowed(sriov.KernelDriver))
+
+	writeConfig("dpdk")
+	_, err = config.ReadConfig(context.Background(), configFile)
+	require.Error(t, err)
+}
+
+func TestReadConfig_NUMANode(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), configFileName)
+	require.NoError(t, os.WriteFile(configFile, []byte(`---
+serviceDomains:
+  service.domain.1:
+    allowedNumaNodes:
+      - 0
+physicalFunctions:
+  0000:01:00.0:
+    pfKernelDriver: pf-driver
+    vfKernelDriver: vf-driver
+    numaNode: 0
+    capabilities:
+      - intel
+    serviceDomains:
+      - service.domain.1
+      - service.domain.2
+    virtualFunctions:
+      - address: 0000:01:00.1
+        iommuGroup: 1
+  0000:02:00.0:
+    pfKernelDriver: pf-driver
+    vfKernelDriver: vf-driver
+    capabilities:
+      - intel
+    serviceDomains:
+      - service.domain.1
+      - service.domain.2
+    virtualFunctions:
+      - address: 0000:02:00.1
+        iommuGroup: 2
+`), 0o600))
+
+	cfg, err := config.ReadConfig(context.Background(), configFile)
+	require.NoError(t, err)
+
+	// Unset NUMA node is not the NUMA node 0
+
+	require.Equal(t, 0, cfg.PhysicalFunctions[pf1PciAddr].GetNUMANode())
+	require.Nil(t, cfg.PhysicalFunctions[pf2PciAddr].NUMANode)
+	require.Equal(t, -1, cfg.PhysicalFunctions[pf2PciAddr].GetNUMANode())
+
+	require.Equal(t, []string{serviceDomain1, serviceDomain2}, cfg.AllowedServiceDomains(cfg.PhysicalFunctions[pf1PciAddr]))
+	require.Equal(t, []string{serviceDomain2}, cfg.AllowedServiceDomains(cfg.PhysicalFunctions[pf2PciAddr]))
+}
+
+func TestReadConfig_DuplicateVFAddress(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), configFileName)
+	writeConfig := func(vfPCIAddr string) {
+		require.NoError(t, os.WriteFile(configFile, []byte(`---
+physicalFunctions:
+  0000:01:00.0:
+    pfKernelDriver: pf-driver
+    vfKernelDriver: vf-driver
+    capabilities:
+      - intel
+    serviceDomains:
+      - service.domain.1
+    virtualFunctions:
+      - address: 0000:01:00.1
+        iommuGroup: 1
+  0000:02:00.0:
+    pfKernelDriver: pf-driver
+    vfKernelDriver: vf-driver
+    capabilities:
+      - intel
+    serviceDomains:
+      - service.domain.1
+    virtualFunctions:
+      - address: `+vfPCIAddr+`
+        iommuGroup: 2
+`), 0o600))
+	}
+
+	writeConfig("0000:02:00.1")
+	_, err := config.ReadConfig(context.Background(), configFile)
+	require.NoError(t, err)
+
+	writeConfig("0000:01:00.1")
+	_, err = config.ReadConfig(context.Background(), configFile)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "0000:01:00.1")
+
+	writeConfig("0000:01:00.0")
+	_, err = config.ReadConfig(context.Background(), configFile)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "0000:01:00.0")
+}
+
+func TestConfig_Validate(t *testing.T) {
+	cfg := &config.Config{
+		PhysicalFunctions: map[string]*config.PhysicalFunction{
+			"0000:01:00.0": {
+				VirtualFunctions: []*config.VirtualFunction{
+					{Address: "0000:01:00.1", IOMMUGroup: 1},
+					{Address: "0000:01:00.2", IOMMUGroup: 1},
+					{Address: "0000:01:00.3", IOMMUGroup: 2},
+				},
+			},
+		},
+	}
+
+	// Mixed IOMMU groups are only logged
+	require.NoError(t, cfg.Validate(context.Background(), false))
+	require.NoError(t, cfg.Validate(context.Background(), true))
+
+	cfg.PhysicalFunctions["0000:02:00.0"] = &config.PhysicalFunction{
+		VirtualFunctions: []*config.VirtualFunction{
+			{Address: "0000:02:00.1", IOMMUGroup: 0},
+		},
+	}
+
+	// IOMMU group 0 is an error only in the strict mode
+	require.NoError(t, cfg.Validate(context.Background(), false))
+
+	err := cfg.Validate(context.Background(), true)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "0000:02:00.1")
+}
+
+func TestReadConfigWithEnv(t *testing.T) {
+	t.Setenv("NSM_SRIOV_PF_0000_01_00_0_KERNEL_DRIVER", "pf-driver-override")
+	t.Setenv("NSM_SRIOV_PF_0000_01_00_0_VF_KERNEL_DRIVER", "vf-driver-override")
+	t.Setenv("NSM_SRIOV_PF_0000_09_00_0_KERNEL_DRIVER", "unknown-pf-driver")
+
+	cfg, err := config.ReadConfigWithEnv(context.Background(), configFileName)
+	require.NoError(t, err)
+
+	require.Equal(t, "pf-driver-override", cfg.PhysicalFunctions["0000:01:00.0"].PFKernelDriver)
+	require.Equal(t, "vf-driver-override", cfg.PhysicalFunctions["0000:01:00.0"].VFKernelDriver)
+	require.Equal(t, "pf-driver", cfg.PhysicalFunctions["0000:02:00.0"].PFKernelDriver)
+	require.Equal(t, "vf-driver", cfg.PhysicalFunctions["0000:02:00.0"].VFKernelDriver)
+
+	cfg, err = config.ReadConfig(context.Background(), configFileName)
+	require.NoError(t, err)
+
+	require.Equal(t, "pf-driver", cfg.PhysicalFunctions["0000:01:00.0"].PFKernelDriver)
+	require.Equal(t, "vf-driver", cfg.PhysicalFunctions["0000:01:00.0"].VFKernelDriver)
+}
+
+func TestConfig_Clone(t *testing.T) {
+	cfg, err := config.ReadConfig(context.Background(), configFileName)
+	require.NoError(t, err)
+
+	expected, err := config.ReadConfig(context.Background(), configFileName)
+	require.NoError(t, err)
+
+	clone := cfg.Clone()
+	require.Equal(t, cfg, clone)
+
+	pfCfg := clone.PhysicalFunctions["0000:01:00.0"]
+	pfCfg.PFKernelDriver = "another-driver"
+	pfCfg.Capabilities[0] = "another-capability"
+	pfCfg.ServiceDomains = append(pfCfg.ServiceDomains, "another-service-domain")
+	pfCfg.VirtualFunctions[0].IOMMUGroup = 100
+	delete(clone.PhysicalFunctions, "0000:02:00.0")
+
+	require.Equal(t, expected, cfg)
+}
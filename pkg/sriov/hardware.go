@@ -0,0 +1,104 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sriov
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+)
+
+// HardwareFunction is a PCI function with the host driver state
+type HardwareFunction interface {
+	GetBoundDriver() (string, error)
+
+	PCIFunction
+}
+
+// HardwareProvider provides virtual functions as they are currently seen on the host
+type HardwareProvider interface {
+	// GetVirtualFunctions returns all virtual functions of the physical function and the kernel driver they are bound
+	// to when free
+	GetVirtualFunctions(pfPCIAddr string) (vfs []HardwareFunction, kernelDriver string, err error)
+}
+
+// HardwareVFState is a VF state as it is seen on the host
+type HardwareVFState int
+
+const (
+	// HardwareVFFree - VF has no driver bound or it is bound to the kernel driver and has a net interface in the host
+	// net namespace
+	HardwareVFFree HardwareVFState = iota
+	// HardwareVFAssigned - VF net interface is moved out of the host net namespace or VF is bound to some driver other
+	// than the kernel and the vfio-pci ones
+	HardwareVFAssigned
+	// HardwareVFUnknown - VF is bound to the vfio-pci driver, it stays bound after the VF is freed, so the host state
+	// doesn't tell if the VF is in use
+	HardwareVFUnknown
+)
+
+// GetHardwareVFState returns the VF state grounded on the host state only
+func GetHardwareVFState(vf HardwareFunction, kernelDriver string) (HardwareVFState, error) {
+	driver, err := vf.GetBoundDriver()
+	if err != nil {
+		return HardwareVFUnknown, err
+	}
+
+	switch driver {
+	case "":
+		return HardwareVFFree, nil
+	case string(VFIOPCIDriver):
+		return HardwareVFUnknown, nil
+	case kernelDriver:
+		if ifName, ifErr := vf.GetNetInterfaceName(); ifErr == nil && ifName != "" {
+			return HardwareVFFree, nil
+		}
+	}
+	return HardwareVFAssigned, nil
+}
+
+// CountHardwareAssignedVFs returns count of assigned and total virtual functions of the physical function grounded on
+// the host state only, VFs are classified with GetHardwareVFState. VFs in the HardwareVFUnknown state are counted as
+// not assigned, so the count is a lower bound of the VFs in use.
+func CountHardwareAssignedVFs(ctx context.Context, provider HardwareProvider, pfPCIAddr string) (assigned, total int, err error) {
+	vfs, kernelDriver, err := provider.GetVirtualFunctions(pfPCIAddr)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	logger := log.FromContext(ctx).WithField("sriov", "CountHardwareAssignedVFs")
+	for _, vf := range vfs {
+		if err = ctx.Err(); err != nil {
+			return 0, 0, errors.Wrapf(err, "failed to count assigned VFs for the PF: %v", pfPCIAddr)
+		}
+
+		state, stateErr := GetHardwareVFState(vf, kernelDriver)
+		if stateErr != nil {
+			return 0, 0, stateErr
+		}
+		if state != HardwareVFAssigned {
+			continue
+		}
+
+		logger.Debugf("VF %v is assigned", vf.GetPCIAddress())
+		assigned++
+	}
+
+	return assigned, len(vfs), nil
+}
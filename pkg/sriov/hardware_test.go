@@ -0,0 +1,60 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sriov_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov"
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/sriovtest"
+)
+
+const (
+	pfPCIAddr    = "0000:01:00.0"
+	vfKernelName = "vf-driver"
+)
+
+func TestCountHardwareAssignedVFs(t *testing.T) {
+	provider := &sriovtest.HardwareProvider{
+		KernelDriver: vfKernelName,
+		VFs: map[string][]*sriovtest.PCIFunction{
+			pfPCIAddr: {
+				// free: bound to the kernel driver with the host net interface
+				{Addr: "0000:01:00.1", IfName: "vf-1", Driver: vfKernelName},
+				// free: no driver bound
+				{Addr: "0000:01:00.2"},
+				// unknown: bound to the vfio-pci driver, it stays bound when the VF is freed
+				{Addr: "0000:01:00.3", Driver: string(sriov.VFIOPCIDriver)},
+				// assigned: net interface is moved out of the host net namespace
+				{Addr: "0000:01:00.4", Driver: vfKernelName},
+				// assigned: bound to some other driver
+				{Addr: "0000:01:00.5", Driver: "dpdk-driver"},
+			},
+		},
+	}
+
+	assigned, total, err := sriov.CountHardwareAssignedVFs(context.Background(), provider, pfPCIAddr)
+	require.NoError(t, err)
+	require.Equal(t, 2, assigned)
+	require.Equal(t, 5, total)
+
+	_, _, err = sriov.CountHardwareAssignedVFs(context.Background(), provider, "0000:02:00.0")
+	require.Error(t, err)
+}
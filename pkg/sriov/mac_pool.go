@@ -0,0 +1,169 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sriov
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"net"
+	"os"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/networkservicemesh/sdk-sriov/pkg/tools/yamlhelper"
+)
+
+const maxMACPoolSize = 1 << 24
+
+// ErrMACPoolExhausted is returned by MACPool.Allocate when there is no free MAC address in the pool
+var ErrMACPoolExhausted = errors.New("MAC pool is exhausted")
+
+// MACPool allocates MAC addresses from the OUI range. Allocated address depends only on the key salt, the key and on
+// the already allocated addresses, so the same key usually gets the same address. Use different OUIs or key salts to
+// avoid collisions between different MAC pools, e.g. on different nodes.
+type MACPool struct {
+	oui         net.HardwareAddr
+	size        uint32
+	stateFile   string
+	keySalt     string
+	allocations map[string]string // key -> MAC
+	keys        map[string]string // MAC -> key
+	lock        sync.Mutex
+}
+
+// NewMACPool returns a new MACPool allocating up to size addresses from the OUI range, oui is a 3 octets string,
+// e.g. "02:00:00"
+func NewMACPool(oui string, size uint32, options ...MACPoolOption) (*MACPool, error) {
+	hwAddr, err := net.ParseMAC(oui + ":00:00:00")
+	if err != nil || len(hwAddr) != 6 {
+		return nil, errors.Errorf("invalid OUI: %v", oui)
+	}
+	if size == 0 || size > maxMACPoolSize {
+		size = maxMACPoolSize
+	}
+
+	p := &MACPool{
+		oui:         hwAddr[:3],
+		size:        size,
+		allocations: map[string]string{},
+		keys:        map[string]string{},
+	}
+	for _, option := range options {
+		option(p)
+	}
+
+	if p.stateFile != "" {
+		if err := p.restore(); err != nil {
+			return nil, err
+		}
+	}
+
+	return p, nil
+}
+
+func (p *MACPool) restore() error {
+	if _, err := os.Stat(p.stateFile); os.IsNotExist(err) {
+		return nil
+	}
+
+	allocations := map[string]string{}
+	if err := yamlhelper.UnmarshalFile(p.stateFile, &allocations); err != nil {
+		return err
+	}
+
+	for key, mac := range allocations {
+		hwAddr, err := net.ParseMAC(mac)
+		if err != nil {
+			return errors.Wrapf(err, "invalid MAC address in the state file: %v", p.stateFile)
+		}
+		p.allocations[key] = hwAddr.String()
+		p.keys[hwAddr.String()] = key
+	}
+
+	return nil
+}
+
+// Allocate returns a MAC address allocated for the key, allocates a new one if there is no such address
+func (p *MACPool) Allocate(key string) (net.HardwareAddr, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if mac, ok := p.allocations[key]; ok {
+		return net.ParseMAC(mac)
+	}
+
+	h := fnv.New32a()
+	if p.keySalt != "" {
+		_, _ = h.Write([]byte(p.keySalt + "/"))
+	}
+	_, _ = h.Write([]byte(key))
+	offset := h.Sum32() % p.size
+
+	for i := uint32(0); i < p.size; i++ {
+		hwAddr := p.hardwareAddr((offset + i) % p.size)
+		if _, ok := p.keys[hwAddr.String()]; ok {
+			continue
+		}
+
+		p.allocations[key] = hwAddr.String()
+		p.keys[hwAddr.String()] = key
+		if err := p.store(); err != nil {
+			delete(p.allocations, key)
+			delete(p.keys, hwAddr.String())
+			return nil, err
+		}
+
+		return hwAddr, nil
+	}
+
+	return nil, errors.Wrapf(ErrMACPoolExhausted, "failed to allocate MAC address for: %v", key)
+}
+
+// Release releases a MAC address allocated for the key
+func (p *MACPool) Release(key string) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	mac, ok := p.allocations[key]
+	if !ok {
+		return nil
+	}
+
+	delete(p.allocations, key)
+	delete(p.keys, mac)
+	if err := p.store(); err != nil {
+		p.allocations[key] = mac
+		p.keys[mac] = key
+		return err
+	}
+
+	return nil
+}
+
+func (p *MACPool) hardwareAddr(index uint32) net.HardwareAddr {
+	suffix := make([]byte, 4)
+	binary.BigEndian.PutUint32(suffix, index)
+	return append(append(net.HardwareAddr{}, p.oui...), suffix[1:]...)
+}
+
+func (p *MACPool) store() error {
+	if p.stateFile == "" {
+		return nil
+	}
+	return yamlhelper.MarshalFile(p.stateFile, p.allocations)
+}
@@ -0,0 +1,116 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sriov_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov"
+)
+
+const oui = "02:00:00"
+
+func TestMACPool_Allocate(t *testing.T) {
+	p, err := sriov.NewMACPool(oui, 0)
+	require.NoError(t, err)
+
+	mac1, err := p.Allocate("1")
+	require.NoError(t, err)
+	require.Equal(t, oui, mac1.String()[:len(oui)])
+
+	mac2, err := p.Allocate("2")
+	require.NoError(t, err)
+	require.NotEqual(t, mac1, mac2)
+
+	mac, err := p.Allocate("1")
+	require.NoError(t, err)
+	require.Equal(t, mac1, mac)
+
+	require.NoError(t, p.Release("1"))
+
+	mac, err = p.Allocate("1")
+	require.NoError(t, err)
+	require.Equal(t, mac1, mac)
+}
+
+func TestMACPool_KeySalt(t *testing.T) {
+	p1, err := sriov.NewMACPool(oui, 0, sriov.WithMACKeySalt("node-1"))
+	require.NoError(t, err)
+
+	p2, err := sriov.NewMACPool(oui, 0, sriov.WithMACKeySalt("node-2"))
+	require.NoError(t, err)
+
+	mac1, err := p1.Allocate("0000:01:00.1")
+	require.NoError(t, err)
+
+	mac2, err := p2.Allocate("0000:01:00.1")
+	require.NoError(t, err)
+	require.NotEqual(t, mac1, mac2)
+}
+
+func TestMACPool_Allocate_Exhausted(t *testing.T) {
+	p, err := sriov.NewMACPool(oui, 2)
+	require.NoError(t, err)
+
+	mac1, err := p.Allocate("1")
+	require.NoError(t, err)
+
+	mac2, err := p.Allocate("2")
+	require.NoError(t, err)
+	require.NotEqual(t, mac1, mac2)
+
+	_, err = p.Allocate("3")
+	require.ErrorIs(t, err, sriov.ErrMACPoolExhausted)
+
+	require.NoError(t, p.Release("1"))
+
+	mac3, err := p.Allocate("3")
+	require.NoError(t, err)
+	require.Equal(t, mac1, mac3)
+}
+
+func TestMACPool_StateFile(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), "macs.yml")
+
+	p, err := sriov.NewMACPool(oui, 2, sriov.WithMACStateFile(stateFile))
+	require.NoError(t, err)
+
+	mac1, err := p.Allocate("1")
+	require.NoError(t, err)
+
+	mac2, err := p.Allocate("2")
+	require.NoError(t, err)
+
+	require.NoError(t, p.Release("2"))
+
+	p, err = sriov.NewMACPool(oui, 2, sriov.WithMACStateFile(stateFile))
+	require.NoError(t, err)
+
+	mac, err := p.Allocate("3")
+	require.NoError(t, err)
+	require.Equal(t, mac2, mac)
+
+	mac, err = p.Allocate("1")
+	require.NoError(t, err)
+	require.Equal(t, mac1, mac)
+
+	_, err = sriov.NewMACPool("invalid", 0)
+	require.Error(t, err)
+}
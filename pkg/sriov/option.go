@@ -0,0 +1,35 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sriov
+
+// MACPoolOption is an option pattern for NewMACPool
+type MACPoolOption func(p *MACPool)
+
+// WithMACKeySalt sets salt mixed into the key hash choosing the MAC address, e.g. the node name, so the same keys
+// (e.g. VF PCI addresses) on different nodes get different MAC addresses
+func WithMACKeySalt(salt string) MACPoolOption {
+	return func(p *MACPool) {
+		p.keySalt = salt
+	}
+}
+
+// WithMACStateFile sets file to persist MAC allocations
+func WithMACStateFile(stateFile string) MACPoolOption {
+	return func(p *MACPool) {
+		p.stateFile = stateFile
+	}
+}
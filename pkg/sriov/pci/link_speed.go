@@ -0,0 +1,108 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pci
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/config"
+)
+
+// ErrLinkSpeedMismatch is returned by CheckLinkSpeeds when the PF negotiated link speed is lower than the speed
+// declared in the PF capabilities
+var ErrLinkSpeedMismatch = errors.New("PF link speed is lower than the declared speed capability")
+
+// LinkSpeedReader reads net interface link speed in Mbps, vfnetlink.Configurator implements it
+type LinkSpeedReader interface {
+	GetLinkSpeed(ifName string) (int, bool)
+}
+
+var speedCapabilityRegexp = regexp.MustCompile(`^(\d+)([MG])$`)
+
+// CheckLinkSpeeds compares speed capabilities declared for the PFs in cfg, e.g. "25G", with the PF negotiated link
+// speeds, so tokens don't advertise capacity the hardware can't deliver. Mismatches are logged as warnings or
+// returned as ErrLinkSpeedMismatch if strict is set. PFs with no link speed available, e.g. in down state, are skipped.
+func (p *Pool) CheckLinkSpeeds(ctx context.Context, cfg *config.Config, reader LinkSpeedReader, strict bool) error {
+	logger := log.FromContext(ctx).WithField("pci.Pool", "CheckLinkSpeeds")
+
+	var pfPCIAddrs []string
+	for pfPCIAddr := range cfg.PhysicalFunctions {
+		pfPCIAddrs = append(pfPCIAddrs, pfPCIAddr)
+	}
+	sort.Strings(pfPCIAddrs)
+
+	for _, pfPCIAddr := range pfPCIAddrs {
+		declared, ok := declaredLinkSpeed(cfg.PhysicalFunctions[pfPCIAddr])
+		if !ok {
+			continue
+		}
+
+		f, ok := p.functions[pfPCIAddr]
+		if !ok {
+			return errors.Errorf("PCI function doesn't exist: %v", pfPCIAddr)
+		}
+		ifName, err := f.function.GetNetInterfaceName()
+		if err != nil {
+			return errors.Wrapf(err, "failed to get PF %v net interface name", pfPCIAddr)
+		}
+
+		speed, ok := reader.GetLinkSpeed(ifName)
+		if !ok {
+			logger.Warnf("PF %v link speed is not available", pfPCIAddr)
+			continue
+		}
+		if speed >= declared {
+			continue
+		}
+
+		if strict {
+			return errors.Wrapf(ErrLinkSpeedMismatch, "PF %v: declared %v Mbps, negotiated %v Mbps", pfPCIAddr, declared, speed)
+		}
+		logger.Warnf("PF %v link speed is lower than declared: declared %v Mbps, negotiated %v Mbps", pfPCIAddr, declared, speed)
+	}
+
+	return nil
+}
+
+// declaredLinkSpeed returns the highest speed in Mbps declared in the PF capabilities
+func declaredLinkSpeed(pfCfg *config.PhysicalFunction) (speed int, ok bool) {
+	for _, capability := range pfCfg.Capabilities {
+		matches := speedCapabilityRegexp.FindStringSubmatch(strings.ToUpper(capability))
+		if matches == nil {
+			continue
+		}
+		value, err := strconv.Atoi(matches[1])
+		if err != nil {
+			continue
+		}
+		if matches[2] == "G" {
+			value *= 1000
+		}
+		if value > speed {
+			speed, ok = value, true
+		}
+	}
+	return speed, ok
+}
@@ -0,0 +1,60 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pci_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/pci"
+)
+
+type linkSpeedReaderStub map[string]int
+
+func (s linkSpeedReaderStub) GetLinkSpeed(ifName string) (int, bool) {
+	speed, ok := s[ifName]
+	return speed, ok
+}
+
+func TestPool_CheckLinkSpeeds(t *testing.T) {
+	pfs, cfg := testFunctions()
+	cfg.PhysicalFunctions[pfPCIAddr].Capabilities = []string{"intel", "25G"}
+
+	p, err := pci.NewTestPool(pfs, cfg)
+	require.NoError(t, err)
+
+	// 1. PF declares 25G, but negotiates 10G
+
+	reader := linkSpeedReaderStub{"pf": 10000}
+
+	require.NoError(t, p.CheckLinkSpeeds(context.Background(), cfg, reader, false))
+	require.ErrorIs(t, p.CheckLinkSpeeds(context.Background(), cfg, reader, true), pci.ErrLinkSpeedMismatch)
+
+	// 2. PF negotiates 25G
+
+	reader["pf"] = 25000
+
+	require.NoError(t, p.CheckLinkSpeeds(context.Background(), cfg, reader, true))
+
+	// 3. PF link speed is not available
+
+	delete(reader, "pf")
+
+	require.NoError(t, p.CheckLinkSpeeds(context.Background(), cfg, reader, true))
+}
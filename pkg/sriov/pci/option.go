@@ -0,0 +1,82 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pci
+
+import "time"
+
+// Option is an option pattern for NewPCIPool
+type Option func(p *Pool)
+
+// WithSkipZeroCapacityPFs makes Pool skip PFs supporting no VFs (e.g. SR-IOV is disabled in BIOS) instead of failing,
+// skipped PFs are reported by Pool.SkippedPFs, the config passed to NewPCIPool is left unchanged
+func WithSkipZeroCapacityPFs() Option {
+	return func(p *Pool) {
+		p.skipZeroCapacityPFs = true
+	}
+}
+
+// WithLinkStateSetter sets net interface administrative state setter used for the PFs with BringPFUp config
+func WithLinkStateSetter(linkStateSetter LinkStateSetter) Option {
+	return func(p *Pool) {
+		p.linkStateSetter = linkStateSetter
+	}
+}
+
+// SimulationOption is an option pattern for NewSimulatedPool
+type SimulationOption func(s *simulation)
+
+// WithBindLatency sets time needed for the bound driver to get ready
+func WithBindLatency(bindLatency time.Duration) SimulationOption {
+	return func(s *simulation) {
+		s.bindLatency = bindLatency
+	}
+}
+
+// WithBindTimeout sets time limit for the bound driver to get ready
+func WithBindTimeout(bindTimeout time.Duration) SimulationOption {
+	return func(s *simulation) {
+		s.bindTimeout = bindTimeout
+	}
+}
+
+// WithBindError makes driver binding fail for the PCI function
+func WithBindError(pciAddr string, err error) SimulationOption {
+	return func(s *simulation) {
+		s.bindErrors[pciAddr] = err
+	}
+}
+
+// WithoutVFIOGroupNode makes VFIO group node never appear for the IOMMU group
+func WithoutVFIOGroupNode(iommuGroup uint) SimulationOption {
+	return func(s *simulation) {
+		s.missingVFIOGroupNodes[iommuGroup] = true
+	}
+}
+
+// WithDeviceError makes bound driver and net interface name reads fail for the PCI function
+func WithDeviceError(pciAddr string, err error) SimulationOption {
+	return func(s *simulation) {
+		s.deviceErrors[pciAddr] = err
+	}
+}
+
+// WithResetting makes the PCI function report that it is resetting for the duration
+func WithResetting(pciAddr string, duration time.Duration) SimulationOption {
+	return func(s *simulation) {
+		s.resetUntil[pciAddr] = time.Now().Add(duration)
+	}
+}
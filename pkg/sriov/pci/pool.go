@@ -23,28 +23,63 @@ package pci
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
 
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+
 	"github.com/networkservicemesh/sdk-sriov/pkg/sriov"
 	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/config"
 	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/pcifunction"
 	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/sriovtest"
 )
 
+// ErrDeviceResetting is returned by BindDriver when the PF is being reset, binding should be retried later
+var ErrDeviceResetting = errors.New("device is resetting")
+
+// ErrDeviceIDMismatch is returned by NewPCIPool when PF PCI vendor or device ID doesn't match the expected one
+var ErrDeviceIDMismatch = errors.New("PCI device ID mismatch")
+
+// BindTimeoutError is returned by BindDriver when the device hasn't got bound to the driver in time
+type BindTimeoutError struct {
+	PCIAddr string
+	Cause   error
+}
+
+func (e *BindTimeoutError) Error() string {
+	return fmt.Sprintf("time for binding kernel driver exceeded: %s, cause: %v", e.PCIAddr, e.Cause)
+}
+
+// Unwrap returns the last driver check error
+func (e *BindTimeoutError) Unwrap() error {
+	return e.Cause
+}
+
 const (
 	vfioDriver        = "vfio-pci"
 	driverBindTimeout = time.Second
-	driverBindCheck   = driverBindTimeout / 10
+	driverBindChecks  = 10
 )
 
 type pciFunction interface {
 	GetBoundDriver() (string, error)
-	BindDriver(driver string) error
+	BindDriverWithContext(ctx context.Context, driver string) error
+	UnbindDriver() error
+	GetDeviceInfo() (*sriov.DeviceInfo, error)
+	GetPCIeLinkStatus() (*sriov.PCIeLinkStatus, error)
+	GetDeviceIDs() (vendorID, deviceID string, err error)
+	GetNUMANode() (int, error)
+	GetOperState() (string, error)
+	IsResetting() (bool, error)
+	Reset() error
 
 	sriov.PCIFunction
 }
@@ -55,11 +90,38 @@ type Pool struct {
 	functionsByIOMMUGroup map[uint][]*function // iommuGroup -> []*function
 	vfioDir               string
 	skipDriverCheck       bool
+	bindTimeout           time.Duration
+	vfioGroupNodeCheck    func(iommuGroup uint) error
+	skipZeroCapacityPFs   bool
+	skippedPFs            []string
+	linkStateSetter       LinkStateSetter
+	upPFIfNames           []string
+	infoLock              sync.Mutex
+	readyCh               chan struct{}
+	readyOnce             sync.Once
+}
+
+// LinkStateSetter sets net interface administrative state, vfnetlink.Configurator implements it
+type LinkStateSetter interface {
+	IsLinkUp(ifName string) (bool, error)
+	SetLinkUp(ctx context.Context, ifName string) error
+	SetLinkDown(ctx context.Context, ifName string) error
 }
 
 type function struct {
 	function     pciFunction
 	kernelDriver string
+	info         *FunctionInfo // guarded by Pool.infoLock
+	pf           *function
+	vfs          []*function // VFs of the PF in the VF index order
+	vfIndex      int         // index of the VF on its PF
+	iommuGroup   uint
+}
+
+// VFRef contains VF PCI address and IOMMU group
+type VFRef struct {
+	PCIAddr    string
+	IOMMUGroup uint
 }
 
 // NewPool returns a new PCI Pool
@@ -67,29 +129,53 @@ func NewPool(pciDevicesPath, pciDriversPath, vfioDir string, cfg *config.Config)
 	return NewPCIPool(pciDevicesPath, pciDriversPath, vfioDir, cfg, false)
 }
 
-// NewPCIPool returns a new PCI Pool
-func NewPCIPool(pciDevicesPath, pciDriversPath, vfioDir string, cfg *config.Config, skipDriverCheck bool) (*Pool, error) {
+// NewPCIPool returns a new PCI Pool, PFs with BringPFUp config are set up with the WithLinkStateSetter setter. cfg is
+// not modified, PFs skipped with WithSkipZeroCapacityPFs are returned by SkippedPFs.
+func NewPCIPool(pciDevicesPath, pciDriversPath, vfioDir string, cfg *config.Config, skipDriverCheck bool, options ...Option) (*Pool, error) {
 	p := &Pool{
 		functions:             map[string]*function{},
 		functionsByIOMMUGroup: map[uint][]*function{},
 		vfioDir:               vfioDir,
 		skipDriverCheck:       skipDriverCheck,
+		bindTimeout:           driverBindTimeout,
+		readyCh:               make(chan struct{}),
+	}
+	p.vfioGroupNodeCheck = p.statVFIOGroupNode
+
+	for _, option := range options {
+		option(p)
 	}
 
 	for pfPCIAddr, pfCfg := range cfg.PhysicalFunctions {
 		pf, err := pcifunction.NewPhysicalFunction(pfPCIAddr, pciDevicesPath, pciDriversPath)
+		if p.skipZeroCapacityPFs && errors.Is(err, pcifunction.ErrZeroCapacity) {
+			p.skippedPFs = append(p.skippedPFs, pfPCIAddr)
+			continue
+		}
 		if err != nil {
 			return nil, err
 		}
 
-		if err := p.addFunction(&pf.Function, pfCfg.PFKernelDriver); err != nil {
+		if err = checkDeviceIDs(&pf.Function, pfCfg); err != nil {
 			return nil, err
 		}
 
-		for _, vf := range pf.GetVirtualFunctions() {
-			if err := p.addFunction(vf, pfCfg.VFKernelDriver); err != nil {
+		if err = p.bringPFUp(&pf.Function, pfCfg); err != nil {
+			return nil, err
+		}
+
+		pfFunc, err := p.addFunction(&pf.Function, pfCfg.PFKernelDriver, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		vfIndexes := pf.GetVirtualFunctionIndexes()
+		for i, vf := range pf.GetVirtualFunctions() {
+			var vfFunc *function
+			if vfFunc, err = p.addFunction(vf, pfCfg.VFKernelDriver, pfFunc); err != nil {
 				return nil, err
 			}
+			vfFunc.vfIndex = vfIndexes[i]
 		}
 	}
 
@@ -97,11 +183,17 @@ func NewPCIPool(pciDevicesPath, pciDriversPath, vfioDir string, cfg *config.Conf
 }
 
 // NewTestPool returns a new PCI Pool for testing
-func NewTestPool(physicalFunctions map[string]*sriovtest.PCIPhysicalFunction, cfg *config.Config) (*Pool, error) {
+func NewTestPool(physicalFunctions map[string]*sriovtest.PCIPhysicalFunction, cfg *config.Config, options ...Option) (*Pool, error) {
 	p := &Pool{
 		functions:             map[string]*function{},
 		functionsByIOMMUGroup: map[uint][]*function{},
 		skipDriverCheck:       true,
+		bindTimeout:           driverBindTimeout,
+		readyCh:               make(chan struct{}),
+	}
+
+	for _, option := range options {
+		option(p)
 	}
 
 	for pfPCIAddr, pfCfg := range cfg.PhysicalFunctions {
@@ -110,31 +202,122 @@ func NewTestPool(physicalFunctions map[string]*sriovtest.PCIPhysicalFunction, cf
 			return nil, errors.Errorf("PF doesn't exist: %v", pfPCIAddr)
 		}
 
-		_ = p.addFunction(&pf.PCIFunction, pfCfg.PFKernelDriver)
+		if err := checkDeviceIDs(&pf.PCIFunction, pfCfg); err != nil {
+			return nil, err
+		}
+
+		if err := p.bringPFUp(&pf.PCIFunction, pfCfg); err != nil {
+			return nil, err
+		}
+
+		pfFunc, _ := p.addFunction(&pf.PCIFunction, pfCfg.PFKernelDriver, nil)
 
-		for _, vf := range pf.Vfs {
-			_ = p.addFunction(vf, pfCfg.VFKernelDriver)
+		for i, vf := range pf.Vfs {
+			vfFunc, _ := p.addFunction(vf, pfCfg.VFKernelDriver, pfFunc)
+			vfFunc.vfIndex = i
 		}
 	}
 
 	return p, nil
 }
 
-func (p *Pool) addFunction(pcif pciFunction, kernelDriver string) (err error) {
-	f := &function{
+func checkDeviceIDs(pcif pciFunction, pfCfg *config.PhysicalFunction) error {
+	if pfCfg.ExpectedVendorID == "" && pfCfg.ExpectedDeviceID == "" {
+		return nil
+	}
+
+	vendorID, deviceID, err := pcif.GetDeviceIDs()
+	if err != nil {
+		return err
+	}
+
+	if !isDeviceIDMatching(pfCfg.ExpectedVendorID, vendorID) || !isDeviceIDMatching(pfCfg.ExpectedDeviceID, deviceID) {
+		return errors.Wrapf(ErrDeviceIDMismatch, "PF %v: expected vendor:device %v:%v, actual %v:%v",
+			pcif.GetPCIAddress(), pfCfg.ExpectedVendorID, pfCfg.ExpectedDeviceID, vendorID, deviceID)
+	}
+
+	return nil
+}
+
+func (p *Pool) bringPFUp(pcif pciFunction, pfCfg *config.PhysicalFunction) error {
+	if !pfCfg.BringPFUp {
+		return nil
+	}
+	if p.linkStateSetter == nil {
+		return errors.Errorf("PF %v: no link state setter to bring the PF up", pcif.GetPCIAddress())
+	}
+
+	ifName, err := pcif.GetNetInterfaceName()
+	if err != nil || ifName == "" {
+		return errors.Errorf("PF %v has no net interface to bring up: %v", pcif.GetPCIAddress(), err)
+	}
+
+	// PF already up is not brought up by the Pool, so it shouldn't be brought down on teardown
+	switch up, err := p.linkStateSetter.IsLinkUp(ifName); {
+	case err != nil:
+		return errors.Wrapf(err, "failed to get PF %v link state", pcif.GetPCIAddress())
+	case up:
+		return nil
+	}
+
+	if err = p.linkStateSetter.SetLinkUp(context.Background(), ifName); err != nil {
+		return errors.Wrapf(err, "failed to bring PF %v up", pcif.GetPCIAddress())
+	}
+	p.upPFIfNames = append(p.upPFIfNames, ifName)
+
+	return nil
+}
+
+// BringPFsDown sets down the PF net interfaces brought up on the Pool init, the ones already up before the Pool init
+// are kept up. It should be called on teardown if needed.
+func (p *Pool) BringPFsDown(ctx context.Context) error {
+	var errs []string
+	for _, ifName := range p.upPFIfNames {
+		if err := p.linkStateSetter.SetLinkDown(ctx, ifName); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	p.upPFIfNames = nil
+
+	if len(errs) > 0 {
+		return errors.Errorf("failed to bring PFs down: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func isDeviceIDMatching(expected, actual string) bool {
+	normalize := func(id string) string {
+		return strings.TrimPrefix(strings.ToLower(strings.TrimSpace(id)), "0x")
+	}
+	return expected == "" || normalize(expected) == normalize(actual)
+}
+
+func (p *Pool) addFunction(pcif pciFunction, kernelDriver string, pf *function) (f *function, err error) {
+	f = &function{
 		function:     pcif,
 		kernelDriver: kernelDriver,
+		pf:           pf,
 	}
 
 	p.functions[pcif.GetPCIAddress()] = f
+	if pf != nil {
+		pf.vfs = append(pf.vfs, f)
+	}
 
-	iommuGroup, err := pcif.GetIOMMUGroup()
-	if err != nil {
-		return err
+	if f.iommuGroup, err = pcif.GetIOMMUGroup(); err != nil {
+		return f, err
 	}
-	p.functionsByIOMMUGroup[iommuGroup] = append(p.functionsByIOMMUGroup[iommuGroup], f)
+	p.functionsByIOMMUGroup[f.iommuGroup] = append(p.functionsByIOMMUGroup[f.iommuGroup], f)
 
-	return nil
+	return f, nil
+}
+
+// SkippedPFs returns sorted PCI addresses of the config PFs skipped on the Pool creation, they should be removed from
+// a cfg.Clone() used for the token and resource pools, so these pools don't advertise them
+func (p *Pool) SkippedPFs() []string {
+	skippedPFs := append([]string(nil), p.skippedPFs...)
+	sort.Strings(skippedPFs)
+	return skippedPFs
 }
 
 // GetPCIFunction returns PCI function for the given PCI address
@@ -143,28 +326,121 @@ func (p *Pool) GetPCIFunction(pciAddr string) (sriov.PCIFunction, error) {
 	if !ok {
 		return nil, errors.Errorf("PCI function doesn't exist: %v", pciAddr)
 	}
-	return f.function, nil
+	return &cachedFunction{pool: p, f: f}, nil
+}
+
+// GetBoundDriver returns the driver bound to the given PCI address, empty if there is no bound driver
+func (p *Pool) GetBoundDriver(pciAddr string) (string, error) {
+	f, ok := p.functions[pciAddr]
+	if !ok {
+		return "", errors.Errorf("PCI function doesn't exist: %v", pciAddr)
+	}
+	if info := p.cachedInfo(f); info != nil {
+		return info.BoundDriver, nil
+	}
+	return f.function.GetBoundDriver()
 }
 
-// BindDriver binds selected IOMMU group to the given driver type
+// GetDeviceInfo returns driver and firmware info for the given PCI address
+func (p *Pool) GetDeviceInfo(pciAddr string) (*sriov.DeviceInfo, error) {
+	f, ok := p.functions[pciAddr]
+	if !ok {
+		return nil, errors.Errorf("PCI function doesn't exist: %v", pciAddr)
+	}
+	return f.function.GetDeviceInfo()
+}
+
+// GetPCIeLinkStatus returns PCIe link current and max speeds and widths for the given PCI address, use
+// sriov.PCIeLinkStatus.IsDegraded to check if the link is trained lower than the device supports
+func (p *Pool) GetPCIeLinkStatus(pciAddr string) (*sriov.PCIeLinkStatus, error) {
+	f, ok := p.functions[pciAddr]
+	if !ok {
+		return nil, errors.Errorf("PCI function doesn't exist: %v", pciAddr)
+	}
+	return f.function.GetPCIeLinkStatus()
+}
+
+// GetOperState returns net interface operational state for the given PCI address
+func (p *Pool) GetOperState(pciAddr string) (string, error) {
+	f, ok := p.functions[pciAddr]
+	if !ok {
+		return "", errors.Errorf("PCI function doesn't exist: %v", pciAddr)
+	}
+	return f.function.GetOperState()
+}
+
+// GetNumaNode returns NUMA node for the given PCI address in the long or short BDF form, -1 means no NUMA affinity
+func (p *Pool) GetNumaNode(_ context.Context, pciAddr string) (int, error) {
+	pciAddr, err := pcifunction.ToBDFAddress(pciAddr)
+	if err != nil {
+		return 0, err
+	}
+
+	f, ok := p.functions[pciAddr]
+	if !ok {
+		return 0, errors.Errorf("PCI function doesn't exist: %v", pciAddr)
+	}
+
+	return f.function.GetNUMANode()
+}
+
+// ResetFunction performs a function-level reset of the given PCI function, e.g. to recover a VF stuck after a failed
+// BindDriver, returns pcifunction.ErrResetNotSupported if the function can't be reset
+func (p *Pool) ResetFunction(pciAddr string) error {
+	f, ok := p.functions[pciAddr]
+	if !ok {
+		return errors.Errorf("PCI function doesn't exist: %v", pciAddr)
+	}
+
+	// bound driver and net interface can change after the reset, so the cached info becomes stale
+	p.dropInfo(f)
+
+	return f.function.Reset()
+}
+
+// BindDriver binds selected IOMMU group to the given driver type, returns ErrDeviceResetting if any of the group
+// functions PF is being reset. On any failure the group functions already switched to the new driver are bound back
+// to their previous drivers, so the group is never left half-migrated. The functions bind the driver waiting for it
+// to get bound until ctx is done, then the Pool waits for the driver to get ready.
 func (p *Pool) BindDriver(ctx context.Context, iommuGroup uint, driverType sriov.DriverType) error {
-	for _, f := range p.functionsByIOMMUGroup[iommuGroup] {
+	functions := p.functionsByIOMMUGroup[iommuGroup]
+	for _, f := range functions {
+		if err := checkPFResetting(f); err != nil {
+			return err
+		}
+	}
+
+	drivers := make([]string, len(functions))
+	prevDrivers := make([]string, len(functions))
+	for i, f := range functions {
 		switch driverType {
 		case sriov.KernelDriver:
-			if err := f.function.BindDriver(f.kernelDriver); err != nil {
-				return err
-			}
+			drivers[i] = f.kernelDriver
 		case sriov.VFIOPCIDriver:
-			if err := f.function.BindDriver(vfioDriver); err != nil {
-				return err
-			}
+			drivers[i] = vfioDriver
 		default:
 			return errors.Errorf("driver type is not supported: %v", driverType)
 		}
+
+		var err error
+		if prevDrivers[i], err = f.function.GetBoundDriver(); err != nil {
+			return err
+		}
 	}
 
-	for _, f := range p.functionsByIOMMUGroup[iommuGroup] {
+	for i, f := range functions {
+		// bound driver and net interface change with the driver, so the cached info becomes stale
+		p.dropInfo(f)
+
+		if err := f.function.BindDriverWithContext(ctx, drivers[i]); err != nil {
+			p.restoreDrivers(ctx, functions[:i+1], prevDrivers)
+			return err
+		}
+	}
+
+	for _, f := range functions {
 		if err := p.waitDriverGettingBound(ctx, f.function, driverType); err != nil {
+			p.restoreDrivers(ctx, functions, prevDrivers)
 			return err
 		}
 	}
@@ -172,8 +448,49 @@ func (p *Pool) BindDriver(ctx context.Context, iommuGroup uint, driverType sriov
 	return nil
 }
 
+// restoreDrivers binds the functions back to their previous drivers, functions with no previous driver are unbound,
+// failures are only logged as the caller returns the original error. The caller ctx can be already done (it is often
+// the reason of the failure), so the drivers are restored with its values but a separate bind timeout.
+func (p *Pool) restoreDrivers(ctx context.Context, functions []*function, prevDrivers []string) {
+	logger := log.FromContext(ctx).WithField("pci.Pool", "restoreDrivers")
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), p.bindTimeout)
+	defer cancel()
+
+	for i, f := range functions {
+		p.dropInfo(f)
+
+		if prevDrivers[i] == "" {
+			if err := f.function.UnbindDriver(); err != nil {
+				logger.Errorf("failed to unbind the device %v: %v", f.function.GetPCIAddress(), err)
+			}
+			continue
+		}
+
+		if err := f.function.BindDriverWithContext(ctx, prevDrivers[i]); err != nil {
+			logger.Errorf("failed to bind the device %v back to the driver %v: %v", f.function.GetPCIAddress(), prevDrivers[i], err)
+		}
+	}
+}
+
+func checkPFResetting(f *function) error {
+	if f.pf == nil {
+		return nil
+	}
+
+	resetting, err := f.pf.function.IsResetting()
+	if err != nil {
+		return err
+	}
+	if resetting {
+		return errors.Wrapf(ErrDeviceResetting, "PF %v of the device %v", f.pf.function.GetPCIAddress(), f.function.GetPCIAddress())
+	}
+
+	return nil
+}
+
 func (p *Pool) waitDriverGettingBound(ctx context.Context, pcif pciFunction, driverType sriov.DriverType) error {
-	timeoutCh := time.After(driverBindTimeout)
+	timeoutCh := time.After(p.bindTimeout)
 	for {
 		var driverCheck func(pciFunction) error
 		switch driverType {
@@ -194,8 +511,8 @@ func (p *Pool) waitDriverGettingBound(ctx context.Context, pcif pciFunction, dri
 		case <-ctx.Done():
 			return errors.Wrap(ctx.Err(), "provided context is done")
 		case <-timeoutCh:
-			return errors.Errorf("time for binding kernel driver exceeded: %s, cause: %v", pcif.GetPCIAddress(), err)
-		case <-time.After(driverBindCheck):
+			return errors.WithStack(&BindTimeoutError{PCIAddr: pcif.GetPCIAddress(), Cause: err})
+		case <-time.After(p.bindTimeout / driverBindChecks):
 		}
 	}
 }
@@ -219,6 +536,43 @@ func (p *Pool) vfioDriverCheck(pcif pciFunction) error {
 		return err
 	}
 
-	_, err = os.Stat(filepath.Join(p.vfioDir, strconv.FormatUint(uint64(iommuGroup), 10)))
+	return p.vfioGroupNodeCheck(iommuGroup)
+}
+
+func (p *Pool) statVFIOGroupNode(iommuGroup uint) error {
+	_, err := os.Stat(filepath.Join(p.vfioDir, strconv.FormatUint(uint64(iommuGroup), 10)))
 	return errors.Wrapf(err, "failed to join path elements: %s, %s", p.vfioDir, strconv.FormatUint(uint64(iommuGroup), 10))
 }
+
+// VFIndexMap returns PF VFs by their indexes, it uses the data read on the Pool creation
+func (p *Pool) VFIndexMap(pfPCIAddr string) (map[int]VFRef, error) {
+	pf, ok := p.functions[pfPCIAddr]
+	if !ok || pf.pf != nil {
+		return nil, errors.Errorf("PF doesn't exist: %v", pfPCIAddr)
+	}
+
+	vfIndexMap := make(map[int]VFRef, len(pf.vfs))
+	for _, vf := range pf.vfs {
+		vfIndexMap[vf.vfIndex] = VFRef{
+			PCIAddr:    vf.function.GetPCIAddress(),
+			IOMMUGroup: vf.iommuGroup,
+		}
+	}
+	return vfIndexMap, nil
+}
+
+// GetVirtualFunctions returns all virtual functions of the physical function in the VF index order and the kernel
+// driver they are bound to when free
+func (p *Pool) GetVirtualFunctions(pfPCIAddr string) (vfs []sriov.HardwareFunction, kernelDriver string, err error) {
+	pf, ok := p.functions[pfPCIAddr]
+	if !ok {
+		return nil, "", errors.Errorf("PCI function doesn't exist: %v", pfPCIAddr)
+	}
+
+	for _, vf := range pf.vfs {
+		vfs = append(vfs, vf.function)
+		kernelDriver = vf.kernelDriver
+	}
+
+	return vfs, kernelDriver, nil
+}
@@ -0,0 +1,374 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pci_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov"
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/config"
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/pci"
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/sriovtest"
+)
+
+const (
+	pfPCIAddr = "0000:01:00.0"
+	vfPCIAddr = "0000:01:00.1"
+)
+
+func testFunctions() (map[string]*sriovtest.PCIPhysicalFunction, *config.Config) {
+	pfs := map[string]*sriovtest.PCIPhysicalFunction{
+		pfPCIAddr: {
+			PCIFunction: sriovtest.PCIFunction{
+				Addr:            pfPCIAddr,
+				IfName:          "pf",
+				IOMMUGroup:      1,
+				Driver:          "pf-driver",
+				DriverVersion:   "1.2.3",
+				FirmwareVersion: "4.5.6",
+				VendorID:        "8086",
+				DeviceID:        "1572",
+			},
+			Vfs: []*sriovtest.PCIFunction{
+				{
+					Addr:       vfPCIAddr,
+					IfName:     "vf",
+					IOMMUGroup: 2,
+					Driver:     "vf-driver",
+				},
+			},
+		},
+	}
+
+	cfg := &config.Config{
+		PhysicalFunctions: map[string]*config.PhysicalFunction{
+			pfPCIAddr: {
+				PFKernelDriver: "pf-driver",
+				VFKernelDriver: "vf-driver",
+				VirtualFunctions: []*config.VirtualFunction{
+					{
+						Address:    vfPCIAddr,
+						IOMMUGroup: 2,
+					},
+				},
+			},
+		},
+	}
+
+	return pfs, cfg
+}
+
+func testPool(t *testing.T) (*pci.Pool, map[string]*sriovtest.PCIPhysicalFunction) {
+	pfs, cfg := testFunctions()
+
+	p, err := pci.NewTestPool(pfs, cfg)
+	require.NoError(t, err)
+
+	return p, pfs
+}
+
+func TestPool_GetDeviceInfo(t *testing.T) {
+	p, _ := testPool(t)
+
+	info, err := p.GetDeviceInfo(pfPCIAddr)
+	require.NoError(t, err)
+	require.Equal(t, &sriov.DeviceInfo{
+		Driver:          "pf-driver",
+		DriverVersion:   "1.2.3",
+		FirmwareVersion: "4.5.6",
+	}, info)
+
+	_, err = p.GetDeviceInfo(vfPCIAddr)
+	require.ErrorIs(t, err, sriov.ErrNotSupported)
+}
+
+func TestPool_GetPCIeLinkStatus(t *testing.T) {
+	p, pfs := testPool(t)
+
+	pfs[pfPCIAddr].PCIeLinkStatus = &sriov.PCIeLinkStatus{
+		CurrentSpeed: 2.5,
+		CurrentWidth: 1,
+		MaxSpeed:     8,
+		MaxWidth:     16,
+	}
+
+	status, err := p.GetPCIeLinkStatus(pfPCIAddr)
+	require.NoError(t, err)
+	require.True(t, status.IsDegraded())
+
+	_, err = p.GetPCIeLinkStatus(vfPCIAddr)
+	require.ErrorIs(t, err, sriov.ErrNotSupported)
+}
+
+func TestNewTestPool_ExpectedDeviceIDs(t *testing.T) {
+	pfs, cfg := testFunctions()
+
+	cfg.PhysicalFunctions[pfPCIAddr].ExpectedVendorID = "8086"
+	cfg.PhysicalFunctions[pfPCIAddr].ExpectedDeviceID = "0x1572"
+
+	_, err := pci.NewTestPool(pfs, cfg)
+	require.NoError(t, err)
+
+	cfg.PhysicalFunctions[pfPCIAddr].ExpectedDeviceID = "0x1593"
+
+	_, err = pci.NewTestPool(pfs, cfg)
+	require.ErrorIs(t, err, pci.ErrDeviceIDMismatch)
+	require.Contains(t, err.Error(), "expected vendor:device 8086:0x1593, actual 8086:1572")
+}
+
+type linkStateSetterStub struct {
+	up map[string]bool
+}
+
+func (s *linkStateSetterStub) IsLinkUp(ifName string) (bool, error) {
+	return s.up[ifName], nil
+}
+
+func (s *linkStateSetterStub) SetLinkUp(_ context.Context, ifName string) error {
+	s.up[ifName] = true
+	return nil
+}
+
+func (s *linkStateSetterStub) SetLinkDown(_ context.Context, ifName string) error {
+	s.up[ifName] = false
+	return nil
+}
+
+func TestNewTestPool_BringPFUp(t *testing.T) {
+	pfs, cfg := testFunctions()
+	linkStateSetter := &linkStateSetterStub{up: map[string]bool{}}
+
+	_, err := pci.NewTestPool(pfs, cfg, pci.WithLinkStateSetter(linkStateSetter))
+	require.NoError(t, err)
+	require.Empty(t, linkStateSetter.up)
+
+	cfg.PhysicalFunctions[pfPCIAddr].BringPFUp = true
+
+	// No link state setter
+	_, err = pci.NewTestPool(pfs, cfg)
+	require.Error(t, err)
+
+	p, err := pci.NewTestPool(pfs, cfg, pci.WithLinkStateSetter(linkStateSetter))
+	require.NoError(t, err)
+	require.Equal(t, map[string]bool{"pf": true}, linkStateSetter.up)
+
+	require.NoError(t, p.BringPFsDown(context.Background()))
+	require.Equal(t, map[string]bool{"pf": false}, linkStateSetter.up)
+
+	// PF already up is kept up
+	linkStateSetter.up["pf"] = true
+
+	p, err = pci.NewTestPool(pfs, cfg, pci.WithLinkStateSetter(linkStateSetter))
+	require.NoError(t, err)
+
+	require.NoError(t, p.BringPFsDown(context.Background()))
+	require.Equal(t, map[string]bool{"pf": true}, linkStateSetter.up)
+
+	// No PF net interface
+	pfs[pfPCIAddr].IfName = ""
+
+	_, err = pci.NewTestPool(pfs, cfg, pci.WithLinkStateSetter(linkStateSetter))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "no net interface")
+}
+
+func TestPool_GetNumaNode(t *testing.T) {
+	p, pfs := testPool(t)
+
+	pfs[pfPCIAddr].NUMANode = 1
+
+	numaNode, err := p.GetNumaNode(context.Background(), pfPCIAddr)
+	require.NoError(t, err)
+	require.Equal(t, 1, numaNode)
+
+	numaNode, err = p.GetNumaNode(context.Background(), "01:00.0")
+	require.NoError(t, err)
+	require.Equal(t, 1, numaNode)
+
+	_, err = p.GetNumaNode(context.Background(), "invalid")
+	require.Error(t, err)
+
+	_, err = p.GetNumaNode(context.Background(), "0000:02:00.0")
+	require.Error(t, err)
+}
+
+func TestPool_BindDriver_PFResetting(t *testing.T) {
+	p, pfs := testPool(t)
+
+	pfs[pfPCIAddr].Resetting = true
+
+	require.ErrorIs(t, p.BindDriver(context.Background(), 2, sriov.VFIOPCIDriver), pci.ErrDeviceResetting)
+	require.Equal(t, "vf-driver", pfs[pfPCIAddr].Vfs[0].Driver)
+
+	pfs[pfPCIAddr].Resetting = false
+
+	require.NoError(t, p.BindDriver(context.Background(), 2, sriov.VFIOPCIDriver))
+	require.Equal(t, "vfio-pci", pfs[pfPCIAddr].Vfs[0].Driver)
+}
+
+func TestPool_BindDriver_Rollback(t *testing.T) {
+	vfPCIAddrs := []string{"0000:01:00.1", "0000:01:00.2", "0000:01:00.3"}
+
+	pfs := map[string]*sriovtest.PCIPhysicalFunction{
+		pfPCIAddr: {
+			PCIFunction: sriovtest.PCIFunction{
+				Addr:       pfPCIAddr,
+				IfName:     "pf",
+				IOMMUGroup: 1,
+				Driver:     "pf-driver",
+			},
+		},
+	}
+	cfg := &config.Config{
+		PhysicalFunctions: map[string]*config.PhysicalFunction{
+			pfPCIAddr: {
+				PFKernelDriver: "pf-driver",
+				VFKernelDriver: "vf-driver",
+			},
+		},
+	}
+	for i, addr := range vfPCIAddrs {
+		pfs[pfPCIAddr].Vfs = append(pfs[pfPCIAddr].Vfs, &sriovtest.PCIFunction{
+			Addr:       addr,
+			IfName:     "vf-" + strconv.Itoa(i),
+			IOMMUGroup: 2,
+			Driver:     "vf-driver",
+		})
+		cfg.PhysicalFunctions[pfPCIAddr].VirtualFunctions = append(cfg.PhysicalFunctions[pfPCIAddr].VirtualFunctions,
+			&config.VirtualFunction{
+				Address:    addr,
+				IOMMUGroup: 2,
+			})
+	}
+
+	p, err := pci.NewTestPool(pfs, cfg)
+	require.NoError(t, err)
+
+	vfs := pfs[pfPCIAddr].Vfs
+	bindErr := errors.New("bind error")
+	vfs[2].BindDriverErr = bindErr
+
+	require.ErrorIs(t, p.BindDriver(context.Background(), 2, sriov.VFIOPCIDriver), bindErr)
+	for _, vf := range vfs {
+		require.Equal(t, "vf-driver", vf.Driver, vf.Addr)
+	}
+
+	// VF with no driver is unbound back
+	vfs[0].Driver = ""
+
+	require.ErrorIs(t, p.BindDriver(context.Background(), 2, sriov.VFIOPCIDriver), bindErr)
+	require.Empty(t, vfs[0].Driver)
+	vfs[0].Driver = "vf-driver"
+
+	vfs[2].BindDriverErr = nil
+
+	require.NoError(t, p.BindDriver(context.Background(), 2, sriov.VFIOPCIDriver))
+	for _, vf := range vfs {
+		require.Equal(t, "vfio-pci", vf.Driver, vf.Addr)
+	}
+}
+
+func TestPool_BindDriver_RollbackContextDone(t *testing.T) {
+	pfs, cfg := testFunctions()
+
+	p, err := pci.NewSimulatedPool(pfs, cfg, pci.WithoutVFIOGroupNode(2))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	require.ErrorIs(t, p.BindDriver(ctx, 2, sriov.VFIOPCIDriver), context.DeadlineExceeded)
+	require.Equal(t, "vf-driver", pfs[pfPCIAddr].Vfs[0].Driver)
+}
+
+func TestPool_ResetFunction(t *testing.T) {
+	p, pfs := testPool(t)
+
+	vf := pfs[pfPCIAddr].Vfs[0]
+
+	require.NoError(t, p.ResetFunction(vf.Addr))
+	require.Equal(t, 1, vf.ResetCount)
+
+	require.Error(t, p.ResetFunction("0000:00:00.0"))
+}
+
+func TestPool_GetVirtualFunctions(t *testing.T) {
+	p, pfs := testPool(t)
+
+	assigned, total, err := sriov.CountHardwareAssignedVFs(context.Background(), p, pfPCIAddr)
+	require.NoError(t, err)
+	require.Equal(t, 0, assigned)
+	require.Equal(t, 1, total)
+
+	pfs[pfPCIAddr].Vfs[0].Driver = "other-driver"
+
+	assigned, _, err = sriov.CountHardwareAssignedVFs(context.Background(), p, pfPCIAddr)
+	require.NoError(t, err)
+	require.Equal(t, 1, assigned)
+
+	_, _, err = p.GetVirtualFunctions(vfPCIAddr + "0")
+	require.Error(t, err)
+}
+
+func TestPool_VFIndexMap(t *testing.T) {
+	pfCfg := &config.PhysicalFunction{
+		PFKernelDriver: "pf-driver",
+		VFKernelDriver: "vf-driver",
+		VirtualFunctions: []*config.VirtualFunction{
+			{Address: "0000:01:00.1", IOMMUGroup: 2},
+			{Address: "0000:01:00.2", IOMMUGroup: 2},
+			{Address: "0000:01:00.3", IOMMUGroup: 3},
+		},
+	}
+
+	pf := &sriovtest.PCIPhysicalFunction{
+		PCIFunction: sriovtest.PCIFunction{
+			Addr:       pfPCIAddr,
+			IOMMUGroup: 1,
+		},
+	}
+	for _, vfCfg := range pfCfg.VirtualFunctions {
+		pf.Vfs = append(pf.Vfs, &sriovtest.PCIFunction{
+			Addr:       vfCfg.Address,
+			IOMMUGroup: vfCfg.IOMMUGroup,
+		})
+	}
+
+	p, err := pci.NewTestPool(map[string]*sriovtest.PCIPhysicalFunction{pfPCIAddr: pf}, &config.Config{
+		PhysicalFunctions: map[string]*config.PhysicalFunction{pfPCIAddr: pfCfg},
+	})
+	require.NoError(t, err)
+
+	vfIndexMap, err := p.VFIndexMap(pfPCIAddr)
+	require.NoError(t, err)
+	require.Len(t, vfIndexMap, len(pfCfg.VirtualFunctions))
+	for i, vfCfg := range pfCfg.VirtualFunctions {
+		require.Equal(t, pci.VFRef{
+			PCIAddr:    vfCfg.Address,
+			IOMMUGroup: vfCfg.IOMMUGroup,
+		}, vfIndexMap[i])
+	}
+
+	_, err = p.VFIndexMap("0000:01:00.1")
+	require.Error(t, err)
+}
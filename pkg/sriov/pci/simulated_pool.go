@@ -0,0 +1,179 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pci
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/config"
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/sriovtest"
+)
+
+type simulation struct {
+	bindLatency           time.Duration
+	bindTimeout           time.Duration
+	bindErrors            map[string]error     // pciAddr -> error
+	deviceErrors          map[string]error     // pciAddr -> error
+	resetUntil            map[string]time.Time // pciAddr -> time
+	missingVFIOGroupNodes map[uint]bool
+	lock                  sync.Mutex
+}
+
+type simulatedFunction struct {
+	*sriovtest.PCIFunction
+
+	sim     *simulation
+	readyAt time.Time
+}
+
+// NewSimulatedPool returns a new PCI Pool for testing simulating driver binding for the given PCI functions: bound
+// driver gets ready after the bind latency, bind can fail and VFIO group nodes can be missing
+func NewSimulatedPool(physicalFunctions map[string]*sriovtest.PCIPhysicalFunction, cfg *config.Config, options ...SimulationOption) (*Pool, error) {
+	sim := &simulation{
+		bindTimeout:           driverBindTimeout,
+		bindErrors:            map[string]error{},
+		deviceErrors:          map[string]error{},
+		resetUntil:            map[string]time.Time{},
+		missingVFIOGroupNodes: map[uint]bool{},
+	}
+	for _, option := range options {
+		option(sim)
+	}
+
+	p := &Pool{
+		functions:             map[string]*function{},
+		functionsByIOMMUGroup: map[uint][]*function{},
+		bindTimeout:           sim.bindTimeout,
+		readyCh:               make(chan struct{}),
+	}
+	p.vfioGroupNodeCheck = func(iommuGroup uint) error {
+		return sim.checkVFIOGroupNode(iommuGroup, p.functionsByIOMMUGroup[iommuGroup])
+	}
+
+	for pfPCIAddr, pfCfg := range cfg.PhysicalFunctions {
+		pf, ok := physicalFunctions[pfPCIAddr]
+		if !ok {
+			return nil, errors.Errorf("PF doesn't exist: %v", pfPCIAddr)
+		}
+
+		pfFunc, _ := p.addFunction(&simulatedFunction{PCIFunction: &pf.PCIFunction, sim: sim}, pfCfg.PFKernelDriver, nil)
+
+		for _, vf := range pf.Vfs {
+			_, _ = p.addFunction(&simulatedFunction{PCIFunction: vf, sim: sim}, pfCfg.VFKernelDriver, pfFunc)
+		}
+	}
+
+	return p, nil
+}
+
+func (s *simulation) checkVFIOGroupNode(iommuGroup uint, functions []*function) error {
+	if s.missingVFIOGroupNodes[iommuGroup] {
+		return errors.Errorf("VFIO group node doesn't exist: %v", iommuGroup)
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for _, f := range functions {
+		if sf, ok := f.function.(*simulatedFunction); ok && (sf.Driver != vfioDriver || time.Now().Before(sf.readyAt)) {
+			return errors.Errorf("VFIO group node is not ready yet: %v", iommuGroup)
+		}
+	}
+
+	return nil
+}
+
+// GetNetInterfaceName returns f.IfName if f is bound to the kernel driver and the driver is ready
+func (f *simulatedFunction) GetNetInterfaceName() (string, error) {
+	f.sim.lock.Lock()
+	defer f.sim.lock.Unlock()
+
+	if err := f.sim.deviceErrors[f.Addr]; err != nil {
+		return "", errors.Wrapf(err, "failed to read net directory for the device: %v", f.Addr)
+	}
+	if f.Driver == vfioDriver || time.Now().Before(f.readyAt) {
+		return "", errors.Errorf("no net interface found for the device: %v", f.Addr)
+	}
+	return f.IfName, nil
+}
+
+// IsResetting returns true if f is resetting until the simulated reset time
+func (f *simulatedFunction) IsResetting() (bool, error) {
+	f.sim.lock.Lock()
+	defer f.sim.lock.Unlock()
+
+	return time.Now().Before(f.sim.resetUntil[f.Addr]), nil
+}
+
+// GetBoundDriver returns f.Driver
+func (f *simulatedFunction) GetBoundDriver() (string, error) {
+	f.sim.lock.Lock()
+	defer f.sim.lock.Unlock()
+
+	if err := f.sim.deviceErrors[f.Addr]; err != nil {
+		return "", errors.Wrapf(err, "failed to get bound driver for the device: %v", f.Addr)
+	}
+
+	return f.Driver, nil
+}
+
+// UnbindDriver sets f.Driver = ""
+func (f *simulatedFunction) UnbindDriver() error {
+	f.sim.lock.Lock()
+	defer f.sim.lock.Unlock()
+
+	f.Driver = ""
+
+	return nil
+}
+
+// BindDriverWithContext is the same as BindDriver, but fails if ctx is done and f is not bound to the driver yet, as
+// the real binding does
+func (f *simulatedFunction) BindDriverWithContext(ctx context.Context, driver string) error {
+	f.sim.lock.Lock()
+	boundDriver := f.Driver
+	f.sim.lock.Unlock()
+
+	if boundDriver != driver && ctx.Err() != nil {
+		return errors.Wrapf(ctx.Err(), "failed to bind the driver to the device: %v %v", driver, f.Addr)
+	}
+
+	return f.BindDriver(driver)
+}
+
+// BindDriver fails with the simulated bind error or binds f to the driver getting ready after the bind latency
+func (f *simulatedFunction) BindDriver(driver string) error {
+	f.sim.lock.Lock()
+	defer f.sim.lock.Unlock()
+
+	if err := f.sim.bindErrors[f.Addr]; err != nil {
+		return errors.Wrapf(err, "failed to bind the driver to the device: %v %v", driver, f.Addr)
+	}
+
+	if f.Driver == driver {
+		return nil
+	}
+
+	f.Driver = driver
+	f.readyAt = time.Now().Add(f.sim.bindLatency)
+
+	return nil
+}
@@ -21,7 +21,7 @@ import (
 	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/pcifunction"
 )
 
-// UpdateConfig updates config with virtual functions
+// UpdateConfig updates config with virtual functions and with the PF NUMA nodes not set in the config
 func UpdateConfig(pciDevicesPath, pciDriversPath string, cfg *config.Config) error {
 	for pfPCIAddr, pfCfg := range cfg.PhysicalFunctions {
 		pf, err := pcifunction.NewPhysicalFunction(pfPCIAddr, pciDevicesPath, pciDriversPath)
@@ -29,6 +29,17 @@ func UpdateConfig(pciDevicesPath, pciDriversPath string, cfg *config.Config) err
 			return err
 		}
 
+		// -1 NUMA node means no NUMA affinity, the same as not set one
+		if pfCfg.NUMANode == nil {
+			numaNode, numaErr := pf.GetNUMANode()
+			if numaErr != nil {
+				return numaErr
+			}
+			if numaNode >= 0 {
+				pfCfg.NUMANode = &numaNode
+			}
+		}
+
 		for _, vf := range pf.GetVirtualFunctions() {
 			iommuGroup, err := vf.GetIOMMUGroup()
 			if err != nil {
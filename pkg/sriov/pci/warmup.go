@@ -0,0 +1,154 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pci
+
+import (
+	"context"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+)
+
+// FunctionInfo is a PCI function info cached by Warmup
+type FunctionInfo struct {
+	IOMMUGroup  uint
+	BoundDriver string
+	// NetInterfaceName is empty if the function is not bound to a kernel driver
+	NetInterfaceName string
+}
+
+// Warmup reads and caches IOMMU groups, bound drivers and net interface names for all PCI functions, so device errors
+// are reported on startup instead of the first Request. PCI functions returned by GetPCIFunction use the cached info,
+// cached info for the IOMMU group is dropped on BindDriver. Returns the first device error, all of them are logged.
+// Pool becomes ready when the first Warmup finishes, even with the device errors.
+func (p *Pool) Warmup(ctx context.Context) error {
+	logger := log.FromContext(ctx).WithField("pci.Pool", "Warmup")
+	defer p.readyOnce.Do(func() { close(p.readyCh) })
+
+	pciAddrs := make([]string, 0, len(p.functions))
+	for pciAddr := range p.functions {
+		pciAddrs = append(pciAddrs, pciAddr)
+	}
+	sort.Strings(pciAddrs)
+
+	var firstErr error
+	var errCount int
+	for _, pciAddr := range pciAddrs {
+		f := p.functions[pciAddr]
+
+		info, err := readFunctionInfo(f.function)
+		if err != nil {
+			logger.Errorf("failed to warm up PCI function %v: %v", pciAddr, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+			errCount++
+			continue
+		}
+		p.infoLock.Lock()
+		f.info = info
+		p.infoLock.Unlock()
+	}
+
+	if firstErr != nil {
+		return errors.Wrapf(firstErr, "failed to warm up %d PCI functions", errCount)
+	}
+	return nil
+}
+
+// Ready returns a channel closed when the first Warmup finishes
+func (p *Pool) Ready() <-chan struct{} {
+	return p.readyCh
+}
+
+// GetFunctionInfo returns PCI function info cached by Warmup
+func (p *Pool) GetFunctionInfo(pciAddr string) (*FunctionInfo, error) {
+	f, ok := p.functions[pciAddr]
+	if !ok {
+		return nil, errors.Errorf("PCI function doesn't exist: %v", pciAddr)
+	}
+	info := p.cachedInfo(f)
+	if info == nil {
+		return nil, errors.Errorf("PCI function info is not cached: %v", pciAddr)
+	}
+	return info, nil
+}
+
+func (p *Pool) cachedInfo(f *function) *FunctionInfo {
+	p.infoLock.Lock()
+	defer p.infoLock.Unlock()
+
+	return f.info
+}
+
+func (p *Pool) dropInfo(f *function) {
+	p.infoLock.Lock()
+	defer p.infoLock.Unlock()
+
+	f.info = nil
+}
+
+// cachedFunction is a PCI function reading IOMMU group and net interface name from the info cached by Warmup if any
+type cachedFunction struct {
+	pool *Pool
+	f    *function
+}
+
+func (cf *cachedFunction) GetPCIAddress() string {
+	return cf.f.function.GetPCIAddress()
+}
+
+func (cf *cachedFunction) GetNetInterfaceName() (string, error) {
+	if info := cf.pool.cachedInfo(cf.f); info != nil && info.NetInterfaceName != "" {
+		return info.NetInterfaceName, nil
+	}
+	return cf.f.function.GetNetInterfaceName()
+}
+
+func (cf *cachedFunction) GetIOMMUGroup() (uint, error) {
+	if info := cf.pool.cachedInfo(cf.f); info != nil {
+		return info.IOMMUGroup, nil
+	}
+	return cf.f.function.GetIOMMUGroup()
+}
+
+func readFunctionInfo(pcif pciFunction) (*FunctionInfo, error) {
+	iommuGroup, err := pcif.GetIOMMUGroup()
+	if err != nil {
+		return nil, err
+	}
+
+	boundDriver, err := pcif.GetBoundDriver()
+	if err != nil {
+		return nil, err
+	}
+
+	info := &FunctionInfo{
+		IOMMUGroup:  iommuGroup,
+		BoundDriver: boundDriver,
+	}
+
+	if boundDriver != "" && boundDriver != vfioDriver {
+		if info.NetInterfaceName, err = pcif.GetNetInterfaceName(); err != nil {
+			return nil, err
+		}
+	}
+
+	return info, nil
+}
@@ -0,0 +1,158 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pci_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov"
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/pci"
+)
+
+func TestPool_Warmup(t *testing.T) {
+	p, _ := testPool(t)
+
+	_, err := p.GetFunctionInfo(vfPCIAddr)
+	require.Error(t, err)
+
+	require.NoError(t, p.Warmup(context.Background()))
+
+	info, err := p.GetFunctionInfo(pfPCIAddr)
+	require.NoError(t, err)
+	require.Equal(t, &pci.FunctionInfo{
+		IOMMUGroup:       1,
+		BoundDriver:      "pf-driver",
+		NetInterfaceName: "pf",
+	}, info)
+
+	info, err = p.GetFunctionInfo(vfPCIAddr)
+	require.NoError(t, err)
+	require.Equal(t, &pci.FunctionInfo{
+		IOMMUGroup:       2,
+		BoundDriver:      "vf-driver",
+		NetInterfaceName: "vf",
+	}, info)
+
+	// Cached info is dropped on driver rebind
+
+	require.NoError(t, p.BindDriver(context.Background(), 2, sriov.VFIOPCIDriver))
+
+	_, err = p.GetFunctionInfo(vfPCIAddr)
+	require.Error(t, err)
+
+	require.NoError(t, p.Warmup(context.Background()))
+
+	info, err = p.GetFunctionInfo(vfPCIAddr)
+	require.NoError(t, err)
+	require.Equal(t, &pci.FunctionInfo{
+		IOMMUGroup:  2,
+		BoundDriver: "vfio-pci",
+	}, info)
+}
+
+func TestPool_Warmup_Ready(t *testing.T) {
+	pfs, cfg := testFunctions()
+
+	p, err := pci.NewSimulatedPool(pfs, cfg, pci.WithDeviceError(vfPCIAddr, errors.New("device is broken")))
+	require.NoError(t, err)
+
+	select {
+	case <-p.Ready():
+		require.FailNow(t, "Pool should not be ready before the warmup")
+	default:
+	}
+
+	// Pool is ready even if some device fails the warmup
+
+	require.Error(t, p.Warmup(context.Background()))
+	<-p.Ready()
+
+	require.Error(t, p.Warmup(context.Background()))
+}
+
+func TestPool_Warmup_DeviceError(t *testing.T) {
+	pfs, cfg := testFunctions()
+
+	deviceErr := errors.New("device is broken")
+	p, err := pci.NewSimulatedPool(pfs, cfg, pci.WithDeviceError(vfPCIAddr, deviceErr))
+	require.NoError(t, err)
+
+	require.ErrorIs(t, p.Warmup(context.Background()), deviceErr)
+
+	_, err = p.GetFunctionInfo(pfPCIAddr)
+	require.NoError(t, err)
+
+	_, err = p.GetFunctionInfo(vfPCIAddr)
+	require.Error(t, err)
+}
+
+func TestPool_Warmup_GetPCIFunction(t *testing.T) {
+	p, pfs := testPool(t)
+
+	require.NoError(t, p.Warmup(context.Background()))
+
+	// PCI functions use the cached info
+
+	pfs[pfPCIAddr].IfName = "pf-renamed"
+	pfs[pfPCIAddr].Vfs[0].IfName = "vf-renamed"
+
+	pf, err := p.GetPCIFunction(pfPCIAddr)
+	require.NoError(t, err)
+	ifName, err := pf.GetNetInterfaceName()
+	require.NoError(t, err)
+	require.Equal(t, "pf", ifName)
+
+	vf, err := p.GetPCIFunction(vfPCIAddr)
+	require.NoError(t, err)
+	iommuGroup, err := vf.GetIOMMUGroup()
+	require.NoError(t, err)
+	require.Equal(t, uint(2), iommuGroup)
+
+	// Stale info is not used after driver rebind
+
+	require.NoError(t, p.BindDriver(context.Background(), 2, sriov.KernelDriver))
+
+	ifName, err = vf.GetNetInterfaceName()
+	require.NoError(t, err)
+	require.Equal(t, "vf-renamed", ifName)
+}
+
+func TestPool_Warmup_Concurrent(t *testing.T) {
+	p, _ := testPool(t)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		assert.NoError(t, p.Warmup(context.Background()))
+	}()
+
+	for i := 0; i < 10; i++ {
+		require.NoError(t, p.BindDriver(context.Background(), 2, sriov.KernelDriver))
+		vf, err := p.GetPCIFunction(vfPCIAddr)
+		require.NoError(t, err)
+		_, err = vf.GetIOMMUGroup()
+		require.NoError(t, err)
+	}
+	wg.Wait()
+}
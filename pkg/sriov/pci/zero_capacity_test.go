@@ -0,0 +1,67 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pci_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/config"
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/pci"
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/pcifunction"
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/sriovtest"
+)
+
+const zeroCapPFPCIAddr = "0000:02:00.0"
+
+func TestNewPCIPool_ZeroCapacityPF(t *testing.T) {
+	fs := sriovtest.NewSysfs(t)
+
+	fs.AddPhysicalFunction(t, pfPCIAddr, vfPCIAddr)
+	fs.SetIOMMUGroup(t, pfPCIAddr, 1)
+	fs.AddDevice(t, vfPCIAddr)
+	fs.SetIOMMUGroup(t, vfPCIAddr, 2)
+	fs.AddPhysicalFunction(t, zeroCapPFPCIAddr)
+
+	cfg := &config.Config{
+		PhysicalFunctions: map[string]*config.PhysicalFunction{
+			pfPCIAddr:        {},
+			zeroCapPFPCIAddr: {},
+		},
+	}
+
+	_, err := pci.NewPCIPool(fs.DevicesPath, fs.DriversPath, t.TempDir(), cfg, true)
+	require.ErrorIs(t, err, pcifunction.ErrZeroCapacity)
+	require.Contains(t, err.Error(), zeroCapPFPCIAddr)
+
+	p, err := pci.NewPCIPool(fs.DevicesPath, fs.DriversPath, t.TempDir(), cfg, true, pci.WithSkipZeroCapacityPFs())
+	require.NoError(t, err)
+
+	_, err = p.GetPCIFunction(pfPCIAddr)
+	require.NoError(t, err)
+	_, err = p.GetPCIFunction(vfPCIAddr)
+	require.NoError(t, err)
+	_, err = p.GetPCIFunction(zeroCapPFPCIAddr)
+	require.Error(t, err)
+
+	require.Equal(t, []string{zeroCapPFPCIAddr}, p.SkippedPFs())
+
+	// shared config is left unchanged
+	require.Contains(t, cfg.PhysicalFunctions, pfPCIAddr)
+	require.Contains(t, cfg.PhysicalFunctions, zeroCapPFPCIAddr)
+}
@@ -0,0 +1,30 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sriov
+
+// PCIeLinkStatus contains PCIe link current and max speeds in GT/s and widths in lanes
+type PCIeLinkStatus struct {
+	CurrentSpeed float64
+	CurrentWidth uint
+	MaxSpeed     float64
+	MaxWidth     uint
+}
+
+// IsDegraded returns true if the link is trained to lower speed or width than the device supports
+func (s *PCIeLinkStatus) IsDegraded() bool {
+	return s.CurrentSpeed < s.MaxSpeed || s.CurrentWidth < s.MaxWidth
+}
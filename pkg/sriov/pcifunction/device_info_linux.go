@@ -0,0 +1,52 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pcifunction
+
+import (
+	"github.com/pkg/errors"
+	"golang.org/x/sys/unix"
+
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov"
+)
+
+// GetDeviceInfo returns f driver name, driver version and firmware version
+func (f *Function) GetDeviceInfo() (*sriov.DeviceInfo, error) {
+	ifName, err := f.GetNetInterfaceName()
+	if err != nil {
+		return nil, err
+	}
+
+	fd, err := unix.Socket(unix.AF_INET, unix.SOCK_DGRAM, 0)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open ethtool socket")
+	}
+	defer func() { _ = unix.Close(fd) }()
+
+	drvInfo, err := unix.IoctlGetEthtoolDrvinfo(fd, ifName)
+	switch {
+	case errors.Is(err, unix.EOPNOTSUPP):
+		return nil, errors.Wrapf(sriov.ErrNotSupported, "failed to get driver info for the device: %v", f.address)
+	case err != nil:
+		return nil, errors.Wrapf(err, "failed to get driver info for the device: %v", f.address)
+	}
+
+	return &sriov.DeviceInfo{
+		Driver:          unix.ByteSliceToString(drvInfo.Driver[:]),
+		DriverVersion:   unix.ByteSliceToString(drvInfo.Version[:]),
+		FirmwareVersion: unix.ByteSliceToString(drvInfo.Fw_version[:]),
+	}, nil
+}
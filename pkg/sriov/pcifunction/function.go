@@ -19,23 +19,52 @@
 package pcifunction
 
 import (
+	"context"
 	"os"
 
 	"path"
 	"path/filepath"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/pkg/errors"
+
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov"
+)
+
+const (
+	netInterfacesPath  = "net"
+	iommuGroup         = "iommu_group"
+	boundDriverPath    = "driver"
+	bindDriverPath     = "bind"
+	unbindDriverPath   = "unbind"
+	operStatePath      = "operstate"
+	enablePath         = "enable"
+	vendorIDPath       = "vendor"
+	deviceIDPath       = "device"
+	numaNodePath       = "numa_node"
+	physFnPath         = "physfn"
+	resetPath          = "reset"
+	driverOverridePath = "driver_override"
+	driversProbePath   = "drivers_probe"
+	curLinkSpeedPath   = "current_link_speed"
+	curLinkWidthPath   = "current_link_width"
+	maxLinkSpeedPath   = "max_link_speed"
+	maxLinkWidthPath   = "max_link_width"
 )
 
 const (
-	netInterfacesPath = "net"
-	iommuGroup        = "iommu_group"
-	boundDriverPath   = "driver"
-	bindDriverPath    = "bind"
-	unbindDriverPath  = "unbind"
+	bindDriverTimeout       = 500 * time.Millisecond
+	bindDriverRetryInterval = 10 * time.Millisecond
 )
 
+// ErrNotVirtualFunction is returned when the PCI function is not an SR-IOV virtual function
+var ErrNotVirtualFunction = errors.New("PCI function is not a VF")
+
+// ErrResetNotSupported is returned by Reset when the device has no reset sysfs file
+var ErrResetNotSupported = errors.New("device does not support reset")
+
 // Function describes Linux PCI function
 type Function struct {
 	address        string
@@ -70,6 +99,36 @@ func (f *Function) GetNetInterfaceName() (string, error) {
 	}
 }
 
+// GetOperState returns f net interface operational state, e.g. "up", "down"
+func (f *Function) GetOperState() (string, error) {
+	ifName, err := f.GetNetInterfaceName()
+	if err != nil {
+		return "", err
+	}
+
+	data, err := os.ReadFile(filepath.Clean(f.withDevicePath(netInterfacesPath, ifName, operStatePath)))
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to read operstate for the device: %v", f.address)
+	}
+
+	return strings.TrimSpace(string(data)), nil
+}
+
+// IsResetting returns true if f is disabled, e.g. during the function level reset. It makes sense only for the
+// functions bound to a driver, unbound function is disabled as well.
+func (f *Function) IsResetting() (bool, error) {
+	if !isFileExists(f.withDevicePath(enablePath)) {
+		return false, nil
+	}
+
+	enable, err := readUintFromFile(f.withDevicePath(enablePath))
+	if err != nil {
+		return false, err
+	}
+
+	return enable == 0, nil
+}
+
 // GetIOMMUGroup returns f IOMMU group id
 func (f *Function) GetIOMMUGroup() (uint, error) {
 	stringIOMMUGroup, err := evalSymlinkAndGetBaseName(f.withDevicePath(iommuGroup))
@@ -77,11 +136,65 @@ func (f *Function) GetIOMMUGroup() (uint, error) {
 		return 0, err
 	}
 
-	iommuGroup, _ := strconv.Atoi(stringIOMMUGroup)
+	// group 0 is a valid IOMMU group, so malformed group name must not be parsed as 0
+	iommuGroup, err := strconv.ParseUint(stringIOMMUGroup, 10, 0)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid IOMMU group for the device %v: %v", f.address, stringIOMMUGroup)
+	}
 
 	return uint(iommuGroup), nil
 }
 
+// GetNUMANode returns f NUMA node, -1 means no NUMA affinity
+func (f *Function) GetNUMANode() (int, error) {
+	data, err := readStringFromFile(f.withDevicePath(numaNodePath))
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to read NUMA node for the device: %v", f.address)
+	}
+
+	numaNode, err := strconv.Atoi(data)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid NUMA node for the device: %v", f.address)
+	}
+
+	return numaNode, nil
+}
+
+// GetDeviceIDs returns f PCI vendor and device IDs in the same format as GetVendorID and GetDeviceID, e.g. "8086",
+// "1572"
+func (f *Function) GetDeviceIDs() (vendorID, deviceID string, err error) {
+	if vendorID, err = f.GetVendorID(); err != nil {
+		return "", "", err
+	}
+	if deviceID, err = f.GetDeviceID(); err != nil {
+		return "", "", err
+	}
+	return vendorID, deviceID, nil
+}
+
+// GetVendorID returns f PCI vendor ID without the "0x" prefix, e.g. "8086"
+func (f *Function) GetVendorID() (string, error) {
+	return readIDFromFile(f.withDevicePath(vendorIDPath))
+}
+
+// GetDeviceID returns f PCI device ID without the "0x" prefix, e.g. "1572"
+func (f *Function) GetDeviceID() (string, error) {
+	return readIDFromFile(f.withDevicePath(deviceIDPath))
+}
+
+// IsVirtualFunction returns true if f is an SR-IOV virtual function
+func (f *Function) IsVirtualFunction() bool {
+	return isFileExists(f.withDevicePath(physFnPath))
+}
+
+// GetPhysicalFunctionAddress returns PCI address of the f parent PF, returns ErrNotVirtualFunction if f is not a VF
+func (f *Function) GetPhysicalFunctionAddress() (string, error) {
+	if !f.IsVirtualFunction() {
+		return "", errors.Wrapf(ErrNotVirtualFunction, "%v", f.address)
+	}
+	return evalSymlinkAndGetBaseName(f.withDevicePath(physFnPath))
+}
+
 // GetBoundDriver returns driver name that is bound to f, if no driver bound, returns ""
 func (f *Function) GetBoundDriver() (string, error) {
 	if !isFileExists(f.withDevicePath(boundDriverPath)) {
@@ -96,31 +209,160 @@ func (f *Function) GetBoundDriver() (string, error) {
 	return driver, nil
 }
 
-// BindDriver unbinds currently bound driver and binds the given driver to f
+// BindDriver is the same as BindDriverWithContext with the background context
 func (f *Function) BindDriver(driver string) error {
-	switch boundDriver, err := f.GetBoundDriver(); {
-	case err != nil:
+	return f.BindDriverWithContext(context.Background(), driver)
+}
+
+// BindDriverWithContext unbinds currently bound driver and binds the given driver to f retrying until the driver gets
+// bound, ctx is done or bindDriverTimeout elapses. If f supports driver_override, the driver is bound by the driver
+// override and drivers_probe, so udev and other drivers can't bind to f in between, otherwise the driver is bound
+// explicitly with the driver bind file. Driver override is cleared after the binding, so it doesn't stick to f.
+func (f *Function) BindDriverWithContext(ctx context.Context, driver string) (err error) {
+	boundDriver, err := f.GetBoundDriver()
+	if err != nil {
 		return err
-	case boundDriver == driver:
+	}
+	if boundDriver == driver {
 		return nil
-	case boundDriver != "":
-		unbindPath := f.withDevicePath(boundDriverPath, unbindDriverPath)
-		if err := os.WriteFile(unbindPath, []byte(f.address), 0); err != nil {
-			return errors.Wrapf(err, "failed to unbind driver from the device: %v", f.address)
+	}
+
+	overridePath := f.withDevicePath(driverOverridePath)
+	useOverride := isFileExists(overridePath)
+	if useOverride {
+		if err = os.WriteFile(overridePath, []byte(driver), 0); err != nil {
+			return errors.Wrapf(err, "failed to set driver override for the device: %v %v", f.address, driver)
+		}
+		defer func() {
+			// newline write clears the driver override
+			if clearErr := os.WriteFile(overridePath, []byte("\n"), 0); clearErr != nil && err == nil {
+				err = errors.Wrapf(clearErr, "failed to clear driver override for the device: %v", f.address)
+			}
+		}()
+	}
+
+	if boundDriver != "" {
+		if err = f.unbind(); err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, bindDriverTimeout)
+	defer cancel()
+
+	for {
+		// For some reasons write to the driver/bind file fails but binds the driver to the PCI function
+		// so we ignore error and simply compare the bound driver with the given one
+		err = f.bind(driver, useOverride)
+
+		newDriver, _ := f.GetBoundDriver()
+		if newDriver == driver {
+			return nil
 		}
+
+		select {
+		case <-ctx.Done():
+			return errors.Errorf("failed to bind the driver to the device: %v %v, bound driver: %v, cause: %v", f.address, driver, newDriver, err)
+		case <-time.After(bindDriverRetryInterval):
+		}
+	}
+}
+
+// UnbindDriver unbinds currently bound driver from f, does nothing if no driver is bound
+func (f *Function) UnbindDriver() error {
+	boundDriver, err := f.GetBoundDriver()
+	if err != nil || boundDriver == "" {
+		return err
+	}
+	return f.unbind()
+}
+
+func (f *Function) unbind() error {
+	unbindPath := f.withDevicePath(boundDriverPath, unbindDriverPath)
+	if err := os.WriteFile(unbindPath, []byte(f.address), 0); err != nil {
+		return errors.Wrapf(err, "failed to unbind driver from the device: %v", f.address)
+	}
+	return nil
+}
+
+func (f *Function) bind(driver string, useOverride bool) error {
+	if useOverride {
+		return os.WriteFile(filepath.Join(filepath.Dir(f.pciDriversPath), driversProbePath), []byte(f.address), 0)
+	}
+	return os.WriteFile(filepath.Join(f.pciDriversPath, driver, bindDriverPath), []byte(f.address), 0)
+}
+
+// Reset performs a function-level reset of f, returns ErrResetNotSupported if f can't be reset
+func (f *Function) Reset() error {
+	resetFilePath := f.withDevicePath(resetPath)
+	if !isFileExists(resetFilePath) {
+		return errors.Wrapf(ErrResetNotSupported, "%v", f.address)
 	}
 
-	// For some reasons write to the driver/bind file fails but binds the driver to the PCI function
-	// so we ignore error and simply compare the bound driver with the given one
-	bindPath := filepath.Join(f.pciDriversPath, driver, bindDriverPath)
-	err := os.WriteFile(bindPath, []byte(f.address), 0)
-	if boundDriver, _ := f.GetBoundDriver(); boundDriver != driver {
-		return errors.Wrapf(err, "failed to bind the driver to the device: %v %v", f.address, driver)
+	if err := os.WriteFile(resetFilePath, []byte("1"), 0); err != nil {
+		return errors.Wrapf(err, "failed to reset the device: %v", f.address)
 	}
 
 	return nil
 }
 
+// GetPCIeLinkStatus returns f PCIe link current and max speeds and widths, returns sriov.ErrNotSupported if f has no
+// PCIe link info, e.g. for VFs
+func (f *Function) GetPCIeLinkStatus() (*sriov.PCIeLinkStatus, error) {
+	if !isFileExists(f.withDevicePath(curLinkSpeedPath)) {
+		return nil, errors.Wrapf(sriov.ErrNotSupported, "failed to get PCIe link status for the device: %v", f.address)
+	}
+
+	status := new(sriov.PCIeLinkStatus)
+	for _, speed := range []struct {
+		path  string
+		value *float64
+	}{
+		{curLinkSpeedPath, &status.CurrentSpeed},
+		{maxLinkSpeedPath, &status.MaxSpeed},
+	} {
+		value, err := f.readLinkSpeed(speed.path)
+		if err != nil {
+			return nil, err
+		}
+		*speed.value = value
+	}
+	for _, width := range []struct {
+		path  string
+		value *uint
+	}{
+		{curLinkWidthPath, &status.CurrentWidth},
+		{maxLinkWidthPath, &status.MaxWidth},
+	} {
+		value, err := readUintFromFile(f.withDevicePath(width.path))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get PCIe link width for the device: %v", f.address)
+		}
+		*width.value = value
+	}
+
+	return status, nil
+}
+
+// readLinkSpeed reads link speed in GT/s from the "8.0 GT/s PCIe" or "8 GT/s" formatted file
+func (f *Function) readLinkSpeed(name string) (float64, error) {
+	data, err := readStringFromFile(f.withDevicePath(name))
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to get PCIe link speed for the device: %v", f.address)
+	}
+
+	fields := strings.Fields(data)
+	if len(fields) < 2 || fields[1] != "GT/s" {
+		return 0, errors.Wrapf(sriov.ErrNotSupported, "unknown PCIe link speed for the device: %v - %v", f.address, data)
+	}
+	speed, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid PCIe link speed for the device: %v - %v", f.address, data)
+	}
+
+	return speed, nil
+}
+
 func (f *Function) withDevicePath(elem ...string) string {
 	return path.Join(append([]string{f.pciDevicesPath, f.address}, elem...)...)
 }
@@ -0,0 +1,242 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package pcifunction_test
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov"
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/pcifunction"
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/sriovtest"
+)
+
+const (
+	vfDriver   = "vf-driver"
+	vfioDriver = "vfio-pci"
+)
+
+// readFIFO waits for the FIFO writer and returns the written data
+func readFIFO(t *testing.T, path string) string {
+	file, err := os.Open(filepath.Clean(path))
+	if !assert.NoError(t, err) {
+		return ""
+	}
+	defer func() { _ = file.Close() }()
+
+	data, err := io.ReadAll(file)
+	assert.NoError(t, err)
+	return string(data)
+}
+
+// bindDriverSysfs creates VF bound to the vfDriver with the FIFO unbind file, on unbind VF gets bound to the vfioDriver
+// and the bindPath FIFO is opened for reading, so the bindPath write returns only after the driver is changed
+func bindDriverSysfs(t *testing.T, bindPath func(fs *sriovtest.Sysfs) string) (vf *pcifunction.Function, bindCh <-chan string) {
+	fs := sriovtest.NewSysfs(t)
+	fs.AddPhysicalFunction(t, pfPCIAddr, vf1PCIAddr)
+	vfPath := fs.AddDevice(t, vf1PCIAddr)
+
+	for _, driver := range []string{vfDriver, vfioDriver} {
+		require.NoError(t, os.MkdirAll(filepath.Join(fs.DriversPath, driver), mkdirPerm))
+	}
+	require.NoError(t, unix.Mkfifo(filepath.Join(fs.DriversPath, vfDriver, "unbind"), filePerm))
+	bindFIFOPath := bindPath(fs)
+	require.NoError(t, unix.Mkfifo(bindFIFOPath, filePerm))
+	require.NoError(t, os.Symlink(filepath.Join(fs.DriversPath, vfDriver), filepath.Join(vfPath, "driver")))
+
+	ch := make(chan string, 1)
+	go func() {
+		defer close(ch)
+
+		assert.Equal(t, vf1PCIAddr, readFIFO(t, filepath.Join(fs.DriversPath, vfDriver, "unbind")))
+
+		driverPath := filepath.Join(vfPath, "driver")
+		assert.NoError(t, os.Remove(driverPath))
+		assert.NoError(t, os.Symlink(filepath.Join(fs.DriversPath, vfioDriver), driverPath))
+
+		ch <- readFIFO(t, bindFIFOPath)
+	}()
+
+	pf, err := pcifunction.NewPhysicalFunction(pfPCIAddr, fs.DevicesPath, fs.DriversPath)
+	require.NoError(t, err)
+
+	return pf.GetVirtualFunctions()[0], ch
+}
+
+func TestFunction_BindDriver_DriverOverride(t *testing.T) {
+	var overridePath string
+	vf, bindCh := bindDriverSysfs(t, func(fs *sriovtest.Sysfs) string {
+		overridePath = filepath.Join(fs.DevicesPath, vf1PCIAddr, "driver_override")
+		require.NoError(t, os.WriteFile(overridePath, []byte("(null)\n"), filePerm))
+		return filepath.Join(filepath.Dir(fs.DriversPath), "drivers_probe")
+	})
+
+	require.NoError(t, vf.BindDriver(vfioDriver))
+	require.Equal(t, vf1PCIAddr, <-bindCh)
+
+	// driver override is cleared after the binding
+	override, err := os.ReadFile(filepath.Clean(overridePath))
+	require.NoError(t, err)
+	require.Equal(t, "\n", string(override))
+
+	driver, err := vf.GetBoundDriver()
+	require.NoError(t, err)
+	require.Equal(t, vfioDriver, driver)
+}
+
+func TestFunction_BindDriver_Fallback(t *testing.T) {
+	vf, bindCh := bindDriverSysfs(t, func(fs *sriovtest.Sysfs) string {
+		return filepath.Join(fs.DriversPath, vfioDriver, "bind")
+	})
+
+	require.NoError(t, vf.BindDriver(vfioDriver))
+	require.Equal(t, vf1PCIAddr, <-bindCh)
+
+	driver, err := vf.GetBoundDriver()
+	require.NoError(t, err)
+	require.Equal(t, vfioDriver, driver)
+}
+
+func TestFunction_UnbindDriver(t *testing.T) {
+	fs := sriovtest.NewSysfs(t)
+	fs.AddPhysicalFunction(t, pfPCIAddr, vf1PCIAddr)
+	vfPath := fs.AddDevice(t, vf1PCIAddr)
+
+	pf, err := pcifunction.NewPhysicalFunction(pfPCIAddr, fs.DevicesPath, fs.DriversPath)
+	require.NoError(t, err)
+	vf := pf.GetVirtualFunctions()[0]
+
+	// no driver is bound
+	require.NoError(t, vf.UnbindDriver())
+
+	require.NoError(t, os.MkdirAll(filepath.Join(fs.DriversPath, vfDriver), mkdirPerm))
+	require.NoError(t, os.Symlink(filepath.Join(fs.DriversPath, vfDriver), filepath.Join(vfPath, "driver")))
+
+	require.NoError(t, vf.UnbindDriver())
+
+	unbind, err := os.ReadFile(filepath.Join(fs.DriversPath, vfDriver, "unbind"))
+	require.NoError(t, err)
+	require.Equal(t, vf1PCIAddr, string(unbind))
+}
+
+func TestFunction_BindDriverWithContext_Retry(t *testing.T) {
+	const bindAttempts = 3
+
+	fs := sriovtest.NewSysfs(t)
+	fs.AddPhysicalFunction(t, pfPCIAddr, vf1PCIAddr)
+	vfPath := fs.AddDevice(t, vf1PCIAddr)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(fs.DriversPath, vfioDriver), mkdirPerm))
+	bindPath := filepath.Join(fs.DriversPath, vfioDriver, "bind")
+	require.NoError(t, unix.Mkfifo(bindPath, filePerm))
+
+	// driver gets bound only on the last bind attempt, FIFO write returns only after it is read, so the driver is
+	// changed before the last attempt verification
+	go func() {
+		for i := 1; i <= bindAttempts; i++ {
+			if i == bindAttempts {
+				assert.NoError(t, os.Symlink(filepath.Join(fs.DriversPath, vfioDriver), filepath.Join(vfPath, "driver")))
+			}
+			assert.Equal(t, vf1PCIAddr, readFIFO(t, bindPath))
+		}
+	}()
+
+	pf, err := pcifunction.NewPhysicalFunction(pfPCIAddr, fs.DevicesPath, fs.DriversPath)
+	require.NoError(t, err)
+	vf := pf.GetVirtualFunctions()[0]
+
+	require.NoError(t, vf.BindDriverWithContext(context.Background(), vfioDriver))
+
+	driver, err := vf.GetBoundDriver()
+	require.NoError(t, err)
+	require.Equal(t, vfioDriver, driver)
+}
+
+func TestFunction_BindDriverWithContext_Timeout(t *testing.T) {
+	fs := sriovtest.NewSysfs(t)
+	fs.AddPhysicalFunction(t, pfPCIAddr, vf1PCIAddr)
+	fs.AddDevice(t, vf1PCIAddr)
+	require.NoError(t, os.MkdirAll(filepath.Join(fs.DriversPath, vfioDriver), mkdirPerm))
+
+	pf, err := pcifunction.NewPhysicalFunction(pfPCIAddr, fs.DevicesPath, fs.DriversPath)
+	require.NoError(t, err)
+	vf := pf.GetVirtualFunctions()[0]
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	require.Error(t, vf.BindDriverWithContext(ctx, vfioDriver))
+	require.ErrorIs(t, ctx.Err(), context.DeadlineExceeded)
+}
+
+func TestFunction_GetPCIeLinkStatus(t *testing.T) {
+	fs := sriovtest.NewSysfs(t)
+	pfPath := fs.AddPhysicalFunction(t, pfPCIAddr, vf1PCIAddr)
+	fs.AddDevice(t, vf1PCIAddr)
+
+	writeLinkStatus := func(curSpeed, curWidth string) {
+		for name, value := range map[string]string{
+			"current_link_speed": curSpeed,
+			"current_link_width": curWidth,
+			"max_link_speed":     "8.0 GT/s PCIe\n",
+			"max_link_width":     "16\n",
+		} {
+			require.NoError(t, os.WriteFile(filepath.Join(pfPath, name), []byte(value), filePerm))
+		}
+	}
+
+	pf, err := pcifunction.NewPhysicalFunction(pfPCIAddr, fs.DevicesPath, fs.DriversPath)
+	require.NoError(t, err)
+
+	writeLinkStatus("8.0 GT/s PCIe\n", "16\n")
+
+	status, err := pf.GetPCIeLinkStatus()
+	require.NoError(t, err)
+	require.Equal(t, &sriov.PCIeLinkStatus{
+		CurrentSpeed: 8,
+		CurrentWidth: 16,
+		MaxSpeed:     8,
+		MaxWidth:     16,
+	}, status)
+	require.False(t, status.IsDegraded())
+
+	writeLinkStatus("2.5 GT/s\n", "1\n")
+
+	status, err = pf.GetPCIeLinkStatus()
+	require.NoError(t, err)
+	require.Equal(t, &sriov.PCIeLinkStatus{
+		CurrentSpeed: 2.5,
+		CurrentWidth: 1,
+		MaxSpeed:     8,
+		MaxWidth:     16,
+	}, status)
+	require.True(t, status.IsDegraded())
+
+	_, err = pf.GetVirtualFunctions()[0].GetPCIeLinkStatus()
+	require.ErrorIs(t, err, sriov.ErrNotSupported)
+}
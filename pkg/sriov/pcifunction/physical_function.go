@@ -20,14 +20,18 @@
 package pcifunction
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"regexp"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
+
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
 )
 
 // TODO: add unit tests with sriovtest.FileAPI
@@ -37,6 +41,15 @@ const (
 	totalVFFile           = "sriov_totalvfs"
 	configuredVFFile      = "sriov_numvfs"
 	virtualFunctionPrefix = "virtfn"
+
+	vfRemovalPollInterval = 50 * time.Millisecond
+)
+
+var (
+	// ErrNotSRIOVCapable is returned when the PCI device is not SR-IOV capable
+	ErrNotSRIOVCapable = errors.New("PCI device is not SR-IOV capable")
+	// ErrZeroCapacity is returned when the PCI device supports no VFs, usually SR-IOV is disabled in BIOS
+	ErrZeroCapacity = errors.New("PCI device supports no VFs")
 )
 
 var (
@@ -46,21 +59,17 @@ var (
 
 // PhysicalFunction describes Linux PCI physical function
 type PhysicalFunction struct {
-	virtualFunctions []*Function
+	virtualFunctions       []*Function
+	virtualFunctionIndexes []int // virtfnN indexes of the virtualFunctions
 
 	Function
 }
 
 // NewPhysicalFunction returns a new PhysicalFunction
 func NewPhysicalFunction(pciAddress, pciDevicesPath, pciDriversPath string) (*PhysicalFunction, error) {
-	var bdfPCIAddress string
-	switch {
-	case validLongPCIAddr.MatchString(pciAddress):
-		bdfPCIAddress = pciAddress
-	case validShortPCIAddr.MatchString(pciAddress):
-		bdfPCIAddress = bdfDomain + pciAddress
-	default:
-		return nil, errors.Errorf("invalid PCI address format: %v", pciAddress)
+	bdfPCIAddress, err := ToBDFAddress(pciAddress)
+	if err != nil {
+		return nil, err
 	}
 
 	pciDevicePath := filepath.Join(pciDevicesPath, bdfPCIAddress)
@@ -68,10 +77,6 @@ func NewPhysicalFunction(pciAddress, pciDevicesPath, pciDriversPath string) (*Ph
 		return nil, errors.Errorf("PCI device doesn't exist: %v", bdfPCIAddress)
 	}
 
-	if !isFileExists(filepath.Join(pciDevicePath, totalVFFile)) {
-		return nil, errors.Errorf("PCI device is not SR-IOV capable: %v", bdfPCIAddress)
-	}
-
 	pf := &PhysicalFunction{
 		Function: Function{
 			address:        pciAddress,
@@ -79,23 +84,185 @@ func NewPhysicalFunction(pciAddress, pciDevicesPath, pciDriversPath string) (*Ph
 			pciDriversPath: pciDriversPath,
 		},
 	}
-	if err := pf.createVirtualFunctions(); err != nil {
+	if err = pf.createVirtualFunctions(); err != nil {
 		return nil, err
 	}
-	if err := pf.loadVirtualFunctions(); err != nil {
+	if err = pf.loadVirtualFunctions(); err != nil {
 		return nil, err
 	}
 	return pf, nil
 }
 
-// GetVirtualFunctions returns pf virtual functions
+// ToBDFAddress validates the PCI address format and returns it in the full domain:bus:device.function form
+func ToBDFAddress(pciAddress string) (string, error) {
+	switch {
+	case validLongPCIAddr.MatchString(pciAddress):
+		return pciAddress, nil
+	case validShortPCIAddr.MatchString(pciAddress):
+		return bdfDomain + pciAddress, nil
+	default:
+		return "", errors.Errorf("invalid PCI address format: %v", pciAddress)
+	}
+}
+
+// GetVirtualFunctions returns pf virtual functions in the VF index order
 func (pf *PhysicalFunction) GetVirtualFunctions() []*Function {
 	vfs := make([]*Function, len(pf.virtualFunctions))
 	copy(vfs, pf.virtualFunctions)
 	return vfs
 }
 
+// GetVirtualFunctionIndexes returns pf virtual functions indexes (N from the virtfnN sysfs links) in the same order as
+// GetVirtualFunctions
+func (pf *PhysicalFunction) GetVirtualFunctionIndexes() []int {
+	indexes := make([]int, len(pf.virtualFunctionIndexes))
+	copy(indexes, pf.virtualFunctionIndexes)
+	return indexes
+}
+
+// GetPhysicalFunctionAddress returns PCI address of the VF parent PF, returns ErrNotVirtualFunction if the PCI function
+// is not a VF
+func GetPhysicalFunctionAddress(vfPCIAddress, pciDevicesPath string) (string, error) {
+	bdfPCIAddress, err := ToBDFAddress(vfPCIAddress)
+	if err != nil {
+		return "", err
+	}
+
+	vf := &Function{
+		address:        bdfPCIAddress,
+		pciDevicesPath: pciDevicesPath,
+	}
+	if !isFileExists(vf.withDevicePath()) {
+		return "", errors.Errorf("PCI device doesn't exist: %v", bdfPCIAddress)
+	}
+	return vf.GetPhysicalFunctionAddress()
+}
+
+// DestroyVirtualFunctions removes all virtual functions of the PF by writing 0 to its sriov_numvfs, it does nothing if
+// the PF has no virtual functions. Kernel refuses to remove virtual functions while some of them are in use.
+func DestroyVirtualFunctions(ctx context.Context, pfPCIAddress, pciDevicesPath string) error {
+	pf, err := newSRIOVPhysicalFunction(pfPCIAddress, pciDevicesPath)
+	if err != nil {
+		return err
+	}
+
+	vfsCount, err := readUintFromFile(pf.withDevicePath(configuredVFFile))
+	if err != nil || vfsCount == 0 {
+		return err
+	}
+
+	log.FromContext(ctx).WithField("pcifunction", "DestroyVirtualFunctions").Infof("destroying VFs for the PF: %v", pf.address)
+	return pf.destroyVirtualFunctions()
+}
+
+// ReconfigureVirtualFunctions changes the number of the PF virtual functions to vfNumber, it does nothing if the PF
+// already has vfNumber virtual functions. Kernel doesn't allow to change a non-zero number of virtual functions, so
+// the existing ones are destroyed first, ctx limits the time to wait for them to disappear.
+func ReconfigureVirtualFunctions(ctx context.Context, pfPCIAddress, pciDevicesPath string, vfNumber int) error {
+	pf, err := newSRIOVPhysicalFunction(pfPCIAddress, pciDevicesPath)
+	if err != nil {
+		return err
+	}
+
+	vfsCount, err := readUintFromFile(pf.withDevicePath(configuredVFFile))
+	if err != nil {
+		return err
+	}
+	if int(vfsCount) == vfNumber {
+		return nil
+	}
+
+	totalVFsCount, err := readUintFromFile(pf.withDevicePath(totalVFFile))
+	if err != nil {
+		return errors.Wrapf(err, "failed to get available VFs number for the PCI device: %v", pf.address)
+	}
+	if vfNumber < 0 || vfNumber > int(totalVFsCount) {
+		return errors.Errorf("invalid VFs number for the PCI device %v: %d, the device supports up to %d VFs",
+			pf.address, vfNumber, totalVFsCount)
+	}
+
+	logger := log.FromContext(ctx).WithField("pcifunction", "ReconfigureVirtualFunctions")
+	logger.Infof("changing VFs number for the PF %v: %d -> %d", pf.address, vfsCount, vfNumber)
+
+	if vfsCount > 0 {
+		if err = pf.destroyVirtualFunctions(); err != nil {
+			return err
+		}
+		if err = pf.waitVirtualFunctionsRemoved(ctx); err != nil {
+			return err
+		}
+	}
+	if vfNumber == 0 {
+		return nil
+	}
+
+	if err = os.WriteFile(pf.withDevicePath(configuredVFFile), []byte(strconv.Itoa(vfNumber)), 0); err != nil {
+		return errors.Wrapf(err, "failed to create VFs for the PCI device: %v", pf.address)
+	}
+	return nil
+}
+
+func newSRIOVPhysicalFunction(pfPCIAddress, pciDevicesPath string) (*PhysicalFunction, error) {
+	bdfPCIAddress, err := ToBDFAddress(pfPCIAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	pf := &PhysicalFunction{
+		Function: Function{
+			address:        bdfPCIAddress,
+			pciDevicesPath: pciDevicesPath,
+		},
+	}
+	if !isFileExists(pf.withDevicePath()) {
+		return nil, errors.Errorf("PCI device doesn't exist: %v", bdfPCIAddress)
+	}
+	if err = pf.checkSRIOVCapable(); err != nil {
+		return nil, err
+	}
+	return pf, nil
+}
+
+func (pf *PhysicalFunction) destroyVirtualFunctions() error {
+	if err := os.WriteFile(pf.withDevicePath(configuredVFFile), []byte("0"), 0); err != nil {
+		return errors.Wrapf(err, "failed to destroy VFs for the PCI device, check that no VF is in use: %v", pf.address)
+	}
+	return nil
+}
+
+func (pf *PhysicalFunction) waitVirtualFunctionsRemoved(ctx context.Context) error {
+	ticker := time.NewTicker(vfRemovalPollInterval)
+	defer ticker.Stop()
+
+	for {
+		vfDirs, err := filepath.Glob(pf.withDevicePath(virtualFunctionPrefix + "*"))
+		if err != nil {
+			return errors.Wrapf(err, "failed to find virtual function directories for the device: %v", pf.address)
+		}
+		if len(vfDirs) == 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return errors.Wrapf(ctx.Err(), "%d VFs are still present for the PCI device: %v", len(vfDirs), pf.address)
+		case <-ticker.C:
+		}
+	}
+}
+
+func (pf *PhysicalFunction) checkSRIOVCapable() error {
+	if !isFileExists(pf.withDevicePath(totalVFFile)) {
+		return errors.Wrapf(ErrNotSRIOVCapable, "%v", pf.address)
+	}
+	return nil
+}
+
 func (pf *PhysicalFunction) createVirtualFunctions() error {
+	if err := pf.checkSRIOVCapable(); err != nil {
+		return err
+	}
+
 	switch vfsCount, err := readUintFromFile(pf.withDevicePath(configuredVFFile)); {
 	case err != nil:
 		return err
@@ -103,12 +270,16 @@ func (pf *PhysicalFunction) createVirtualFunctions() error {
 		return nil
 	}
 
-	vfsCount, err := os.ReadFile(pf.withDevicePath(totalVFFile))
+	totalVFsCount, err := readUintFromFile(pf.withDevicePath(totalVFFile))
 	if err != nil {
 		return errors.Wrapf(err, "failed to get available VFs number for the PCI device: %v", pf.address)
 	}
+	if totalVFsCount == 0 {
+		return errors.Wrapf(ErrZeroCapacity, "%v: %v is 0, check that SR-IOV is enabled in the BIOS settings",
+			pf.address, totalVFFile)
+	}
 
-	err = os.WriteFile(pf.withDevicePath(configuredVFFile), vfsCount, 0)
+	err = os.WriteFile(pf.withDevicePath(configuredVFFile), []byte(strconv.FormatUint(uint64(totalVFsCount), 10)), 0)
 	if err != nil {
 		return errors.Wrapf(err, "failed to create VFs for the PCI device: %v", pf.address)
 	}
@@ -122,21 +293,32 @@ func (pf *PhysicalFunction) loadVirtualFunctions() error {
 		return errors.Wrapf(err, "failed to find virtual function directories for the device: %v", pf.address)
 	}
 
+	// We don't stop on the first invalid directory to report all of them at once
+	var vfErrs []string
+	vfNums := map[string]int{}
+	for _, vfDir := range vfDirs {
+		vfNum, err := strconv.Atoi(strings.TrimPrefix(filepath.Base(vfDir), virtualFunctionPrefix))
+		if err != nil {
+			vfErrs = append(vfErrs, errors.Wrapf(err, "invalid virtual function directory name: %v", vfDir).Error())
+			continue
+		}
+		vfNums[vfDir] = vfNum
+	}
+
 	sort.Slice(vfDirs, func(i, k int) bool {
-		leftVFNum, _ := strconv.Atoi(strings.TrimPrefix(vfDirs[i], virtualFunctionPrefix))
-		rightVFNum, _ := strconv.Atoi(strings.TrimPrefix(vfDirs[k], virtualFunctionPrefix))
-		return leftVFNum < rightVFNum
+		return vfNums[vfDirs[i]] < vfNums[vfDirs[k]]
 	})
 
 	for _, vfDir := range vfDirs {
-		vfDirInfo, err := os.Lstat(vfDir)
-		if err != nil || vfDirInfo.Mode()&os.ModeSymlink == 0 {
-			return errors.Wrapf(err, "invalid virtual function directory: %v", vfDir)
+		vfNum, ok := vfNums[vfDir]
+		if !ok {
+			continue
 		}
 
-		linkName, err := filepath.EvalSymlinks(vfDir)
+		linkName, err := resolveVirtualFunctionDir(vfDir)
 		if err != nil {
-			return errors.Wrapf(err, "invalid virtual function directory: %v", vfDir)
+			vfErrs = append(vfErrs, err.Error())
+			continue
 		}
 
 		pf.virtualFunctions = append(pf.virtualFunctions, &Function{
@@ -144,6 +326,27 @@ func (pf *PhysicalFunction) loadVirtualFunctions() error {
 			pciDevicesPath: pf.pciDevicesPath,
 			pciDriversPath: pf.pciDriversPath,
 		})
+		pf.virtualFunctionIndexes = append(pf.virtualFunctionIndexes, vfNum)
+	}
+	if len(vfErrs) > 0 {
+		return errors.Errorf("failed to load %d of %d virtual functions for the device: %v - %s",
+			len(vfErrs), len(vfDirs), pf.address, strings.Join(vfErrs, "; "))
 	}
 	return nil
 }
+
+func resolveVirtualFunctionDir(vfDir string) (string, error) {
+	vfDirInfo, err := os.Lstat(vfDir)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid virtual function directory: %v", vfDir)
+	}
+	if vfDirInfo.Mode()&os.ModeSymlink == 0 {
+		return "", errors.Errorf("virtual function directory is not a symbolic link: %v", vfDir)
+	}
+
+	linkName, err := filepath.EvalSymlinks(vfDir)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid virtual function directory: %v", vfDir)
+	}
+	return linkName, nil
+}
@@ -0,0 +1,374 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pcifunction_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/pcifunction"
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/sriovtest"
+)
+
+const (
+	mkdirPerm  = 0o750
+	filePerm   = 0o600
+	pfPCIAddr  = "0000:01:00.0"
+	vf1PCIAddr = "0000:01:00.1"
+	vf2PCIAddr = "0000:01:00.2"
+)
+
+func TestNewPhysicalFunction(t *testing.T) {
+	fs := sriovtest.NewSysfs(t)
+	fs.AddPhysicalFunction(t, pfPCIAddr, vf1PCIAddr, vf2PCIAddr)
+	fs.AddDevice(t, vf1PCIAddr)
+	fs.AddDevice(t, vf2PCIAddr)
+
+	pf, err := pcifunction.NewPhysicalFunction(pfPCIAddr, fs.DevicesPath, fs.DriversPath)
+	require.NoError(t, err)
+
+	vfs := pf.GetVirtualFunctions()
+	require.Len(t, vfs, 2)
+	require.Equal(t, vf1PCIAddr, vfs[0].GetPCIAddress())
+	require.Equal(t, vf2PCIAddr, vfs[1].GetPCIAddress())
+}
+
+func TestNewPhysicalFunction_VirtualFunctionIndexes(t *testing.T) {
+	fs := sriovtest.NewSysfs(t)
+	pfPath := fs.AddPhysicalFunction(t, pfPCIAddr)
+	vfPCIAddrs := map[int]string{0: vf1PCIAddr, 2: vf2PCIAddr, 10: "0000:01:01.3"}
+	for vfNum, vfPCIAddr := range vfPCIAddrs {
+		fs.AddDevice(t, vfPCIAddr)
+		require.NoError(t, os.Symlink(filepath.Join("..", vfPCIAddr), filepath.Join(pfPath, "virtfn"+strconv.Itoa(vfNum))))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(pfPath, "sriov_numvfs"), []byte("3"), filePerm))
+
+	pf, err := pcifunction.NewPhysicalFunction(pfPCIAddr, fs.DevicesPath, fs.DriversPath)
+	require.NoError(t, err)
+
+	// virtfn10 goes after virtfn2
+	require.Equal(t, []int{0, 2, 10}, pf.GetVirtualFunctionIndexes())
+	vfs := pf.GetVirtualFunctions()
+	require.Len(t, vfs, 3)
+	for i, vfNum := range pf.GetVirtualFunctionIndexes() {
+		require.Equal(t, vfPCIAddrs[vfNum], vfs[i].GetPCIAddress())
+	}
+}
+
+func TestNewPhysicalFunction_DanglingVirtualFunction(t *testing.T) {
+	fs := sriovtest.NewSysfs(t)
+	fs.AddPhysicalFunction(t, pfPCIAddr, vf1PCIAddr, vf2PCIAddr)
+	fs.AddDevice(t, vf1PCIAddr)
+
+	_, err := pcifunction.NewPhysicalFunction(pfPCIAddr, fs.DevicesPath, fs.DriversPath)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "virtfn1")
+	require.NotContains(t, err.Error(), "virtfn0")
+}
+
+func TestNewPhysicalFunction_NotSRIOVCapable(t *testing.T) {
+	fs := sriovtest.NewSysfs(t)
+	devicePath := fs.AddDevice(t, pfPCIAddr)
+
+	_, err := pcifunction.NewPhysicalFunction(pfPCIAddr, fs.DevicesPath, fs.DriversPath)
+	require.ErrorIs(t, err, pcifunction.ErrNotSRIOVCapable)
+	require.Contains(t, err.Error(), pfPCIAddr)
+
+	_, err = os.Stat(filepath.Join(devicePath, "sriov_numvfs"))
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestNewPhysicalFunction_ZeroCapacity(t *testing.T) {
+	fs := sriovtest.NewSysfs(t)
+	fs.AddPhysicalFunction(t, pfPCIAddr)
+
+	_, err := pcifunction.NewPhysicalFunction(pfPCIAddr, fs.DevicesPath, fs.DriversPath)
+	require.ErrorIs(t, err, pcifunction.ErrZeroCapacity)
+	require.Contains(t, err.Error(), "BIOS")
+}
+
+func TestFunction_GetOperState(t *testing.T) {
+	fs := sriovtest.NewSysfs(t)
+	fs.AddPhysicalFunction(t, pfPCIAddr, vf1PCIAddr)
+	vfPath := fs.AddDevice(t, vf1PCIAddr)
+
+	netPath := filepath.Join(vfPath, "net", "vf1")
+	require.NoError(t, os.MkdirAll(netPath, mkdirPerm))
+	require.NoError(t, os.WriteFile(filepath.Join(netPath, "operstate"), []byte("up\n"), filePerm))
+
+	pf, err := pcifunction.NewPhysicalFunction(pfPCIAddr, fs.DevicesPath, fs.DriversPath)
+	require.NoError(t, err)
+
+	operState, err := pf.GetVirtualFunctions()[0].GetOperState()
+	require.NoError(t, err)
+	require.Equal(t, "up", operState)
+
+	_, err = pf.GetOperState()
+	require.Error(t, err)
+}
+
+func TestFunction_IsResetting(t *testing.T) {
+	fs := sriovtest.NewSysfs(t)
+	pfPath := fs.AddPhysicalFunction(t, pfPCIAddr, vf1PCIAddr)
+	fs.AddDevice(t, vf1PCIAddr)
+
+	pf, err := pcifunction.NewPhysicalFunction(pfPCIAddr, fs.DevicesPath, fs.DriversPath)
+	require.NoError(t, err)
+
+	resetting, err := pf.IsResetting()
+	require.NoError(t, err)
+	require.False(t, resetting)
+
+	require.NoError(t, os.WriteFile(filepath.Join(pfPath, "enable"), []byte("0\n"), filePerm))
+
+	resetting, err = pf.IsResetting()
+	require.NoError(t, err)
+	require.True(t, resetting)
+
+	require.NoError(t, os.WriteFile(filepath.Join(pfPath, "enable"), []byte("1\n"), filePerm))
+
+	resetting, err = pf.IsResetting()
+	require.NoError(t, err)
+	require.False(t, resetting)
+}
+
+func TestFunction_GetDeviceIDs(t *testing.T) {
+	fs := sriovtest.NewSysfs(t)
+	pfPath := fs.AddPhysicalFunction(t, pfPCIAddr, vf1PCIAddr)
+	fs.AddDevice(t, vf1PCIAddr)
+
+	pf, err := pcifunction.NewPhysicalFunction(pfPCIAddr, fs.DevicesPath, fs.DriversPath)
+	require.NoError(t, err)
+
+	_, _, err = pf.GetDeviceIDs()
+	require.Error(t, err)
+
+	require.NoError(t, os.WriteFile(filepath.Join(pfPath, "vendor"), []byte("0x8086\n"), filePerm))
+	require.NoError(t, os.WriteFile(filepath.Join(pfPath, "device"), []byte("0x1572\n"), filePerm))
+
+	vendorID, deviceID, err := pf.GetDeviceIDs()
+	require.NoError(t, err)
+	require.Equal(t, "8086", vendorID)
+	require.Equal(t, "1572", deviceID)
+}
+
+func TestFunction_GetVendorID_GetDeviceID(t *testing.T) {
+	fs := sriovtest.NewSysfs(t)
+	pfPath := fs.AddPhysicalFunction(t, pfPCIAddr, vf1PCIAddr)
+	fs.AddDevice(t, vf1PCIAddr)
+
+	pf, err := pcifunction.NewPhysicalFunction(pfPCIAddr, fs.DevicesPath, fs.DriversPath)
+	require.NoError(t, err)
+
+	_, err = pf.GetVendorID()
+	require.ErrorIs(t, err, os.ErrNotExist)
+	_, err = pf.GetDeviceID()
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	require.NoError(t, os.WriteFile(filepath.Join(pfPath, "vendor"), []byte("0x8086\n"), filePerm))
+	require.NoError(t, os.WriteFile(filepath.Join(pfPath, "device"), []byte(" 0x1572 \n"), filePerm))
+
+	vendorID, err := pf.GetVendorID()
+	require.NoError(t, err)
+	require.Equal(t, "8086", vendorID)
+
+	deviceID, err := pf.GetDeviceID()
+	require.NoError(t, err)
+	require.Equal(t, "1572", deviceID)
+}
+
+func TestFunction_Reset(t *testing.T) {
+	fs := sriovtest.NewSysfs(t)
+	fs.AddPhysicalFunction(t, pfPCIAddr, vf1PCIAddr)
+	vfPath := fs.AddDevice(t, vf1PCIAddr)
+
+	pf, err := pcifunction.NewPhysicalFunction(pfPCIAddr, fs.DevicesPath, fs.DriversPath)
+	require.NoError(t, err)
+	vf := pf.GetVirtualFunctions()[0]
+
+	require.ErrorIs(t, vf.Reset(), pcifunction.ErrResetNotSupported)
+
+	require.NoError(t, os.WriteFile(filepath.Join(vfPath, "reset"), nil, filePerm))
+	require.NoError(t, vf.Reset())
+
+	data, err := os.ReadFile(filepath.Clean(filepath.Join(vfPath, "reset")))
+	require.NoError(t, err)
+	require.Equal(t, "1", string(data))
+}
+
+func TestFunction_GetIOMMUGroup(t *testing.T) {
+	fs := sriovtest.NewSysfs(t)
+	fs.AddPhysicalFunction(t, pfPCIAddr, vf1PCIAddr, vf2PCIAddr)
+	vf1Path := fs.AddDevice(t, vf1PCIAddr)
+	vf2Path := fs.AddDevice(t, vf2PCIAddr)
+
+	iommuGroupsPath := filepath.Join(filepath.Dir(fs.DevicesPath), "iommu_groups")
+	for _, iommuGroup := range []string{"0", "group-1"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(iommuGroupsPath, iommuGroup), mkdirPerm))
+	}
+	require.NoError(t, os.Symlink(filepath.Join(iommuGroupsPath, "0"), filepath.Join(vf1Path, "iommu_group")))
+	require.NoError(t, os.Symlink(filepath.Join(iommuGroupsPath, "group-1"), filepath.Join(vf2Path, "iommu_group")))
+
+	pf, err := pcifunction.NewPhysicalFunction(pfPCIAddr, fs.DevicesPath, fs.DriversPath)
+	require.NoError(t, err)
+
+	iommuGroup, err := pf.GetVirtualFunctions()[0].GetIOMMUGroup()
+	require.NoError(t, err)
+	require.Equal(t, uint(0), iommuGroup)
+
+	_, err = pf.GetVirtualFunctions()[1].GetIOMMUGroup()
+	require.Error(t, err)
+}
+
+func TestFunction_GetNUMANode(t *testing.T) {
+	fs := sriovtest.NewSysfs(t)
+	pfPath := fs.AddPhysicalFunction(t, pfPCIAddr, vf1PCIAddr)
+	fs.AddDevice(t, vf1PCIAddr)
+
+	pf, err := pcifunction.NewPhysicalFunction(pfPCIAddr, fs.DevicesPath, fs.DriversPath)
+	require.NoError(t, err)
+
+	_, err = pf.GetNUMANode()
+	require.Error(t, err)
+
+	require.NoError(t, os.WriteFile(filepath.Join(pfPath, "numa_node"), []byte("-1\n"), filePerm))
+
+	numaNode, err := pf.GetNUMANode()
+	require.NoError(t, err)
+	require.Equal(t, -1, numaNode)
+
+	require.NoError(t, os.WriteFile(filepath.Join(pfPath, "numa_node"), []byte("1\n"), filePerm))
+
+	numaNode, err = pf.GetNUMANode()
+	require.NoError(t, err)
+	require.Equal(t, 1, numaNode)
+}
+
+func TestToBDFAddress(t *testing.T) {
+	bdfAddr, err := pcifunction.ToBDFAddress("01:00.0")
+	require.NoError(t, err)
+	require.Equal(t, "0000:01:00.0", bdfAddr)
+
+	bdfAddr, err = pcifunction.ToBDFAddress("0000:01:00.1")
+	require.NoError(t, err)
+	require.Equal(t, "0000:01:00.1", bdfAddr)
+
+	_, err = pcifunction.ToBDFAddress("0000:01:00.8")
+	require.Error(t, err)
+}
+
+func TestDestroyVirtualFunctions(t *testing.T) {
+	fs := sriovtest.NewSysfs(t)
+	pfPath := fs.AddPhysicalFunction(t, pfPCIAddr, vf1PCIAddr)
+	fs.AddDevice(t, vf1PCIAddr)
+
+	require.NoError(t, pcifunction.DestroyVirtualFunctions(context.Background(), pfPCIAddr, fs.DevicesPath))
+
+	numVFs, err := os.ReadFile(filepath.Join(pfPath, "sriov_numvfs"))
+	require.NoError(t, err)
+	require.Equal(t, "0", string(numVFs))
+
+	// No VFs configured
+	require.NoError(t, pcifunction.DestroyVirtualFunctions(context.Background(), pfPCIAddr, fs.DevicesPath))
+}
+
+func TestDestroyVirtualFunctions_NotSRIOVCapable(t *testing.T) {
+	fs := sriovtest.NewSysfs(t)
+	fs.AddDevice(t, pfPCIAddr)
+
+	err := pcifunction.DestroyVirtualFunctions(context.Background(), pfPCIAddr, fs.DevicesPath)
+	require.ErrorIs(t, err, pcifunction.ErrNotSRIOVCapable)
+
+	require.Error(t, pcifunction.DestroyVirtualFunctions(context.Background(), "invalid", fs.DevicesPath))
+	require.Error(t, pcifunction.DestroyVirtualFunctions(context.Background(), vf1PCIAddr, fs.DevicesPath))
+}
+
+func TestReconfigureVirtualFunctions(t *testing.T) {
+	fs := sriovtest.NewSysfs(t)
+	pfPath := fs.AddPhysicalFunction(t, pfPCIAddr, vf1PCIAddr, vf2PCIAddr)
+	fs.AddDevice(t, vf1PCIAddr)
+	fs.AddDevice(t, vf2PCIAddr)
+	require.NoError(t, os.WriteFile(filepath.Join(pfPath, "sriov_totalvfs"), []byte("4"), filePerm))
+
+	readNumVFs := func() string {
+		numVFs, err := os.ReadFile(filepath.Join(pfPath, "sriov_numvfs"))
+		require.NoError(t, err)
+		return string(numVFs)
+	}
+
+	// Same number of VFs
+	require.NoError(t, pcifunction.ReconfigureVirtualFunctions(context.Background(), pfPCIAddr, fs.DevicesPath, 2))
+	require.Equal(t, "2", readNumVFs())
+
+	// Capacity exceeded
+	err := pcifunction.ReconfigureVirtualFunctions(context.Background(), pfPCIAddr, fs.DevicesPath, 5)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), pfPCIAddr)
+	require.Equal(t, "2", readNumVFs())
+
+	// VFs are not removed
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	err = pcifunction.ReconfigureVirtualFunctions(ctx, pfPCIAddr, fs.DevicesPath, 3)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Equal(t, "0", readNumVFs())
+
+	// VFs are removed
+	require.NoError(t, os.WriteFile(filepath.Join(pfPath, "sriov_numvfs"), []byte("2"), filePerm))
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		assert.NoError(t, os.Remove(filepath.Join(pfPath, "virtfn0")))
+		assert.NoError(t, os.Remove(filepath.Join(pfPath, "virtfn1")))
+	}()
+
+	require.NoError(t, pcifunction.ReconfigureVirtualFunctions(context.Background(), pfPCIAddr, fs.DevicesPath, 3))
+	require.Equal(t, "3", readNumVFs())
+}
+
+func TestGetPhysicalFunctionAddress(t *testing.T) {
+	fs := sriovtest.NewSysfs(t)
+	fs.AddPhysicalFunction(t, pfPCIAddr, vf1PCIAddr)
+	vfPath := fs.AddDevice(t, vf1PCIAddr)
+	require.NoError(t, os.Symlink(filepath.Join("..", pfPCIAddr), filepath.Join(vfPath, "physfn")))
+
+	pfAddr, err := pcifunction.GetPhysicalFunctionAddress(vf1PCIAddr, fs.DevicesPath)
+	require.NoError(t, err)
+	require.Equal(t, pfPCIAddr, pfAddr)
+
+	pfAddr, err = pcifunction.GetPhysicalFunctionAddress("01:00.1", fs.DevicesPath)
+	require.NoError(t, err)
+	require.Equal(t, pfPCIAddr, pfAddr)
+
+	_, err = pcifunction.GetPhysicalFunctionAddress(pfPCIAddr, fs.DevicesPath)
+	require.ErrorIs(t, err, pcifunction.ErrNotVirtualFunction)
+
+	_, err = pcifunction.GetPhysicalFunctionAddress(vf2PCIAddr, fs.DevicesPath)
+	require.Error(t, err)
+
+	pf, err := pcifunction.NewPhysicalFunction(pfPCIAddr, fs.DevicesPath, fs.DriversPath)
+	require.NoError(t, err)
+	require.False(t, pf.IsVirtualFunction())
+	require.True(t, pf.GetVirtualFunctions()[0].IsVirtualFunction())
+}
@@ -44,6 +44,24 @@ func readUintFromFile(path string) (uint, error) {
 	return uint(value), nil
 }
 
+func readStringFromFile(path string) (string, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return "", errors.Wrapf(err, "unable to locate file: %v", path)
+	}
+
+	return strings.TrimSpace(string(data)), nil
+}
+
+func readIDFromFile(path string) (string, error) {
+	id, err := readStringFromFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimPrefix(id, "0x"), nil
+}
+
 func evalSymlinkAndGetBaseName(path string) (string, error) {
 	fileInfo, err := os.Lstat(path)
 	if err != nil {
@@ -0,0 +1,94 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov"
+)
+
+// ErrInvalidAllocation is returned by Select when the Allocator returns a VF that is not one of the candidates
+var ErrInvalidAllocation = errors.New("allocated VF is not a candidate")
+
+// AllocRequest is a request to choose a VF for the token
+type AllocRequest struct {
+	TokenID    string
+	TokenName  string
+	DriverType sriov.DriverType
+	// Candidates are PCI addresses of the free VFs matching the token name and the driver type, in the Pool
+	// preference order
+	Candidates []string
+}
+
+// Allocator chooses a VF from the candidates, it makes possible to delegate the choice to an external scheduler
+type Allocator interface {
+	Allocate(ctx context.Context, req AllocRequest) (vfPCIAddr string, err error)
+}
+
+// localAllocator is a default Allocator choosing the most preferred candidate
+type localAllocator struct{}
+
+func (localAllocator) Allocate(_ context.Context, req AllocRequest) (string, error) {
+	return req.Candidates[0], nil
+}
+
+// allocate calls the Allocator with all the candidate VFs. If the Pool lock is set with WithAllocator, it is released
+// while the Allocator is working, so the candidates are found again with refind after the lock is taken back.
+func (p *Pool) allocate(
+	ctx context.Context,
+	tokenID, tokenName string,
+	driverType sriov.DriverType,
+	vfs []*virtualFunction,
+	refind func() ([]*virtualFunction, error),
+) (*virtualFunction, error) {
+	req := AllocRequest{
+		TokenID:    tokenID,
+		TokenName:  tokenName,
+		DriverType: driverType,
+	}
+	for _, vf := range vfs {
+		req.Candidates = append(req.Candidates, vf.pciAddr)
+	}
+
+	vfPCIAddr, err := p.allocateUnlocked(ctx, req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to allocate VF for the token: %v", tokenID)
+	}
+
+	if p.allocatorLock != nil {
+		if vfs, err = refind(); err != nil {
+			return nil, errors.Wrapf(err, "failed to allocate VF for the token: %v", tokenID)
+		}
+	}
+	for _, vf := range vfs {
+		if vf.pciAddr == vfPCIAddr {
+			return vf, nil
+		}
+	}
+	return nil, errors.Wrapf(ErrInvalidAllocation, "%v", vfPCIAddr)
+}
+
+func (p *Pool) allocateUnlocked(ctx context.Context, req AllocRequest) (string, error) {
+	if p.allocatorLock != nil {
+		p.allocatorLock.Unlock()
+		defer p.allocatorLock.Lock()
+	}
+	return p.allocator.Allocate(ctx, req)
+}
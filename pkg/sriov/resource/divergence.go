@@ -0,0 +1,69 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ErrPoolDivergence is returned when the token pool and the resource pool are built from the diverged configs
+var ErrPoolDivergence = errors.New("token pool diverges from the resource pool")
+
+// CheckTokenNames compares token names served by the token pool with token names served by the physical functions,
+// returns ErrPoolDivergence listing names missing on either side
+func (p *Pool) CheckTokenNames(tokenNames []string) error {
+	poolNames := map[string]struct{}{}
+	for _, pf := range p.physicalFunctions {
+		for tokenName := range pf.tokenNames {
+			poolNames[tokenName] = struct{}{}
+		}
+	}
+
+	var noPF []string
+	for _, tokenName := range tokenNames {
+		if _, ok := poolNames[tokenName]; !ok {
+			noPF = append(noPF, tokenName)
+		}
+		delete(poolNames, tokenName)
+	}
+
+	var noTokens []string
+	for tokenName := range poolNames {
+		noTokens = append(noTokens, tokenName)
+	}
+
+	if len(noPF) == 0 && len(noTokens) == 0 {
+		return nil
+	}
+
+	sort.Strings(noPF)
+	sort.Strings(noTokens)
+	return errors.Wrapf(ErrPoolDivergence, "token names with no PF: [%v], PF token names with no tokens: [%v]",
+		strings.Join(noPF, ", "), strings.Join(noTokens, ", "))
+}
+
+func (p *Pool) isTokenNameServed(tokenName string) bool {
+	for _, pf := range p.physicalFunctions {
+		if _, ok := pf.tokenNames[tokenName]; ok {
+			return true
+		}
+	}
+	return false
+}
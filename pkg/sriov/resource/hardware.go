@@ -0,0 +1,70 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"context"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov"
+)
+
+// ErrHardwareDivergence is returned by CheckHardware when the selected VFs don't match the host state
+var ErrHardwareDivergence = errors.New("selected VFs diverge from the hardware state")
+
+// CheckHardware checks that all the VFs assigned on the host are selected in the pool, returns ErrHardwareDivergence
+// for the first diverged VF. Host state is classified with sriov.GetHardwareVFState: selected VFs can still look free
+// on the host (e.g. kernel VF is not yet moved to the client net namespace) and the free VFs can stay bound to the
+// vfio-pci driver, so only the VFs surely assigned on the host are compared with the pool state.
+func (p *Pool) CheckHardware(ctx context.Context, provider sriov.HardwareProvider) error {
+	var pfPCIAddrs []string
+	for pfPCIAddr := range p.physicalFunctions {
+		pfPCIAddrs = append(pfPCIAddrs, pfPCIAddr)
+	}
+	sort.Strings(pfPCIAddrs)
+
+	for _, pfPCIAddr := range pfPCIAddrs {
+		hwVFs, kernelDriver, err := provider.GetVirtualFunctions(pfPCIAddr)
+		if err != nil {
+			return err
+		}
+
+		for _, hwVF := range hwVFs {
+			if err = ctx.Err(); err != nil {
+				return errors.Wrapf(err, "failed to check hardware for the PF: %v", pfPCIAddr)
+			}
+
+			vf, ok := p.virtualFunctions[hwVF.GetPCIAddress()]
+			if !ok || vf.tokenID != "" {
+				continue
+			}
+
+			state, stateErr := sriov.GetHardwareVFState(hwVF, kernelDriver)
+			if stateErr != nil {
+				return stateErr
+			}
+			if state == sriov.HardwareVFAssigned {
+				return errors.Wrapf(ErrHardwareDivergence, "PF %v: VF %v is assigned on the host, but not selected",
+					pfPCIAddr, vf.pciAddr)
+			}
+		}
+	}
+
+	return nil
+}
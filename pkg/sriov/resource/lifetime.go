@@ -0,0 +1,78 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ExpireFunc is called when the VF selection outlives the max VF lifetime, the connection using the token should be
+// gracefully closed and requested again to let the Pool rebalance it
+type ExpireFunc func(tokenID, vfPCIAddr string)
+
+type vfLifetime struct {
+	ctx      context.Context
+	duration time.Duration
+	lock     sync.Locker
+	onExpire ExpireFunc
+}
+
+// IsExpired returns true if the selected virtual function has outlived the max VF lifetime
+func (p *Pool) IsExpired(vfPCIAddr string) (bool, error) {
+	vf, ok := p.virtualFunctions[vfPCIAddr]
+	if !ok {
+		return false, errors.Errorf("VF doesn't exist: %v", vfPCIAddr)
+	}
+	return vf.expired, nil
+}
+
+// startExpiryTimer (re)starts the VF expiry timer for the rest of the max VF lifetime counted from vf.selectedAt, so the
+// token lifetime is not reset by moving it to another VF
+func (p *Pool) startExpiryTimer(vf *virtualFunction) {
+	if p.lifetime == nil {
+		return
+	}
+	p.stopExpiryTimer(vf)
+
+	tokenID := vf.tokenID
+	var timer *time.Timer
+	timer = time.AfterFunc(p.lifetime.duration-time.Since(vf.selectedAt), func() {
+		p.lifetime.lock.Lock()
+		if p.lifetime.ctx.Err() != nil || vf.expiryTimer != timer || vf.tokenID != tokenID {
+			p.lifetime.lock.Unlock()
+			return
+		}
+		vf.expired = true
+		p.lifetime.lock.Unlock()
+
+		p.lifetime.onExpire(tokenID, vf.pciAddr)
+	})
+	vf.expiryTimer = timer
+	vf.expired = false
+}
+
+func (p *Pool) stopExpiryTimer(vf *virtualFunction) {
+	if vf.expiryTimer != nil {
+		vf.expiryTimer.Stop()
+		vf.expiryTimer = nil
+	}
+	vf.expired = false
+}
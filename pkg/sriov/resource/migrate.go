@@ -0,0 +1,95 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// Migrate moves the token to a free virtual function of the target PF keeping its driver type, QoS class and MAC
+// address, the old virtual function is freed only after the new one is selected. Returns the new virtual function PCI
+// address, on failure the token is kept selected on the old virtual function
+func (p *Pool) Migrate(tokenID, targetPFPCIAddr string) (string, error) {
+	vf, ok := p.tokens[tokenID]
+	if !ok {
+		return "", errors.Errorf("no VF is selected for the token: %v", tokenID)
+	}
+	if vf.reserved {
+		return "", errors.Errorf("token has reserved VF, it should be committed or cancelled first: %v", vf.pciAddr)
+	}
+	if _, ok := p.physicalFunctions[targetPFPCIAddr]; !ok {
+		return "", errors.Errorf("PF doesn't exist: %v", targetPFPCIAddr)
+	}
+	if vf.pfPCIAddr == targetPFPCIAddr {
+		return vf.pciAddr, nil
+	}
+
+	target, err := p.findMigrationTarget(tokenID, vf, targetPFPCIAddr)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to migrate the token %v to the PF: %v", tokenID, targetPFPCIAddr)
+	}
+
+	// token is moved in the token pool to the target PF token names, so it closes the right tokens
+	if err := p.tokenPool.StopUsing(tokenID); err != nil {
+		return "", errors.Wrapf(err, "failed to migrate the token %v to the PF: %v", tokenID, targetPFPCIAddr)
+	}
+	if err := p.tokenPool.Use(tokenID, p.pfTokenNames(target)); err != nil {
+		if restoreErr := p.tokenPool.Use(tokenID, p.pfTokenNames(vf)); restoreErr != nil {
+			return "", errors.Wrapf(err, "failed to restore the token %v on the VF %v: %v", tokenID, vf.pciAddr, restoreErr)
+		}
+		return "", errors.Wrapf(err, "failed to migrate the token %v to the PF: %v", tokenID, targetPFPCIAddr)
+	}
+
+	p.tokens[tokenID] = target
+	target.tokenID = tokenID
+	target.driverType = vf.driverType
+	target.hardwareAddr = vf.hardwareAddr
+	target.selectedAt = vf.selectedAt
+
+	p.physicalFunctions[target.pfPCIAddr].freeVFsCount--
+	p.iommuGroups[target.iommuGroup] = target.driverType
+	p.startExpiryTimer(target)
+
+	p.release(vf)
+
+	return target.pciAddr, nil
+}
+
+func (p *Pool) findMigrationTarget(tokenID string, vf *virtualFunction, targetPFPCIAddr string) (*virtualFunction, error) {
+	tokenName, err := p.tokenPool.Find(tokenID)
+	if err != nil {
+		return nil, err
+	}
+
+	// only the target PF VFs can be selected
+	excluded := map[string]struct{}{}
+	for vfPCIAddr, otherVF := range p.virtualFunctions {
+		if otherVF.pfPCIAddr != targetPFPCIAddr {
+			excluded[vfPCIAddr] = struct{}{}
+		}
+	}
+
+	vfs, selectErr := p.find(vf.driverType, tokenName, p.tokenQoSClasses[tokenName], excluded)
+	if selectErr != nil {
+		return nil, errors.WithStack(selectErr)
+	}
+	sort.Slice(vfs, p.selectionOrder(vfs, vf.driverType))
+
+	return vfs[0], nil
+}
@@ -0,0 +1,83 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov"
+)
+
+// WriteOpenMetrics writes the current Pool state to w in OpenMetrics text format, it is suitable for the node_exporter
+// textfile collector
+func (p *Pool) WriteOpenMetrics(w io.Writer) error {
+	state := p.State()
+
+	var pfPCIAddrs []string
+	for pfPCIAddr := range state.PhysicalFunctions {
+		pfPCIAddrs = append(pfPCIAddrs, pfPCIAddr)
+	}
+	sort.Strings(pfPCIAddrs)
+
+	bw := bufio.NewWriter(w)
+
+	writeMetricHeader(bw, "sriov_pf_numa_node", "PF NUMA node, -1 if unknown")
+	for _, pfPCIAddr := range pfPCIAddrs {
+		_, _ = fmt.Fprintf(bw, "sriov_pf_numa_node{pf=%q} %d\n", pfPCIAddr, state.PhysicalFunctions[pfPCIAddr].NUMANode)
+	}
+
+	writeMetricHeader(bw, "sriov_pf_free_vfs", "Number of not selected PF VFs")
+	for _, pfPCIAddr := range pfPCIAddrs {
+		_, _ = fmt.Fprintf(bw, "sriov_pf_free_vfs{pf=%q} %d\n", pfPCIAddr, state.PhysicalFunctions[pfPCIAddr].FreeVFsCount)
+	}
+
+	writeMetricHeader(bw, "sriov_vf_selected", "1 if VF is selected, 0 otherwise")
+	for _, pfPCIAddr := range pfPCIAddrs {
+		for _, vf := range state.PhysicalFunctions[pfPCIAddr].VirtualFunctions {
+			selected := 0
+			if vf.TokenID != "" {
+				selected = 1
+			}
+			_, _ = fmt.Fprintf(bw, "sriov_vf_selected{pf=%q,vf=%q,iommu_group=\"%d\"} %d\n",
+				pfPCIAddr, vf.PCIAddr, vf.IOMMUGroup, selected)
+		}
+	}
+
+	writeMetricHeader(bw, "sriov_vf_driver", "1 for the VF driver type")
+	for _, pfPCIAddr := range pfPCIAddrs {
+		for _, vf := range state.PhysicalFunctions[pfPCIAddr].VirtualFunctions {
+			driverType := vf.DriverType
+			if driverType == "" {
+				driverType = sriov.NoDriver
+			}
+			_, _ = fmt.Fprintf(bw, "sriov_vf_driver{pf=%q,vf=%q,driver=%q} 1\n", pfPCIAddr, vf.PCIAddr, driverType)
+		}
+	}
+
+	_, _ = bw.WriteString("# EOF\n")
+
+	return errors.Wrap(bw.Flush(), "failed to write OpenMetrics")
+}
+
+func writeMetricHeader(w io.Writer, name, help string) {
+	_, _ = fmt.Fprintf(w, "# TYPE %s gauge\n# HELP %s %s.\n", name, name, help)
+}
@@ -0,0 +1,126 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov"
+)
+
+// Option is an option for NewPool
+type Option func(p *Pool)
+
+// WithOrderedSelection makes Pool select VFs in ascending PF PCI address and VF index order instead of balancing them
+// between PFs, so the same sequence of selections always results in the same VFs
+func WithOrderedSelection() Option {
+	return func(p *Pool) {
+		p.orderedSelection = true
+	}
+}
+
+// WithWeightedSelection makes Pool randomly choose a PF for each selection with probability proportional to the PF
+// free VFs count instead of always choosing the PF with the most free VFs, if rnd is nil a time seeded one is used
+func WithWeightedSelection(rnd *rand.Rand) Option {
+	return func(p *Pool) {
+		if rnd == nil {
+			rnd = rand.New(rand.NewSource(time.Now().UnixNano()))
+		}
+		p.weightedRand = rnd
+	}
+}
+
+// WithMACPool makes Pool allocate MAC address from the MAC pool for each selected VF and release it on free, MAC
+// address is allocated for the token, so it is kept when the token is moved to another VF
+func WithMACPool(macPool MACPool) Option {
+	return func(p *Pool) {
+		p.macPool = macPool
+	}
+}
+
+// WithStateFile makes Pool write its state to the file every interval until the ctx is done, lock should be the
+// lock used to synchronize the Pool
+func WithStateFile(ctx context.Context, path string, interval time.Duration, lock sync.Locker) Option {
+	return func(p *Pool) {
+		p.stateFileWriter = &stateFileWriter{
+			ctx:      ctx,
+			path:     path,
+			interval: interval,
+			lock:     lock,
+		}
+		p.setLock(lock)
+	}
+}
+
+// WithMaxVFLifetime makes Pool mark VF selections older than lifetime as expired and call onExpire for them until the
+// ctx is done, lock should be the lock used to synchronize the Pool
+func WithMaxVFLifetime(ctx context.Context, lifetime time.Duration, lock sync.Locker, onExpire ExpireFunc) Option {
+	return func(p *Pool) {
+		p.lifetime = &vfLifetime{
+			ctx:      ctx,
+			duration: lifetime,
+			lock:     lock,
+			onExpire: onExpire,
+		}
+		p.setLock(lock)
+	}
+}
+
+// WithAllocator makes Pool delegate the choice of the VF from the candidates to the allocator, e.g. a client of an
+// external scheduler. If lock is not nil, it should be the lock used to synchronize the Pool, it is released while the
+// allocator is working.
+func WithAllocator(allocator Allocator, lock sync.Locker) Option {
+	return func(p *Pool) {
+		p.allocator = allocator
+		p.allocatorLock = lock
+		p.setLock(lock)
+	}
+}
+
+// WithPreBoundIOMMUGroups makes Pool start with the given IOMMU groups already bound to the given driver types, e.g.
+// for devices pre-bound on the host, so only VFs for the same driver type can be selected from them. The groups are
+// kept bound to the given driver types after all their VFs are freed.
+func WithPreBoundIOMMUGroups(iommuGroups map[uint]sriov.DriverType) Option {
+	return func(p *Pool) {
+		for iommuGroup, driverType := range iommuGroups {
+			p.iommuGroups[iommuGroup] = driverType
+			p.preBoundGroups[iommuGroup] = driverType
+		}
+	}
+}
+
+// WithReleaseListener makes Pool call onRelease in a separate goroutine without the Pool lock held every time a VF
+// gets free, e.g. on Free, Cancel or migration, so the Requests waiting for a free VF can be served
+func WithReleaseListener(onRelease func()) Option {
+	return func(p *Pool) {
+		p.onRelease = onRelease
+	}
+}
+
+// WithPreemption makes Pool preempt a VF reserved by an allocated, but not yet used token of a lower priority service
+// domain if there is no free VF for Select/Reserve of a token of a higher priority service domain, onPreempt is called
+// for the preempted token in a separate goroutine without the Pool lock held. Select/Reserve fails with the AllInUse
+// SelectError until the preempted VF is released, its token is freed in the token pool on the release.
+func WithPreemption(onPreempt PreemptFunc) Option {
+	return func(p *Pool) {
+		p.preemption = true
+		p.onPreempt = onPreempt
+	}
+}
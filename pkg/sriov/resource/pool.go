@@ -18,21 +18,44 @@
 package resource
 
 import (
-	"path"
+	"context"
+	"math/rand"
+	"net"
 	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/pkg/errors"
 
 	"github.com/networkservicemesh/sdk-sriov/pkg/sriov"
 	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/config"
+	"github.com/networkservicemesh/sdk-sriov/pkg/tools/tokens"
 )
 
+var (
+	// ErrNodeCapacity is returned by Select when node-wide VF capacity is exhausted
+	ErrNodeCapacity = errors.New("node-wide VF capacity is exhausted")
+	// ErrNoFreeVF is returned by Select when there is no free VF for the token and driver type, use SelectError to
+	// get the reason
+	ErrNoFreeVF = errors.New("no free VF")
+	// ErrPreempted is returned by Commit when the reserved VF is preempted for a token of a higher priority service
+	// domain
+	ErrPreempted = errors.New("VF reservation is preempted")
+)
+
+// MACPool is a sriov.MACPool interface
+type MACPool interface {
+	Allocate(key string) (net.HardwareAddr, error)
+	Release(key string) error
+}
+
 // TokenPool is a token.Pool interface
 type TokenPool interface {
 	Find(id string) (string, error)
 	Use(id string, names []string) error
 	StopUsing(id string) error
+	Free(id string) error
 }
 
 // Pool manages host SR-IOV state
@@ -42,82 +65,327 @@ type Pool struct {
 	virtualFunctions  map[string]*virtualFunction
 	tokens            map[string]*virtualFunction
 	iommuGroups       map[uint]sriov.DriverType
+	preBoundGroups    map[uint]sriov.DriverType
+	iommuGroupVFs     map[uint][]*virtualFunction
 	tokenPool         TokenPool
+	maxAllocatableVFs int
+	orderedSelection  bool
+	weightedRand      *rand.Rand
+	macPool           MACPool
+	stateFileWriter   *stateFileWriter
+	lifetime          *vfLifetime
+	allocator         Allocator
+	allocatorLock     sync.Locker
+	lock              sync.Locker
+	tokenQoSClasses   map[string]string // tokenName -> QoS class
+	priorities        map[string]int    // serviceDomain -> priority
+	preemption        bool
+	onPreempt         PreemptFunc
+	onRelease         func()
 }
 
 type physicalFunction struct {
+	numaNode         int
 	tokenNames       map[string]struct{}
 	virtualFunctions map[uint][]*virtualFunction
 	freeVFsCount     int
 }
 
 type virtualFunction struct {
-	pciAddr    string
-	pfPCIAddr  string
-	iommuGroup uint
-	tokenID    string
+	pciAddr      string
+	pfPCIAddr    string
+	index        int
+	iommuGroup   uint
+	tokenID      string
+	driverType   sriov.DriverType
+	hardwareAddr net.HardwareAddr
+	selectedAt   time.Time
+	expiryTimer  *time.Timer
+	expired      bool
+	qosClass     string
+	reserved     bool
+	preemptedFor string
 }
 
 // NewPool returns a new Pool
-func NewPool(tokenPool TokenPool, cfg *config.Config) *Pool {
+func NewPool(tokenPool TokenPool, cfg *config.Config, options ...Option) *Pool {
 	p := &Pool{
 		physicalFunctions: map[string]*physicalFunction{},
 		virtualFunctions:  map[string]*virtualFunction{},
 		tokens:            map[string]*virtualFunction{},
 		iommuGroups:       map[uint]sriov.DriverType{},
+		preBoundGroups:    map[uint]sriov.DriverType{},
+		iommuGroupVFs:     map[uint][]*virtualFunction{},
 		tokenPool:         tokenPool,
+		maxAllocatableVFs: int(cfg.MaxAllocatableVFs),
+		allocator:         localAllocator{},
+		tokenQoSClasses:   map[string]string{},
+		priorities:        map[string]int{},
+	}
+
+	for _, option := range options {
+		option(p)
+	}
+
+	for serviceDomain, sdCfg := range cfg.ServiceDomains {
+		p.priorities[serviceDomain] = sdCfg.Priority
 	}
 
 	for pfPCIAddr, pFun := range cfg.PhysicalFunctions {
 		pf := &physicalFunction{
+			numaNode:         pFun.GetNUMANode(),
 			tokenNames:       map[string]struct{}{},
 			virtualFunctions: map[uint][]*virtualFunction{},
 			freeVFsCount:     len(pFun.VirtualFunctions),
 		}
 		p.physicalFunctions[pfPCIAddr] = pf
 
-		for _, serviceDomain := range pFun.ServiceDomains {
-			for _, capability := range pFun.Capabilities {
-				pf.tokenNames[path.Join(serviceDomain, capability)] = struct{}{}
+		// token names are partitioned by the QoS class the same way as in the token pool
+		qosClasses := map[string]struct{}{}
+		for _, vFun := range pFun.VirtualFunctions {
+			qosClasses[vFun.QoSClass] = struct{}{}
+		}
+		// PF serves only the service domains allowed on its NUMA node the same way as in the token pool
+		for _, name := range tokens.Names(cfg.AllowedServiceDomains(pFun), pFun.Capabilities) {
+			for qosClass := range qosClasses {
+				tokenName := tokens.QoSClassName(name, qosClass)
+				pf.tokenNames[tokenName] = struct{}{}
+				p.tokenQoSClasses[tokenName] = qosClass
 			}
 		}
 
-		for _, vFun := range pFun.VirtualFunctions {
+		for i, vFun := range pFun.VirtualFunctions {
 			vf := &virtualFunction{
 				pciAddr:    vFun.Address,
 				pfPCIAddr:  pfPCIAddr,
+				index:      i,
 				iommuGroup: vFun.IOMMUGroup,
+				driverType: sriov.NoDriver,
+				qosClass:   vFun.QoSClass,
 			}
 			p.virtualFunctions[vFun.Address] = vf
 
 			pf.virtualFunctions[vFun.IOMMUGroup] = append(pf.virtualFunctions[vFun.IOMMUGroup], vf)
-			p.iommuGroups[vFun.IOMMUGroup] = sriov.NoDriver
+			// IOMMU group can be shared by VFs of the same PF, keep the driver type if it is already set
+			if _, ok := p.iommuGroups[vFun.IOMMUGroup]; !ok {
+				p.iommuGroups[vFun.IOMMUGroup] = sriov.NoDriver
+			}
+			// IOMMU group can contain VFs of different PFs
+			p.iommuGroupVFs[vFun.IOMMUGroup] = append(p.iommuGroupVFs[vFun.IOMMUGroup], vf)
 		}
 	}
 
+	if p.stateFileWriter != nil {
+		go p.runStateFileWriter(p.stateFileWriter)
+	}
+
 	return p
 }
 
-// Select selects a virtual function for the given driver type and marks it as "in-use"
+// Select selects a virtual function of the token QoS class for the given driver type and marks it as "in-use", if
+// the token has already selected a virtual function for another driver type, it is replaced with a virtual function
+// for the given driver type or kept selected on failure
 func (p *Pool) Select(tokenID string, driverType sriov.DriverType) (string, error) {
-	switch vf, err := p.trySelected(tokenID, driverType); {
-	case err != nil:
+	return p.SelectExcluding(context.Background(), tokenID, driverType)
+}
+
+// SelectExcluding is the same as Select, but it never selects any of the excluded virtual functions, e.g. the ones
+// that have already failed to get bound to the driver, ctx is passed to the Allocator
+func (p *Pool) SelectExcluding(ctx context.Context, tokenID string, driverType sriov.DriverType, excludedVFs ...string) (string, error) {
+	excluded := map[string]struct{}{}
+	for _, vfPCIAddr := range excludedVFs {
+		excluded[vfPCIAddr] = struct{}{}
+	}
+
+	if vf, ok := p.tokens[tokenID]; ok {
+		if vf.reserved {
+			return "", errors.Errorf("token has reserved VF, it should be committed or cancelled first: %v", vf.pciAddr)
+		}
+		if _, isExcluded := excluded[vf.pciAddr]; !isExcluded && vf.driverType == driverType {
+			return vf.pciAddr, nil
+		}
+		return p.reselect(ctx, vf, driverType, excluded)
+	}
+	return p.selectFree(ctx, tokenID, driverType, excluded, false)
+}
+
+// Reserve selects a virtual function of the token QoS class for the given driver type and marks it as "reserved", it
+// is not selectable by the other tokens, but the token is not used in the token pool until Commit is called. Cancel
+// should be called to release the virtual function if it is not going to be committed.
+func (p *Pool) Reserve(tokenID string, driverType sriov.DriverType) (string, error) {
+	return p.ReserveExcluding(context.Background(), tokenID, driverType)
+}
+
+// ReserveExcluding is the same as Reserve, but it never reserves any of the excluded virtual functions, ctx is passed
+// to the Allocator
+func (p *Pool) ReserveExcluding(ctx context.Context, tokenID string, driverType sriov.DriverType, excludedVFs ...string) (string, error) {
+	if vf, ok := p.tokens[tokenID]; ok {
+		return "", errors.Errorf("token has already selected VF: %v", vf.pciAddr)
+	}
+
+	excluded := map[string]struct{}{}
+	for _, vfPCIAddr := range excludedVFs {
+		excluded[vfPCIAddr] = struct{}{}
+	}
+	return p.selectFree(ctx, tokenID, driverType, excluded, true)
+}
+
+// Commit marks the reserved virtual function as "in-use" and uses its token in the token pool, on failure the virtual
+// function is kept reserved
+func (p *Pool) Commit(vfPCIAddr string) error {
+	vf, err := p.findReserved(vfPCIAddr)
+	if err != nil {
+		return err
+	}
+	if vf.preemptedFor != "" {
+		return errors.Wrapf(ErrPreempted, "%v", vfPCIAddr)
+	}
+
+	if err := p.tokenPool.Use(vf.tokenID, p.pfTokenNames(vf)); err != nil {
+		return err
+	}
+	vf.reserved = false
+	vf.selectedAt = time.Now()
+
+	p.startExpiryTimer(vf)
+
+	return nil
+}
+
+// Cancel releases the reserved virtual function the same way as Free does for the selected one
+func (p *Pool) Cancel(vfPCIAddr string) error {
+	if _, err := p.findReserved(vfPCIAddr); err != nil {
+		return err
+	}
+	return p.Free(vfPCIAddr)
+}
+
+func (p *Pool) findReserved(vfPCIAddr string) (*virtualFunction, error) {
+	vf, ok := p.virtualFunctions[vfPCIAddr]
+	if !ok {
+		return nil, errors.Errorf("VF doesn't exist: %v", vfPCIAddr)
+	}
+	if !vf.reserved {
+		return nil, errors.Errorf("VF is not reserved: %v", vfPCIAddr)
+	}
+	return vf, nil
+}
+
+func (p *Pool) reselect(ctx context.Context, vf *virtualFunction, driverType sriov.DriverType, excluded map[string]struct{}) (string, error) {
+	tokenID, prevDriverType, selectedAt := vf.tokenID, vf.driverType, vf.selectedAt
+
+	// free the selected VF first to restore its IOMMU group and make it available for the new driver type
+	if err := p.Free(vf.pciAddr); err != nil {
+		return "", err
+	}
+
+	vfPCIAddr, err := p.selectFree(ctx, tokenID, driverType, excluded, false)
+	if err != nil {
+		if restoreErr := p.selectVF(vf, tokenID, prevDriverType, false); restoreErr != nil {
+			return "", errors.Wrapf(err, "failed to restore previously selected VF: %v", restoreErr)
+		}
+		p.keepSelectedAt(vf, selectedAt)
 		return "", err
-	case vf != nil:
-		return vf.pciAddr, nil
+	}
+	p.keepSelectedAt(p.virtualFunctions[vfPCIAddr], selectedAt)
+
+	return vfPCIAddr, nil
+}
+
+func (p *Pool) selectFree(ctx context.Context, tokenID string, driverType sriov.DriverType, excluded map[string]struct{}, reserve bool) (string, error) {
+	if p.maxAllocatableVFs > 0 && len(p.tokens) >= p.maxAllocatableVFs {
+		return "", errors.WithStack(&SelectError{
+			Reason:      NodeCapacity,
+			DriverType:  driverType,
+			SelectedVFs: len(p.tokens),
+		})
 	}
 
 	tokenName, err := p.tokenPool.Find(tokenID)
 	if err != nil {
 		return "", err
 	}
+	if !p.isTokenNameServed(tokenName) {
+		return "", errors.Wrapf(ErrPoolDivergence, "no PF serves the token name: %v", tokenName)
+	}
+	// QoS class is a part of the token name, so the token can be backed only by the VFs of its own class
+	qosClass := p.tokenQoSClasses[tokenName]
+
+	vfs, selectErr := p.find(driverType, tokenName, qosClass, excluded)
+	if selectErr != nil && selectErr.Reason != NoMatchingPF && p.preemption && p.preempt(tokenID, tokenName, driverType, qosClass, excluded) {
+		// preempted VF is in use until its connection releases it
+		selectErr.Reason = AllInUse
+	}
+	if selectErr != nil {
+		return "", errors.WithStack(selectErr)
+	}
+
+	sort.Slice(vfs, p.selectionOrder(vfs, driverType))
+	if p.weightedRand != nil {
+		p.weightedPFFirst(vfs)
+	}
+
+	vf, err := p.allocate(ctx, tokenID, tokenName, driverType, vfs, func() ([]*virtualFunction, error) {
+		if selected, ok := p.tokens[tokenID]; ok {
+			return nil, errors.Errorf("token has selected VF while allocating: %v", selected.pciAddr)
+		}
+		candidates, findErr := p.find(driverType, tokenName, qosClass, excluded)
+		if findErr != nil {
+			return nil, errors.WithStack(findErr)
+		}
+		return candidates, nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if err = p.selectVF(vf, tokenID, driverType, reserve); err != nil {
+		return "", err
+	}
+
+	return vf.pciAddr, nil
+}
+
+// weightedPFFirst randomly chooses a PF with probability proportional to its free VFs count and moves the candidate
+// VFs of the chosen PF to the front keeping the rest of the candidates order
+func (p *Pool) weightedPFFirst(vfs []*virtualFunction) {
+	var pfPCIAddrs []string
+	pfs := map[string]struct{}{}
+	for _, vf := range vfs {
+		if _, ok := pfs[vf.pfPCIAddr]; !ok {
+			pfPCIAddrs = append(pfPCIAddrs, vf.pfPCIAddr)
+			pfs[vf.pfPCIAddr] = struct{}{}
+		}
+	}
+	sort.Strings(pfPCIAddrs)
+
+	totalWeight := 0
+	for _, pfPCIAddr := range pfPCIAddrs {
+		totalWeight += p.physicalFunctions[pfPCIAddr].freeVFsCount
+	}
 
-	vfs := p.find(driverType, tokenName)
-	if len(vfs) == 0 {
-		return "", errors.Errorf("no free VF for the driver type: %v", driverType)
+	r := p.weightedRand.Intn(totalWeight)
+	for _, pfPCIAddr := range pfPCIAddrs {
+		if r -= p.physicalFunctions[pfPCIAddr].freeVFsCount; r < 0 {
+			sort.SliceStable(vfs, func(i, k int) bool {
+				return vfs[i].pfPCIAddr == pfPCIAddr && vfs[k].pfPCIAddr != pfPCIAddr
+			})
+			return
+		}
 	}
+}
 
-	sort.Slice(vfs, func(i, k int) bool {
+func (p *Pool) selectionOrder(vfs []*virtualFunction, driverType sriov.DriverType) func(i, k int) bool {
+	if p.orderedSelection {
+		return func(i, k int) bool {
+			if vfs[i].pfPCIAddr != vfs[k].pfPCIAddr {
+				return strings.Compare(vfs[i].pfPCIAddr, vfs[k].pfPCIAddr) < 0
+			}
+			return vfs[i].index < vfs[k].index
+		}
+	}
+
+	return func(i, k int) bool {
 		leftIG := p.iommuGroups[vfs[i].iommuGroup]
 		rightIG := p.iommuGroups[vfs[k].iommuGroup]
 		leftPF := p.physicalFunctions[vfs[i].pfPCIAddr]
@@ -135,61 +403,188 @@ func (p *Pool) Select(tokenID string, driverType sriov.DriverType) (string, erro
 			// we need this additional comparison to make sort deterministic
 			return strings.Compare(vfs[i].pciAddr, vfs[k].pciAddr) < 0
 		}
-	})
-
-	if err := p.selectVF(vfs[0], tokenID, driverType); err != nil {
-		return "", err
 	}
-
-	return vfs[0].pciAddr, nil
 }
 
-func (p *Pool) trySelected(tokenID string, driverType sriov.DriverType) (*virtualFunction, error) {
-	if vf, ok := p.tokens[tokenID]; ok {
-		if p.iommuGroups[vf.iommuGroup] != driverType {
-			return nil, p.Free(vf.pciAddr)
-		}
-		return vf, nil
+func (p *Pool) find(driverType sriov.DriverType, tokenName, qosClass string, excluded map[string]struct{}) ([]*virtualFunction, *SelectError) {
+	selectErr := &SelectError{
+		TokenName:  tokenName,
+		DriverType: driverType,
 	}
-	return nil, nil
-}
 
-func (p *Pool) find(driverType sriov.DriverType, tokenName string) []*virtualFunction {
 	var virtualFunctions []*virtualFunction
 	for _, pf := range p.physicalFunctions {
-		if _, ok := pf.tokenNames[tokenName]; ok {
-			for iommuGroup, vfs := range pf.virtualFunctions {
+		// pf.tokenNames contains all parent names for the hierarchical capabilities, so a request for a parent name
+		// matches more specific PF capabilities, but not vice versa
+		if _, ok := pf.tokenNames[tokenName]; !ok {
+			continue
+		}
+		selectErr.MatchingPFs++
+
+		for iommuGroup, vfs := range pf.virtualFunctions {
+			for _, vf := range vfs {
+				if vf.qosClass != qosClass {
+					continue
+				}
+				selectErr.MatchingVFs++
+
+				// excluded VFs are not available for the selection, so they are reported as in use
+				if _, ok := excluded[vf.pciAddr]; ok || vf.tokenID != "" {
+					continue
+				}
+				selectErr.FreeVFs++
+
 				if ig := p.iommuGroups[iommuGroup]; ig == sriov.NoDriver || ig == driverType {
-					for _, vf := range vfs {
-						if vf.tokenID == "" {
-							virtualFunctions = append(virtualFunctions, vf)
-						}
-					}
+					virtualFunctions = append(virtualFunctions, vf)
 				}
 			}
 		}
 	}
-	return virtualFunctions
+
+	switch {
+	case len(virtualFunctions) > 0:
+		return virtualFunctions, nil
+	case selectErr.MatchingPFs == 0:
+		selectErr.Reason = NoMatchingPF
+	case selectErr.FreeVFs == 0:
+		selectErr.Reason = AllInUse
+	default:
+		selectErr.Reason = DriverConflict
+	}
+	return nil, selectErr
 }
 
-func (p *Pool) selectVF(vf *virtualFunction, tokenID string, driverType sriov.DriverType) error {
-	var tokenNames []string
-	for tokenName := range p.physicalFunctions[vf.pfPCIAddr].tokenNames {
-		tokenNames = append(tokenNames, tokenName)
+// selectVF marks the VF as selected by the token, if reserve is set the token is not used in the token pool
+func (p *Pool) selectVF(vf *virtualFunction, tokenID string, driverType sriov.DriverType, reserve bool) error {
+	var hardwareAddr net.HardwareAddr
+	if p.macPool != nil {
+		var err error
+		if hardwareAddr, err = p.macPool.Allocate(tokenID); err != nil {
+			return err
+		}
 	}
-	if err := p.tokenPool.Use(tokenID, tokenNames); err != nil {
-		return err
+
+	if !reserve {
+		if err := p.tokenPool.Use(tokenID, p.pfTokenNames(vf)); err != nil {
+			if p.macPool != nil {
+				_ = p.macPool.Release(tokenID)
+			}
+			return err
+		}
 	}
 
 	p.tokens[tokenID] = vf
+	vf.hardwareAddr = hardwareAddr
 	vf.tokenID = tokenID
+	vf.driverType = driverType
+	vf.reserved = reserve
+	vf.selectedAt = time.Now()
 
 	p.physicalFunctions[vf.pfPCIAddr].freeVFsCount--
 	p.iommuGroups[vf.iommuGroup] = driverType
 
+	// reserved VF lifetime starts on Commit
+	if !reserve {
+		p.startExpiryTimer(vf)
+	}
+
 	return nil
 }
 
+// keepSelectedAt makes the VF selected by the token on reselect keep the token selection time, so the token VF
+// lifetime is not reset by the driver type change or migration
+func (p *Pool) keepSelectedAt(vf *virtualFunction, selectedAt time.Time) {
+	vf.selectedAt = selectedAt
+	p.startExpiryTimer(vf)
+}
+
+func (p *Pool) pfTokenNames(vf *virtualFunction) []string {
+	var tokenNames []string
+	for tokenName := range p.physicalFunctions[vf.pfPCIAddr].tokenNames {
+		if p.tokenQoSClasses[tokenName] == vf.qosClass {
+			tokenNames = append(tokenNames, tokenName)
+		}
+	}
+	return tokenNames
+}
+
+// TokenToVF returns a copy of the selected VFs map: token ID -> VF PCI address, as any other Pool method it should be
+// synchronized by the caller
+func (p *Pool) TokenToVF() map[string]string {
+	tokenToVF := make(map[string]string, len(p.tokens))
+	for tokenID, vf := range p.tokens {
+		tokenToVF[tokenID] = vf.pciAddr
+	}
+	return tokenToVF
+}
+
+// FreeVFsByPF returns a copy of the free VFs counts map: PF PCI address -> free VFs count, as any other Pool method it
+// should be synchronized by the caller
+func (p *Pool) FreeVFsByPF() map[string]int {
+	freeVFs := make(map[string]int, len(p.physicalFunctions))
+	for pfPCIAddr, pf := range p.physicalFunctions {
+		freeVFs[pfPCIAddr] = pf.freeVFsCount
+	}
+	return freeVFs
+}
+
+// IsIOMMUGroupFree returns true if there are no selected virtual functions in the IOMMU group and it is not pre-bound
+// (see WithPreBoundIOMMUGroups)
+func (p *Pool) IsIOMMUGroupFree(iommuGroup uint) bool {
+	return p.iommuGroups[iommuGroup] == sriov.NoDriver
+}
+
+// GetHardwareAddr returns MAC address allocated for the selected virtual function, returns nil if there is no MAC
+// pool set
+func (p *Pool) GetHardwareAddr(vfPCIAddr string) (net.HardwareAddr, error) {
+	vf, ok := p.virtualFunctions[vfPCIAddr]
+	if !ok {
+		return nil, errors.Errorf("VF doesn't exist: %v", vfPCIAddr)
+	}
+	if vf.tokenID == "" {
+		return nil, errors.Errorf("VF is not selected: %v", vfPCIAddr)
+	}
+	return vf.hardwareAddr, nil
+}
+
+// FindTokenName returns name of the token with the given ID
+func (p *Pool) FindTokenName(tokenID string) (string, error) {
+	return p.tokenPool.Find(tokenID)
+}
+
+// Ready returns a channel closed when the token pool is ready if it reports its readiness, the Pool itself is ready
+// after NewPool
+func (p *Pool) Ready() <-chan struct{} {
+	if reporter, ok := p.tokenPool.(interface{ Ready() <-chan struct{} }); ok {
+		return reporter.Ready()
+	}
+	readyCh := make(chan struct{})
+	close(readyCh)
+	return readyCh
+}
+
+// Locker returns the lock used to synchronize the Pool passed to its options, e.g. WithMaxVFLifetime, so the Pool users
+// can synchronize it with the same lock, returns nil if no lock has been passed
+func (p *Pool) Locker() sync.Locker {
+	return p.lock
+}
+
+func (p *Pool) setLock(lock sync.Locker) {
+	if lock != nil {
+		p.lock = lock
+	}
+}
+
+// GetTokenID returns ID of the token the virtual function is selected for, returns empty string if the virtual function
+// is not selected
+func (p *Pool) GetTokenID(vfPCIAddr string) (string, error) {
+	vf, ok := p.virtualFunctions[vfPCIAddr]
+	if !ok {
+		return "", errors.Errorf("VF doesn't exist: %v", vfPCIAddr)
+	}
+	return vf.tokenID, nil
+}
+
 // Free marks given virtual function as "free" and binds it to the "NoDriver" driver type
 func (p *Pool) Free(vfPCIAddr string) error {
 	vf, ok := p.virtualFunctions[vfPCIAddr]
@@ -200,24 +595,54 @@ func (p *Pool) Free(vfPCIAddr string) error {
 	if vf.tokenID == "" {
 		return errors.Errorf("trying to free not selected VF: %v", vf.pciAddr)
 	}
-	if err := p.tokenPool.StopUsing(vf.tokenID); err != nil {
-		return err
+	// reserved VF token is not used in the token pool yet
+	if !vf.reserved {
+		if err := p.tokenPool.StopUsing(vf.tokenID); err != nil {
+			return err
+		}
+	}
+	if p.macPool != nil {
+		if err := p.macPool.Release(vf.tokenID); err != nil {
+			if !vf.reserved {
+				_ = p.tokenPool.Use(vf.tokenID, p.pfTokenNames(vf))
+			}
+			return err
+		}
 	}
 	delete(p.tokens, vf.tokenID)
+	tokenID, preempted := vf.tokenID, vf.preemptedFor != ""
+	p.release(vf)
+	// preempted token is freed in the token pool, the VF is released anyway if it fails
+	if preempted {
+		_ = p.tokenPool.Free(tokenID)
+	}
+
+	return nil
+}
+
+// release makes the VF free, its token should be already stopped using or moved to another VF
+func (p *Pool) release(vf *virtualFunction) {
+	if p.onRelease != nil {
+		go p.onRelease()
+	}
+	p.stopExpiryTimer(vf)
 	vf.tokenID = ""
+	vf.reserved = false
+	vf.preemptedFor = ""
+	vf.driverType = sriov.NoDriver
+	vf.hardwareAddr = nil
 
 	p.physicalFunctions[vf.pfPCIAddr].freeVFsCount++
 
-	for _, pf := range p.physicalFunctions {
-		if vffs, ok := pf.virtualFunctions[vf.iommuGroup]; ok {
-			for _, vff := range vffs {
-				if vff.tokenID != "" {
-					return nil
-				}
-			}
+	for _, groupVF := range p.iommuGroupVFs[vf.iommuGroup] {
+		if groupVF.tokenID != "" {
+			return
 		}
 	}
+	// pre-bound IOMMU group stays bound to its driver type when all its VFs are freed
+	if driverType, ok := p.preBoundGroups[vf.iommuGroup]; ok {
+		p.iommuGroups[vf.iommuGroup] = driverType
+		return
+	}
 	p.iommuGroups[vf.iommuGroup] = sriov.NoDriver
-
-	return nil
 }
@@ -17,29 +17,48 @@
 package resource_test
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"math/rand"
+	"os"
 	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/pkg/errors"
+	"github.com/prometheus/common/expfmt"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
 
 	"github.com/networkservicemesh/sdk-sriov/pkg/sriov"
 	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/config"
 	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/resource"
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/sriovtest"
+	"github.com/networkservicemesh/sdk-sriov/pkg/tools/tokens"
 )
 
 const (
-	configFileName  = "config.yml"
-	serviceDomain1  = "service.domain.1"
-	serviceDomain2  = "service.domain.2"
-	capabilityIntel = "intel"
-	capability10G   = "10G"
-	vf11PciAddr     = "0000:01:00.1"
-	vf21PciAddr     = "0000:02:00.1"
-	vf22PciAddr     = "0000:02:00.2"
-	vf31PciAddr     = "0000:03:00.1"
+	configFileName             = "config.yml"
+	hierarchicalConfigFileName = "hierarchical_config.yml"
+	numaConfigFileName         = "numa_config.yml"
+	threePFsConfigFileName     = "three_pfs_config.yml"
+	qosConfigFileName          = "qos_config.yml"
+	preemptionConfigFileName   = "preemption_config.yml"
+	serviceDomain1             = "service.domain.1"
+	serviceDomain2             = "service.domain.2"
+	capabilityIntel            = "intel"
+	capability10G              = "10G"
+	capabilityNet              = "net"
+	vf11PciAddr                = "0000:01:00.1"
+	vf21PciAddr                = "0000:02:00.1"
+	vf22PciAddr                = "0000:02:00.2"
+	vf31PciAddr                = "0000:03:00.1"
 )
 
 func TestPool_Select_Selected(t *testing.T) {
@@ -98,6 +117,120 @@ func TestPool_Select_SelectedAnotherDriver(t *testing.T) {
 	assert.Equal(t, vf22PciAddr, vfPCIAddr) // <-- same
 }
 
+func TestPool_Select_ChangeDriverType(t *testing.T) {
+	tokenPool := &tokenPoolStub{
+		tokens: map[string]string{
+			"1": path.Join(serviceDomain1, capabilityIntel),
+			"2": path.Join(serviceDomain2, capabilityIntel),
+		},
+	}
+
+	cfg, err := config.ReadConfig(context.TODO(), configFileName)
+	require.NoError(t, err)
+
+	p := resource.NewPool(tokenPool, cfg)
+
+	vfPCIAddr, err := p.Select("1", sriov.KernelDriver)
+	require.NoError(t, err)
+	require.Equal(t, vf11PciAddr, vfPCIAddr)
+
+	// The only VF in the IOMMU group is freed, so the group can be switched to the new driver type.
+
+	vfPCIAddr, err = p.Select("1", sriov.VFIOPCIDriver)
+	require.NoError(t, err)
+	require.Equal(t, vf11PciAddr, vfPCIAddr)
+	require.Len(t, tokenPool.inUse, 1)
+
+	// IOMMU group 1 is now VFIO, so kernel VF should be selected from the IOMMU group 2.
+
+	vfPCIAddr, err = p.Select("2", sriov.KernelDriver)
+	require.NoError(t, err)
+	require.Equal(t, vf22PciAddr, vfPCIAddr)
+}
+
+func TestPool_Select_ChangeDriverType_Failed(t *testing.T) {
+	tokenPool := &tokenPoolStub{
+		tokens: map[string]string{
+			"1": path.Join(serviceDomain1, capabilityIntel),
+			"2": path.Join(serviceDomain2, capabilityIntel),
+		},
+	}
+
+	cfg, err := config.ReadConfig(context.TODO(), configFileName)
+	require.NoError(t, err)
+
+	p := resource.NewPool(tokenPool, cfg)
+
+	vfPCIAddr, err := p.Select("1", sriov.KernelDriver)
+	require.NoError(t, err)
+	require.Equal(t, vf11PciAddr, vfPCIAddr)
+
+	vfPCIAddr, err = p.Select("2", sriov.KernelDriver)
+	require.NoError(t, err)
+	require.Equal(t, vf31PciAddr, vfPCIAddr)
+
+	// IOMMU group 1 is still used by the kernel VF, so there is no VFIO VF for the token and the previous selection
+	// should be kept.
+
+	_, err = p.Select("1", sriov.VFIOPCIDriver)
+	require.ErrorIs(t, err, resource.ErrNoFreeVF)
+	require.Len(t, tokenPool.inUse, 2)
+
+	vfPCIAddr, err = p.Select("1", sriov.KernelDriver)
+	require.NoError(t, err)
+	require.Equal(t, vf11PciAddr, vfPCIAddr)
+}
+
+func TestPool_Migrate(t *testing.T) {
+	tokenPool := &tokenPoolStub{
+		tokens: map[string]string{
+			"1": path.Join(serviceDomain2, capabilityIntel),
+		},
+	}
+
+	cfg, err := config.ReadConfig(context.TODO(), configFileName)
+	require.NoError(t, err)
+
+	macPool, err := sriov.NewMACPool("02:00:00", 1)
+	require.NoError(t, err)
+
+	p := resource.NewPool(tokenPool, cfg, resource.WithOrderedSelection(), resource.WithMACPool(macPool))
+
+	vfPCIAddr, err := p.Select("1", sriov.KernelDriver)
+	require.NoError(t, err)
+	require.Equal(t, vf21PciAddr, vfPCIAddr)
+	mac, err := p.GetHardwareAddr(vfPCIAddr)
+	require.NoError(t, err)
+
+	vfPCIAddr, err = p.Migrate("1", "0000:03:00.0")
+	require.NoError(t, err)
+	require.Equal(t, vf31PciAddr, vfPCIAddr)
+
+	// MAC address is kept by the token
+
+	migratedMAC, err := p.GetHardwareAddr(vfPCIAddr)
+	require.NoError(t, err)
+	require.Equal(t, mac, migratedMAC)
+	_, err = p.GetHardwareAddr(vf21PciAddr)
+	require.Error(t, err)
+
+	tokenID, err := p.GetTokenID(vf21PciAddr)
+	require.NoError(t, err)
+	require.Empty(t, tokenID)
+	require.Equal(t, map[string]string{"1": vf31PciAddr}, p.TokenToVF())
+	require.Len(t, tokenPool.inUse, 1)
+
+	// PF 0000:01:00.0 doesn't serve the token name, so the token should stay on the old VF
+
+	_, err = p.Migrate("1", "0000:01:00.0")
+	require.ErrorIs(t, err, resource.ErrNoFreeVF)
+	require.Equal(t, map[string]string{"1": vf31PciAddr}, p.TokenToVF())
+	require.Len(t, tokenPool.inUse, 1)
+
+	_, err = p.Migrate("2", "0000:02:00.0")
+	require.Error(t, err)
+}
+
 func TestPool_Select_Capability(t *testing.T) {
 	tokenPool := &tokenPoolStub{
 		tokens: map[string]string{
@@ -132,6 +265,31 @@ func TestPool_Select_FreeVFsCount(t *testing.T) {
 	assert.Equal(t, vf31PciAddr, vfPCIAddr)
 }
 
+func TestPool_Select_HierarchicalCapability(t *testing.T) {
+	tokenPool := &tokenPoolStub{
+		tokens: map[string]string{
+			"1": path.Join(serviceDomain1, capabilityNet),
+			"2": path.Join(serviceDomain2, capabilityNet, capability10G),
+		},
+	}
+
+	cfg, err := config.ReadConfig(context.TODO(), hierarchicalConfigFileName)
+	require.NoError(t, err)
+
+	p := resource.NewPool(tokenPool, cfg)
+
+	// Parent name should match more specific PF capability.
+
+	vfPCIAddr, err := p.Select("1", sriov.KernelDriver)
+	require.NoError(t, err)
+	require.Equal(t, vf11PciAddr, vfPCIAddr)
+
+	// More specific name shouldn't match parent PF capability.
+
+	_, err = p.Select("2", sriov.KernelDriver)
+	require.Error(t, err)
+}
+
 func TestPool_Free(t *testing.T) {
 	tokenPool := &tokenPoolStub{
 		tokens: map[string]string{
@@ -156,26 +314,1244 @@ func TestPool_Free(t *testing.T) {
 	assert.Equal(t, vf11PciAddr, vfPCIAddr)
 }
 
-type tokenPoolStub struct {
-	tokens map[string]string
+func TestPool_Free_IOMMUGroupSpansPFs(t *testing.T) {
+	tokenPool := &tokenPoolStub{
+		tokens: map[string]string{
+			"1": path.Join(serviceDomain1, capabilityIntel),
+			"2": path.Join(serviceDomain1, capabilityIntel),
+		},
+	}
+
+	pfConfig := func(vfPCIAddr string) *config.PhysicalFunction {
+		return &config.PhysicalFunction{
+			Capabilities:   []string{capabilityIntel},
+			ServiceDomains: []string{serviceDomain1},
+			VirtualFunctions: []*config.VirtualFunction{
+				{Address: vfPCIAddr, IOMMUGroup: 1},
+			},
+		}
+	}
+	cfg := &config.Config{
+		PhysicalFunctions: map[string]*config.PhysicalFunction{
+			"0000:01:00.0": pfConfig(vf11PciAddr),
+			"0000:02:00.0": pfConfig(vf21PciAddr),
+		},
+	}
+
+	p := resource.NewPool(tokenPool, cfg, resource.WithOrderedSelection())
+
+	vf1PCIAddr, err := p.Select("1", sriov.VFIOPCIDriver)
+	require.NoError(t, err)
+	require.Equal(t, vf11PciAddr, vf1PCIAddr)
+
+	vf2PCIAddr, err := p.Select("2", sriov.VFIOPCIDriver)
+	require.NoError(t, err)
+	require.Equal(t, vf21PciAddr, vf2PCIAddr)
+
+	for _, vfPCIAddr := range []string{vf1PCIAddr, vf2PCIAddr} {
+		require.False(t, p.IsIOMMUGroupFree(1))
+		require.NoError(t, p.Free(vfPCIAddr))
+	}
+	require.True(t, p.IsIOMMUGroupFree(1))
 }
 
-func (tp *tokenPoolStub) Find(id string) (string, error) {
-	if tokenName, ok := tp.tokens[id]; ok {
-		return tokenName, nil
+func TestPool_PreBoundIOMMUGroups(t *testing.T) {
+	tokenPool := &tokenPoolStub{
+		tokens: map[string]string{
+			"1": path.Join(serviceDomain1, capabilityIntel),
+		},
 	}
-	return "", errors.New("invalid token ID")
+
+	cfg := &config.Config{
+		PhysicalFunctions: map[string]*config.PhysicalFunction{
+			"0000:01:00.0": {
+				Capabilities:   []string{capabilityIntel},
+				ServiceDomains: []string{serviceDomain1},
+				VirtualFunctions: []*config.VirtualFunction{
+					{Address: vf11PciAddr, IOMMUGroup: 1},
+					{Address: "0000:01:00.2", IOMMUGroup: 1},
+				},
+			},
+		},
+	}
+
+	p := resource.NewPool(tokenPool, cfg, resource.WithPreBoundIOMMUGroups(map[uint]sriov.DriverType{
+		1: sriov.KernelDriver,
+	}))
+	require.False(t, p.IsIOMMUGroupFree(1))
+
+	_, err := p.Select("1", sriov.VFIOPCIDriver)
+	require.ErrorIs(t, err, resource.ErrNoFreeVF)
+
+	vfPCIAddr, err := p.Select("1", sriov.KernelDriver)
+	require.NoError(t, err)
+	require.Equal(t, vf11PciAddr, vfPCIAddr)
+
+	// IOMMU group stays bound to the pre-bound driver type after the VF is freed
+
+	require.NoError(t, p.Free(vfPCIAddr))
+	require.False(t, p.IsIOMMUGroupFree(1))
+
+	_, err = p.Select("1", sriov.VFIOPCIDriver)
+	require.ErrorIs(t, err, resource.ErrNoFreeVF)
 }
 
-func (tp *tokenPoolStub) Use(id string, _ []string) error {
-	if _, ok := tp.tokens[id]; ok {
-		return nil
+func TestPool_Reserve(t *testing.T) {
+	tokenPool := &tokenPoolStub{
+		tokens: map[string]string{
+			"1": path.Join(serviceDomain1, capabilityIntel),
+			"2": path.Join(serviceDomain1, capabilityIntel),
+		},
 	}
-	return errors.New("invalid token ID")
+
+	cfg, err := config.ReadConfig(context.TODO(), configFileName)
+	require.NoError(t, err)
+
+	p := resource.NewPool(tokenPool, cfg)
+
+	vfPCIAddr, err := p.Reserve("1", sriov.VFIOPCIDriver)
+	require.NoError(t, err)
+	require.Equal(t, vf11PciAddr, vfPCIAddr)
+	require.Empty(t, tokenPool.inUse)
+
+	_, err = p.Select("2", sriov.VFIOPCIDriver)
+	require.ErrorIs(t, err, resource.ErrNoFreeVF)
+
+	require.NoError(t, p.Cancel(vfPCIAddr))
+	require.True(t, p.IsIOMMUGroupFree(1))
+
+	vfPCIAddr, err = p.Select("2", sriov.VFIOPCIDriver)
+	require.NoError(t, err)
+	require.Equal(t, vf11PciAddr, vfPCIAddr)
+	require.Error(t, p.Cancel(vfPCIAddr))
 }
 
-func (tp *tokenPoolStub) StopUsing(id string) error {
+func TestPool_Reserve_Commit(t *testing.T) {
+	tokenPool := &tokenPoolStub{
+		tokens: map[string]string{
+			"1": path.Join(serviceDomain1, capabilityIntel),
+		},
+	}
+
+	cfg, err := config.ReadConfig(context.TODO(), configFileName)
+	require.NoError(t, err)
+
+	p := resource.NewPool(tokenPool, cfg)
+
+	vfPCIAddr, err := p.Reserve("1", sriov.VFIOPCIDriver)
+	require.NoError(t, err)
+
+	require.NoError(t, p.Commit(vfPCIAddr))
+	require.Contains(t, tokenPool.inUse, "1")
+	require.Error(t, p.Commit(vfPCIAddr))
+
+	require.NoError(t, p.Free(vfPCIAddr))
+	require.NotContains(t, tokenPool.inUse, "1")
+}
+
+func TestPool_Preemption(t *testing.T) {
+	tokenPool := &tokenPoolStub{
+		tokens: map[string]string{
+			"high-1": path.Join(serviceDomain1, capabilityIntel),
+			"high-2": path.Join(serviceDomain1, capabilityIntel),
+			"high-3": path.Join(serviceDomain1, capabilityIntel),
+			"low-1":  path.Join(serviceDomain2, capabilityIntel),
+			"low-2":  path.Join(serviceDomain2, capabilityIntel),
+			"low-3":  path.Join(serviceDomain2, capabilityIntel),
+		},
+	}
+
+	cfg, err := config.ReadConfig(context.TODO(), preemptionConfigFileName)
+	require.NoError(t, err)
+
+	lock := new(sync.Mutex)
+	preemptedCh := make(chan string, 1)
+	var p *resource.Pool
+	p = resource.NewPool(tokenPool, cfg, resource.WithOrderedSelection(), resource.WithPreemption(func(tokenID, vfPCIAddr string) {
+		// onPreempt is called without the Pool lock held, so it can use the Pool
+		lock.Lock()
+		defer lock.Unlock()
+
+		vfTokenID, err := p.GetTokenID(vfPCIAddr)
+		assert.NoError(t, err)
+		assert.Equal(t, tokenID, vfTokenID)
+
+		preemptedCh <- tokenID
+	}))
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	inUseVF, err := p.Select("low-1", sriov.KernelDriver)
+	require.NoError(t, err)
+	reservedVF, err := p.Reserve("low-2", sriov.KernelDriver)
+	require.NoError(t, err)
+
+	// 1. Reserved VF of the lower priority service domain is preempted, it is handed over after it is released
+
+	_, err = p.Select("high-1", sriov.KernelDriver)
+	require.ErrorIs(t, err, resource.ErrNoFreeVF)
+
+	lock.Unlock()
+	require.Equal(t, "low-2", <-preemptedCh)
+	lock.Lock()
+
+	_, err = p.Select("high-1", sriov.KernelDriver)
+	require.ErrorIs(t, err, resource.ErrNoFreeVF)
+	require.ErrorIs(t, p.Commit(reservedVF), resource.ErrPreempted)
+	require.NotContains(t, tokenPool.inUse, "low-2")
+	require.Empty(t, tokenPool.freed)
+
+	require.NoError(t, p.Cancel(reservedVF))
+	require.Equal(t, map[string]struct{}{"low-2": {}}, tokenPool.freed)
+
+	vfPCIAddr, err := p.Select("high-1", sriov.KernelDriver)
+	require.NoError(t, err)
+	require.Equal(t, reservedVF, vfPCIAddr)
+	require.Equal(t, map[string]string{"low-1": inUseVF, "high-1": reservedVF}, p.TokenToVF())
+	require.Empty(t, preemptedCh)
+
+	// 2. VF in use is never preempted
+
+	_, err = p.Select("high-2", sriov.KernelDriver)
+	require.ErrorIs(t, err, resource.ErrNoFreeVF)
+	require.Len(t, tokenPool.freed, 1)
+
+	// 3. Reserved VF of the same or higher priority service domain is not preempted
+
+	require.NoError(t, p.Free(reservedVF))
+	_, err = p.Reserve("high-2", sriov.KernelDriver)
+	require.NoError(t, err)
+
+	_, err = p.Select("high-3", sriov.KernelDriver)
+	require.ErrorIs(t, err, resource.ErrNoFreeVF)
+	_, err = p.Select("low-3", sriov.KernelDriver)
+	require.ErrorIs(t, err, resource.ErrNoFreeVF)
+	require.Len(t, tokenPool.freed, 1)
+}
+
+func TestPool_Locker(t *testing.T) {
+	cfg, err := config.ReadConfig(context.TODO(), configFileName)
+	require.NoError(t, err)
+
+	require.Nil(t, resource.NewPool(&tokenPoolStub{}, cfg).Locker())
+
+	lock := new(sync.Mutex)
+	p := resource.NewPool(&tokenPoolStub{}, cfg, resource.WithMaxVFLifetime(context.TODO(), time.Hour, lock, nil))
+	require.Equal(t, lock, p.Locker())
+}
+
+func TestPool_FreeVFsByPF(t *testing.T) {
+	tokenPool := &tokenPoolStub{
+		tokens: map[string]string{
+			"1": path.Join(serviceDomain1, capabilityIntel),
+		},
+	}
+
+	cfg, err := config.ReadConfig(context.TODO(), configFileName)
+	require.NoError(t, err)
+
+	p := resource.NewPool(tokenPool, cfg)
+
+	freeVFs := p.FreeVFsByPF()
+	require.Equal(t, map[string]int{
+		"0000:01:00.0": 1,
+		"0000:02:00.0": 2,
+		"0000:03:00.0": 3,
+	}, freeVFs)
+
+	vfPCIAddr, err := p.Select("1", sriov.KernelDriver)
+	require.NoError(t, err)
+	require.Equal(t, vf11PciAddr, vfPCIAddr)
+
+	require.Equal(t, 0, p.FreeVFsByPF()["0000:01:00.0"])
+	require.Equal(t, 1, freeVFs["0000:01:00.0"])
+}
+
+func TestPool_TokenToVF(t *testing.T) {
+	tokenPool := &tokenPoolStub{
+		tokens: map[string]string{
+			"1": path.Join(serviceDomain1, capabilityIntel),
+			"2": path.Join(serviceDomain2, capabilityIntel),
+		},
+	}
+
+	cfg, err := config.ReadConfig(context.TODO(), configFileName)
+	require.NoError(t, err)
+
+	p := resource.NewPool(tokenPool, cfg)
+	require.Empty(t, p.TokenToVF())
+
+	vf1PCIAddr, err := p.Select("1", sriov.KernelDriver)
+	require.NoError(t, err)
+	vf2PCIAddr, err := p.Select("2", sriov.VFIOPCIDriver)
+	require.NoError(t, err)
+
+	tokenToVF := p.TokenToVF()
+	require.Equal(t, map[string]string{"1": vf1PCIAddr, "2": vf2PCIAddr}, tokenToVF)
+
+	require.NoError(t, p.Free(vf1PCIAddr))
+	require.Equal(t, map[string]string{"2": vf2PCIAddr}, p.TokenToVF())
+
+	// returned map is a copy
+	require.Len(t, tokenToVF, 2)
+}
+
+func TestPool_Select_NodeCapacity(t *testing.T) {
+	tokenPool := &tokenPoolStub{
+		tokens: map[string]string{
+			"1": path.Join(serviceDomain2, capabilityIntel),
+			"2": path.Join(serviceDomain2, capabilityIntel),
+			"3": path.Join(serviceDomain2, capabilityIntel),
+		},
+	}
+
+	cfg, err := config.ReadConfig(context.TODO(), configFileName)
+	require.NoError(t, err)
+	cfg.MaxAllocatableVFs = 2
+
+	p := resource.NewPool(tokenPool, cfg)
+
+	_, err = p.Select("1", sriov.KernelDriver)
+	require.NoError(t, err)
+
+	vfPCIAddr, err := p.Select("2", sriov.KernelDriver)
+	require.NoError(t, err)
+
+	_, err = p.Select("3", sriov.KernelDriver)
+	require.ErrorIs(t, err, resource.ErrNodeCapacity)
+
+	var selectErr *resource.SelectError
+	require.ErrorAs(t, err, &selectErr)
+	require.Equal(t, resource.NodeCapacity, selectErr.Reason)
+	require.Equal(t, 2, selectErr.SelectedVFs)
+
+	require.NoError(t, p.Free(vfPCIAddr))
+
+	_, err = p.Select("3", sriov.KernelDriver)
+	require.NoError(t, err)
+}
+
+func TestPool_Select_SelectError(t *testing.T) {
+	tokenPool := &tokenPoolStub{
+		tokens: map[string]string{
+			"1": path.Join(serviceDomain1, capabilityIntel),
+			"2": path.Join(serviceDomain1, capability10G),
+			"3": path.Join(serviceDomain2, "20G"),
+			"4": path.Join(serviceDomain1, "20G"),
+		},
+	}
+
+	cfg, err := config.ReadConfig(context.TODO(), configFileName)
+	require.NoError(t, err)
+
+	p := resource.NewPool(tokenPool, cfg)
+
+	vfPCIAddr, err := p.Select("1", sriov.KernelDriver)
+	require.NoError(t, err)
+	require.Equal(t, vf11PciAddr, vfPCIAddr)
+
+	for _, sample := range []struct {
+		tokenID    string
+		driverType sriov.DriverType
+		expected   *resource.SelectError
+	}{
+		{
+			tokenID:    "2",
+			driverType: sriov.KernelDriver,
+			expected: &resource.SelectError{
+				Reason:      resource.AllInUse,
+				TokenName:   path.Join(serviceDomain1, capability10G),
+				DriverType:  sriov.KernelDriver,
+				MatchingPFs: 1,
+				MatchingVFs: 1,
+			},
+		},
+		{
+			// IOMMU group 1 is used by the kernel VF
+			tokenID:    "3",
+			driverType: sriov.VFIOPCIDriver,
+			expected: &resource.SelectError{
+				Reason:      resource.DriverConflict,
+				TokenName:   path.Join(serviceDomain2, "20G"),
+				DriverType:  sriov.VFIOPCIDriver,
+				MatchingPFs: 1,
+				MatchingVFs: 3,
+				FreeVFs:     3,
+			},
+		},
+	} {
+		_, err = p.Select(sample.tokenID, sample.driverType)
+		require.ErrorIs(t, err, resource.ErrNoFreeVF)
+
+		var selectErr *resource.SelectError
+		require.ErrorAs(t, err, &selectErr)
+		require.Equal(t, sample.expected, selectErr)
+	}
+
+	// No PF serves the token name at all
+	_, err = p.Select("4", sriov.KernelDriver)
+	require.ErrorIs(t, err, resource.ErrPoolDivergence)
+	require.Contains(t, err.Error(), path.Join(serviceDomain1, "20G"))
+}
+
+func TestPool_CheckTokenNames(t *testing.T) {
+	cfg, err := config.ReadConfig(context.TODO(), configFileName)
+	require.NoError(t, err)
+
+	p := resource.NewPool(&tokenPoolStub{}, cfg)
+
+	tokenNamesCfg, err := config.ReadConfig(context.TODO(), configFileName)
+	require.NoError(t, err)
+	require.NoError(t, p.CheckTokenNames(tokenNames(tokenNamesCfg)))
+
+	// Token config has an additional capability and lacks a service domain
+	tokenNamesCfg.PhysicalFunctions["0000:01:00.0"].Capabilities = append(
+		tokenNamesCfg.PhysicalFunctions["0000:01:00.0"].Capabilities, "40G")
+	delete(tokenNamesCfg.PhysicalFunctions, "0000:02:00.0")
+	delete(tokenNamesCfg.PhysicalFunctions, "0000:03:00.0")
+
+	err = p.CheckTokenNames(tokenNames(tokenNamesCfg))
+	require.ErrorIs(t, err, resource.ErrPoolDivergence)
+	require.Contains(t, err.Error(), path.Join(serviceDomain1, "40G"))
+	require.Contains(t, err.Error(), path.Join(serviceDomain2, capabilityIntel))
+}
+
+func tokenNames(cfg *config.Config) []string {
+	names := map[string]struct{}{}
+	for _, pfCfg := range cfg.PhysicalFunctions {
+		for _, name := range tokens.Names(pfCfg.ServiceDomains, pfCfg.Capabilities) {
+			names[name] = struct{}{}
+		}
+	}
+
+	var result []string
+	for name := range names {
+		result = append(result, name)
+	}
+	return result
+}
+
+func TestPool_Select_MACPool(t *testing.T) {
+	tokenPool := &tokenPoolStub{
+		tokens: map[string]string{
+			"1": path.Join(serviceDomain2, capabilityIntel),
+			"2": path.Join(serviceDomain2, capabilityIntel),
+			"3": path.Join(serviceDomain2, capabilityIntel),
+		},
+	}
+
+	cfg, err := config.ReadConfig(context.TODO(), configFileName)
+	require.NoError(t, err)
+
+	macPool, err := sriov.NewMACPool("02:00:00", 2)
+	require.NoError(t, err)
+
+	p := resource.NewPool(tokenPool, cfg, resource.WithMACPool(macPool))
+
+	vf1PCIAddr, err := p.Select("1", sriov.KernelDriver)
+	require.NoError(t, err)
+	mac1, err := p.GetHardwareAddr(vf1PCIAddr)
+	require.NoError(t, err)
+	require.NotNil(t, mac1)
+
+	vf2PCIAddr, err := p.Select("2", sriov.KernelDriver)
+	require.NoError(t, err)
+	mac2, err := p.GetHardwareAddr(vf2PCIAddr)
+	require.NoError(t, err)
+	require.NotEqual(t, mac1, mac2)
+
+	// MAC pool is exhausted, so no VF can be selected
+
+	_, err = p.Select("3", sriov.KernelDriver)
+	require.ErrorIs(t, err, sriov.ErrMACPoolExhausted)
+	require.Len(t, tokenPool.inUse, 2)
+
+	// MAC is kept if the VF fails to be freed
+
+	tokenName := tokenPool.tokens["1"]
+	delete(tokenPool.tokens, "1")
+
+	require.Error(t, p.Free(vf1PCIAddr))
+	mac, err := p.GetHardwareAddr(vf1PCIAddr)
+	require.NoError(t, err)
+	require.Equal(t, mac1, mac)
+
+	_, err = p.Select("3", sriov.KernelDriver)
+	require.ErrorIs(t, err, sriov.ErrMACPoolExhausted)
+
+	tokenPool.tokens["1"] = tokenName
+
+	// Same VF gets the same MAC again
+
+	require.NoError(t, p.Free(vf1PCIAddr))
+	_, err = p.GetHardwareAddr(vf1PCIAddr)
+	require.Error(t, err)
+
+	vfPCIAddr, err := p.Select("1", sriov.KernelDriver)
+	require.NoError(t, err)
+	require.Equal(t, vf1PCIAddr, vfPCIAddr)
+
+	mac, err = p.GetHardwareAddr(vfPCIAddr)
+	require.NoError(t, err)
+	require.Equal(t, mac1, mac)
+}
+
+func TestPool_Select_AllowedNUMANodes(t *testing.T) {
+	tokenPool := &tokenPoolStub{
+		tokens: map[string]string{
+			"1": path.Join(serviceDomain1, capabilityIntel),
+			"2": path.Join(serviceDomain1, capabilityIntel),
+			"3": path.Join(serviceDomain2, capabilityIntel),
+		},
+	}
+
+	cfg, err := config.ReadConfig(context.TODO(), numaConfigFileName)
+	require.NoError(t, err)
+
+	p := resource.NewPool(tokenPool, cfg)
+
+	// service.domain.1 is allowed only on the NUMA node 1
+
+	vfPCIAddr, err := p.Select("1", sriov.KernelDriver)
+	require.NoError(t, err)
+	require.Equal(t, vf21PciAddr, vfPCIAddr)
+
+	_, err = p.Select("2", sriov.KernelDriver)
+	var selectErr *resource.SelectError
+	require.ErrorAs(t, err, &selectErr)
+	require.Equal(t, resource.AllInUse, selectErr.Reason)
+
+	// service.domain.2 has no restrictions
+
+	vfPCIAddr, err = p.Select("3", sriov.KernelDriver)
+	require.NoError(t, err)
+	require.Equal(t, vf11PciAddr, vfPCIAddr)
+}
+
+func TestPool_WriteStateFile(t *testing.T) {
+	tokenPool := &tokenPoolStub{
+		tokens: map[string]string{
+			"1": path.Join(serviceDomain1, capabilityIntel),
+		},
+	}
+
+	cfg, err := config.ReadConfig(context.TODO(), configFileName)
+	require.NoError(t, err)
+
+	p := resource.NewPool(tokenPool, cfg)
+
+	_, err = p.Select("1", sriov.VFIOPCIDriver)
+	require.NoError(t, err)
+
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+	require.NoError(t, p.WriteStateFile(stateFile))
+
+	state := readStateFile(t, stateFile)
+	require.Equal(t, p.State(), state)
+	require.Equal(t, 0, state.PhysicalFunctions["0000:01:00.0"].FreeVFsCount)
+	require.Equal(t, "1", state.PhysicalFunctions["0000:01:00.0"].VirtualFunctions[0].TokenID)
+	require.Equal(t, sriov.VFIOPCIDriver, state.IOMMUGroups[1])
+}
+
+func TestPool_WithStateFile(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	tokenPool := &tokenPoolStub{
+		tokens: map[string]string{
+			"1": path.Join(serviceDomain1, capabilityIntel),
+		},
+	}
+
+	cfg, err := config.ReadConfig(context.TODO(), configFileName)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+
+	var lock sync.Mutex
+	p := resource.NewPool(tokenPool, cfg, resource.WithStateFile(ctx, stateFile, 10*time.Millisecond, &lock))
+
+	require.Eventually(t, func() bool {
+		_, statErr := os.Stat(stateFile)
+		return statErr == nil
+	}, time.Second, 10*time.Millisecond)
+	require.Equal(t, 1, readStateFile(t, stateFile).PhysicalFunctions["0000:01:00.0"].FreeVFsCount)
+
+	lock.Lock()
+	_, err = p.Select("1", sriov.KernelDriver)
+	lock.Unlock()
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		return readStateFile(t, stateFile).PhysicalFunctions["0000:01:00.0"].FreeVFsCount == 0
+	}, time.Second, 10*time.Millisecond)
+
+	cancel()
+}
+
+func TestPool_WriteOpenMetrics(t *testing.T) {
+	tokenPool := &tokenPoolStub{
+		tokens: map[string]string{
+			"1": path.Join(serviceDomain1, capabilityIntel),
+		},
+	}
+
+	cfg, err := config.ReadConfig(context.TODO(), configFileName)
+	require.NoError(t, err)
+
+	p := resource.NewPool(tokenPool, cfg)
+
+	_, err = p.Select("1", sriov.VFIOPCIDriver)
+	require.NoError(t, err)
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, p.WriteOpenMetrics(buf))
+
+	text := buf.String()
+	require.Contains(t, text, `sriov_pf_free_vfs{pf="0000:01:00.0"} 0`)
+	require.Contains(t, text, `sriov_pf_free_vfs{pf="0000:02:00.0"} 2`)
+	require.Contains(t, text, `sriov_vf_selected{pf="0000:01:00.0",vf="0000:01:00.1",iommu_group="1"} 1`)
+	require.Contains(t, text, `sriov_vf_selected{pf="0000:02:00.0",vf="0000:02:00.1",iommu_group="1"} 0`)
+	require.Contains(t, text, `sriov_vf_driver{pf="0000:01:00.0",vf="0000:01:00.1",driver="vfio-pci"} 1`)
+	require.Contains(t, text, `sriov_vf_driver{pf="0000:02:00.0",vf="0000:02:00.2",driver="no-driver"} 1`)
+	require.True(t, strings.HasSuffix(text, "# EOF\n"))
+
+	families, err := new(expfmt.TextParser).TextToMetricFamilies(strings.NewReader(text))
+	require.NoError(t, err)
+	require.Len(t, families["sriov_vf_selected"].GetMetric(), 6)
+	require.Len(t, families["sriov_pf_numa_node"].GetMetric(), 3)
+}
+
+func readStateFile(t *testing.T, stateFile string) *resource.State {
+	data, err := os.ReadFile(filepath.Clean(stateFile))
+	require.NoError(t, err)
+
+	state := new(resource.State)
+	require.NoError(t, json.Unmarshal(data, state))
+
+	return state
+}
+
+func TestPool_Select_Weighted(t *testing.T) {
+	const iterations = 3000
+
+	tokenPool := &tokenPoolStub{
+		tokens: map[string]string{
+			"1": path.Join(serviceDomain2, capabilityIntel),
+		},
+	}
+
+	cfg, err := config.ReadConfig(context.TODO(), configFileName)
+	require.NoError(t, err)
+
+	p := resource.NewPool(tokenPool, cfg, resource.WithWeightedSelection(rand.New(rand.NewSource(1))))
+
+	// 0000:02:00.0 has 2 free VFs, 0000:03:00.0 has 3 free VFs
+	selections := map[string]int{}
+	for i := 0; i < iterations; i++ {
+		vfPCIAddr, selectErr := p.Select("1", sriov.KernelDriver)
+		require.NoError(t, selectErr)
+		selections[vfPCIAddr[:len(vfPCIAddr)-1]+"0"]++
+
+		require.NoError(t, p.Free(vfPCIAddr))
+	}
+
+	require.InDelta(t, 0.4, float64(selections["0000:02:00.0"])/iterations, 0.05)
+	require.InDelta(t, 0.6, float64(selections["0000:03:00.0"])/iterations, 0.05)
+}
+
+func TestPool_Select_Balanced(t *testing.T) {
+	tokenPool := &tokenPoolStub{
+		tokens: map[string]string{},
+	}
+	for i := 0; i < 7; i++ {
+		tokenPool.tokens[strconv.Itoa(i)] = path.Join(serviceDomain1, capabilityIntel)
+	}
+
+	cfg, err := config.ReadConfig(context.TODO(), threePFsConfigFileName)
+	require.NoError(t, err)
+
+	p := resource.NewPool(tokenPool, cfg)
+
+	selectPFs := func(from, to int) map[string]int {
+		pfs := map[string]int{}
+		for i := from; i < to; i++ {
+			vfPCIAddr, selectErr := p.Select(strconv.Itoa(i), sriov.KernelDriver)
+			require.NoError(t, selectErr)
+			pfs[vfPCIAddr[:len(vfPCIAddr)-1]+"0"]++
+		}
+		return pfs
+	}
+
+	// Each PF should get a VF before any PF gets the second one
+	require.Equal(t, map[string]int{"0000:01:00.0": 1, "0000:02:00.0": 1, "0000:03:00.0": 1}, selectPFs(0, 3))
+	require.Equal(t, map[string]int{"0000:01:00.0": 1, "0000:02:00.0": 1, "0000:03:00.0": 1}, selectPFs(3, 6))
+
+	_, err = p.Select("6", sriov.KernelDriver)
+	require.ErrorIs(t, err, resource.ErrNoFreeVF)
+}
+
+func TestPool_Select_Ordered(t *testing.T) {
+	tokenPool := &tokenPoolStub{
+		tokens: map[string]string{
+			"1": path.Join(serviceDomain2, capabilityIntel),
+			"2": path.Join(serviceDomain2, capabilityIntel),
+			"3": path.Join(serviceDomain2, capabilityIntel),
+		},
+	}
+
+	cfg, err := config.ReadConfig(context.TODO(), configFileName)
+	require.NoError(t, err)
+
+	p := resource.NewPool(tokenPool, cfg, resource.WithOrderedSelection())
+
+	vfPCIAddr, err := p.Select("1", sriov.KernelDriver)
+	require.NoError(t, err)
+	require.Equal(t, vf21PciAddr, vfPCIAddr)
+
+	vfPCIAddr, err = p.Select("2", sriov.KernelDriver)
+	require.NoError(t, err)
+	require.Equal(t, vf22PciAddr, vfPCIAddr)
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, p.Free(vf21PciAddr))
+
+		vfPCIAddr, err = p.Select("3", sriov.KernelDriver)
+		require.NoError(t, err)
+		require.Equal(t, vf21PciAddr, vfPCIAddr) // <-- the lowest free index
+	}
+}
+
+func TestPool_CheckHardware(t *testing.T) {
+	tokenPool := &tokenPoolStub{
+		tokens: map[string]string{
+			"1": path.Join(serviceDomain1, capabilityIntel),
+		},
+	}
+
+	cfg, err := config.ReadConfig(context.TODO(), configFileName)
+	require.NoError(t, err)
+
+	p := resource.NewPool(tokenPool, cfg)
+
+	provider := &sriovtest.HardwareProvider{
+		KernelDriver: "vf-driver",
+		VFs: map[string][]*sriovtest.PCIFunction{
+			"0000:01:00.0": {
+				{Addr: vf11PciAddr, IfName: "vf-11", Driver: "vf-driver"},
+			},
+			"0000:02:00.0": {
+				{Addr: vf21PciAddr, Driver: string(sriov.VFIOPCIDriver)},
+				{Addr: vf22PciAddr, IfName: "vf-22", Driver: "vf-driver"},
+			},
+			"0000:03:00.0": {
+				{Addr: vf31PciAddr},
+				{Addr: "0000:03:00.2"},
+				{Addr: "0000:03:00.3"},
+			},
+		},
+	}
+
+	// 1. Free VF bound to the vfio-pci driver is not assigned on the host
+
+	require.NoError(t, p.CheckHardware(context.Background(), provider))
+
+	// 2. Selected VF not yet moved out of the host net namespace is not a divergence
+
+	vfPCIAddr, err := p.Select("1", sriov.KernelDriver)
+	require.NoError(t, err)
+	require.Equal(t, vf11PciAddr, vfPCIAddr)
+
+	require.NoError(t, p.CheckHardware(context.Background(), provider))
+
+	// 3. VF net interface is moved out of the host net namespace, but the VF is not selected
+
+	require.NoError(t, p.Free(vfPCIAddr))
+	provider.VFs["0000:01:00.0"][0].IfName = ""
+
+	require.ErrorIs(t, p.CheckHardware(context.Background(), provider), resource.ErrHardwareDivergence)
+
+	_, err = p.Select("1", sriov.KernelDriver)
+	require.NoError(t, err)
+
+	require.NoError(t, p.CheckHardware(context.Background(), provider))
+}
+
+func TestPool_MaxVFLifetime(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	tokenPool := &tokenPoolStub{
+		tokens: map[string]string{
+			"1": path.Join(serviceDomain1, capabilityIntel),
+			"2": path.Join(serviceDomain1, capabilityIntel),
+		},
+	}
+
+	cfg, err := config.ReadConfig(context.TODO(), configFileName)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	lock := new(sync.Mutex)
+	expiredCh := make(chan string, 1)
+	p := resource.NewPool(tokenPool, cfg, resource.WithMaxVFLifetime(ctx, 10*time.Millisecond, lock,
+		func(tokenID, vfPCIAddr string) {
+			require.Equal(t, "1", tokenID)
+			expiredCh <- vfPCIAddr
+		}))
+
+	lock.Lock()
+	vfPCIAddr, err := p.Select("1", sriov.KernelDriver)
+	lock.Unlock()
+	require.NoError(t, err)
+	require.Equal(t, vf11PciAddr, vfPCIAddr)
+
+	select {
+	case expiredVF := <-expiredCh:
+		require.Equal(t, vfPCIAddr, expiredVF)
+	case <-time.After(time.Second):
+		require.FailNow(t, "no expiry signal")
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	expired, err := p.IsExpired(vfPCIAddr)
+	require.NoError(t, err)
+	require.True(t, expired)
+	require.True(t, p.State().PhysicalFunctions["0000:01:00.0"].VirtualFunctions[0].Expired)
+
+	// Expired VF is reclaimable after free
+
+	require.NoError(t, p.Free(vfPCIAddr))
+
+	expired, err = p.IsExpired(vfPCIAddr)
+	require.NoError(t, err)
+	require.False(t, expired)
+
+	cancel()
+
+	vfPCIAddr, err = p.Select("2", sriov.KernelDriver)
+	require.NoError(t, err)
+	require.Equal(t, vf11PciAddr, vfPCIAddr)
+}
+
+func TestPool_MaxVFLifetime_Reserve(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	tokenPool := &tokenPoolStub{
+		tokens: map[string]string{
+			"1": path.Join(serviceDomain1, capabilityIntel),
+		},
+	}
+
+	cfg, err := config.ReadConfig(context.TODO(), configFileName)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	lock := new(sync.Mutex)
+	expiredCh := make(chan string, 1)
+	p := resource.NewPool(tokenPool, cfg, resource.WithMaxVFLifetime(ctx, 10*time.Millisecond, lock,
+		func(_, vfPCIAddr string) {
+			expiredCh <- vfPCIAddr
+		}))
+
+	// Reserved VF lifetime starts on Commit
+
+	lock.Lock()
+	vfPCIAddr, err := p.Reserve("1", sriov.KernelDriver)
+	lock.Unlock()
+	require.NoError(t, err)
+
+	select {
+	case <-expiredCh:
+		require.FailNow(t, "reserved VF has expired")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	lock.Lock()
+	require.NoError(t, p.Commit(vfPCIAddr))
+	lock.Unlock()
+
+	select {
+	case expiredVF := <-expiredCh:
+		require.Equal(t, vfPCIAddr, expiredVF)
+	case <-time.After(time.Second):
+		require.FailNow(t, "no expiry signal")
+	}
+}
+
+func TestPool_MaxVFLifetime_Reselect(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	tokenPool := &tokenPoolStub{
+		tokens: map[string]string{
+			"1": path.Join(serviceDomain2, capabilityIntel),
+		},
+	}
+
+	cfg, err := config.ReadConfig(context.TODO(), configFileName)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	p := resource.NewPool(tokenPool, cfg, resource.WithMaxVFLifetime(ctx, time.Hour, new(sync.Mutex),
+		func(_, _ string) {}))
+
+	selectedAt := func(vfPCIAddr string) time.Time {
+		for _, pfState := range p.State().PhysicalFunctions {
+			for _, vfState := range pfState.VirtualFunctions {
+				if vfState.PCIAddr == vfPCIAddr {
+					require.NotNil(t, vfState.SelectedAt)
+					return *vfState.SelectedAt
+				}
+			}
+		}
+		require.FailNow(t, "no VF state")
+		return time.Time{}
+	}
+
+	vfPCIAddr, err := p.Select("1", sriov.KernelDriver)
+	require.NoError(t, err)
+	initial := selectedAt(vfPCIAddr)
+
+	// Token lifetime is not reset by the driver type change, migration and restore
+
+	vfPCIAddr, err = p.Select("1", sriov.VFIOPCIDriver)
+	require.NoError(t, err)
+	require.Equal(t, initial, selectedAt(vfPCIAddr))
+
+	vfPCIAddr, err = p.Migrate("1", "0000:03:00.0")
+	require.NoError(t, err)
+	require.Equal(t, initial, selectedAt(vfPCIAddr))
+
+	restored := resource.NewPool(tokenPool, cfg, resource.WithMaxVFLifetime(ctx, time.Hour, new(sync.Mutex),
+		func(_, _ string) {}))
+	require.NoError(t, restored.RestoreState(p.State()))
+	p = restored
+	require.Equal(t, initial, selectedAt(vfPCIAddr))
+
+	require.NoError(t, p.Free(vfPCIAddr))
+}
+
+func TestPool_Select_Allocator(t *testing.T) {
+	tokenPool := &tokenPoolStub{
+		tokens: map[string]string{
+			"1": path.Join(serviceDomain2, capabilityIntel),
+			"2": path.Join(serviceDomain2, capabilityIntel),
+			"3": path.Join(serviceDomain2, capabilityIntel),
+		},
+	}
+
+	cfg, err := config.ReadConfig(context.TODO(), configFileName)
+	require.NoError(t, err)
+
+	allocator := &allocatorStub{vfPCIAddr: vf22PciAddr}
+	p := resource.NewPool(tokenPool, cfg, resource.WithAllocator(allocator, nil))
+
+	vfPCIAddr, err := p.Select("1", sriov.KernelDriver)
+	require.NoError(t, err)
+	require.Equal(t, vf22PciAddr, vfPCIAddr)
+	require.Equal(t, resource.AllocRequest{
+		TokenID:    "1",
+		TokenName:  path.Join(serviceDomain2, capabilityIntel),
+		DriverType: sriov.KernelDriver,
+		Candidates: []string{vf31PciAddr, "0000:03:00.2", "0000:03:00.3", vf21PciAddr, vf22PciAddr},
+	}, allocator.req)
+
+	// Already selected VF is not a candidate anymore
+
+	_, err = p.Select("2", sriov.KernelDriver)
+	require.ErrorIs(t, err, resource.ErrInvalidAllocation)
+
+	allocator.err = errors.New("scheduler is not available")
+	_, err = p.Select("3", sriov.KernelDriver)
+	require.Error(t, err)
+}
+
+func TestPool_Select_AllocatorUnlocked(t *testing.T) {
+	type ctxKey struct{}
+
+	tokenPool := &tokenPoolStub{
+		tokens: map[string]string{
+			"1": path.Join(serviceDomain2, capabilityIntel),
+			"2": path.Join(serviceDomain2, capabilityIntel),
+			"3": path.Join(serviceDomain2, capabilityIntel),
+		},
+	}
+
+	cfg, err := config.ReadConfig(context.TODO(), configFileName)
+	require.NoError(t, err)
+
+	lock := new(sync.Mutex)
+	allocator := &allocatorStub{vfPCIAddr: vf22PciAddr}
+	p := resource.NewPool(tokenPool, cfg, resource.WithAllocator(allocator, lock))
+
+	// Lock is released while allocating and ctx is passed to the allocator
+
+	allocator.onAllocate = func() {
+		require.True(t, lock.TryLock())
+		lock.Unlock()
+	}
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, "value")
+
+	lock.Lock()
+	vfPCIAddr, err := p.SelectExcluding(ctx, "1", sriov.KernelDriver)
+	lock.Unlock()
+	require.NoError(t, err)
+	require.Equal(t, vf22PciAddr, vfPCIAddr)
+	require.Equal(t, "value", allocator.ctx.Value(ctxKey{}))
+
+	// VF selected by someone else while allocating is not a candidate anymore
+
+	allocator.vfPCIAddr = vf21PciAddr
+	allocator.onAllocate = func() {
+		lock.Lock()
+		defer lock.Unlock()
+
+		allocator.onAllocate = nil
+		_, selectErr := p.Select("3", sriov.KernelDriver)
+		require.NoError(t, selectErr)
+	}
+
+	lock.Lock()
+	_, err = p.Select("2", sriov.KernelDriver)
+	lock.Unlock()
+	require.ErrorIs(t, err, resource.ErrInvalidAllocation)
+}
+
+func TestPool_Select_WeightedAllocator(t *testing.T) {
+	tokenPool := &tokenPoolStub{
+		tokens: map[string]string{
+			"1": path.Join(serviceDomain2, capabilityIntel),
+		},
+	}
+
+	cfg, err := config.ReadConfig(context.TODO(), configFileName)
+	require.NoError(t, err)
+
+	allocator := &allocatorStub{vfPCIAddr: vf21PciAddr}
+	p := resource.NewPool(tokenPool, cfg,
+		resource.WithWeightedSelection(rand.New(rand.NewSource(1))),
+		resource.WithAllocator(allocator, nil))
+
+	// Weighted selection only reorders the candidates, all of them are passed to the allocator
+
+	for i := 0; i < 10; i++ {
+		vfPCIAddr, selectErr := p.Select("1", sriov.KernelDriver)
+		require.NoError(t, selectErr)
+		require.Equal(t, vf21PciAddr, vfPCIAddr)
+		require.ElementsMatch(t, []string{vf31PciAddr, "0000:03:00.2", "0000:03:00.3", vf21PciAddr, vf22PciAddr},
+			allocator.req.Candidates)
+
+		require.NoError(t, p.Free(vfPCIAddr))
+	}
+}
+
+func TestPool_Select_LocalAllocator(t *testing.T) {
+	tokenPool := &tokenPoolStub{
+		tokens: map[string]string{
+			"1": path.Join(serviceDomain2, capabilityIntel),
+		},
+	}
+
+	cfg, err := config.ReadConfig(context.TODO(), configFileName)
+	require.NoError(t, err)
+
+	p := resource.NewPool(tokenPool, cfg)
+
+	vfPCIAddr, err := p.Select("1", sriov.KernelDriver)
+	require.NoError(t, err)
+	require.Equal(t, vf31PciAddr, vfPCIAddr) // <-- PF with the most free VFs
+}
+
+func TestPool_Select_QoSClasses(t *testing.T) {
+	name := path.Join(serviceDomain1, capabilityIntel)
+	tokenPool := &tokenPoolStub{
+		tokens: map[string]string{
+			"0": tokens.QoSClassName(name, "guaranteed"),
+			"1": tokens.QoSClassName(name, "guaranteed"),
+			"2": tokens.QoSClassName(name, "guaranteed"),
+			"3": tokens.QoSClassName(name, "best-effort"),
+			"4": tokens.QoSClassName(name, "best-effort"),
+			"5": tokens.QoSClassName(name, "best-effort"),
+			"6": name,
+			"7": tokens.QoSClassName(name, "burstable"),
+		},
+	}
+
+	cfg, err := config.ReadConfig(context.TODO(), qosConfigFileName)
+	require.NoError(t, err)
+
+	p := resource.NewPool(tokenPool, cfg, resource.WithOrderedSelection())
+
+	guaranteed := map[string]bool{"0000:01:00.1": true, "0000:01:00.2": true}
+	bestEffort := map[string]bool{"0000:01:00.3": true, "0000:01:00.4": true}
+
+	// QoS class comes from the token, so the token never gets a VF of another class
+
+	for i := 0; i < 2; i++ {
+		vfPCIAddr, selectErr := p.Select(strconv.Itoa(i), sriov.KernelDriver)
+		require.NoError(t, selectErr)
+		require.True(t, guaranteed[vfPCIAddr], vfPCIAddr)
+	}
+	_, err = p.Select("2", sriov.KernelDriver)
+	require.ErrorIs(t, err, resource.ErrNoFreeVF)
+
+	for i := 3; i < 5; i++ {
+		vfPCIAddr, selectErr := p.Select(strconv.Itoa(i), sriov.KernelDriver)
+		require.NoError(t, selectErr)
+		require.True(t, bestEffort[vfPCIAddr], vfPCIAddr)
+	}
+	_, err = p.Select("5", sriov.KernelDriver)
+	require.ErrorIs(t, err, resource.ErrNoFreeVF)
+
+	// Default class gets only VFs with no class
+
+	vfPCIAddr, err := p.Select("6", sriov.KernelDriver)
+	require.NoError(t, err)
+	require.Equal(t, "0000:01:00.5", vfPCIAddr)
+
+	// No PF serves the token of the undeclared class
+
+	_, err = p.Select("7", sriov.KernelDriver)
+	require.ErrorIs(t, err, resource.ErrPoolDivergence)
+}
+
+func TestPool_SelectExcluding(t *testing.T) {
+	tokenPool := &tokenPoolStub{
+		tokens: map[string]string{
+			"1": tokens.QoSClassName(path.Join(serviceDomain1, capabilityIntel), "guaranteed"),
+		},
+	}
+
+	cfg, err := config.ReadConfig(context.TODO(), qosConfigFileName)
+	require.NoError(t, err)
+
+	p := resource.NewPool(tokenPool, cfg, resource.WithOrderedSelection())
+
+	vfPCIAddr, err := p.Select("1", sriov.KernelDriver)
+	require.NoError(t, err)
+	require.Equal(t, "0000:01:00.1", vfPCIAddr)
+
+	// Excluded selected VF is freed and another one of the same class is selected
+
+	vfPCIAddr, err = p.SelectExcluding(context.Background(), "1", sriov.KernelDriver, "0000:01:00.1")
+	require.NoError(t, err)
+	require.Equal(t, "0000:01:00.2", vfPCIAddr)
+
+	tokenID, err := p.GetTokenID("0000:01:00.1")
+	require.NoError(t, err)
+	require.Empty(t, tokenID)
+
+	_, err = p.SelectExcluding(context.Background(), "1", sriov.KernelDriver, "0000:01:00.1", "0000:01:00.2")
+	require.ErrorIs(t, err, resource.ErrNoFreeVF)
+
+	// Excluded VFs are not reported as free
+
+	var selectErr *resource.SelectError
+	require.ErrorAs(t, err, &selectErr)
+	require.Equal(t, resource.AllInUse, selectErr.Reason)
+	require.Zero(t, selectErr.FreeVFs)
+}
+
+func TestPool_Ready(t *testing.T) {
+	cfg, err := config.ReadConfig(context.TODO(), configFileName)
+	require.NoError(t, err)
+
+	// Pool is ready from the start if the token pool doesn't report its readiness
+
+	p := resource.NewPool(&tokenPoolStub{}, cfg)
+	<-p.Ready()
+
+	// Pool is ready when the token pool is ready
+
+	tokenPool := &readyTokenPoolStub{readyCh: make(chan struct{})}
+	p = resource.NewPool(tokenPool, cfg)
+
+	select {
+	case <-p.Ready():
+		require.FailNow(t, "Pool should wait for the token pool")
+	default:
+	}
+
+	close(tokenPool.readyCh)
+	<-p.Ready()
+}
+
+type readyTokenPoolStub struct {
+	tokenPoolStub
+
+	readyCh chan struct{}
+}
+
+func (tp *readyTokenPoolStub) Ready() <-chan struct{} {
+	return tp.readyCh
+}
+
+type allocatorStub struct {
+	vfPCIAddr  string
+	err        error
+	ctx        context.Context
+	req        resource.AllocRequest
+	onAllocate func()
+}
+
+func (a *allocatorStub) Allocate(ctx context.Context, req resource.AllocRequest) (string, error) {
+	a.ctx, a.req = ctx, req
+	if a.onAllocate != nil {
+		a.onAllocate()
+	}
+	return a.vfPCIAddr, a.err
+}
+
+type tokenPoolStub struct {
+	tokens map[string]string
+	inUse  map[string]struct{}
+	freed  map[string]struct{}
+}
+
+func (tp *tokenPoolStub) Find(id string) (string, error) {
+	if tokenName, ok := tp.tokens[id]; ok {
+		return tokenName, nil
+	}
+	return "", errors.New("invalid token ID")
+}
+
+func (tp *tokenPoolStub) Use(id string, _ []string) error {
+	if _, ok := tp.tokens[id]; ok {
+		if tp.inUse == nil {
+			tp.inUse = map[string]struct{}{}
+		}
+		tp.inUse[id] = struct{}{}
+		return nil
+	}
+	return errors.New("invalid token ID")
+}
+
+func (tp *tokenPoolStub) StopUsing(id string) error {
+	if _, ok := tp.tokens[id]; ok {
+		delete(tp.inUse, id)
+		return nil
+	}
+	return errors.New("invalid token ID")
+}
+
+func (tp *tokenPoolStub) Free(id string) error {
 	if _, ok := tp.tokens[id]; ok {
+		if tp.freed == nil {
+			tp.freed = map[string]struct{}{}
+		}
+		delete(tp.inUse, id)
+		tp.freed[id] = struct{}{}
 		return nil
 	}
 	return errors.New("invalid token ID")
@@ -0,0 +1,107 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov"
+)
+
+// PreemptFunc is called when the VF reserved by the token is reclaimed for the token of a higher priority service
+// domain, the VF stays reserved until the connection releases it: Commit fails with ErrPreempted, Cancel or Free
+// release the VF and free the token, so the connection should stop the Request and release the VF
+type PreemptFunc func(tokenID, vfPCIAddr string)
+
+// preempt marks as preempted the VF reserved by the token of the lowest priority service domain lower than the token
+// name service domain priority, which can be selected for the token name and driver type. Only VFs reserved by the
+// allocated, but not yet used tokens are preempted, VFs in use are never touched. The VF is handed over only after
+// its connection releases it, so preempt returns true if there is a VF being preempted for the token and Select
+// should be retried on the VF release.
+func (p *Pool) preempt(tokenID, tokenName string, driverType sriov.DriverType, qosClass string, excluded map[string]struct{}) bool {
+	for _, vf := range p.virtualFunctions {
+		if vf.preemptedFor == tokenID {
+			return true
+		}
+	}
+
+	priority := p.priorities[serviceDomainOf(tokenName)]
+
+	var victims []*virtualFunction
+	victimPriorities := map[*virtualFunction]int{}
+	for _, pf := range p.physicalFunctions {
+		if _, ok := pf.tokenNames[tokenName]; !ok {
+			continue
+		}
+		for _, vfs := range pf.virtualFunctions {
+			for _, vf := range vfs {
+				if !vf.reserved || vf.preemptedFor != "" || vf.qosClass != qosClass || !p.isPreemptibleFor(vf, driverType) {
+					continue
+				}
+				if _, ok := excluded[vf.pciAddr]; ok {
+					continue
+				}
+
+				victimTokenName, err := p.tokenPool.Find(vf.tokenID)
+				if err != nil {
+					continue
+				}
+				if victimPriority := p.priorities[serviceDomainOf(victimTokenName)]; victimPriority < priority {
+					victims = append(victims, vf)
+					victimPriorities[vf] = victimPriority
+				}
+			}
+		}
+	}
+	if len(victims) == 0 {
+		return false
+	}
+
+	sort.Slice(victims, func(i, k int) bool {
+		if victimPriorities[victims[i]] != victimPriorities[victims[k]] {
+			return victimPriorities[victims[i]] < victimPriorities[victims[k]]
+		}
+		return strings.Compare(victims[i].pciAddr, victims[k].pciAddr) < 0
+	})
+
+	victim := victims[0]
+	victim.preemptedFor = tokenID
+	if p.onPreempt != nil {
+		go p.onPreempt(victim.tokenID, victim.pciAddr)
+	}
+
+	return true
+}
+
+// isPreemptibleFor returns true if the VF IOMMU group can be used by the driver type after the VF is freed
+func (p *Pool) isPreemptibleFor(vf *virtualFunction, driverType sriov.DriverType) bool {
+	if p.iommuGroups[vf.iommuGroup] == driverType {
+		return true
+	}
+	for _, groupVF := range p.iommuGroupVFs[vf.iommuGroup] {
+		if groupVF != vf && groupVF.tokenID != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// serviceDomainOf returns the service domain of the "serviceDomain/capability" token name
+func serviceDomainOf(tokenName string) string {
+	return strings.SplitN(tokenName, "/", 2)[0]
+}
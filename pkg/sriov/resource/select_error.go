@@ -0,0 +1,71 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"fmt"
+
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov"
+)
+
+// SelectReason is a reason why Select has failed
+type SelectReason string
+
+const (
+	// NoMatchingPF means there is no PF matching the token name on the allowed NUMA nodes, the token name not served
+	// by any PF at all is reported as ErrPoolDivergence
+	NoMatchingPF SelectReason = "NoMatchingPF"
+	// AllInUse means all VFs of the matching PFs are already selected
+	AllInUse SelectReason = "AllInUse"
+	// DriverConflict means there are free VFs of the matching PFs, but their IOMMU groups are used by another
+	// driver type
+	DriverConflict SelectReason = "DriverConflict"
+	// NodeCapacity means node-wide VF capacity is exhausted
+	NodeCapacity SelectReason = "NodeCapacity"
+)
+
+// SelectError is returned by Select when there is no VF to select, it matches ErrNodeCapacity for the NodeCapacity
+// reason and ErrNoFreeVF for the others
+type SelectError struct {
+	Reason     SelectReason
+	TokenName  string
+	DriverType sriov.DriverType
+	// MatchingPFs is a number of PFs matching the token name
+	MatchingPFs int
+	// MatchingVFs is a number of VFs of the matching PFs
+	MatchingVFs int
+	// FreeVFs is a number of not selected and not excluded VFs of the matching PFs
+	FreeVFs int
+	// SelectedVFs is a number of VFs selected on the node
+	SelectedVFs int
+}
+
+func (e *SelectError) Error() string {
+	if e.Reason == NodeCapacity {
+		return fmt.Sprintf("%v: %d VFs are already selected", ErrNodeCapacity, e.SelectedVFs)
+	}
+	return fmt.Sprintf("%v: %v - token name: %v, driver type: %v, matching PFs: %d, matching VFs: %d, free VFs: %d",
+		ErrNoFreeVF, e.Reason, e.TokenName, e.DriverType, e.MatchingPFs, e.MatchingVFs, e.FreeVFs)
+}
+
+// Is returns if e matches the target error
+func (e *SelectError) Is(target error) bool {
+	if e.Reason == NodeCapacity {
+		return target == ErrNodeCapacity
+	}
+	return target == ErrNoFreeVF
+}
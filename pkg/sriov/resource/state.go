@@ -0,0 +1,202 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov"
+	"github.com/networkservicemesh/sdk-sriov/pkg/tools/yamlhelper"
+)
+
+// State is a Pool state
+type State struct {
+	PhysicalFunctions map[string]*PhysicalFunctionState `json:"physicalFunctions"`
+	IOMMUGroups       map[uint]sriov.DriverType         `json:"iommuGroups"`
+}
+
+// PhysicalFunctionState is a Pool physical function state
+type PhysicalFunctionState struct {
+	NUMANode         int                     `json:"numaNode"`
+	FreeVFsCount     int                     `json:"freeVFsCount"`
+	VirtualFunctions []*VirtualFunctionState `json:"virtualFunctions"`
+}
+
+// VirtualFunctionState is a Pool virtual function state
+type VirtualFunctionState struct {
+	PCIAddr      string           `json:"pciAddr"`
+	IOMMUGroup   uint             `json:"iommuGroup"`
+	TokenID      string           `json:"tokenID,omitempty"`
+	DriverType   sriov.DriverType `json:"driverType"`
+	HardwareAddr string           `json:"hardwareAddr,omitempty"`
+	SelectedAt   *time.Time       `json:"selectedAt,omitempty"`
+	Expired      bool             `json:"expired,omitempty"`
+	QoSClass     string           `json:"qosClass,omitempty"`
+	Reserved     bool             `json:"reserved,omitempty"`
+	PreemptedFor string           `json:"preemptedFor,omitempty"`
+}
+
+type stateFileWriter struct {
+	ctx      context.Context
+	path     string
+	interval time.Duration
+	lock     sync.Locker
+}
+
+// State returns the current Pool state
+func (p *Pool) State() *State {
+	state := &State{
+		PhysicalFunctions: map[string]*PhysicalFunctionState{},
+		IOMMUGroups:       map[uint]sriov.DriverType{},
+	}
+
+	for pfPCIAddr, pf := range p.physicalFunctions {
+		pfState := &PhysicalFunctionState{
+			NUMANode:     pf.numaNode,
+			FreeVFsCount: pf.freeVFsCount,
+		}
+		for _, vfs := range pf.virtualFunctions {
+			for _, vf := range vfs {
+				vfState := &VirtualFunctionState{
+					PCIAddr:      vf.pciAddr,
+					IOMMUGroup:   vf.iommuGroup,
+					TokenID:      vf.tokenID,
+					DriverType:   vf.driverType,
+					Expired:      vf.expired,
+					QoSClass:     vf.qosClass,
+					Reserved:     vf.reserved,
+					PreemptedFor: vf.preemptedFor,
+				}
+				if vf.hardwareAddr != nil {
+					vfState.HardwareAddr = vf.hardwareAddr.String()
+				}
+				if vf.tokenID != "" {
+					selectedAt := vf.selectedAt.UTC()
+					vfState.SelectedAt = &selectedAt
+				}
+				pfState.VirtualFunctions = append(pfState.VirtualFunctions, vfState)
+			}
+		}
+		sort.Slice(pfState.VirtualFunctions, func(i, k int) bool {
+			return pfState.VirtualFunctions[i].PCIAddr < pfState.VirtualFunctions[k].PCIAddr
+		})
+		state.PhysicalFunctions[pfPCIAddr] = pfState
+	}
+
+	for iommuGroup, driverType := range p.iommuGroups {
+		state.IOMMUGroups[iommuGroup] = driverType
+	}
+
+	return state
+}
+
+// RestoreState restores selected VFs from the state, it should be called on a new Pool before any Select. Token pool
+// is not updated, it should be restored separately. MAC addresses are restored as is, they are not allocated from the
+// MAC pool.
+func (p *Pool) RestoreState(state *State) error {
+	for _, pfState := range state.PhysicalFunctions {
+		for _, vfState := range pfState.VirtualFunctions {
+			if vfState.TokenID == "" {
+				continue
+			}
+
+			vf, ok := p.virtualFunctions[vfState.PCIAddr]
+			if !ok {
+				return errors.Errorf("VF doesn't exist: %v", vfState.PCIAddr)
+			}
+			if vf.tokenID != "" {
+				return errors.Errorf("VF is already selected: %v", vfState.PCIAddr)
+			}
+
+			var hardwareAddr net.HardwareAddr
+			if vfState.HardwareAddr != "" {
+				var err error
+				if hardwareAddr, err = net.ParseMAC(vfState.HardwareAddr); err != nil {
+					return errors.Wrapf(err, "invalid VF %v MAC address", vfState.PCIAddr)
+				}
+			}
+
+			p.tokens[vfState.TokenID] = vf
+			vf.tokenID = vfState.TokenID
+			vf.driverType = vfState.DriverType
+			vf.hardwareAddr = hardwareAddr
+			vf.expired = vfState.Expired
+			vf.reserved = vfState.Reserved
+			vf.preemptedFor = vfState.PreemptedFor
+			// VF lifetime is counted from the original selection, not from the restore
+			vf.selectedAt = time.Now()
+			if vfState.SelectedAt != nil {
+				vf.selectedAt = *vfState.SelectedAt
+			}
+
+			p.physicalFunctions[vf.pfPCIAddr].freeVFsCount--
+			p.iommuGroups[vf.iommuGroup] = vf.driverType
+
+			if !vf.expired && !vf.reserved {
+				p.startExpiryTimer(vf)
+			}
+		}
+	}
+
+	return nil
+}
+
+// WriteStateFile writes the current Pool state to the file in JSON format, the file is replaced atomically
+func (p *Pool) WriteStateFile(path string) error {
+	return writeStateFile(path, p.State())
+}
+
+func writeStateFile(path string, state *State) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal pool state")
+	}
+
+	return yamlhelper.WriteFileAtomic(path, data)
+}
+
+func (p *Pool) runStateFileWriter(w *stateFileWriter) {
+	logger := log.FromContext(w.ctx).WithField("resourcePool", "stateFileWriter")
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		w.lock.Lock()
+		state := p.State()
+		w.lock.Unlock()
+
+		if err := writeStateFile(w.path, state); err != nil {
+			logger.Warnf("failed to write pool state: %v", err)
+		}
+
+		select {
+		case <-w.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
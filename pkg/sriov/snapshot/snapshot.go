@@ -0,0 +1,80 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package snapshot provides a consistent point-in-time snapshot of the token and resource pools
+package snapshot
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/resource"
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/token"
+)
+
+// TokenPool is a token.Pool interface
+type TokenPool interface {
+	Quiesce() (state *token.State, release func())
+	RestoreState(state *token.State) error
+}
+
+// ResourcePool is a resource.Pool interface
+type ResourcePool interface {
+	State() *resource.State
+	RestoreState(state *resource.State) error
+}
+
+// ConsistentSnapshot is a serializable point-in-time state of the token and resource pools
+type ConsistentSnapshot struct {
+	Tokens    *token.State    `json:"tokens"`
+	Resources *resource.State `json:"resources"`
+}
+
+// Take captures the token and resource pools state atomically. resourceLock is the lock guarding the resource pool,
+// it is the same lock passed to the resourcepool chain elements.
+//
+// Locks are acquired in the order: resourceLock, then the token pool lock. It is the same order resource.Pool uses
+// when it calls token.Pool.Use and token.Pool.StopUsing under resourceLock on Select and Free, so Take doesn't
+// deadlock with the ongoing Requests. Nothing should acquire resourceLock while holding the token pool lock.
+func Take(ctx context.Context, tokenPool TokenPool, resourcePool ResourcePool, resourceLock sync.Locker) (*ConsistentSnapshot, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to take snapshot")
+	}
+
+	resourceLock.Lock()
+	defer resourceLock.Unlock()
+
+	tokens, release := tokenPool.Quiesce()
+	defer release()
+
+	return &ConsistentSnapshot{
+		Tokens:    tokens,
+		Resources: resourcePool.State(),
+	}, nil
+}
+
+// Restore restores the snapshot into the new untouched token and resource pools, e.g. in a new process
+func (s *ConsistentSnapshot) Restore(tokenPool TokenPool, resourcePool ResourcePool) error {
+	if err := tokenPool.RestoreState(s.Tokens); err != nil {
+		return errors.Wrap(err, "failed to restore token pool")
+	}
+	if err := resourcePool.RestoreState(s.Resources); err != nil {
+		return errors.Wrap(err, "failed to restore resource pool")
+	}
+	return nil
+}
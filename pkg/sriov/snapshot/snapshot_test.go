@@ -0,0 +1,112 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshot_test
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov"
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/config"
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/resource"
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/snapshot"
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/token"
+)
+
+const (
+	intelTokenName = "service.domain.1/intel"
+	tenGTokenName  = "service.domain.1/10G"
+)
+
+func testConfig() *config.Config {
+	return &config.Config{
+		PhysicalFunctions: map[string]*config.PhysicalFunction{
+			"0000:01:00.0": {
+				Capabilities:   []string{"intel", "10G"},
+				ServiceDomains: []string{"service.domain.1"},
+				VirtualFunctions: []*config.VirtualFunction{
+					{Address: "0000:01:00.1", IOMMUGroup: 1},
+					{Address: "0000:01:00.2", IOMMUGroup: 2},
+				},
+			},
+		},
+	}
+}
+
+func TestSnapshot_RoundTrip(t *testing.T) {
+	cfg := testConfig()
+
+	tokenPool := token.NewPool(cfg)
+	resourcePool := resource.NewPool(tokenPool, cfg)
+
+	var tokenID string
+	for id := range tokenPool.Tokens()[intelTokenName] {
+		tokenID = id
+		break
+	}
+	require.NoError(t, tokenPool.Allocate(tokenID))
+
+	vfPCIAddr, err := resourcePool.Select(tokenID, sriov.KernelDriver)
+	require.NoError(t, err)
+
+	snap, err := snapshot.Take(context.Background(), tokenPool, resourcePool, new(sync.Mutex))
+	require.NoError(t, err)
+
+	data, err := json.Marshal(snap)
+	require.NoError(t, err)
+
+	// Restore in a "new process"
+
+	restoredSnap := new(snapshot.ConsistentSnapshot)
+	require.NoError(t, json.Unmarshal(data, restoredSnap))
+
+	restoredTokenPool := token.NewPool(cfg)
+	restoredResourcePool := resource.NewPool(restoredTokenPool, cfg)
+	require.NoError(t, restoredSnap.Restore(restoredTokenPool, restoredResourcePool))
+
+	require.Equal(t, tokenPool.Counts(), restoredTokenPool.Counts())
+	require.Equal(t, token.TokenCounts{Total: 2, Free: 1, InUse: 1}, restoredTokenPool.Counts()[intelTokenName])
+	require.Equal(t, token.TokenCounts{Total: 2, Free: 1, Closed: 1}, restoredTokenPool.Counts()[tenGTokenName])
+	require.Equal(t, resourcePool.State(), restoredResourcePool.State())
+
+	restoredTokenID, err := restoredResourcePool.GetTokenID(vfPCIAddr)
+	require.NoError(t, err)
+	require.Equal(t, tokenID, restoredTokenID)
+
+	// In-use token is restored with the closed tokens
+
+	require.NoError(t, restoredResourcePool.Free(vfPCIAddr))
+	require.Equal(t, token.TokenCounts{Total: 2, Free: 1, Allocated: 1}, restoredTokenPool.Counts()[intelTokenName])
+	require.Equal(t, token.TokenCounts{Total: 2, Free: 2}, restoredTokenPool.Counts()[tenGTokenName])
+}
+
+func TestSnapshot_RestoreTouchedPool(t *testing.T) {
+	cfg := testConfig()
+
+	tokenPool := token.NewPool(cfg)
+	resourcePool := resource.NewPool(tokenPool, cfg)
+
+	snap, err := snapshot.Take(context.Background(), tokenPool, resourcePool, new(sync.Mutex))
+	require.NoError(t, err)
+
+	// Take has quiesced the token pool, so it is not untouched anymore
+	require.Error(t, snap.Restore(tokenPool, resourcePool))
+}
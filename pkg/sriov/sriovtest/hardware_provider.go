@@ -0,0 +1,43 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sriovtest
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov"
+)
+
+// HardwareProvider is a test sriov.HardwareProvider returning VFs by the PF PCI addresses
+type HardwareProvider struct {
+	VFs          map[string][]*PCIFunction
+	KernelDriver string
+}
+
+// GetVirtualFunctions returns VFs of the PF and the KernelDriver, returns error if the PF doesn't exist
+func (p *HardwareProvider) GetVirtualFunctions(pfPCIAddr string) ([]sriov.HardwareFunction, string, error) {
+	pfVFs, ok := p.VFs[pfPCIAddr]
+	if !ok {
+		return nil, "", errors.Errorf("PF doesn't exist: %v", pfPCIAddr)
+	}
+
+	var vfs []sriov.HardwareFunction
+	for _, vf := range pfVFs {
+		vfs = append(vfs, vf)
+	}
+	return vfs, p.KernelDriver, nil
+}
@@ -0,0 +1,240 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sriovtest
+
+import (
+	"net"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/vishvananda/netlink"
+)
+
+// NetlinkHandle is a test vfnetlink.Handle storing PF links in memory
+type NetlinkHandle struct {
+	// Err is returned by all link setters if not nil
+	Err error
+
+	lock    sync.Mutex
+	links   map[string]*netlink.Device
+	devlink devlinkState
+}
+
+// NewNetlinkHandle returns a new NetlinkHandle
+func NewNetlinkHandle() *NetlinkHandle {
+	return &NetlinkHandle{
+		links: map[string]*netlink.Device{},
+	}
+}
+
+// DefaultMTU is a default MTU for the NetlinkHandle links
+const DefaultMTU = 1500
+
+// AddPhysicalFunction adds PF link with vfCount VFs
+func (h *NetlinkHandle) AddPhysicalFunction(ifName string, vfCount int) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	link := &netlink.Device{
+		LinkAttrs: netlink.LinkAttrs{
+			Name: ifName,
+			MTU:  DefaultMTU,
+		},
+	}
+	for i := 0; i < vfCount; i++ {
+		link.Vfs = append(link.Vfs, netlink.VfInfo{ID: i})
+	}
+	h.links[ifName] = link
+}
+
+// AddLink adds a link with no VFs, e.g. VF net interface
+func (h *NetlinkHandle) AddLink(ifName string, mtu int) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	h.links[ifName] = &netlink.Device{
+		LinkAttrs: netlink.LinkAttrs{
+			Name: ifName,
+			MTU:  mtu,
+		},
+	}
+}
+
+// GetVF returns a copy of the PF link VF info
+func (h *NetlinkHandle) GetVF(ifName string, vfIndex int) netlink.VfInfo {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	return h.links[ifName].Vfs[vfIndex]
+}
+
+// UpdateVF updates the PF link VF info, e.g. to set VF stats
+func (h *NetlinkHandle) UpdateVF(ifName string, vfIndex int, update func(vfInfo *netlink.VfInfo)) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	update(&h.links[ifName].Vfs[vfIndex])
+}
+
+// LinkByName returns a copy of the PF link
+func (h *NetlinkHandle) LinkByName(name string) (netlink.Link, error) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	link, ok := h.links[name]
+	if !ok {
+		return nil, errors.Errorf("link not found: %v", name)
+	}
+
+	linkCopy := *link
+	linkCopy.Vfs = append([]netlink.VfInfo(nil), link.Vfs...)
+	linkCopy.AltNames = append([]string(nil), link.AltNames...)
+
+	return &linkCopy, nil
+}
+
+// LinkSetVfVlanQos sets VF VLAN and QoS
+func (h *NetlinkHandle) LinkSetVfVlanQos(link netlink.Link, vf, vlan, qos int) error {
+	return h.updateVF(link, vf, func(vfInfo *netlink.VfInfo) {
+		vfInfo.Vlan = vlan
+		vfInfo.Qos = qos
+	})
+}
+
+// LinkSetVfSpoofchk sets VF spoof check
+func (h *NetlinkHandle) LinkSetVfSpoofchk(link netlink.Link, vf int, check bool) error {
+	return h.updateVF(link, vf, func(vfInfo *netlink.VfInfo) {
+		vfInfo.Spoofchk = check
+	})
+}
+
+// LinkSetVfTrust sets VF trust
+func (h *NetlinkHandle) LinkSetVfTrust(link netlink.Link, vf int, state bool) error {
+	return h.updateVF(link, vf, func(vfInfo *netlink.VfInfo) {
+		vfInfo.Trust = 0
+		if state {
+			vfInfo.Trust = 1
+		}
+	})
+}
+
+// LinkSetVfRate sets VF min and max TX rates
+func (h *NetlinkHandle) LinkSetVfRate(link netlink.Link, vf, minRate, maxRate int) error {
+	return h.updateVF(link, vf, func(vfInfo *netlink.VfInfo) {
+		vfInfo.MinTxRate = uint32(minRate)
+		vfInfo.MaxTxRate = uint32(maxRate)
+	})
+}
+
+// LinkSetVfState sets VF link state
+func (h *NetlinkHandle) LinkSetVfState(link netlink.Link, vf int, state uint32) error {
+	return h.updateVF(link, vf, func(vfInfo *netlink.VfInfo) {
+		vfInfo.LinkState = state
+	})
+}
+
+// LinkSetVfHardwareAddr sets VF MAC address
+func (h *NetlinkHandle) LinkSetVfHardwareAddr(link netlink.Link, vf int, hwaddr net.HardwareAddr) error {
+	return h.updateVF(link, vf, func(vfInfo *netlink.VfInfo) {
+		vfInfo.Mac = append(net.HardwareAddr(nil), hwaddr...)
+	})
+}
+
+// LinkSetMTU sets link MTU
+func (h *NetlinkHandle) LinkSetMTU(link netlink.Link, mtu int) error {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if h.Err != nil {
+		return h.Err
+	}
+
+	storedLink, ok := h.links[link.Attrs().Name]
+	if !ok {
+		return errors.Errorf("link not found: %v", link.Attrs().Name)
+	}
+	storedLink.MTU = mtu
+
+	return nil
+}
+
+// LinkAddAltName adds link alternative name
+func (h *NetlinkHandle) LinkAddAltName(link netlink.Link, name string) error {
+	return h.updateLink(link, func(storedLink *netlink.Device) {
+		storedLink.AltNames = append(storedLink.AltNames, name)
+	})
+}
+
+// LinkDelAltName deletes link alternative name
+func (h *NetlinkHandle) LinkDelAltName(link netlink.Link, name string) error {
+	return h.updateLink(link, func(storedLink *netlink.Device) {
+		for i, altName := range storedLink.AltNames {
+			if altName == name {
+				storedLink.AltNames = append(storedLink.AltNames[:i:i], storedLink.AltNames[i+1:]...)
+				return
+			}
+		}
+	})
+}
+
+// LinkSetUp sets link administratively up
+func (h *NetlinkHandle) LinkSetUp(link netlink.Link) error {
+	return h.updateLink(link, func(storedLink *netlink.Device) {
+		storedLink.Flags |= net.FlagUp
+	})
+}
+
+// LinkSetDown sets link administratively down
+func (h *NetlinkHandle) LinkSetDown(link netlink.Link) error {
+	return h.updateLink(link, func(storedLink *netlink.Device) {
+		storedLink.Flags &^= net.FlagUp
+	})
+}
+
+func (h *NetlinkHandle) updateVF(link netlink.Link, vf int, update func(vfInfo *netlink.VfInfo)) error {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if h.Err != nil {
+		return h.Err
+	}
+
+	storedLink, ok := h.links[link.Attrs().Name]
+	if !ok || vf < 0 || vf >= len(storedLink.Vfs) {
+		return errors.Errorf("VF not found: %v %v", link.Attrs().Name, vf)
+	}
+	update(&storedLink.Vfs[vf])
+
+	return nil
+}
+
+func (h *NetlinkHandle) updateLink(link netlink.Link, update func(storedLink *netlink.Device)) error {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if h.Err != nil {
+		return h.Err
+	}
+
+	storedLink, ok := h.links[link.Attrs().Name]
+	if !ok {
+		return errors.Errorf("link not found: %v", link.Attrs().Name)
+	}
+	update(storedLink)
+
+	return nil
+}
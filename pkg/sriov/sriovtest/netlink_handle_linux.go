@@ -0,0 +1,127 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package sriovtest
+
+import (
+	"github.com/pkg/errors"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+)
+
+type devlinkState struct {
+	ports        map[devlinkPortKey]*netlink.DevlinkPort
+	eswitchModes map[string]string
+}
+
+type devlinkPortKey struct {
+	device    string
+	portIndex uint32
+}
+
+// SetEswitchMode sets devlink eswitch mode for the PF PCI address, default mode is "legacy"
+func (h *NetlinkHandle) SetEswitchMode(pfPCIAddr, mode string) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if h.devlink.eswitchModes == nil {
+		h.devlink.eswitchModes = map[string]string{}
+	}
+	h.devlink.eswitchModes[pfPCIAddr] = mode
+}
+
+// DevLinkGetDeviceByName returns devlink device with the eswitch mode set by SetEswitchMode
+func (h *NetlinkHandle) DevLinkGetDeviceByName(bus, device string) (*netlink.DevlinkDevice, error) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	mode, ok := h.devlink.eswitchModes[device]
+	if !ok {
+		mode = "legacy"
+	}
+
+	return &netlink.DevlinkDevice{
+		BusName:    bus,
+		DeviceName: device,
+		Attrs: netlink.DevlinkDevAttrs{
+			Eswitch: netlink.DevlinkDevEswitchAttr{Mode: mode},
+		},
+	}, nil
+}
+
+// AddDevlinkPort adds devlink port for the PF PCI address, port with nil fn doesn't support port function
+func (h *NetlinkHandle) AddDevlinkPort(pfPCIAddr string, portIndex uint32, fn *netlink.DevlinkPortFn) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if h.devlink.ports == nil {
+		h.devlink.ports = map[devlinkPortKey]*netlink.DevlinkPort{}
+	}
+	h.devlink.ports[devlinkPortKey{device: pfPCIAddr, portIndex: portIndex}] = &netlink.DevlinkPort{
+		BusName:    "pci",
+		DeviceName: pfPCIAddr,
+		PortIndex:  portIndex,
+		Fn:         fn,
+	}
+}
+
+// DevLinkGetPortByIndex returns a copy of the devlink port
+func (h *NetlinkHandle) DevLinkGetPortByIndex(_, device string, portIndex uint32) (*netlink.DevlinkPort, error) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	port, ok := h.devlink.ports[devlinkPortKey{device: device, portIndex: portIndex}]
+	if !ok {
+		return nil, errors.Errorf("devlink port not found: %v %v", device, portIndex)
+	}
+
+	portCopy := *port
+	if port.Fn != nil {
+		fnCopy := *port.Fn
+		portCopy.Fn = &fnCopy
+	}
+
+	return &portCopy, nil
+}
+
+// DevlinkPortFnSet sets devlink port function attributes, returns unix.EOPNOTSUPP if the port has no function
+func (h *NetlinkHandle) DevlinkPortFnSet(_, device string, portIndex uint32, fnAttrs netlink.DevlinkPortFnSetAttrs) error {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if h.Err != nil {
+		return h.Err
+	}
+
+	port, ok := h.devlink.ports[devlinkPortKey{device: device, portIndex: portIndex}]
+	if !ok {
+		return errors.Errorf("devlink port not found: %v %v", device, portIndex)
+	}
+	if port.Fn == nil {
+		return unix.EOPNOTSUPP
+	}
+	if fnAttrs.StateValid {
+		port.Fn.State = fnAttrs.FnAttrs.State
+	}
+	if fnAttrs.HwAddrValid {
+		port.Fn.HwAddr = fnAttrs.FnAttrs.HwAddr
+	}
+
+	return nil
+}
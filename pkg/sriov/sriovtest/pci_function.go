@@ -17,6 +17,14 @@
 // Package sriovtest provides utils for SR-IOV testing
 package sriovtest
 
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov"
+)
+
 // PCIPhysicalFunction is a test data class for pcifunction.PhysicalFunction
 type PCIPhysicalFunction struct {
 	Vfs []*PCIFunction `yaml:"vfs"`
@@ -26,10 +34,22 @@ type PCIPhysicalFunction struct {
 
 // PCIFunction is a test data class for pcifunction.Function
 type PCIFunction struct {
-	Addr       string `yaml:"addr"`
-	IfName     string `yaml:"ifName"`
-	IOMMUGroup uint   `yaml:"iommuGroup"`
-	Driver     string `yaml:"driver"`
+	Addr            string `yaml:"addr"`
+	IfName          string `yaml:"ifName"`
+	IOMMUGroup      uint   `yaml:"iommuGroup"`
+	Driver          string `yaml:"driver"`
+	DriverVersion   string `yaml:"driverVersion"`
+	FirmwareVersion string `yaml:"firmwareVersion"`
+	OperState       string `yaml:"operState"`
+	Resetting       bool   `yaml:"resetting"`
+	VendorID        string `yaml:"vendorID"`
+	DeviceID        string `yaml:"deviceID"`
+	NUMANode        int    `yaml:"numaNode"`
+	ResetCount      int    `yaml:"-"`
+	// BindDriverErr is returned by BindDriver if set, the bound driver is not changed then
+	BindDriverErr error `yaml:"-"`
+
+	PCIeLinkStatus *sriov.PCIeLinkStatus `yaml:"pcieLinkStatus"`
 }
 
 // GetPCIAddress returns f.Addr
@@ -42,18 +62,79 @@ func (f *PCIFunction) GetNetInterfaceName() (string, error) {
 	return f.IfName, nil
 }
 
+// GetOperState returns f.OperState
+func (f *PCIFunction) GetOperState() (string, error) {
+	return f.OperState, nil
+}
+
+// IsResetting returns f.Resetting
+func (f *PCIFunction) IsResetting() (bool, error) {
+	return f.Resetting, nil
+}
+
 // GetIOMMUGroup returns f.IOMMUGroup
 func (f *PCIFunction) GetIOMMUGroup() (uint, error) {
 	return f.IOMMUGroup, nil
 }
 
+// GetNUMANode returns f.NUMANode
+func (f *PCIFunction) GetNUMANode() (int, error) {
+	return f.NUMANode, nil
+}
+
+// GetDeviceIDs returns f.VendorID, f.DeviceID
+func (f *PCIFunction) GetDeviceIDs() (vendorID, deviceID string, err error) {
+	return f.VendorID, f.DeviceID, nil
+}
+
 // GetBoundDriver returns f.Driver
 func (f *PCIFunction) GetBoundDriver() (string, error) {
 	return f.Driver, nil
 }
 
-// BindDriver sets f.Driver = driver
+// BindDriver sets f.Driver = driver or returns f.BindDriverErr if it is set
 func (f *PCIFunction) BindDriver(driver string) error {
+	if f.BindDriverErr != nil {
+		return f.BindDriverErr
+	}
 	f.Driver = driver
 	return nil
 }
+
+// BindDriverWithContext is the same as BindDriver
+func (f *PCIFunction) BindDriverWithContext(_ context.Context, driver string) error {
+	return f.BindDriver(driver)
+}
+
+// UnbindDriver sets f.Driver = ""
+func (f *PCIFunction) UnbindDriver() error {
+	f.Driver = ""
+	return nil
+}
+
+// Reset increments f.ResetCount
+func (f *PCIFunction) Reset() error {
+	f.ResetCount++
+	return nil
+}
+
+// GetDeviceInfo returns f.Driver, f.DriverVersion, f.FirmwareVersion, if f.DriverVersion is not set returns
+// sriov.ErrNotSupported
+func (f *PCIFunction) GetDeviceInfo() (*sriov.DeviceInfo, error) {
+	if f.DriverVersion == "" {
+		return nil, errors.Wrapf(sriov.ErrNotSupported, "failed to get driver info for the device: %v", f.Addr)
+	}
+	return &sriov.DeviceInfo{
+		Driver:          f.Driver,
+		DriverVersion:   f.DriverVersion,
+		FirmwareVersion: f.FirmwareVersion,
+	}, nil
+}
+
+// GetPCIeLinkStatus returns f.PCIeLinkStatus, if f.PCIeLinkStatus is not set returns sriov.ErrNotSupported
+func (f *PCIFunction) GetPCIeLinkStatus() (*sriov.PCIeLinkStatus, error) {
+	if f.PCIeLinkStatus == nil {
+		return nil, errors.Wrapf(sriov.ErrNotSupported, "failed to get PCIe link status for the device: %v", f.Addr)
+	}
+	return f.PCIeLinkStatus, nil
+}
@@ -0,0 +1,79 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sriovtest
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	sysfsDirPerm  = 0o750
+	sysfsFilePerm = 0o600
+)
+
+// Sysfs is a test PCI devices and drivers sysfs tree in a temporary directory
+type Sysfs struct {
+	DevicesPath string
+	DriversPath string
+}
+
+// NewSysfs returns a new empty Sysfs
+func NewSysfs(t *testing.T) *Sysfs {
+	tmpDir := t.TempDir()
+	fs := &Sysfs{
+		DevicesPath: filepath.Join(tmpDir, "devices"),
+		DriversPath: filepath.Join(tmpDir, "drivers"),
+	}
+	require.NoError(t, os.MkdirAll(fs.DriversPath, sysfsDirPerm))
+	return fs
+}
+
+// AddDevice adds PCI device directory, returns its path
+func (fs *Sysfs) AddDevice(t *testing.T, pciAddr string) string {
+	devicePath := filepath.Join(fs.DevicesPath, pciAddr)
+	require.NoError(t, os.MkdirAll(devicePath, sysfsDirPerm))
+	return devicePath
+}
+
+// AddPhysicalFunction adds PF device directory with the VFs count files and the virtfnN links to the VF devices,
+// returns its path. VF devices should be added separately.
+func (fs *Sysfs) AddPhysicalFunction(t *testing.T, pciAddr string, vfPCIAddrs ...string) string {
+	devicePath := fs.AddDevice(t, pciAddr)
+
+	vfsCount := []byte(strconv.Itoa(len(vfPCIAddrs)))
+	require.NoError(t, os.WriteFile(filepath.Join(devicePath, "sriov_totalvfs"), vfsCount, sysfsFilePerm))
+	require.NoError(t, os.WriteFile(filepath.Join(devicePath, "sriov_numvfs"), vfsCount, sysfsFilePerm))
+
+	for i, vfPCIAddr := range vfPCIAddrs {
+		virtfn := filepath.Join(devicePath, "virtfn"+strconv.Itoa(i))
+		require.NoError(t, os.Symlink(filepath.Join("..", vfPCIAddr), virtfn))
+	}
+
+	return devicePath
+}
+
+// SetIOMMUGroup links the PCI device to the IOMMU group
+func (fs *Sysfs) SetIOMMUGroup(t *testing.T, pciAddr string, iommuGroup uint) {
+	iommuGroupPath := filepath.Join(filepath.Dir(fs.DevicesPath), "iommu_groups", strconv.FormatUint(uint64(iommuGroup), 10))
+	require.NoError(t, os.MkdirAll(iommuGroupPath, sysfsDirPerm))
+	require.NoError(t, os.Symlink(iommuGroupPath, filepath.Join(fs.DevicesPath, pciAddr, "iommu_group")))
+}
@@ -0,0 +1,61 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package sriovtest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/networkservicemesh/sdk-kernel/pkg/kernel/networkservice/vfconfig"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/chain"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/utils/checks/checkcontext"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/utils/metadata"
+)
+
+// ConnectionID is an ID of the connections requested with NewExtraContextRequest
+const ConnectionID = "id"
+
+// NewVFServer returns a server chain storing a copy of the VF config to the metadata on every Request and Close the
+// same way the resourcepool server does, followed by the server under test and the additional functionality
+func NewVFServer(t *testing.T, vfConfig *vfconfig.VFConfig, server networkservice.NetworkServiceServer,
+	additionalFunctionality ...networkservice.NetworkServiceServer) networkservice.NetworkServiceServer {
+	return chain.NewNetworkServiceServer(append([]networkservice.NetworkServiceServer{
+		metadata.NewServer(),
+		checkcontext.NewServer(t, func(_ *testing.T, ctx context.Context) {
+			vfConfigCopy := *vfConfig
+			vfconfig.Store(ctx, false, &vfConfigCopy)
+		}),
+		server,
+	}, additionalFunctionality...)...)
+}
+
+// NewExtraContextRequest returns a new Request for the ConnectionID connection with the connection context extra
+// context
+func NewExtraContextRequest(extraContext map[string]string) *networkservice.NetworkServiceRequest {
+	return &networkservice.NetworkServiceRequest{
+		Connection: &networkservice.Connection{
+			Id: ConnectionID,
+			Context: &networkservice.ConnectionContext{
+				ExtraContext: extraContext,
+			},
+		},
+	}
+}
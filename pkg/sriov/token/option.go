@@ -0,0 +1,28 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package token
+
+// Option is an option pattern for NewPool
+type Option func(p *Pool)
+
+// WithIdempotentAllocate sets Pool to keep "inUse" tokens "inUse" on Allocate, so repeated Allocate calls from the
+// Device Plugin don't free the tokens closed by Use
+func WithIdempotentAllocate() Option {
+	return func(p *Pool) {
+		p.idempotentAllocate = true
+	}
+}
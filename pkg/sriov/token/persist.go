@@ -0,0 +1,117 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package token
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/config"
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/token/storage"
+)
+
+// NewPoolWithStorage returns a new Pool restored from the state stored in the store, the state is stored back on
+// every token state change. If the stored state cannot be loaded, the Pool starts with all tokens free. VF selections
+// of the "inUse" tokens don't survive the restart, so they are restored as "allocated" and the tokens closed by them
+// are restored as "free", same as StopUsing does.
+// NOTE: Restore and RestoreState cannot be called on the restored Pool
+func NewPoolWithStorage(store storage.Storage, cfg *config.Config, options ...Option) *Pool {
+	p := NewPool(cfg, options...)
+
+	if err := p.load(store); err != nil {
+		log.L().WithField("tokenPool", "NewPoolWithStorage").Warnf("failed to restore token pool state: %v", err)
+		p = NewPool(cfg, options...)
+	}
+	p.store = store
+
+	// the stored state is the only one the Pool can be restored with
+	p.lock.Lock()
+	p.setDirty()
+	p.lock.Unlock()
+
+	return p
+}
+
+func (p *Pool) load(store storage.Storage) error {
+	data, err := store.Load()
+	if err != nil || data == nil {
+		return err
+	}
+
+	state := new(State)
+	if err := json.Unmarshal(data, state); err != nil {
+		return err
+	}
+	for _, tokState := range state.Tokens {
+		switch tokState.State {
+		case inUse.String():
+			tokState.State = allocated.String()
+		case closed.String():
+			tokState.State = free.String()
+		}
+		tokState.ClosedBy = ""
+	}
+	return p.RestoreState(state)
+}
+
+// update applies the change and stores the new state to the storage if any, the change is rolled back if it fails
+// to be stored, so the Pool state never diverges from the stored one. It should be called under the lock.
+// NOTE: every Use/StopUsing/Allocate/Free stores the whole state with FileStorage syncing the file and its directory to
+// the disk under the lock, so the token state changes are serialized by the disk latency. Changes are not batched on
+// purpose: a change acknowledged to the caller must survive the node crash.
+func (p *Pool) update(change func() error) error {
+	if p.store == nil {
+		return change()
+	}
+
+	states := make(map[*token]state, len(p.tokens))
+	for _, tok := range p.tokens {
+		states[tok] = tok.state
+	}
+	closedTokens := make(map[string][]*token, len(p.closedTokens))
+	for id, toks := range p.closedTokens {
+		closedTokens[id] = toks
+	}
+
+	if err := change(); err != nil {
+		return err
+	}
+	if err := p.persist(); err != nil {
+		for tok, st := range states {
+			tok.state = st
+		}
+		p.closedTokens = closedTokens
+		return err
+	}
+	return nil
+}
+
+// persist stores the current state to the storage if any, it should be called under the lock
+func (p *Pool) persist() error {
+	if p.store == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(p.state())
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal token pool state")
+	}
+	return errors.Wrap(p.store.Store(data), "failed to store token pool state")
+}
@@ -20,12 +20,12 @@
 package token
 
 import (
-	"path"
 	"sync"
 
 	"github.com/pkg/errors"
 
 	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/config"
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/token/storage"
 	sriovtokens "github.com/networkservicemesh/sdk-sriov/pkg/tools/tokens"
 )
 
@@ -41,9 +41,27 @@ type Pool struct {
 	tokens        map[string]*token   // tokens[id] -> *token
 	tokensByNames map[string][]*token // tokensByNames[name] -> []*token
 	closedTokens  map[string][]*token // closedTokens[id] -> []*token
-	listeners     []func()
+	listeners     []*listenerEntry
 	lock          sync.Mutex
 	dirty         bool
+	readyCh       chan struct{}
+	notifyCh      chan struct{}
+	closeCh       chan struct{}
+	dispatcherWg  sync.WaitGroup
+	closeOnce     sync.Once
+
+	capacityListeners []func()
+	capacityNotifyCh  chan struct{}
+	dispatcherStarted bool
+
+	eventChs     []chan struct{}
+	eventsClosed bool
+
+	lastListenerID uint64
+
+	idempotentAllocate bool
+
+	store storage.Storage
 }
 
 type state int
@@ -60,6 +78,25 @@ func (ts state) String() string {
 	}[ts]
 }
 
+// TokenCounts is a number of tokens of a name by the token states
+type TokenCounts struct {
+	Total     int
+	Free      int
+	Allocated int
+	InUse     int
+	Closed    int
+}
+
+// ListenerHandle is an opaque handle returned by AddListener to remove the listener with RemoveListener
+type ListenerHandle struct {
+	id uint64
+}
+
+type listenerEntry struct {
+	id       uint64
+	listener func()
+}
+
 type token struct {
 	id    string
 	name  string
@@ -67,21 +104,35 @@ type token struct {
 }
 
 // NewPool returns a new Pool
-func NewPool(cfg *config.Config) *Pool {
+func NewPool(cfg *config.Config, options ...Option) *Pool {
 	p := &Pool{
 		tokens:        map[string]*token{},
 		tokensByNames: map[string][]*token{},
 		closedTokens:  map[string][]*token{},
+		notifyCh:      make(chan struct{}, 1),
+		closeCh:       make(chan struct{}),
+		readyCh:       make(chan struct{}),
+
+		capacityNotifyCh: make(chan struct{}, 1),
+	}
+
+	for _, option := range options {
+		option(p)
 	}
 
 	for _, pfCfg := range cfg.PhysicalFunctions {
-		for _, serviceDomain := range pfCfg.ServiceDomains {
-			for _, capability := range pfCfg.Capabilities {
-				name := path.Join(serviceDomain, capability)
-				for i := 0; i < len(pfCfg.VirtualFunctions); i++ {
+		// tokens are partitioned by the QoS class: VFs of each class back only the tokens of this class
+		qosClassVFs := map[string]int{}
+		for _, vfCfg := range pfCfg.VirtualFunctions {
+			qosClassVFs[vfCfg.QoSClass]++
+		}
+		// PF VFs can't be selected for the service domains not allowed on its NUMA node, so it doesn't back their tokens
+		for _, name := range sriovtokens.Names(cfg.AllowedServiceDomains(pfCfg), pfCfg.Capabilities) {
+			for qosClass, vfsCount := range qosClassVFs {
+				for i := 0; i < vfsCount; i++ {
 					tok := &token{
 						id:    sriovtokens.NewTokenID(),
-						name:  name,
+						name:  sriovtokens.QoSClassName(name, qosClass),
 						state: free,
 					}
 					p.tokens[tok.id] = tok
@@ -103,7 +154,7 @@ func (p *Pool) Restore(tokens map[string][]string) error {
 	if p.dirty {
 		return errors.New("token pool has already been accessed")
 	}
-	p.dirty = true
+	p.setDirty()
 
 	for name, ids := range tokens {
 		toks, ok := p.tokensByNames[name]
@@ -122,15 +173,161 @@ func (p *Pool) Restore(tokens map[string][]string) error {
 		}
 	}
 
-	return nil
+	return p.persist()
+}
+
+// setDirty disables Restore and RestoreState, so the Pool state is settled and the Pool becomes ready, it should be
+// called under the lock
+func (p *Pool) setDirty() {
+	if !p.dirty {
+		close(p.readyCh)
+	}
+	p.dirty = true
+}
+
+// Ready returns a channel closed when the Pool state is settled: it has been restored with Restore, RestoreState or
+// from the storage, or it has been accessed in any other way disabling the restore
+func (p *Pool) Ready() <-chan struct{} {
+	return p.readyCh
+}
+
+// AddListener adds a new listener that fires on tokens state change to/from "closed". Listeners are called in order
+// from a single dispatcher goroutine, rapid state changes are coalesced into a single call. Pool should be closed with
+// Close to stop the dispatcher goroutine. Returned handle can be used to remove the listener with RemoveListener.
+func (p *Pool) AddListener(listener func()) ListenerHandle {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.startDispatcher()
+	p.lastListenerID++
+	p.listeners = append(p.listeners, &listenerEntry{
+		id:       p.lastListenerID,
+		listener: listener,
+	})
+	return ListenerHandle{id: p.lastListenerID}
+}
+
+// RemoveListener removes the listener added with AddListener, it does nothing if the listener has already been
+// removed. Listener being called at the moment can still complete the call.
+func (p *Pool) RemoveListener(handle ListenerHandle) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for i, entry := range p.listeners {
+		if entry.id == handle.id {
+			p.listeners = append(p.listeners[:i:i], p.listeners[i+1:]...)
+			return
+		}
+	}
 }
 
-// AddListener adds a new listener that fires on tokens state change to/from "closed"
-func (p *Pool) AddListener(listener func()) {
+// AddCapacityListener adds a new listener that fires on free tokens count change. Capacity listeners are called the
+// same way as the AddListener ones.
+func (p *Pool) AddCapacityListener(listener func()) {
 	p.lock.Lock()
 	defer p.lock.Unlock()
 
-	p.listeners = append(p.listeners, listener)
+	p.startDispatcher()
+	p.capacityListeners = append(p.capacityListeners, listener)
+}
+
+func (p *Pool) startDispatcher() {
+	if !p.dispatcherStarted {
+		p.dispatcherStarted = true
+		p.dispatcherWg.Add(1)
+		go p.runDispatcher()
+	}
+}
+
+// Close stops the listeners dispatcher goroutine, listeners are not called after Close returns
+func (p *Pool) Close() {
+	p.closeOnce.Do(func() {
+		close(p.closeCh)
+	})
+	p.dispatcherWg.Wait()
+}
+
+func (p *Pool) runDispatcher() {
+	defer p.dispatcherWg.Done()
+
+	for {
+		var listeners []func()
+		select {
+		case <-p.closeCh:
+			return
+		case <-p.notifyCh:
+			p.lock.Lock()
+			for _, entry := range p.listeners {
+				listeners = append(listeners, entry.listener)
+			}
+			p.lock.Unlock()
+		case <-p.capacityNotifyCh:
+			p.lock.Lock()
+			listeners = append(listeners, p.capacityListeners...)
+			p.lock.Unlock()
+		}
+
+		for _, listener := range listeners {
+			select {
+			case <-p.closeCh:
+				return
+			default:
+				listener()
+			}
+		}
+	}
+}
+
+// Events returns a new channel signaled on tokens state change to/from "closed", signals are coalesced: the channel
+// is never blocked on and keeps at most one pending signal. All the events channels are closed by CloseEvents.
+func (p *Pool) Events() <-chan struct{} {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	eventCh := make(chan struct{}, 1)
+	if p.eventsClosed {
+		close(eventCh)
+		return eventCh
+	}
+	p.eventChs = append(p.eventChs, eventCh)
+	return eventCh
+}
+
+// CloseEvents closes all the channels returned by Events, Events called after CloseEvents returns a closed channel
+func (p *Pool) CloseEvents() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.eventsClosed {
+		return
+	}
+	p.eventsClosed = true
+
+	for _, eventCh := range p.eventChs {
+		close(eventCh)
+	}
+	p.eventChs = nil
+}
+
+// notifyListeners signals the events channels and schedules listeners call, listeners dispatcher is signaled the same
+// way as the events channels, so it doesn't block if there is a pending call already
+func (p *Pool) notifyListeners() {
+	for _, eventCh := range p.eventChs {
+		signal(eventCh)
+	}
+	signal(p.notifyCh)
+}
+
+func signal(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
+// notifyCapacityListeners schedules capacity listeners call, it doesn't block if there is a pending call already
+func (p *Pool) notifyCapacityListeners() {
+	signal(p.capacityNotifyCh)
 }
 
 // Tokens returns a map of tokens by names marked as available/not available
@@ -138,7 +335,7 @@ func (p *Pool) Tokens() map[string]map[string]bool {
 	p.lock.Lock()
 	defer p.lock.Unlock()
 
-	p.dirty = true
+	p.setDirty()
 
 	tokens := map[string]map[string]bool{}
 	for name, toks := range p.tokensByNames {
@@ -150,12 +347,59 @@ func (p *Pool) Tokens() map[string]map[string]bool {
 	return tokens
 }
 
+// Capacity returns a number of free tokens by names, aggregated across all PFs serving the name
+func (p *Pool) Capacity() map[string]int {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.setDirty()
+
+	capacity := map[string]int{}
+	for name, toks := range p.tokensByNames {
+		capacity[name] = 0
+		for _, tok := range toks {
+			if tok.state == free {
+				capacity[name]++
+			}
+		}
+	}
+	return capacity
+}
+
+// Counts returns a number of tokens by names aggregated by the token states
+func (p *Pool) Counts() map[string]TokenCounts {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.setDirty()
+
+	counts := map[string]TokenCounts{}
+	for name, toks := range p.tokensByNames {
+		var c TokenCounts
+		for _, tok := range toks {
+			c.Total++
+			switch tok.state {
+			case free:
+				c.Free++
+			case allocated:
+				c.Allocated++
+			case inUse:
+				c.InUse++
+			case closed:
+				c.Closed++
+			}
+		}
+		counts[name] = c
+	}
+	return counts
+}
+
 // Find returns a token name selected by the given ID
 func (p *Pool) Find(id string) (string, error) {
 	p.lock.Lock()
 	defer p.lock.Unlock()
 
-	p.dirty = true
+	p.setDirty()
 
 	tok, err := p.find(id)
 	if err != nil {
@@ -174,28 +418,37 @@ func (p *Pool) find(id string) (*token, error) {
 // Allocate marks a token selected by the given ID as "allocated":
 // * `free` -> `allocated` (common case)
 // * `allocated` -> `allocated` (we have not called Free, but Device Plugin is already using the token)
-// * `inUse` -stopUsing-> `allocated` (we have not called StopUsing, Free, but Device Plugin is already using the token)
+// * `inUse` -stopUsing-> `allocated` (we have not called StopUsing, Free, but Device Plugin is already using the token),
+// it also frees all related closed tokens
+// * `inUse` -> `inUse` (with WithIdempotentAllocate: Device Plugin repeats Allocate for the token we are still using)
 // * `closed` -XXX-> `error`
 func (p *Pool) Allocate(id string) error {
 	p.lock.Lock()
 	defer p.lock.Unlock()
 
-	p.dirty = true
+	p.setDirty()
 
-	tok, err := p.find(id)
-	if err != nil {
-		return err
-	}
+	return p.update(func() error {
+		tok, err := p.find(id)
+		if err != nil {
+			return err
+		}
 
-	switch tok.state {
-	case inUse:
-		return p.stopUsing(id)
-	case closed:
-		return errors.Errorf("token is closed: %s:%s", tok.name, tok.id)
-	}
-	tok.state = allocated
+		switch tok.state {
+		case inUse:
+			if p.idempotentAllocate {
+				return nil
+			}
+			return p.stopUsing(id)
+		case closed:
+			return errors.Errorf("token is closed: %s:%s", tok.name, tok.id)
+		case free:
+			p.notifyCapacityListeners()
+		}
+		tok.state = allocated
 
-	return nil
+		return nil
+	})
 }
 
 // Free marks a token selected by the given ID as "free":
@@ -207,22 +460,27 @@ func (p *Pool) Free(id string) error {
 	p.lock.Lock()
 	defer p.lock.Unlock()
 
-	p.dirty = true
+	p.setDirty()
 
-	tok, err := p.find(id)
-	if err != nil {
-		return err
-	}
+	return p.update(func() error {
+		tok, err := p.find(id)
+		if err != nil {
+			return err
+		}
 
-	switch tok.state {
-	case inUse:
-		_ = p.stopUsing(id)
-	case closed:
-		return nil
-	}
-	tok.state = free
+		switch tok.state {
+		case inUse:
+			_ = p.stopUsing(id)
+		case closed:
+			return nil
+		}
+		if tok.state != free {
+			p.notifyCapacityListeners()
+		}
+		tok.state = free
 
-	return nil
+		return nil
+	})
 }
 
 // Use marks a token selected by the given ID as "inUse" and closes 1 token for any of names:
@@ -234,37 +492,41 @@ func (p *Pool) Use(id string, names []string) error {
 	p.lock.Lock()
 	defer p.lock.Unlock()
 
-	p.dirty = true
+	p.setDirty()
 
-	tok, err := p.find(id)
-	if err != nil {
-		return err
-	}
-
-	if tok.state == inUse || tok.state == closed {
-		return errors.Errorf("token is %v: %s:%s", tok.state, tok.name, tok.id)
-	}
-	tok.state = inUse
-
-	for i := range names {
-		if names[i] == tok.name {
-			continue
+	return p.update(func() error {
+		tok, err := p.find(id)
+		if err != nil {
+			return err
 		}
 
-		tokToClose := p.findToClose(names[i])
-		if tokToClose == nil {
-			continue
+		if tok.state == inUse || tok.state == closed {
+			return errors.Errorf("token is %v: %s:%s", tok.state, tok.name, tok.id)
 		}
-		tokToClose.state = closed
+		tok.state = inUse
+		p.notifyCapacityListeners()
+
+		// names can contain duplicates, only 1 token should be closed for each of them
+		closedNames := map[string]struct{}{tok.name: {}}
+		for i := range names {
+			if _, ok := closedNames[names[i]]; ok {
+				continue
+			}
+			closedNames[names[i]] = struct{}{}
 
-		p.closedTokens[tok.id] = append(p.closedTokens[tok.id], tokToClose)
-	}
+			tokToClose := p.findToClose(names[i])
+			if tokToClose == nil {
+				continue
+			}
+			tokToClose.state = closed
 
-	for _, listener := range p.listeners {
-		go listener()
-	}
+			p.closedTokens[tok.id] = append(p.closedTokens[tok.id], tokToClose)
+		}
 
-	return nil
+		p.notifyListeners()
+
+		return nil
+	})
 }
 
 func (p *Pool) findToClose(name string) *token {
@@ -290,9 +552,11 @@ func (p *Pool) StopUsing(id string) error {
 	p.lock.Lock()
 	defer p.lock.Unlock()
 
-	p.dirty = true
+	p.setDirty()
 
-	return p.stopUsing(id)
+	return p.update(func() error {
+		return p.stopUsing(id)
+	})
 }
 
 func (p *Pool) stopUsing(id string) error {
@@ -311,9 +575,8 @@ func (p *Pool) stopUsing(id string) error {
 	}
 	delete(p.closedTokens, tok.id)
 
-	for _, listener := range p.listeners {
-		go listener()
-	}
+	p.notifyListeners()
+	p.notifyCapacityListeners()
 
 	return nil
 }
@@ -21,21 +21,32 @@ package token_test
 import (
 	"context"
 	"path"
+	"path/filepath"
+	"runtime"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
 
 	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/config"
 	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/token"
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/token/storage"
+	sriovtokens "github.com/networkservicemesh/sdk-sriov/pkg/tools/tokens"
 )
 
 const (
-	configFileName  = "config.yml"
-	serviceDomain1  = "service.domain.1"
-	serviceDomain2  = "service.domain.2"
-	capabilityIntel = "intel"
-	capability10G   = "10G"
-	capability20G   = "20G"
+	configFileName         = "config.yml"
+	threePFsConfigFileName = "three_pfs_config.yml"
+	qosConfigFileName      = "qos_config.yml"
+	numaConfigFileName     = "numa_config.yml"
+	serviceDomain1         = "service.domain.1"
+	serviceDomain2         = "service.domain.2"
+	capabilityIntel        = "intel"
+	capability10G          = "10G"
+	capability20G          = "20G"
 )
 
 func TestPool_Tokens(t *testing.T) {
@@ -53,6 +64,58 @@ func TestPool_Tokens(t *testing.T) {
 	require.Equal(t, 3, countTrue(tokens[path.Join(serviceDomain2, capability20G)]))
 }
 
+func TestPool_Tokens_QoSClasses(t *testing.T) {
+	cfg, err := config.ReadConfig(context.TODO(), qosConfigFileName)
+	require.NoError(t, err)
+
+	p := token.NewPool(cfg)
+
+	name := path.Join(serviceDomain1, capabilityIntel)
+
+	tokens := p.Tokens()
+	require.Equal(t, 3, len(tokens))
+	require.Equal(t, 1, countTrue(tokens[name]))
+	require.Equal(t, 2, countTrue(tokens[sriovtokens.QoSClassName(name, "guaranteed")]))
+	require.Equal(t, 2, countTrue(tokens[sriovtokens.QoSClassName(name, "best-effort")]))
+}
+
+func TestPool_Tokens_AllowedNUMANodes(t *testing.T) {
+	cfg, err := config.ReadConfig(context.TODO(), numaConfigFileName)
+	require.NoError(t, err)
+
+	p := token.NewPool(cfg)
+
+	// service.domain.1 is allowed only on the NUMA node 1, so only 0000:02:00.0 PF backs its tokens
+
+	tokens := p.Tokens()
+	require.Equal(t, 2, len(tokens))
+	require.Equal(t, 1, countTrue(tokens[path.Join(serviceDomain1, capabilityIntel)]))
+	require.Equal(t, 3, countTrue(tokens[path.Join(serviceDomain2, capabilityIntel)]))
+}
+
+func TestPool_Capacity(t *testing.T) {
+	cfg, err := config.ReadConfig(context.TODO(), threePFsConfigFileName)
+	require.NoError(t, err)
+
+	p := token.NewPool(cfg)
+
+	name := path.Join(serviceDomain1, capabilityIntel)
+	require.Equal(t, map[string]int{name: 6}, p.Capacity())
+
+	var ids []string
+	for id := range p.Tokens()[name] {
+		ids = append(ids, id)
+	}
+
+	require.NoError(t, p.Allocate(ids[0]))
+	require.NoError(t, p.Allocate(ids[1]))
+	require.NoError(t, p.Use(ids[1], []string{name}))
+	require.Equal(t, map[string]int{name: 4}, p.Capacity())
+
+	require.NoError(t, p.Free(ids[1]))
+	require.Equal(t, map[string]int{name: 5}, p.Capacity())
+}
+
 func TestPool_Use(t *testing.T) {
 	cfg, err := config.ReadConfig(context.TODO(), configFileName)
 	require.NoError(t, err)
@@ -91,6 +154,280 @@ func TestPool_Use(t *testing.T) {
 	require.Equal(t, 3, countTrue(tokens[path.Join(serviceDomain2, capability20G)]))
 }
 
+func TestPool_Use_DuplicateNames(t *testing.T) {
+	cfg, err := config.ReadConfig(context.TODO(), configFileName)
+	require.NoError(t, err)
+
+	p := token.NewPool(cfg)
+
+	name := path.Join(serviceDomain2, capability20G)
+	var tokenID string
+	for id := range p.Tokens()[name] {
+		tokenID = id
+		break
+	}
+
+	require.NoError(t, p.Use(tokenID, []string{
+		name,
+		name,
+		path.Join(serviceDomain1, capabilityIntel),
+		path.Join(serviceDomain1, capabilityIntel),
+		path.Join(serviceDomain1, capabilityIntel),
+	}))
+
+	counts := p.Counts()
+	require.Equal(t, 1, counts[path.Join(serviceDomain1, capabilityIntel)].Closed)
+	require.Equal(t, 0, counts[name].Closed)
+	require.Equal(t, 1, counts[name].InUse)
+}
+
+func TestPool_Allocate(t *testing.T) {
+	cfg, err := config.ReadConfig(context.TODO(), configFileName)
+	require.NoError(t, err)
+
+	sd1Intel := path.Join(serviceDomain1, capabilityIntel)
+	sd2Intel := path.Join(serviceDomain2, capabilityIntel)
+
+	for _, idempotent := range []bool{false, true} {
+		var options []token.Option
+		if idempotent {
+			options = append(options, token.WithIdempotentAllocate())
+		}
+		p := token.NewPool(cfg, options...)
+
+		ids := tokenIDs(p.Tokens()[sd2Intel])
+		require.Len(t, ids, 3)
+		freeID, allocatedID, inUseID := ids[0], ids[1], ids[2]
+
+		// free -> allocated
+		require.NoError(t, p.Allocate(freeID))
+
+		// allocated -> allocated
+		require.NoError(t, p.Allocate(allocatedID))
+		require.NoError(t, p.Allocate(allocatedID))
+
+		// Use closes 1 sd1Intel token
+		require.NoError(t, p.Allocate(inUseID))
+		require.NoError(t, p.Use(inUseID, []string{sd1Intel, sd2Intel}))
+		require.Equal(t, 3, countTrue(p.Tokens()[sd1Intel]))
+
+		// closed -> error
+		var closedID string
+		for id, available := range p.Tokens()[sd1Intel] {
+			if !available {
+				closedID = id
+			}
+		}
+		require.Error(t, p.Allocate(closedID))
+
+		require.NoError(t, p.Allocate(inUseID))
+		if idempotent {
+			// inUse -> inUse, closed tokens are kept closed
+			require.Equal(t, 3, countTrue(p.Tokens()[sd1Intel]))
+			require.Error(t, p.Use(inUseID, []string{sd1Intel, sd2Intel}))
+			require.NoError(t, p.StopUsing(inUseID))
+		} else {
+			// inUse -> allocated, closed tokens are freed
+			require.Equal(t, 4, countTrue(p.Tokens()[sd1Intel]))
+			require.Error(t, p.StopUsing(inUseID))
+		}
+		require.Equal(t, 4, countTrue(p.Tokens()[sd1Intel]))
+	}
+}
+
+func TestPool_Listeners(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	cfg, err := config.ReadConfig(context.TODO(), configFileName)
+	require.NoError(t, err)
+
+	p := token.NewPool(cfg)
+	defer p.Close()
+
+	var calls int32
+	p.AddListener(func() {
+		atomic.AddInt32(&calls, 1)
+	})
+
+	sd1Intel := path.Join(serviceDomain1, capabilityIntel)
+	sd2Intel := path.Join(serviceDomain2, capabilityIntel)
+	id := tokenIDs(p.Tokens()[sd2Intel])[0]
+
+	goroutines := runtime.NumGoroutine()
+	for i := 0; i < 1000; i++ {
+		require.NoError(t, p.Use(id, []string{sd1Intel, sd2Intel}))
+		require.NoError(t, p.StopUsing(id))
+		require.LessOrEqual(t, runtime.NumGoroutine(), goroutines)
+	}
+
+	require.Eventually(t, func() bool {
+		return atomic.LoadInt32(&calls) > 0
+	}, time.Second, 10*time.Millisecond)
+	require.LessOrEqual(t, atomic.LoadInt32(&calls), int32(2000))
+
+	p.Close()
+}
+
+func TestPool_RemoveListener(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	cfg, err := config.ReadConfig(context.TODO(), configFileName)
+	require.NoError(t, err)
+
+	p := token.NewPool(cfg)
+	defer p.Close()
+
+	var removedCalls, remainingCalls, selfRemovingCalls int32
+	removed := p.AddListener(func() {
+		atomic.AddInt32(&removedCalls, 1)
+	})
+	p.AddListener(func() {
+		atomic.AddInt32(&remainingCalls, 1)
+	})
+	var selfRemoving token.ListenerHandle
+	selfRemoving = p.AddListener(func() {
+		atomic.AddInt32(&selfRemovingCalls, 1)
+		p.RemoveListener(selfRemoving)
+	})
+
+	p.RemoveListener(removed)
+	p.RemoveListener(removed)
+
+	sd1Intel := path.Join(serviceDomain1, capabilityIntel)
+	sd2Intel := path.Join(serviceDomain2, capabilityIntel)
+	id := tokenIDs(p.Tokens()[sd2Intel])[0]
+
+	require.NoError(t, p.Use(id, []string{sd1Intel, sd2Intel}))
+	require.Eventually(t, func() bool {
+		return atomic.LoadInt32(&remainingCalls) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	require.NoError(t, p.StopUsing(id))
+	require.Eventually(t, func() bool {
+		return atomic.LoadInt32(&remainingCalls) == 2
+	}, time.Second, 10*time.Millisecond)
+
+	require.Zero(t, atomic.LoadInt32(&removedCalls))
+	require.Equal(t, int32(1), atomic.LoadInt32(&selfRemovingCalls))
+
+	p.Close()
+}
+
+func TestPool_CapacityListeners(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	cfg, err := config.ReadConfig(context.TODO(), configFileName)
+	require.NoError(t, err)
+
+	p := token.NewPool(cfg)
+	defer p.Close()
+
+	calls := make(chan struct{}, 10)
+	p.AddCapacityListener(func() {
+		calls <- struct{}{}
+	})
+
+	id := tokenIDs(p.Tokens()[path.Join(serviceDomain1, capabilityIntel)])[0]
+
+	require.NoError(t, p.Allocate(id))
+	<-calls
+
+	require.NoError(t, p.Allocate(id))
+	require.NoError(t, p.Free(id))
+	<-calls
+
+	require.NoError(t, p.Free(id))
+	select {
+	case <-calls:
+		require.FailNow(t, "unexpected capacity listener call")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	p.Close()
+}
+
+func TestPool_Events(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	cfg, err := config.ReadConfig(context.TODO(), configFileName)
+	require.NoError(t, err)
+
+	p := token.NewPool(cfg)
+
+	events := p.Events()
+
+	sd1Intel := path.Join(serviceDomain1, capabilityIntel)
+	sd2Intel := path.Join(serviceDomain2, capabilityIntel)
+	id := tokenIDs(p.Tokens()[sd2Intel])[0]
+
+	// 1. Use closes tokens
+
+	require.NoError(t, p.Use(id, []string{sd1Intel, sd2Intel}))
+
+	select {
+	case _, ok := <-events:
+		require.True(t, ok)
+	default:
+		require.FailNow(t, "no event received")
+	}
+
+	// 2. Events are coalesced
+
+	require.NoError(t, p.StopUsing(id))
+	require.NoError(t, p.Use(id, []string{sd1Intel, sd2Intel}))
+
+	<-events
+	select {
+	case <-events:
+		require.FailNow(t, "events are not coalesced")
+	default:
+	}
+
+	// 3. CloseEvents closes the channels
+
+	p.CloseEvents()
+
+	_, ok := <-events
+	require.False(t, ok)
+
+	_, ok = <-p.Events()
+	require.False(t, ok)
+}
+
+func TestPool_Counts(t *testing.T) {
+	cfg, err := config.ReadConfig(context.TODO(), configFileName)
+	require.NoError(t, err)
+
+	p := token.NewPool(cfg)
+
+	sd1Intel := path.Join(serviceDomain1, capabilityIntel)
+	sd2Intel := path.Join(serviceDomain2, capabilityIntel)
+	id := tokenIDs(p.Tokens()[sd2Intel])[0]
+
+	counts := p.Counts()
+	require.Equal(t, token.TokenCounts{Total: 4, Free: 4}, counts[sd1Intel])
+	require.Equal(t, token.TokenCounts{Total: 3, Free: 3}, counts[sd2Intel])
+
+	require.NoError(t, p.Allocate(id))
+
+	counts = p.Counts()
+	require.Equal(t, token.TokenCounts{Total: 4, Free: 4}, counts[sd1Intel])
+	require.Equal(t, token.TokenCounts{Total: 3, Free: 2, Allocated: 1}, counts[sd2Intel])
+
+	require.NoError(t, p.Use(id, []string{sd1Intel, sd2Intel}))
+
+	counts = p.Counts()
+	require.Equal(t, token.TokenCounts{Total: 4, Free: 3, Closed: 1}, counts[sd1Intel])
+	require.Equal(t, token.TokenCounts{Total: 3, Free: 2, InUse: 1}, counts[sd2Intel])
+
+	require.NoError(t, p.StopUsing(id))
+	require.NoError(t, p.Free(id))
+
+	counts = p.Counts()
+	require.Equal(t, token.TokenCounts{Total: 4, Free: 4}, counts[sd1Intel])
+	require.Equal(t, token.TokenCounts{Total: 3, Free: 3}, counts[sd2Intel])
+}
+
 func TestPool_Restore(t *testing.T) {
 	cfg, err := config.ReadConfig(context.TODO(), configFileName)
 	require.NoError(t, err)
@@ -110,6 +447,135 @@ func TestPool_Restore(t *testing.T) {
 	require.Equal(t, tokens, p.Tokens())
 }
 
+func TestPool_Ready(t *testing.T) {
+	cfg, err := config.ReadConfig(context.TODO(), configFileName)
+	require.NoError(t, err)
+
+	// Pool is ready after the restore
+
+	p := token.NewPool(cfg)
+	requireNotReady(t, p.Ready())
+
+	require.NoError(t, p.Restore(map[string][]string{}))
+	<-p.Ready()
+
+	// Pool is ready after any other access disabling the restore
+
+	p = token.NewPool(cfg)
+	requireNotReady(t, p.Ready())
+
+	_ = p.Tokens()
+	<-p.Ready()
+
+	// Pool is ready after restoring from the storage
+
+	p = token.NewPoolWithStorage(storage.NewFileStorage(filepath.Join(t.TempDir(), "tokens.json")), cfg)
+	<-p.Ready()
+}
+
+func requireNotReady(t *testing.T, readyCh <-chan struct{}) {
+	select {
+	case <-readyCh:
+		require.FailNow(t, "Pool should not be ready")
+	default:
+	}
+}
+
+func TestPool_Storage(t *testing.T) {
+	cfg, err := config.ReadConfig(context.TODO(), configFileName)
+	require.NoError(t, err)
+
+	store := storage.NewFileStorage(filepath.Join(t.TempDir(), "tokens.json"))
+
+	p := token.NewPoolWithStorage(store, cfg)
+
+	name := path.Join(serviceDomain2, capability20G)
+	var ids []string
+	for id := range p.Tokens()[name] {
+		ids = append(ids, id)
+	}
+
+	require.NoError(t, p.Allocate(ids[0]))
+	require.NoError(t, p.Allocate(ids[1]))
+	require.NoError(t, p.Use(ids[1], []string{name, path.Join(serviceDomain2, capabilityIntel)}))
+
+	expected, release := p.Quiesce()
+	release()
+
+	// VF selections don't survive the restart, so "inUse" tokens are restored as "allocated" and the tokens closed by
+	// them are restored as "free"
+	for _, tokState := range expected.Tokens {
+		switch tokState.State {
+		case "inUse":
+			tokState.State = "allocated"
+		case "closed":
+			tokState.State = "free"
+		}
+		tokState.ClosedBy = ""
+	}
+
+	restored := token.NewPoolWithStorage(store, cfg)
+
+	actual, release := restored.Quiesce()
+	release()
+	require.Equal(t, expected, actual)
+
+	require.Error(t, restored.StopUsing(ids[1]))
+	require.NoError(t, restored.Use(ids[1], []string{name, path.Join(serviceDomain2, capabilityIntel)}))
+	require.NoError(t, restored.StopUsing(ids[1]))
+	require.NoError(t, restored.Free(ids[1]))
+	require.Equal(t, map[string]int{
+		path.Join(serviceDomain1, capabilityIntel): 4,
+		path.Join(serviceDomain1, capability10G):   1,
+		path.Join(serviceDomain1, capability20G):   3,
+		path.Join(serviceDomain2, capabilityIntel): 3,
+		path.Join(serviceDomain2, capability20G):   2,
+	}, restored.Capacity())
+}
+
+func TestPool_Storage_StoreFailed(t *testing.T) {
+	cfg, err := config.ReadConfig(context.TODO(), configFileName)
+	require.NoError(t, err)
+
+	store := &failingStorage{}
+	p := token.NewPoolWithStorage(store, cfg)
+
+	name := path.Join(serviceDomain2, capability20G)
+	ids := tokenIDs(p.Tokens()[name])
+	require.NoError(t, p.Allocate(ids[0]))
+
+	before, release := p.Quiesce()
+	release()
+
+	// state change is rolled back if it fails to be stored
+
+	store.err = errors.New("disk is full")
+
+	require.ErrorIs(t, p.Use(ids[0], []string{name, path.Join(serviceDomain2, capabilityIntel)}), store.err)
+	require.ErrorIs(t, p.Free(ids[0]), store.err)
+
+	after, release := p.Quiesce()
+	release()
+	require.Equal(t, before, after)
+}
+
+type failingStorage struct {
+	data []byte
+	err  error
+}
+
+func (s *failingStorage) Load() ([]byte, error) {
+	return s.data, nil
+}
+
+func (s *failingStorage) Store(data []byte) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.data = data
+	return nil
+}
+
 func TestPool_ToEnv(t *testing.T) {
 	cfg, err := config.ReadConfig(context.TODO(), configFileName)
 	require.NoError(t, err)
@@ -120,6 +586,13 @@ func TestPool_ToEnv(t *testing.T) {
 	require.Equal(t, "1,2,3", value)
 }
 
+func tokenIDs(m map[string]bool) (ids []string) {
+	for id := range m {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
 func countTrue(m map[string]bool) (count int) {
 	for _, v := range m {
 		if v {
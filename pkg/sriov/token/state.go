@@ -0,0 +1,135 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package token
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// State is a Pool state
+type State struct {
+	Tokens []*TokenState `json:"tokens"`
+}
+
+// TokenState is a Pool token state
+type TokenState struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	State string `json:"state"`
+	// ClosedBy is an ID of the "inUse" token the "closed" token is closed by
+	ClosedBy string `json:"closedBy,omitempty"`
+}
+
+// Quiesce locks the Pool and returns its current state, all the Pool methods are blocked until release is called
+func (p *Pool) Quiesce() (state *State, release func()) {
+	p.lock.Lock()
+
+	p.setDirty()
+
+	return p.state(), p.lock.Unlock
+}
+
+// state returns the current Pool state, it should be called under the lock
+func (p *Pool) state() *State {
+	closedBy := map[*token]string{}
+	for id, toks := range p.closedTokens {
+		for _, tok := range toks {
+			closedBy[tok] = id
+		}
+	}
+
+	state := new(State)
+	for _, tok := range p.tokens {
+		state.Tokens = append(state.Tokens, &TokenState{
+			ID:       tok.id,
+			Name:     tok.name,
+			State:    tok.state.String(),
+			ClosedBy: closedBy[tok],
+		})
+	}
+	sort.Slice(state.Tokens, func(i, k int) bool {
+		if state.Tokens[i].Name != state.Tokens[k].Name {
+			return state.Tokens[i].Name < state.Tokens[k].Name
+		}
+		return state.Tokens[i].ID < state.Tokens[k].ID
+	})
+
+	return state
+}
+
+// RestoreState replaces existing tokens with the tokens from the state, tokens missing in the state are left free
+// NOTE: same as Restore it can be called only on untouched Pool
+func (p *Pool) RestoreState(state *State) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.dirty {
+		return errors.New("token pool has already been accessed")
+	}
+	p.setDirty()
+
+	restored := map[string]int{}
+	tokens := map[string]*token{}
+	for _, tokState := range state.Tokens {
+		st, err := parseState(tokState.State)
+		if err != nil {
+			return err
+		}
+
+		toks := p.tokensByNames[tokState.Name]
+		i := restored[tokState.Name]
+		if i >= len(toks) {
+			return errors.Errorf("token pool has not enough tokens for: %s", tokState.Name)
+		}
+		restored[tokState.Name]++
+
+		toks[i].id = tokState.ID
+		toks[i].state = st
+		tokens[tokState.ID] = toks[i]
+	}
+
+	for _, toks := range p.tokensByNames {
+		for _, tok := range toks {
+			tokens[tok.id] = tok
+		}
+	}
+	p.tokens = tokens
+
+	p.closedTokens = map[string][]*token{}
+	for _, tokState := range state.Tokens {
+		if tokState.ClosedBy == "" {
+			continue
+		}
+		if _, ok := p.tokens[tokState.ClosedBy]; !ok {
+			return errors.Errorf("token is closed by unknown token: %s:%s", tokState.Name, tokState.ClosedBy)
+		}
+		p.closedTokens[tokState.ClosedBy] = append(p.closedTokens[tokState.ClosedBy], p.tokens[tokState.ID])
+	}
+
+	return p.persist()
+}
+
+func parseState(s string) (state, error) {
+	for st := free; st <= closed; st++ {
+		if st.String() == s {
+			return st, nil
+		}
+	}
+	return 0, errors.Errorf("invalid token state: %s", s)
+}
@@ -0,0 +1,61 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package storage provides a storage for the token pool state
+package storage
+
+import (
+	"os"
+
+	"github.com/pkg/errors"
+
+	"github.com/networkservicemesh/sdk-sriov/pkg/tools/yamlhelper"
+)
+
+// Storage is a token pool state storage
+type Storage interface {
+	// Load returns the stored data, it returns nil if nothing has been stored yet
+	Load() ([]byte, error)
+	// Store replaces the stored data with the given data
+	Store(data []byte) error
+}
+
+// FileStorage is a Storage keeping the data in a file
+type FileStorage struct {
+	path string
+}
+
+// NewFileStorage returns a new FileStorage keeping the data in the file by the path
+func NewFileStorage(path string) *FileStorage {
+	return &FileStorage{
+		path: path,
+	}
+}
+
+// Load returns the file content, it returns nil if the file doesn't exist
+func (s *FileStorage) Load() ([]byte, error) {
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return data, errors.Wrapf(err, "failed to read file: %v", s.path)
+}
+
+// Store replaces the file content with the data, the file is replaced atomically and synced to the disk, so the
+// stored data survives the node crash
+func (s *FileStorage) Store(data []byte) error {
+	return yamlhelper.WriteFileAtomic(s.path, data)
+}
@@ -0,0 +1,41 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/token/storage"
+)
+
+func TestFileStorage(t *testing.T) {
+	s := storage.NewFileStorage(filepath.Join(t.TempDir(), "tokens.json"))
+
+	data, err := s.Load()
+	require.NoError(t, err)
+	require.Nil(t, data)
+
+	require.NoError(t, s.Store([]byte("a")))
+	require.NoError(t, s.Store([]byte("b")))
+
+	data, err = s.Load()
+	require.NoError(t, err)
+	require.Equal(t, []byte("b"), data)
+}
@@ -0,0 +1,87 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package vfnetlink
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/pkg/errors"
+	"github.com/vishvananda/netlink"
+
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+)
+
+// maxAltNameLen is ALTIFNAMSIZ - 1
+const maxAltNameLen = 127
+
+// ErrInvalidAltName is returned when the VF net interface alternative name is too long or has invalid characters
+var ErrInvalidAltName = errors.New("invalid net interface alternative name")
+
+var altNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]*$`)
+
+// SetVFAltName adds the alternative name to the VF net interface, so it can be found by the name regardless of the
+// VF net interface name, it does nothing if the VF net interface already has the alternative name
+func (c *Configurator) SetVFAltName(ctx context.Context, vfIfName, altName string) error {
+	if len(altName) > maxAltNameLen || !altNameRegexp.MatchString(altName) {
+		return errors.Wrapf(ErrInvalidAltName, "%q", altName)
+	}
+
+	link, err := c.handle.LinkByName(vfIfName)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get VF link: %v", vfIfName)
+	}
+	if hasAltName(link, altName) {
+		return nil
+	}
+
+	log.FromContext(ctx).Infof("adding VF %v alternative name: %v", vfIfName, altName)
+	if err = c.handle.LinkAddAltName(link, altName); err != nil {
+		return wrapError(err, "failed to add VF %v alternative name: %v", vfIfName, altName)
+	}
+	return nil
+}
+
+// DelVFAltName deletes the alternative name from the VF net interface, it does nothing if the VF net interface has
+// no such alternative name
+func (c *Configurator) DelVFAltName(ctx context.Context, vfIfName, altName string) error {
+	link, err := c.handle.LinkByName(vfIfName)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get VF link: %v", vfIfName)
+	}
+	if !hasAltName(link, altName) {
+		return nil
+	}
+
+	log.FromContext(ctx).Infof("deleting VF %v alternative name: %v", vfIfName, altName)
+	if err = c.handle.LinkDelAltName(link, altName); err != nil {
+		return wrapError(err, "failed to delete VF %v alternative name: %v", vfIfName, altName)
+	}
+	return nil
+}
+
+func hasAltName(link netlink.Link, altName string) bool {
+	for _, name := range link.Attrs().AltNames {
+		if name == altName {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package vfnetlink_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/sriovtest"
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/vfnetlink"
+)
+
+func altNames(t *testing.T, handle *sriovtest.NetlinkHandle) []string {
+	link, err := handle.LinkByName(vfIfName)
+	require.NoError(t, err)
+	return link.Attrs().AltNames
+}
+
+func TestConfigurator_SetVFAltName(t *testing.T) {
+	handle := sriovtest.NewNetlinkHandle()
+	handle.AddLink(vfIfName, sriovtest.DefaultMTU)
+
+	c := vfnetlink.NewConfigurator(vfnetlink.WithHandle(handle))
+
+	require.NoError(t, c.SetVFAltName(context.Background(), vfIfName, "nsm-conn-1"))
+	require.NoError(t, c.SetVFAltName(context.Background(), vfIfName, "nsm-conn-1"))
+	require.Equal(t, []string{"nsm-conn-1"}, altNames(t, handle))
+
+	require.NoError(t, c.DelVFAltName(context.Background(), vfIfName, "nsm-conn-1"))
+	require.NoError(t, c.DelVFAltName(context.Background(), vfIfName, "nsm-conn-1"))
+	require.Empty(t, altNames(t, handle))
+}
+
+func TestConfigurator_SetVFAltName_Invalid(t *testing.T) {
+	handle := sriovtest.NewNetlinkHandle()
+	handle.AddLink(vfIfName, sriovtest.DefaultMTU)
+
+	c := vfnetlink.NewConfigurator(vfnetlink.WithHandle(handle))
+
+	for _, altName := range []string{"", "-nsm", "nsm/conn", "nsm conn", strings.Repeat("a", 128)} {
+		require.ErrorIs(t, c.SetVFAltName(context.Background(), vfIfName, altName), vfnetlink.ErrInvalidAltName)
+	}
+	require.Empty(t, altNames(t, handle))
+}
@@ -0,0 +1,92 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+// Package vfnetlink provides SR-IOV VF configuration through the parent PF net interface
+package vfnetlink
+
+import (
+	"net"
+
+	"github.com/pkg/errors"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov"
+)
+
+const defaultNetClassPath = "/sys/class/net"
+
+// Handle is a netlink.Handle interface
+type Handle interface {
+	LinkByName(name string) (netlink.Link, error)
+	LinkSetVfVlanQos(link netlink.Link, vf, vlan, qos int) error
+	LinkSetMTU(link netlink.Link, mtu int) error
+	LinkAddAltName(link netlink.Link, name string) error
+	LinkDelAltName(link netlink.Link, name string) error
+	LinkSetUp(link netlink.Link) error
+	LinkSetDown(link netlink.Link) error
+	LinkSetVfSpoofchk(link netlink.Link, vf int, check bool) error
+	LinkSetVfTrust(link netlink.Link, vf int, state bool) error
+	LinkSetVfState(link netlink.Link, vf int, state uint32) error
+	LinkSetVfHardwareAddr(link netlink.Link, vf int, hwaddr net.HardwareAddr) error
+	LinkSetVfRate(link netlink.Link, vf, minRate, maxRate int) error
+	DevLinkGetDeviceByName(bus, device string) (*netlink.DevlinkDevice, error)
+	DevLinkGetPortByIndex(bus, device string, portIndex uint32) (*netlink.DevlinkPort, error)
+	DevlinkPortFnSet(bus, device string, portIndex uint32, fnAttrs netlink.DevlinkPortFnSetAttrs) error
+}
+
+// Configurator configures PF VFs with netlink
+type Configurator struct {
+	handle       Handle
+	netClassPath string
+}
+
+// NewConfigurator returns a new Configurator
+func NewConfigurator(options ...Option) *Configurator {
+	c := &Configurator{
+		handle:       &netlink.Handle{},
+		netClassPath: defaultNetClassPath,
+	}
+	for _, opt := range options {
+		opt(c)
+	}
+	return c
+}
+
+func (c *Configurator) getVF(pfIfName string, vfIndex int) (netlink.Link, *netlink.VfInfo, error) {
+	link, err := c.handle.LinkByName(pfIfName)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to get PF link: %v", pfIfName)
+	}
+
+	for i := range link.Attrs().Vfs {
+		if vf := &link.Attrs().Vfs[i]; vf.ID == vfIndex {
+			return link, vf, nil
+		}
+	}
+
+	return nil, nil, errors.Errorf("no VF %v exists for the PF: %v", vfIndex, pfIfName)
+}
+
+func wrapError(err error, format string, args ...interface{}) error {
+	if errors.Is(err, unix.EOPNOTSUPP) {
+		err = sriov.ErrNotSupported
+	}
+	return errors.Wrapf(err, format, args...)
+}
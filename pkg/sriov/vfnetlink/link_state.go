@@ -0,0 +1,66 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package vfnetlink
+
+import (
+	"context"
+	"net"
+
+	"github.com/pkg/errors"
+
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+)
+
+// IsLinkUp returns true if the net interface is administratively up
+func (c *Configurator) IsLinkUp(ifName string) (bool, error) {
+	link, err := c.handle.LinkByName(ifName)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to get link: %v", ifName)
+	}
+	return link.Attrs().Flags&net.FlagUp != 0, nil
+}
+
+// SetLinkUp sets the net interface administratively up, e.g. some PFs need it for the VFs to get carrier
+func (c *Configurator) SetLinkUp(ctx context.Context, ifName string) error {
+	link, err := c.handle.LinkByName(ifName)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get link: %v", ifName)
+	}
+
+	log.FromContext(ctx).Infof("setting link up: %v", ifName)
+	if err = c.handle.LinkSetUp(link); err != nil {
+		return errors.Wrapf(err, "failed to set link up: %v", ifName)
+	}
+	return nil
+}
+
+// SetLinkDown sets the net interface administratively down
+func (c *Configurator) SetLinkDown(ctx context.Context, ifName string) error {
+	link, err := c.handle.LinkByName(ifName)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get link: %v", ifName)
+	}
+
+	log.FromContext(ctx).Infof("setting link down: %v", ifName)
+	if err = c.handle.LinkSetDown(link); err != nil {
+		return errors.Wrapf(err, "failed to set link down: %v", ifName)
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package vfnetlink_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/sriovtest"
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/vfnetlink"
+)
+
+func TestConfigurator_SetLinkUp(t *testing.T) {
+	handle := sriovtest.NewNetlinkHandle()
+	handle.AddPhysicalFunction(pfIfName, 1)
+
+	c := vfnetlink.NewConfigurator(vfnetlink.WithHandle(handle))
+
+	isUp := func() bool {
+		link, err := handle.LinkByName(pfIfName)
+		require.NoError(t, err)
+
+		up, err := c.IsLinkUp(pfIfName)
+		require.NoError(t, err)
+		require.Equal(t, link.Attrs().Flags&net.FlagUp != 0, up)
+
+		return up
+	}
+
+	require.False(t, isUp())
+
+	require.NoError(t, c.SetLinkUp(context.Background(), pfIfName))
+	require.True(t, isUp())
+
+	require.NoError(t, c.SetLinkDown(context.Background(), pfIfName))
+	require.False(t, isUp())
+
+	require.Error(t, c.SetLinkUp(context.Background(), "unknown"))
+	_, err := c.IsLinkUp("unknown")
+	require.Error(t, err)
+}
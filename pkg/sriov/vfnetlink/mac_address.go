@@ -0,0 +1,47 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package vfnetlink
+
+import (
+	"context"
+	"net"
+
+	"github.com/pkg/errors"
+
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+)
+
+// SetVFMACAddress sets VF MAC address, returns sriov.ErrNotSupported if the PF driver doesn't support it
+func (c *Configurator) SetVFMACAddress(ctx context.Context, pfIfName string, vfIndex int, mac net.HardwareAddr) error {
+	if len(mac) == 0 {
+		return errors.Errorf("empty MAC address for the VF %v of the PF: %v", vfIndex, pfIfName)
+	}
+
+	link, _, err := c.getVF(pfIfName, vfIndex)
+	if err != nil {
+		return err
+	}
+
+	log.FromContext(ctx).Infof("setting VF %v MAC address for the PF %v: %v", vfIndex, pfIfName, mac)
+	if err = c.handle.LinkSetVfHardwareAddr(link, vfIndex, mac); err != nil {
+		return wrapError(err, "failed to set VF %v MAC address for the PF: %v", vfIndex, pfIfName)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package vfnetlink_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov"
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/sriovtest"
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/vfnetlink"
+)
+
+func TestConfigurator_SetVFMACAddress(t *testing.T) {
+	handle := sriovtest.NewNetlinkHandle()
+	handle.AddPhysicalFunction(pfIfName, 2)
+
+	c := vfnetlink.NewConfigurator(vfnetlink.WithHandle(handle))
+
+	mac, err := net.ParseMAC("02:00:00:00:00:01")
+	require.NoError(t, err)
+
+	require.NoError(t, c.SetVFMACAddress(context.Background(), pfIfName, 1, mac))
+	require.Equal(t, mac, handle.GetVF(pfIfName, 1).Mac)
+	require.Empty(t, handle.GetVF(pfIfName, 0).Mac)
+
+	require.Error(t, c.SetVFMACAddress(context.Background(), pfIfName, 2, mac))
+	require.Error(t, c.SetVFMACAddress(context.Background(), "unknown", 0, mac))
+	require.Error(t, c.SetVFMACAddress(context.Background(), pfIfName, 0, nil))
+
+	handle.Err = unix.EOPNOTSUPP
+	require.ErrorIs(t, c.SetVFMACAddress(context.Background(), pfIfName, 1, mac), sriov.ErrNotSupported)
+}
@@ -0,0 +1,70 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package vfnetlink
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+)
+
+// ErrMTUTooLarge is returned when the requested VF MTU exceeds the PF MTU
+var ErrMTUTooLarge = errors.New("VF MTU exceeds the PF MTU")
+
+// GetVFMTU returns the VF net interface MTU
+func (c *Configurator) GetVFMTU(vfIfName string) (int, error) {
+	link, err := c.handle.LinkByName(vfIfName)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to get VF link: %v", vfIfName)
+	}
+	return link.Attrs().MTU, nil
+}
+
+// CheckVFMTU returns ErrMTUTooLarge if the VF MTU exceeds the PF MTU
+func (c *Configurator) CheckVFMTU(pfIfName string, mtu int) error {
+	pfLink, err := c.handle.LinkByName(pfIfName)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get PF link: %v", pfIfName)
+	}
+	if pfMTU := pfLink.Attrs().MTU; mtu > pfMTU {
+		return errors.Wrapf(ErrMTUTooLarge, "%v > %v for the PF %v", mtu, pfMTU, pfIfName)
+	}
+	return nil
+}
+
+// SetVFMTU sets the VF net interface MTU, returns ErrMTUTooLarge if the MTU exceeds the PF MTU
+func (c *Configurator) SetVFMTU(ctx context.Context, pfIfName, vfIfName string, mtu int) error {
+	if err := c.CheckVFMTU(pfIfName, mtu); err != nil {
+		return err
+	}
+
+	vfLink, err := c.handle.LinkByName(vfIfName)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get VF link: %v", vfIfName)
+	}
+
+	log.FromContext(ctx).Infof("setting VF %v MTU: %v", vfIfName, mtu)
+	if err = c.handle.LinkSetMTU(vfLink, mtu); err != nil {
+		return wrapError(err, "failed to set VF MTU: %v", vfIfName)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package vfnetlink_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/sriovtest"
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/vfnetlink"
+)
+
+const vfIfName = "vf"
+
+func TestConfigurator_SetVFMTU(t *testing.T) {
+	handle := sriovtest.NewNetlinkHandle()
+	handle.AddPhysicalFunction(pfIfName, 1)
+	handle.AddLink(vfIfName, sriovtest.DefaultMTU)
+
+	c := vfnetlink.NewConfigurator(vfnetlink.WithHandle(handle))
+
+	require.NoError(t, c.SetVFMTU(context.Background(), pfIfName, vfIfName, 1400))
+
+	mtu, err := c.GetVFMTU(vfIfName)
+	require.NoError(t, err)
+	require.Equal(t, 1400, mtu)
+
+	require.ErrorIs(t, c.SetVFMTU(context.Background(), pfIfName, vfIfName, sriovtest.DefaultMTU+1), vfnetlink.ErrMTUTooLarge)
+	require.ErrorIs(t, c.CheckVFMTU(pfIfName, sriovtest.DefaultMTU+1), vfnetlink.ErrMTUTooLarge)
+	require.NoError(t, c.CheckVFMTU(pfIfName, sriovtest.DefaultMTU))
+
+	mtu, err = c.GetVFMTU(vfIfName)
+	require.NoError(t, err)
+	require.Equal(t, 1400, mtu)
+}
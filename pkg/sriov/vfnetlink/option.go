@@ -0,0 +1,37 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package vfnetlink
+
+// Option is an option pattern for Configurator
+type Option func(c *Configurator)
+
+// WithHandle sets netlink handle
+func WithHandle(handle Handle) Option {
+	return func(c *Configurator) {
+		c.handle = handle
+	}
+}
+
+// WithNetClassPath sets path to the net interfaces sysfs directory, default is /sys/class/net
+func WithNetClassPath(netClassPath string) Option {
+	return func(c *Configurator) {
+		c.netClassPath = netClassPath
+	}
+}
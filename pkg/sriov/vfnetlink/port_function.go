@@ -0,0 +1,68 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package vfnetlink
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netlink/nl"
+
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov"
+)
+
+const devlinkBus = "pci"
+
+// GetPortFunctionState returns if the devlink port function is active, returns sriov.ErrNotSupported if the PF
+// driver doesn't support port functions
+func (c *Configurator) GetPortFunctionState(pfPCIAddr string, portIndex int) (bool, error) {
+	port, err := c.handle.DevLinkGetPortByIndex(devlinkBus, pfPCIAddr, uint32(portIndex))
+	if err != nil {
+		return false, wrapError(err, "failed to get devlink port %v for the PF: %v", portIndex, pfPCIAddr)
+	}
+	if port.Fn == nil {
+		return false, errors.Wrapf(sriov.ErrNotSupported, "devlink port %v has no function for the PF: %v", portIndex, pfPCIAddr)
+	}
+	return port.Fn.State == nl.DEVLINK_PORT_FN_STATE_ACTIVE, nil
+}
+
+// SetPortFunctionState activates or deactivates the devlink port function, returns sriov.ErrNotSupported if the PF
+// driver doesn't support port functions
+func (c *Configurator) SetPortFunctionState(ctx context.Context, pfPCIAddr string, portIndex int, active bool) error {
+	state := uint8(nl.DEVLINK_PORT_FN_STATE_INACTIVE)
+	if active {
+		state = nl.DEVLINK_PORT_FN_STATE_ACTIVE
+	}
+
+	log.FromContext(ctx).Infof("setting devlink port %v function state for the PF %v: active=%v", portIndex, pfPCIAddr, active)
+	err := c.handle.DevlinkPortFnSet(devlinkBus, pfPCIAddr, uint32(portIndex), netlink.DevlinkPortFnSetAttrs{
+		FnAttrs: netlink.DevlinkPortFn{
+			State: state,
+		},
+		StateValid: true,
+	})
+	if err != nil {
+		return wrapError(err, "failed to set devlink port %v function state for the PF: %v", portIndex, pfPCIAddr)
+	}
+	return nil
+}
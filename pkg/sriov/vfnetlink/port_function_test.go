@@ -0,0 +1,72 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package vfnetlink_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vishvananda/netlink"
+
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov"
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/sriovtest"
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/vfnetlink"
+)
+
+const pfPCIAddr = "0000:01:00.0"
+
+func TestConfigurator_SetPortFunctionState(t *testing.T) {
+	handle := sriovtest.NewNetlinkHandle()
+	handle.AddDevlinkPort(pfPCIAddr, 1, new(netlink.DevlinkPortFn))
+
+	c := vfnetlink.NewConfigurator(vfnetlink.WithHandle(handle))
+
+	active, err := c.GetPortFunctionState(pfPCIAddr, 1)
+	require.NoError(t, err)
+	require.False(t, active)
+
+	require.NoError(t, c.SetPortFunctionState(context.Background(), pfPCIAddr, 1, true))
+
+	active, err = c.GetPortFunctionState(pfPCIAddr, 1)
+	require.NoError(t, err)
+	require.True(t, active)
+
+	require.NoError(t, c.SetPortFunctionState(context.Background(), pfPCIAddr, 1, false))
+
+	active, err = c.GetPortFunctionState(pfPCIAddr, 1)
+	require.NoError(t, err)
+	require.False(t, active)
+
+	_, err = c.GetPortFunctionState(pfPCIAddr, 2)
+	require.Error(t, err)
+}
+
+func TestConfigurator_SetPortFunctionState_NotSupported(t *testing.T) {
+	handle := sriovtest.NewNetlinkHandle()
+	handle.AddDevlinkPort(pfPCIAddr, 1, nil)
+
+	c := vfnetlink.NewConfigurator(vfnetlink.WithHandle(handle))
+
+	_, err := c.GetPortFunctionState(pfPCIAddr, 1)
+	require.ErrorIs(t, err, sriov.ErrNotSupported)
+
+	require.ErrorIs(t, c.SetPortFunctionState(context.Background(), pfPCIAddr, 1, true), sriov.ErrNotSupported)
+}
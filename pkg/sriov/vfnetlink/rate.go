@@ -0,0 +1,71 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package vfnetlink
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+)
+
+// SetVFRate sets VF min and max TX rates in Mbps, zero maxTxRate means no limit. maxTxRate is validated against the PF
+// link speed if it is available. Returns sriov.ErrNotSupported if the PF driver doesn't support VF rate limiting
+func (c *Configurator) SetVFRate(ctx context.Context, pfIfName string, vfIndex, minTxRate, maxTxRate int) error {
+	if minTxRate < 0 || maxTxRate < 0 {
+		return errors.Errorf("invalid VF TX rate: min %v, max %v", minTxRate, maxTxRate)
+	}
+	if maxTxRate != 0 && minTxRate > maxTxRate {
+		return errors.Errorf("invalid VF TX rate: min %v > max %v", minTxRate, maxTxRate)
+	}
+	if speed, ok := c.GetLinkSpeed(pfIfName); ok && maxTxRate > speed {
+		return errors.Errorf("invalid VF TX rate: max %v > PF %v link speed %v", maxTxRate, pfIfName, speed)
+	}
+
+	link, _, err := c.getVF(pfIfName, vfIndex)
+	if err != nil {
+		return err
+	}
+
+	log.FromContext(ctx).Infof("setting VF %v TX rate for the PF %v: min %v, max %v", vfIndex, pfIfName, minTxRate, maxTxRate)
+	if err = c.handle.LinkSetVfRate(link, vfIndex, minTxRate, maxTxRate); err != nil {
+		return wrapError(err, "failed to set VF %v TX rate for the PF: %v", vfIndex, pfIfName)
+	}
+	return nil
+}
+
+// GetLinkSpeed returns link speed in Mbps, speed is not available for the links in down state and for some virtual
+// links
+func (c *Configurator) GetLinkSpeed(ifName string) (int, bool) {
+	data, err := os.ReadFile(filepath.Clean(filepath.Join(c.netClassPath, ifName, "speed")))
+	if err != nil {
+		return 0, false
+	}
+	speed, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || speed <= 0 {
+		return 0, false
+	}
+	return speed, true
+}
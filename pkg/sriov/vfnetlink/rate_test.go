@@ -0,0 +1,68 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package vfnetlink_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov"
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/sriovtest"
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/vfnetlink"
+)
+
+func TestConfigurator_SetVFRate(t *testing.T) {
+	handle := sriovtest.NewNetlinkHandle()
+	handle.AddPhysicalFunction(pfIfName, 2)
+
+	netClassPath := t.TempDir()
+	c := vfnetlink.NewConfigurator(vfnetlink.WithHandle(handle), vfnetlink.WithNetClassPath(netClassPath))
+
+	// No link speed available
+	require.NoError(t, c.SetVFRate(context.Background(), pfIfName, 1, 100, 50000))
+	require.Equal(t, uint32(100), handle.GetVF(pfIfName, 1).MinTxRate)
+	require.Equal(t, uint32(50000), handle.GetVF(pfIfName, 1).MaxTxRate)
+	require.Equal(t, uint32(0), handle.GetVF(pfIfName, 0).MaxTxRate)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(netClassPath, pfIfName), 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(netClassPath, pfIfName, "speed"), []byte("10000\n"), 0o600))
+
+	require.Error(t, c.SetVFRate(context.Background(), pfIfName, 1, 100, 20000))
+	require.Error(t, c.SetVFRate(context.Background(), pfIfName, 1, 2000, 1000))
+	require.Error(t, c.SetVFRate(context.Background(), pfIfName, 1, -1, 1000))
+	require.Error(t, c.SetVFRate(context.Background(), pfIfName, 2, 100, 1000))
+	require.Equal(t, uint32(50000), handle.GetVF(pfIfName, 1).MaxTxRate)
+
+	require.NoError(t, c.SetVFRate(context.Background(), pfIfName, 1, 1000, 10000))
+	require.Equal(t, uint32(1000), handle.GetVF(pfIfName, 1).MinTxRate)
+	require.Equal(t, uint32(10000), handle.GetVF(pfIfName, 1).MaxTxRate)
+
+	// Zero max rate means no limit
+	require.NoError(t, c.SetVFRate(context.Background(), pfIfName, 1, 1000, 0))
+	require.Equal(t, uint32(0), handle.GetVF(pfIfName, 1).MaxTxRate)
+
+	handle.Err = unix.EOPNOTSUPP
+	require.ErrorIs(t, c.SetVFRate(context.Background(), pfIfName, 1, 0, 1000), sriov.ErrNotSupported)
+}
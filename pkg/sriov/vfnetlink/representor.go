@@ -0,0 +1,106 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package vfnetlink
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// EswitchModeSwitchdev is a devlink eswitch mode of the PF with VF representors
+	EswitchModeSwitchdev = "switchdev"
+
+	physSwitchIDPath = "phys_switch_id"
+	physPortNamePath = "phys_port_name"
+)
+
+// ErrRepresentorNotFound is returned by GetVFRepresentor when there is no representor for the VF
+var ErrRepresentorNotFound = errors.New("VF representor not found")
+
+var (
+	pfPortNameRegexp = regexp.MustCompile(`^p(\d+)$`)
+	vfPortNameRegexp = regexp.MustCompile(`^(?:c\d+)?pf(\d+)vf(\d+)$`)
+)
+
+// IsSwitchdev returns true if the PF devlink eswitch is in the switchdev mode, returns sriov.ErrNotSupported if the
+// PF driver doesn't support devlink
+func (c *Configurator) IsSwitchdev(pfPCIAddr string) (bool, error) {
+	device, err := c.handle.DevLinkGetDeviceByName(devlinkBus, pfPCIAddr)
+	if err != nil {
+		return false, wrapError(err, "failed to get devlink device for the PF: %v", pfPCIAddr)
+	}
+	return device.Attrs.Eswitch.Mode == EswitchModeSwitchdev, nil
+}
+
+// GetVFRepresentor returns the VF representor net interface name, representor shares the switch ID with the PF and
+// has a "pf<N>vf<M>" port name
+func (c *Configurator) GetVFRepresentor(pfIfName string, vfIndex int) (string, error) {
+	switchID, err := c.readNetAttr(pfIfName, physSwitchIDPath)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get switch ID for the PF: %v", pfIfName)
+	}
+
+	// PF port name is optional, if it is available representor should belong to the same PF port
+	pfIndex := ""
+	if pfPortName, pfErr := c.readNetAttr(pfIfName, physPortNamePath); pfErr == nil {
+		if match := pfPortNameRegexp.FindStringSubmatch(pfPortName); match != nil {
+			pfIndex = match[1]
+		}
+	}
+
+	entries, err := os.ReadDir(c.netClassPath)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to read net interfaces: %v", c.netClassPath)
+	}
+	for _, entry := range entries {
+		ifName := entry.Name()
+		if ifName == pfIfName {
+			continue
+		}
+		if ifSwitchID, readErr := c.readNetAttr(ifName, physSwitchIDPath); readErr != nil || ifSwitchID != switchID {
+			continue
+		}
+		portName, readErr := c.readNetAttr(ifName, physPortNamePath)
+		if readErr != nil {
+			continue
+		}
+		match := vfPortNameRegexp.FindStringSubmatch(portName)
+		if match == nil || (pfIndex != "" && match[1] != pfIndex) || match[2] != strconv.Itoa(vfIndex) {
+			continue
+		}
+		return ifName, nil
+	}
+
+	return "", errors.Wrapf(ErrRepresentorNotFound, "VF %v of the PF: %v", vfIndex, pfIfName)
+}
+
+func (c *Configurator) readNetAttr(ifName, attr string) (string, error) {
+	data, err := os.ReadFile(filepath.Clean(filepath.Join(c.netClassPath, ifName, attr)))
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to read %v for the net interface: %v", attr, ifName)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
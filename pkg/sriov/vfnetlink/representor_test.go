@@ -0,0 +1,76 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package vfnetlink_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/sriovtest"
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/vfnetlink"
+)
+
+func addNetInterface(t *testing.T, netClassPath, ifName string, attrs map[string]string) {
+	ifPath := filepath.Join(netClassPath, ifName)
+	require.NoError(t, os.MkdirAll(ifPath, 0o750))
+	for attr, value := range attrs {
+		require.NoError(t, os.WriteFile(filepath.Join(ifPath, attr), []byte(value+"\n"), 0o600))
+	}
+}
+
+func TestConfigurator_IsSwitchdev(t *testing.T) {
+	handle := sriovtest.NewNetlinkHandle()
+	c := vfnetlink.NewConfigurator(vfnetlink.WithHandle(handle))
+
+	switchdev, err := c.IsSwitchdev(pfPCIAddr)
+	require.NoError(t, err)
+	require.False(t, switchdev)
+
+	handle.SetEswitchMode(pfPCIAddr, vfnetlink.EswitchModeSwitchdev)
+
+	switchdev, err = c.IsSwitchdev(pfPCIAddr)
+	require.NoError(t, err)
+	require.True(t, switchdev)
+}
+
+func TestConfigurator_GetVFRepresentor(t *testing.T) {
+	netClassPath := t.TempDir()
+	c := vfnetlink.NewConfigurator(vfnetlink.WithNetClassPath(netClassPath))
+
+	_, err := c.GetVFRepresentor(pfIfName, 1)
+	require.Error(t, err)
+
+	addNetInterface(t, netClassPath, pfIfName, map[string]string{"phys_switch_id": "abcd", "phys_port_name": "p0"})
+	addNetInterface(t, netClassPath, "eth0", nil)
+	addNetInterface(t, netClassPath, "pf0vf0-rep", map[string]string{"phys_switch_id": "abcd", "phys_port_name": "pf0vf0"})
+	addNetInterface(t, netClassPath, "pf0vf1-rep", map[string]string{"phys_switch_id": "abcd", "phys_port_name": "pf0vf1"})
+	addNetInterface(t, netClassPath, "pf1vf1-rep", map[string]string{"phys_switch_id": "abcd", "phys_port_name": "pf1vf1"})
+	addNetInterface(t, netClassPath, "other-rep", map[string]string{"phys_switch_id": "ef01", "phys_port_name": "pf0vf2"})
+
+	repName, err := c.GetVFRepresentor(pfIfName, 1)
+	require.NoError(t, err)
+	require.Equal(t, "pf0vf1-rep", repName)
+
+	_, err = c.GetVFRepresentor(pfIfName, 2)
+	require.ErrorIs(t, err, vfnetlink.ErrRepresentorNotFound)
+}
@@ -0,0 +1,53 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package vfnetlink_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov"
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/sriovtest"
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/vfnetlink"
+)
+
+func TestConfigurator_SetVFSpoofCheck(t *testing.T) {
+	handle := sriovtest.NewNetlinkHandle()
+	handle.AddPhysicalFunction(pfIfName, 2)
+
+	c := vfnetlink.NewConfigurator(vfnetlink.WithHandle(handle))
+
+	require.NoError(t, c.SetVFSpoofCheck(context.Background(), pfIfName, 1, true))
+	require.True(t, handle.GetVF(pfIfName, 1).Spoofchk)
+	require.False(t, handle.GetVF(pfIfName, 0).Spoofchk)
+
+	enabled, err := c.GetVFSpoofCheck(pfIfName, 1)
+	require.NoError(t, err)
+	require.True(t, enabled)
+
+	require.NoError(t, c.SetVFSpoofCheck(context.Background(), pfIfName, 1, false))
+	require.False(t, handle.GetVF(pfIfName, 1).Spoofchk)
+
+	handle.Err = unix.EOPNOTSUPP
+	require.ErrorIs(t, c.SetVFSpoofCheck(context.Background(), pfIfName, 1, true), sriov.ErrNotSupported)
+}
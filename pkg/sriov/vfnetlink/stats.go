@@ -0,0 +1,72 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package vfnetlink
+
+// VFStats contains VF traffic counters
+type VFStats struct {
+	RxPackets uint64
+	TxPackets uint64
+	RxBytes   uint64
+	TxBytes   uint64
+	Multicast uint64
+	Broadcast uint64
+	RxDropped uint64
+	TxDropped uint64
+}
+
+// GetVFStats returns VF traffic counters reported by the PF driver, drivers not supporting VF stats report zeros
+func (c *Configurator) GetVFStats(pfIfName string, vfIndex int) (*VFStats, error) {
+	_, vf, err := c.getVF(pfIfName, vfIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	return &VFStats{
+		RxPackets: vf.RxPackets,
+		TxPackets: vf.TxPackets,
+		RxBytes:   vf.RxBytes,
+		TxBytes:   vf.TxBytes,
+		Multicast: vf.Multicast,
+		Broadcast: vf.Broadcast,
+		RxDropped: vf.RxDropped,
+		TxDropped: vf.TxDropped,
+	}, nil
+}
+
+// Sub returns the counters increase since base, counter less than the base one is considered to be reset and is
+// returned as is
+func (s *VFStats) Sub(base *VFStats) *VFStats {
+	sub := func(value, baseValue uint64) uint64 {
+		if value < baseValue {
+			return value
+		}
+		return value - baseValue
+	}
+	return &VFStats{
+		RxPackets: sub(s.RxPackets, base.RxPackets),
+		TxPackets: sub(s.TxPackets, base.TxPackets),
+		RxBytes:   sub(s.RxBytes, base.RxBytes),
+		TxBytes:   sub(s.TxBytes, base.TxBytes),
+		Multicast: sub(s.Multicast, base.Multicast),
+		Broadcast: sub(s.Broadcast, base.Broadcast),
+		RxDropped: sub(s.RxDropped, base.RxDropped),
+		TxDropped: sub(s.TxDropped, base.TxDropped),
+	}
+}
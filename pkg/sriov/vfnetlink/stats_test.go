@@ -0,0 +1,63 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package vfnetlink_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vishvananda/netlink"
+
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/sriovtest"
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/vfnetlink"
+)
+
+func TestConfigurator_GetVFStats(t *testing.T) {
+	handle := sriovtest.NewNetlinkHandle()
+	handle.AddPhysicalFunction(pfIfName, 2)
+	handle.UpdateVF(pfIfName, 1, func(vfInfo *netlink.VfInfo) {
+		vfInfo.RxPackets = 10
+		vfInfo.TxPackets = 20
+		vfInfo.RxBytes = 1000
+		vfInfo.TxBytes = 2000
+	})
+
+	c := vfnetlink.NewConfigurator(vfnetlink.WithHandle(handle))
+
+	stats, err := c.GetVFStats(pfIfName, 1)
+	require.NoError(t, err)
+	require.Equal(t, &vfnetlink.VFStats{
+		RxPackets: 10,
+		TxPackets: 20,
+		RxBytes:   1000,
+		TxBytes:   2000,
+	}, stats)
+
+	_, err = c.GetVFStats(pfIfName, 2)
+	require.Error(t, err)
+}
+
+func TestVFStats_Sub(t *testing.T) {
+	stats := &vfnetlink.VFStats{RxPackets: 10, TxPackets: 5, RxBytes: 1000}
+	base := &vfnetlink.VFStats{RxPackets: 4, TxPackets: 7, RxBytes: 1000}
+
+	// TxPackets counter has been reset
+	require.Equal(t, &vfnetlink.VFStats{RxPackets: 6, TxPackets: 5}, stats.Sub(base))
+}
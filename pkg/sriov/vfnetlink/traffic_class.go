@@ -0,0 +1,55 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package vfnetlink
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+)
+
+// MaxTrafficClass is the max VF traffic class value
+const MaxTrafficClass = 7
+
+// SetVFTrafficClass sets VF egress 802.1p priority to the traffic class keeping the VF VLAN unchanged, returns
+// sriov.ErrNotSupported if the PF driver doesn't support VF QoS
+func (c *Configurator) SetVFTrafficClass(ctx context.Context, pfIfName string, vfIndex int, tc uint8) error {
+	if tc > MaxTrafficClass {
+		return errors.Errorf("invalid traffic class: %v > %v", tc, MaxTrafficClass)
+	}
+
+	link, vf, err := c.getVF(pfIfName, vfIndex)
+	if err != nil {
+		return err
+	}
+
+	log.FromContext(ctx).Infof("setting VF %v traffic class for the PF %v: %v", vfIndex, pfIfName, tc)
+	if err = c.handle.LinkSetVfVlanQos(link, vfIndex, vf.Vlan, int(tc)); err != nil {
+		return wrapError(err, "failed to set VF %v traffic class for the PF: %v", vfIndex, pfIfName)
+	}
+	return nil
+}
+
+// ClearVFTrafficClass resets VF traffic class to the default one
+func (c *Configurator) ClearVFTrafficClass(ctx context.Context, pfIfName string, vfIndex int) error {
+	return c.SetVFTrafficClass(ctx, pfIfName, vfIndex, 0)
+}
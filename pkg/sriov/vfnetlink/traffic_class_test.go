@@ -0,0 +1,69 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package vfnetlink_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov"
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/sriovtest"
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/vfnetlink"
+)
+
+const (
+	pfIfName = "pf"
+	vlan     = 100
+)
+
+func TestConfigurator_SetVFTrafficClass(t *testing.T) {
+	handle := sriovtest.NewNetlinkHandle()
+	handle.AddPhysicalFunction(pfIfName, 2)
+	link, err := handle.LinkByName(pfIfName)
+	require.NoError(t, err)
+	require.NoError(t, handle.LinkSetVfVlanQos(link, 1, vlan, 0))
+
+	c := vfnetlink.NewConfigurator(vfnetlink.WithHandle(handle))
+
+	require.NoError(t, c.SetVFTrafficClass(context.Background(), pfIfName, 1, 5))
+	require.Equal(t, 5, handle.GetVF(pfIfName, 1).Qos)
+	require.Equal(t, vlan, handle.GetVF(pfIfName, 1).Vlan)
+	require.Equal(t, 0, handle.GetVF(pfIfName, 0).Qos)
+
+	require.NoError(t, c.ClearVFTrafficClass(context.Background(), pfIfName, 1))
+	require.Equal(t, 0, handle.GetVF(pfIfName, 1).Qos)
+	require.Equal(t, vlan, handle.GetVF(pfIfName, 1).Vlan)
+
+	require.Error(t, c.SetVFTrafficClass(context.Background(), pfIfName, 1, vfnetlink.MaxTrafficClass+1))
+	require.Error(t, c.SetVFTrafficClass(context.Background(), pfIfName, 2, 1))
+}
+
+func TestConfigurator_SetVFTrafficClass_NotSupported(t *testing.T) {
+	handle := sriovtest.NewNetlinkHandle()
+	handle.AddPhysicalFunction(pfIfName, 1)
+	handle.Err = unix.EOPNOTSUPP
+
+	c := vfnetlink.NewConfigurator(vfnetlink.WithHandle(handle))
+
+	require.ErrorIs(t, c.SetVFTrafficClass(context.Background(), pfIfName, 0, 1), sriov.ErrNotSupported)
+}
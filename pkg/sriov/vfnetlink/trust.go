@@ -0,0 +1,51 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package vfnetlink
+
+import (
+	"context"
+
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+)
+
+// GetVFTrust returns if VF is trusted
+func (c *Configurator) GetVFTrust(pfIfName string, vfIndex int) (bool, error) {
+	_, vf, err := c.getVF(pfIfName, vfIndex)
+	if err != nil {
+		return false, err
+	}
+	return vf.Trust != 0, nil
+}
+
+// SetVFTrust enables or disables VF trust, returns sriov.ErrNotSupported if the PF driver doesn't support it. Trusted
+// VF can change its MAC address and enable promiscuous mode, so it should be set before the VF is moved to the client
+// network namespace. It requires CAP_NET_ADMIN in the PF network namespace.
+func (c *Configurator) SetVFTrust(ctx context.Context, pfIfName string, vfIndex int, enabled bool) error {
+	link, _, err := c.getVF(pfIfName, vfIndex)
+	if err != nil {
+		return err
+	}
+
+	log.FromContext(ctx).Infof("setting VF %v trust for the PF %v: %v", vfIndex, pfIfName, enabled)
+	if err = c.handle.LinkSetVfTrust(link, vfIndex, enabled); err != nil {
+		return wrapError(err, "failed to set VF %v trust for the PF: %v", vfIndex, pfIfName)
+	}
+	return nil
+}
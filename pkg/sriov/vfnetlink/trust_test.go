@@ -0,0 +1,57 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package vfnetlink_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov"
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/sriovtest"
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/vfnetlink"
+)
+
+func TestConfigurator_SetVFTrust(t *testing.T) {
+	handle := sriovtest.NewNetlinkHandle()
+	handle.AddPhysicalFunction(pfIfName, 2)
+
+	c := vfnetlink.NewConfigurator(vfnetlink.WithHandle(handle))
+
+	require.NoError(t, c.SetVFTrust(context.Background(), pfIfName, 1, true))
+	require.Equal(t, uint32(1), handle.GetVF(pfIfName, 1).Trust)
+	require.Equal(t, uint32(0), handle.GetVF(pfIfName, 0).Trust)
+
+	trusted, err := c.GetVFTrust(pfIfName, 1)
+	require.NoError(t, err)
+	require.True(t, trusted)
+
+	require.NoError(t, c.SetVFTrust(context.Background(), pfIfName, 1, false))
+	trusted, err = c.GetVFTrust(pfIfName, 1)
+	require.NoError(t, err)
+	require.False(t, trusted)
+
+	require.Error(t, c.SetVFTrust(context.Background(), pfIfName, 2, true))
+
+	handle.Err = unix.EOPNOTSUPP
+	require.ErrorIs(t, c.SetVFTrust(context.Background(), pfIfName, 1, true), sriov.ErrNotSupported)
+}
@@ -0,0 +1,67 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package vfnetlink
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/vishvananda/netlink"
+
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+)
+
+// VF link states
+const (
+	// VFLinkStateAuto makes VF link state follow the PF link state
+	VFLinkStateAuto = "auto"
+	// VFLinkStateEnable makes VF link always up
+	VFLinkStateEnable = "enable"
+	// VFLinkStateDisable makes VF link always down
+	VFLinkStateDisable = "disable"
+)
+
+var vfLinkStates = map[string]uint32{
+	VFLinkStateAuto:    netlink.VF_LINK_STATE_AUTO,
+	VFLinkStateEnable:  netlink.VF_LINK_STATE_ENABLE,
+	VFLinkStateDisable: netlink.VF_LINK_STATE_DISABLE,
+}
+
+// SetVFLinkState sets VF link state to one of "auto", "enable", "disable", returns sriov.ErrNotSupported if the PF
+// driver doesn't support it
+func (c *Configurator) SetVFLinkState(ctx context.Context, pfIfName string, vfIndex int, state string) error {
+	linkState, ok := vfLinkStates[state]
+	if !ok {
+		return errors.Errorf("invalid VF link state: %q, valid values: %s", state,
+			strings.Join([]string{VFLinkStateAuto, VFLinkStateEnable, VFLinkStateDisable}, ", "))
+	}
+
+	link, _, err := c.getVF(pfIfName, vfIndex)
+	if err != nil {
+		return err
+	}
+
+	log.FromContext(ctx).Infof("setting VF %v link state for the PF %v: %v", vfIndex, pfIfName, state)
+	if err = c.handle.LinkSetVfState(link, vfIndex, linkState); err != nil {
+		return wrapError(err, "failed to set VF %v link state for the PF: %v", vfIndex, pfIfName)
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package vfnetlink_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov"
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/sriovtest"
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/vfnetlink"
+)
+
+func TestConfigurator_SetVFLinkState(t *testing.T) {
+	handle := sriovtest.NewNetlinkHandle()
+	handle.AddPhysicalFunction(pfIfName, 2)
+
+	c := vfnetlink.NewConfigurator(vfnetlink.WithHandle(handle))
+
+	for _, sample := range []struct {
+		state    string
+		expected uint32
+	}{
+		{state: vfnetlink.VFLinkStateDisable, expected: netlink.VF_LINK_STATE_DISABLE},
+		{state: vfnetlink.VFLinkStateEnable, expected: netlink.VF_LINK_STATE_ENABLE},
+		{state: vfnetlink.VFLinkStateAuto, expected: netlink.VF_LINK_STATE_AUTO},
+	} {
+		require.NoError(t, c.SetVFLinkState(context.Background(), pfIfName, 1, sample.state))
+		require.Equal(t, sample.expected, handle.GetVF(pfIfName, 1).LinkState, sample.state)
+	}
+
+	err := c.SetVFLinkState(context.Background(), pfIfName, 1, "up")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "auto, enable, disable")
+
+	require.Error(t, c.SetVFLinkState(context.Background(), pfIfName, 2, vfnetlink.VFLinkStateEnable))
+
+	handle.Err = unix.EOPNOTSUPP
+	require.ErrorIs(t, c.SetVFLinkState(context.Background(), pfIfName, 1, vfnetlink.VFLinkStateEnable), sriov.ErrNotSupported)
+}
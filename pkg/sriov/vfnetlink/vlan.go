@@ -0,0 +1,53 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package vfnetlink
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+)
+
+// MaxVLANID is the max VF 802.1Q VLAN ID value
+const MaxVLANID = 4094
+
+// SetVFVLAN sets VF 802.1Q VLAN ID and egress 802.1p priority, zero vlanID clears the VF VLAN. Returns
+// sriov.ErrNotSupported if the PF driver doesn't support VF VLAN
+func (c *Configurator) SetVFVLAN(ctx context.Context, pfIfName string, vfIndex, vlanID, qos int) error {
+	if vlanID < 0 || vlanID > MaxVLANID {
+		return errors.Errorf("invalid VLAN ID: %v, should be in [0, %v]", vlanID, MaxVLANID)
+	}
+	if qos < 0 || qos > MaxTrafficClass {
+		return errors.Errorf("invalid VLAN QoS: %v, should be in [0, %v]", qos, MaxTrafficClass)
+	}
+
+	link, _, err := c.getVF(pfIfName, vfIndex)
+	if err != nil {
+		return err
+	}
+
+	log.FromContext(ctx).Infof("setting VF %v VLAN for the PF %v: %v, QoS: %v", vfIndex, pfIfName, vlanID, qos)
+	if err = c.handle.LinkSetVfVlanQos(link, vfIndex, vlanID, qos); err != nil {
+		return wrapError(err, "failed to set VF %v VLAN for the PF: %v", vfIndex, pfIfName)
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package vfnetlink_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov"
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/sriovtest"
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/vfnetlink"
+)
+
+func TestConfigurator_SetVFVLAN(t *testing.T) {
+	handle := sriovtest.NewNetlinkHandle()
+	handle.AddPhysicalFunction(pfIfName, 2)
+
+	c := vfnetlink.NewConfigurator(vfnetlink.WithHandle(handle))
+
+	require.NoError(t, c.SetVFVLAN(context.Background(), pfIfName, 1, vlan, 3))
+	require.Equal(t, vlan, handle.GetVF(pfIfName, 1).Vlan)
+	require.Equal(t, 3, handle.GetVF(pfIfName, 1).Qos)
+	require.Equal(t, 0, handle.GetVF(pfIfName, 0).Vlan)
+
+	// Clear VLAN
+	require.NoError(t, c.SetVFVLAN(context.Background(), pfIfName, 1, 0, 0))
+	require.Equal(t, 0, handle.GetVF(pfIfName, 1).Vlan)
+	require.Equal(t, 0, handle.GetVF(pfIfName, 1).Qos)
+
+	require.Error(t, c.SetVFVLAN(context.Background(), pfIfName, 1, vfnetlink.MaxVLANID+1, 0))
+	require.Error(t, c.SetVFVLAN(context.Background(), pfIfName, 1, -1, 0))
+	require.Error(t, c.SetVFVLAN(context.Background(), pfIfName, 1, vlan, vfnetlink.MaxTrafficClass+1))
+	require.Error(t, c.SetVFVLAN(context.Background(), pfIfName, 2, vlan, 0))
+
+	handle.Err = unix.EOPNOTSUPP
+	require.ErrorIs(t, c.SetVFVLAN(context.Background(), pfIfName, 1, vlan, 0), sriov.ErrNotSupported)
+}
@@ -0,0 +1,248 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package cgroup
+
+import (
+	"encoding/binary"
+	"runtime"
+	"unsafe"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	bpfInsnSize    = 8
+	bpfLicense     = "Apache-2.0"
+	bpfMaxQueryCnt = 64
+)
+
+// bpfInsn is an eBPF instruction
+type bpfInsn struct {
+	code uint8
+	dst  uint8
+	src  uint8
+	off  int16
+	imm  int32
+}
+
+func (insn *bpfInsn) marshal(b []byte) {
+	b[0] = insn.code
+	b[1] = insn.dst&0x0f | insn.src<<4
+	binary.LittleEndian.PutUint16(b[2:], uint16(insn.off))
+	binary.LittleEndian.PutUint32(b[4:], uint32(insn.imm))
+}
+
+func unmarshalInsns(b []byte) []bpfInsn {
+	insns := make([]bpfInsn, len(b)/bpfInsnSize)
+	for i := range insns {
+		ib := b[i*bpfInsnSize:]
+		insns[i] = bpfInsn{
+			code: ib[0],
+			dst:  ib[1] & 0x0f,
+			src:  ib[1] >> 4,
+			off:  int16(binary.LittleEndian.Uint16(ib[2:])),
+			imm:  int32(binary.LittleEndian.Uint32(ib[4:])),
+		}
+	}
+	return insns
+}
+
+func marshalInsns(insns []bpfInsn) []byte {
+	b := make([]byte, len(insns)*bpfInsnSize)
+	for i := range insns {
+		insns[i].marshal(b[i*bpfInsnSize:])
+	}
+	return b
+}
+
+func bpf(cmd int, attr unsafe.Pointer, size uintptr) (uintptr, error) {
+	r, _, errno := unix.Syscall(unix.SYS_BPF, uintptr(cmd), uintptr(attr), size)
+	if errno != 0 {
+		return 0, errno
+	}
+	return r, nil
+}
+
+type bpfQueryAttr struct {
+	targetFD    uint32
+	attachType  uint32
+	queryFlags  uint32
+	attachFlags uint32
+	progIDs     uint64
+	progCnt     uint32
+	_           uint32
+}
+
+// queryDevicePrograms returns IDs and attach flags of the device programs attached directly to the cgroup, with the
+// unix.BPF_F_QUERY_EFFECTIVE query flag it returns IDs of the programs run for the cgroup including the ancestor ones
+func queryDevicePrograms(cgroupFD int, queryFlags uint32) (progIDs []uint32, attachFlags uint32, err error) {
+	ids := make([]uint32, bpfMaxQueryCnt)
+	attr := bpfQueryAttr{
+		targetFD:   uint32(cgroupFD),
+		attachType: unix.BPF_CGROUP_DEVICE,
+		queryFlags: queryFlags,
+		progIDs:    uint64(uintptr(unsafe.Pointer(&ids[0]))),
+		progCnt:    uint32(len(ids)),
+	}
+	_, err = bpf(unix.BPF_PROG_QUERY, unsafe.Pointer(&attr), unsafe.Sizeof(attr))
+	runtime.KeepAlive(ids)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "failed to query cgroup device programs")
+	}
+	return ids[:attr.progCnt], attr.attachFlags, nil
+}
+
+type bpfGetFDByIDAttr struct {
+	id        uint32
+	nextID    uint32
+	openFlags uint32
+}
+
+func progFDByID(id uint32) (int, error) {
+	attr := bpfGetFDByIDAttr{id: id}
+	fd, err := bpf(unix.BPF_PROG_GET_FD_BY_ID, unsafe.Pointer(&attr), unsafe.Sizeof(attr))
+	if err != nil {
+		return -1, errors.Wrapf(err, "failed to get device program by ID: %d", id)
+	}
+	return int(fd), nil
+}
+
+type bpfObjInfoAttr struct {
+	bpfFD   uint32
+	infoLen uint32
+	info    uint64
+}
+
+// bpfProgInfo is the beginning of the struct bpf_prog_info, the kernel fills in only the requested info length
+type bpfProgInfo struct {
+	progType        uint32
+	id              uint32
+	tag             [8]byte
+	jitedProgLen    uint32
+	xlatedProgLen   uint32
+	jitedProgInsns  uint64
+	xlatedProgInsns uint64
+}
+
+func progInfo(progFD int, info *bpfProgInfo) error {
+	attr := bpfObjInfoAttr{
+		bpfFD:   uint32(progFD),
+		infoLen: uint32(unsafe.Sizeof(*info)),
+		info:    uint64(uintptr(unsafe.Pointer(info))),
+	}
+	_, err := bpf(unix.BPF_OBJ_GET_INFO_BY_FD, unsafe.Pointer(&attr), unsafe.Sizeof(attr))
+	return err
+}
+
+// progInsns returns the program instructions as they have been loaded to the kernel
+func progInsns(progFD int) ([]bpfInsn, error) {
+	info := new(bpfProgInfo)
+	if err := progInfo(progFD, info); err != nil {
+		return nil, errors.Wrap(err, "failed to get device program info")
+	}
+	if info.xlatedProgLen == 0 {
+		return nil, errors.New("device program instructions are not available")
+	}
+
+	b := make([]byte, info.xlatedProgLen)
+	*info = bpfProgInfo{
+		xlatedProgLen:   uint32(len(b)),
+		xlatedProgInsns: uint64(uintptr(unsafe.Pointer(&b[0]))),
+	}
+	err := progInfo(progFD, info)
+	runtime.KeepAlive(b)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get device program instructions")
+	}
+
+	return unmarshalInsns(b), nil
+}
+
+type bpfProgLoadAttr struct {
+	progType           uint32
+	insnCnt            uint32
+	insns              uint64
+	license            uint64
+	logLevel           uint32
+	logSize            uint32
+	logBuf             uint64
+	kernVersion        uint32
+	progFlags          uint32
+	progName           [16]byte
+	progIfIndex        uint32
+	expectedAttachType uint32
+}
+
+func loadDeviceProgram(insns []bpfInsn) (int, error) {
+	b := marshalInsns(insns)
+	license := append([]byte(bpfLicense), 0)
+	logBuf := make([]byte, 4096)
+
+	attr := bpfProgLoadAttr{
+		progType:           unix.BPF_PROG_TYPE_CGROUP_DEVICE,
+		insnCnt:            uint32(len(insns)),
+		insns:              uint64(uintptr(unsafe.Pointer(&b[0]))),
+		license:            uint64(uintptr(unsafe.Pointer(&license[0]))),
+		logLevel:           1,
+		logSize:            uint32(len(logBuf)),
+		logBuf:             uint64(uintptr(unsafe.Pointer(&logBuf[0]))),
+		expectedAttachType: unix.BPF_CGROUP_DEVICE,
+	}
+	copy(attr.progName[:], "nsm_devices")
+
+	fd, err := bpf(unix.BPF_PROG_LOAD, unsafe.Pointer(&attr), unsafe.Sizeof(attr))
+	runtime.KeepAlive(b)
+	runtime.KeepAlive(license)
+	runtime.KeepAlive(logBuf)
+	if err != nil {
+		return -1, errors.Wrapf(err, "failed to load device program: %s", unix.ByteSliceToString(logBuf))
+	}
+	return int(fd), nil
+}
+
+type bpfProgAttachAttr struct {
+	targetFD     uint32
+	attachBPFFD  uint32
+	attachType   uint32
+	attachFlags  uint32
+	replaceBPFFD uint32
+}
+
+func attachDeviceProgram(cgroupFD, progFD int, flags uint32) error {
+	attr := bpfProgAttachAttr{
+		targetFD:    uint32(cgroupFD),
+		attachBPFFD: uint32(progFD),
+		attachType:  unix.BPF_CGROUP_DEVICE,
+		attachFlags: flags,
+	}
+	_, err := bpf(unix.BPF_PROG_ATTACH, unsafe.Pointer(&attr), unsafe.Sizeof(attr))
+	return errors.Wrap(err, "failed to attach device program")
+}
+
+func detachDeviceProgram(cgroupFD, progFD int) error {
+	attr := bpfProgAttachAttr{
+		targetFD:    uint32(cgroupFD),
+		attachBPFFD: uint32(progFD),
+		attachType:  unix.BPF_CGROUP_DEVICE,
+	}
+	_, err := bpf(unix.BPF_PROG_DETACH, unsafe.Pointer(&attr), unsafe.Sizeof(attr))
+	return errors.Wrap(err, "failed to detach device program")
+}
@@ -26,6 +26,8 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"sort"
+	"strings"
 
 	"github.com/pkg/errors"
 )
@@ -34,14 +36,26 @@ const (
 	deviceListFileName  = "devices.list"
 	deviceAllowFileName = "devices.allow"
 	deviceDenyFileName  = "devices.deny"
+
+	unifiedControllersFileName = "cgroup.controllers"
+
+	// AllDeviceModes are read, write and mknod device modes
+	AllDeviceModes = "rwm"
 )
 
+// ErrInvalidDeviceModes is returned for device modes not being a non-empty subset of AllDeviceModes
+var ErrInvalidDeviceModes = errors.New("device modes should be a non-empty subset of \"rwm\"")
+
 // Cgroup represents linux devices cgroup
 type Cgroup struct {
 	Path string
+
+	// unified is set for cgroup v2 cgroups, devices are managed with the cgroup device eBPF programs for them
+	unified bool
 }
 
-// NewCgroups returns all cgroups matching pathPattern
+// NewCgroups returns all cgroups matching pathPattern, cgroup v1 devices cgroups are detected by the devices.list file,
+// cgroup v2 cgroups are detected by the cgroup.controllers file
 func NewCgroups(pathPattern string) (cgroups []*Cgroup, err error) {
 	var filePaths []string
 	pattern := filepath.Join(pathPattern, deviceListFileName)
@@ -50,34 +64,139 @@ func NewCgroups(pathPattern string) (cgroups []*Cgroup, err error) {
 		return nil, errors.Wrapf(err, "failed to get filepaths %s", pattern)
 	}
 
+	paths := map[string]struct{}{}
 	for _, filePath := range filePaths {
+		paths[filepath.Dir(filePath)] = struct{}{}
 		cgroups = append(cgroups, &Cgroup{Path: filepath.Dir(filePath)})
 	}
 
+	pattern = filepath.Join(pathPattern, unifiedControllersFileName)
+	if filePaths, err = filepath.Glob(pattern); err != nil {
+		return nil, errors.Wrapf(err, "failed to get filepaths %s", pattern)
+	}
+
+	for _, filePath := range filePaths {
+		if _, ok := paths[filepath.Dir(filePath)]; !ok {
+			cgroups = append(cgroups, &Cgroup{Path: filepath.Dir(filePath), unified: true})
+		}
+	}
+
+	sort.Slice(cgroups, func(i, k int) bool { return cgroups[i].Path < cgroups[k].Path })
+
 	return cgroups, nil
 }
 
+// IsUnified returns true for cgroup v2 cgroup, for such cgroup devices are allowed by prepending the cgroup device eBPF
+// programs attached to it with the allowed devices checks. Cgroup v2 cgroup with no device programs allows all devices.
+func (c *Cgroup) IsUnified() bool {
+	return c.unified
+}
+
+// ValidateDeviceModes returns ErrInvalidDeviceModes if modes is not a non-empty subset of AllDeviceModes
+func ValidateDeviceModes(modes string) error {
+	if modes == "" {
+		return errors.Wrapf(ErrInvalidDeviceModes, "%q", modes)
+	}
+	for i, mode := range modes {
+		if !strings.ContainsRune(AllDeviceModes, mode) || strings.ContainsRune(modes[:i], mode) {
+			return errors.Wrapf(ErrInvalidDeviceModes, "%q", modes)
+		}
+	}
+	return nil
+}
+
+// DeviceID is a character device major:minor number
+type DeviceID struct {
+	Major, Minor uint32
+}
+
 // Allow allows "c major:minor rwm" for cgroup
 func (c *Cgroup) Allow(major, minor uint32) error {
-	dev := newDevice(major, minor, 'r', 'w', 'm')
+	return c.AllowModes(major, minor, AllDeviceModes)
+}
 
-	filePath := filepath.Join(c.Path, deviceAllowFileName)
-	if err := os.WriteFile(filePath, []byte(dev.String()), 0); err != nil {
-		return errors.Wrapf(err, "failed to write to a %s", filePath)
-	}
+// AllowModes allows "c major:minor <modes>" for cgroup, modes should be a subset of AllDeviceModes
+func (c *Cgroup) AllowModes(major, minor uint32, modes string) error {
+	return c.AllowAllModes([]DeviceID{{Major: major, Minor: minor}}, modes)
+}
 
-	return nil
+// AllowAll allows "c major:minor rwm" for all the devices for cgroup in one pass
+func (c *Cgroup) AllowAll(devices []DeviceID) error {
+	return c.AllowAllModes(devices, AllDeviceModes)
+}
+
+// AllowAllModes allows "c major:minor <modes>" for all the devices for cgroup in one pass, modes should be a subset of
+// AllDeviceModes
+func (c *Cgroup) AllowAllModes(devices []DeviceID, modes string) error {
+	if err := ValidateDeviceModes(modes); err != nil {
+		return err
+	}
+	if len(devices) == 0 {
+		return nil
+	}
+	if c.unified {
+		return c.allowUnified(newDevices(devices, modes)...)
+	}
+	return c.writeDevices(deviceAllowFileName, newDevices(devices, modes)...)
 }
 
-// Deny denies "c major:minor rw" for cgroup
+// Deny denies "c major:minor rwm" for cgroup
 func (c *Cgroup) Deny(major, minor uint32) error {
-	dev := newDevice(major, minor, 'r', 'w')
+	return c.DenyModes(major, minor, AllDeviceModes)
+}
+
+// DenyModes denies "c major:minor <modes>" for cgroup, modes should be a subset of AllDeviceModes
+func (c *Cgroup) DenyModes(major, minor uint32, modes string) error {
+	return c.DenyAllModes([]DeviceID{{Major: major, Minor: minor}}, modes)
+}
 
-	filePath := filepath.Join(c.Path, deviceAllowFileName)
-	if err := os.WriteFile(filePath, []byte(dev.String()), 0); err != nil {
-		return errors.Wrapf(err, "failed to write to a %s", filePath)
+// DenyAll denies "c major:minor rwm" for all the devices for cgroup in one pass
+func (c *Cgroup) DenyAll(devices []DeviceID) error {
+	return c.DenyAllModes(devices, AllDeviceModes)
+}
+
+// DenyAllModes denies "c major:minor <modes>" for all the devices for cgroup in one pass, modes should be a subset of
+// AllDeviceModes
+func (c *Cgroup) DenyAllModes(devices []DeviceID, modes string) error {
+	if err := ValidateDeviceModes(modes); err != nil {
+		return err
+	}
+	if len(devices) == 0 {
+		return nil
+	}
+	if c.unified {
+		return c.denyUnified(newDevices(devices, modes)...)
 	}
+	return c.writeDevices(deviceDenyFileName, newDevices(devices, modes)...)
+}
 
+func newDevices(devices []DeviceID, modes string) []*device {
+	devs := make([]*device, 0, len(devices))
+	for _, d := range devices {
+		devs = append(devs, newDevice(d.Major, d.Minor, []rune(modes)...))
+	}
+	return devs
+}
+
+// writeDevices opens the file once and writes each device with a separate write, as the kernel parses a single device
+// per write
+func (c *Cgroup) writeDevices(fileName string, devs ...*device) (err error) {
+	filePath := filepath.Join(c.Path, fileName)
+	file, err := os.OpenFile(filepath.Clean(filePath), os.O_WRONLY, 0)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open a %s", filePath)
+	}
+	defer func() {
+		if closeErr := file.Close(); closeErr != nil && err == nil {
+			err = errors.Wrapf(closeErr, "failed to close a %s", filePath)
+		}
+	}()
+
+	for _, dev := range devs {
+		if _, err := file.WriteString(dev.String()); err != nil {
+			return errors.Wrapf(err, "failed to write to a %s", filePath)
+		}
+	}
 	return nil
 }
 
@@ -87,6 +206,15 @@ func (c *Cgroup) IsAllowed(major, minor uint32) (bool, error) {
 	return isAllowed, err
 }
 
+// IsAllowedModes returns if "c major:minor <modes>" is allowed for cgroup, either exactly or by a wider device group
+func (c *Cgroup) IsAllowedModes(major, minor uint32, modes string) (bool, error) {
+	if err := ValidateDeviceModes(modes); err != nil {
+		return false, err
+	}
+	isAllowed, _, err := c.compareTo(newDevice(major, minor, []rune(modes)...))
+	return isAllowed, err
+}
+
 // IsWiderThan returns if cgroup allows wider device group than "c major:minor rwm":
 //   - "a *:minor rwm"
 //   - "a major:* rwm"
@@ -100,6 +228,10 @@ func (c *Cgroup) IsWiderThan(major, minor uint32) (bool, error) {
 }
 
 func (c *Cgroup) compareTo(dev *device) (isAllowed, isWider bool, err error) {
+	if c.unified {
+		return c.compareToUnified(dev)
+	}
+
 	filePath := filepath.Clean(filepath.Join(c.Path, deviceListFileName))
 	file, err := os.Open(filePath)
 	if err != nil {
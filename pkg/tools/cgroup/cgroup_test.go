@@ -20,9 +20,11 @@
 package cgroup_test
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
@@ -59,6 +61,42 @@ func TestNewCgroups(t *testing.T) {
 	require.Equal(t, filepath.Join(tmpDir, "c"), cgroups[2].Path)
 }
 
+func createUnifiedCgroup(t *testing.T, path string) {
+	require.NoError(t, os.MkdirAll(path, mkdirPerm))
+
+	f, err := os.Create(filepath.Join(path, "cgroup.controllers"))
+	require.NoError(t, err)
+	_ = f.Close()
+}
+
+func TestNewCgroups_Unified(t *testing.T) {
+	tmpDir := filepath.Join(os.TempDir(), t.Name())
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	createUnifiedCgroup(t, filepath.Join(tmpDir, "a"))
+	createCgroup(t, filepath.Join(tmpDir, "b"))
+	createUnifiedCgroup(t, filepath.Join(tmpDir, "c"))
+
+	// v1 devices controller takes precedence over v2 for the same directory
+	createCgroup(t, filepath.Join(tmpDir, "d"))
+	createUnifiedCgroup(t, filepath.Join(tmpDir, "d"))
+
+	require.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "e"), mkdirPerm))
+
+	cgroups, err := cgroup.NewCgroups(filepath.Join(tmpDir, "*"))
+	require.NoError(t, err)
+	require.Len(t, cgroups, 4)
+
+	require.Equal(t, filepath.Join(tmpDir, "a"), cgroups[0].Path)
+	require.True(t, cgroups[0].IsUnified())
+	require.Equal(t, filepath.Join(tmpDir, "b"), cgroups[1].Path)
+	require.False(t, cgroups[1].IsUnified())
+	require.Equal(t, filepath.Join(tmpDir, "c"), cgroups[2].Path)
+	require.True(t, cgroups[2].IsUnified())
+	require.Equal(t, filepath.Join(tmpDir, "d"), cgroups[3].Path)
+	require.False(t, cgroups[3].IsUnified())
+}
+
 func TestCgroup_IsWiderThan(t *testing.T) {
 	samples := []struct {
 		name   string
@@ -124,3 +162,84 @@ func TestCgroup_IsWiderThan(t *testing.T) {
 		})
 	}
 }
+
+func TestCgroup_AllowModes(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cg, err := cgroup.NewFakeCgroup(ctx, filepath.Join(t.TempDir(), "cgroup"))
+	require.NoError(t, err)
+
+	isAllowed := func(modes string) func() bool {
+		return func() bool {
+			allowed, err := cg.IsAllowedModes(1, 2, modes)
+			return err == nil && allowed
+		}
+	}
+
+	require.ErrorIs(t, cg.AllowModes(1, 2, "rx"), cgroup.ErrInvalidDeviceModes)
+	require.ErrorIs(t, cg.AllowModes(1, 2, "rr"), cgroup.ErrInvalidDeviceModes)
+	require.ErrorIs(t, cg.AllowModes(1, 2, ""), cgroup.ErrInvalidDeviceModes)
+
+	require.NoError(t, cg.AllowModes(1, 2, "rm"))
+	require.Eventually(t, isAllowed("rm"), time.Second, 10*time.Millisecond)
+	require.Eventually(t, isAllowed("r"), time.Second, 10*time.Millisecond)
+	require.False(t, isAllowed("w")())
+	require.False(t, isAllowed("rwm")())
+
+	require.NoError(t, cg.DenyModes(1, 2, "rm"))
+	require.Eventually(t, func() bool { return !isAllowed("r")() }, time.Second, 10*time.Millisecond)
+}
+
+func TestCgroup_AllowDeny(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cg, err := cgroup.NewFakeCgroup(ctx, filepath.Join(t.TempDir(), "cgroup"))
+	require.NoError(t, err)
+
+	isAllowed := func() bool {
+		allowed, err := cg.IsAllowed(1, 2)
+		return err == nil && allowed
+	}
+
+	require.NoError(t, cg.Allow(1, 2))
+	require.Eventually(t, isAllowed, time.Second, 10*time.Millisecond)
+
+	require.NoError(t, cg.Deny(1, 2))
+	require.Eventually(t, func() bool { return !isAllowed() }, time.Second, 10*time.Millisecond)
+
+	isRWAllowed, err := cg.IsAllowedModes(1, 2, "rw")
+	require.NoError(t, err)
+	require.False(t, isRWAllowed)
+}
+
+func TestCgroup_AllowAll(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cg, err := cgroup.NewFakeCgroup(ctx, filepath.Join(t.TempDir(), "cgroup"))
+	require.NoError(t, err)
+
+	devices := []cgroup.DeviceID{
+		{Major: 1, Minor: 2},
+		{Major: 3, Minor: 4},
+	}
+	areAllowed := func(expected bool) func() bool {
+		return func() bool {
+			for _, dev := range devices {
+				allowed, err := cg.IsAllowed(dev.Major, dev.Minor)
+				if err != nil || allowed != expected {
+					return false
+				}
+			}
+			return true
+		}
+	}
+
+	require.NoError(t, cg.AllowAll(devices))
+	require.Eventually(t, areAllowed(true), time.Second, 10*time.Millisecond)
+
+	require.NoError(t, cg.DenyAll(devices))
+	require.Eventually(t, areAllowed(false), time.Second, 10*time.Millisecond)
+}
@@ -21,17 +21,22 @@ package cgroup
 
 import (
 	"bufio"
+	"io"
 	"os"
 	"path/filepath"
-	"regexp"
 	"strings"
 
 	"github.com/pkg/errors"
 )
 
-var devicesCgroup = regexp.MustCompile("^[1-9][0-9]*?:devices:(.*)$")
+const (
+	devicesController = "devices"
+	cgroupV1FSType    = "cgroup"
+	cgroupV2FSType    = "cgroup2"
+)
 
-// DirPath returns cgroup dir path pattern matching all pod containers
+// DirPath returns cgroup dir path pattern matching all pod containers, it is relative to the devices controller
+// hierarchy root
 func DirPath() (string, error) {
 	cgroupInfo, err := os.Open("/proc/self/cgroup")
 	if err != nil {
@@ -39,14 +44,102 @@ func DirPath() (string, error) {
 	}
 	defer func() { _ = cgroupInfo.Close() }()
 
-	for scanner := bufio.NewScanner(cgroupInfo); scanner.Scan(); {
-		line := scanner.Text()
-		if devicesCgroup.MatchString(line) {
-			return podDirPath(devicesCgroup.FindStringSubmatch(line)[1]), nil
+	mountInfo, err := os.Open("/proc/self/mountinfo")
+	if err != nil {
+		return "", errors.Wrap(err, "error opening mount info file")
+	}
+	defer func() { _ = mountInfo.Close() }()
+
+	return DirPathFrom(cgroupInfo, mountInfo)
+}
+
+// DirPathFrom is the same as DirPath, but it reads the process cgroup info and mount info in /proc/self/cgroup and
+// /proc/self/mountinfo formats from the given readers. The devices controller is looked up in the cgroup v1 hierarchy
+// if it is mounted, e.g. for the systemd hybrid layout, otherwise in the cgroup v2 unified hierarchy.
+func DirPathFrom(cgroupInfo, mountInfo io.Reader) (string, error) {
+	isDevicesMounted, isUnifiedMounted, err := parseMountInfo(mountInfo)
+	if err != nil {
+		return "", err
+	}
+
+	var cgroupPath string
+	switch {
+	case isDevicesMounted:
+		cgroupPath, err = findCgroupPath(cgroupInfo, func(_ string, controllers []string) bool {
+			for _, controller := range controllers {
+				if controller == devicesController {
+					return true
+				}
+			}
+			return false
+		})
+	case isUnifiedMounted:
+		cgroupPath, err = findCgroupPath(cgroupInfo, func(hierarchyID string, controllers []string) bool {
+			return hierarchyID == "0" && len(controllers) == 1 && controllers[0] == ""
+		})
+	default:
+		return "", errors.New("neither cgroup v1 devices controller nor cgroup v2 is mounted")
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if filepath.Clean(cgroupPath) == "/" {
+		return "", errors.Errorf("process is in the root cgroup, can't find out pod cgroup directory (cgroup v1 devices: %v, "+
+			"cgroup v2: %v)", isDevicesMounted, isUnifiedMounted)
+	}
+
+	return podDirPath(filepath.Clean(cgroupPath)), nil
+}
+
+// parseMountInfo returns if the cgroup v1 devices controller and the cgroup v2 are mounted
+func parseMountInfo(mountInfo io.Reader) (isDevicesMounted, isUnifiedMounted bool, err error) {
+	scanner := bufio.NewScanner(mountInfo)
+	for scanner.Scan() {
+		// 36 35 0:30 / /sys/fs/cgroup/devices rw,nosuid shared:15 - cgroup cgroup rw,devices
+		_, postSeparator, ok := strings.Cut(scanner.Text(), " - ")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(postSeparator)
+		if len(fields) < 3 {
+			continue
+		}
+
+		switch fields[0] {
+		case cgroupV1FSType:
+			for _, option := range strings.Split(fields[2], ",") {
+				if option == devicesController {
+					isDevicesMounted = true
+				}
+			}
+		case cgroupV2FSType:
+			isUnifiedMounted = true
 		}
 	}
+	if err = scanner.Err(); err != nil {
+		return false, false, errors.Wrap(err, "error reading mount info")
+	}
+	return isDevicesMounted, isUnifiedMounted, nil
+}
 
-	return "", errors.New("can't find out cgroup directory")
+// findCgroupPath returns the cgroup path of the first hierarchy matching the given function
+func findCgroupPath(cgroupInfo io.Reader, match func(hierarchyID string, controllers []string) bool) (string, error) {
+	scanner := bufio.NewScanner(cgroupInfo)
+	for scanner.Scan() {
+		// 4:cpu,devices:/kubepods/pod-id/container-id
+		split := strings.SplitN(scanner.Text(), ":", 3)
+		if len(split) != 3 {
+			continue
+		}
+		if match(split[0], strings.Split(split[1], ",")) {
+			return split[2], nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", errors.Wrap(err, "error reading cgroup info")
+	}
+	return "", errors.New("can't find out cgroup directory: no matching hierarchy in the process cgroup info")
 }
 
 func podDirPath(containerCgroupDirPath string) string {
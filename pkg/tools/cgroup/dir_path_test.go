@@ -0,0 +1,116 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !windows
+// +build !windows
+
+package cgroup_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/sdk-sriov/pkg/tools/cgroup"
+)
+
+const (
+	hybridMountInfo = `24 30 0:22 / /sys rw,nosuid,nodev,noexec,relatime shared:7 - sysfs sysfs rw
+33 24 0:28 / /sys/fs/cgroup ro,nosuid,nodev,noexec shared:9 - tmpfs tmpfs ro,mode=755
+34 33 0:29 / /sys/fs/cgroup/unified rw,nosuid,nodev,noexec,relatime shared:10 - cgroup2 cgroup2 rw,nsdelegate
+35 33 0:30 / /sys/fs/cgroup/systemd rw,nosuid,nodev,noexec,relatime shared:11 - cgroup cgroup rw,xattr,name=systemd
+40 33 0:35 / /sys/fs/cgroup/cpu,devices rw,nosuid,nodev,noexec,relatime shared:16 - cgroup cgroup rw,cpu,devices
+`
+	hybridCgroupInfo = `12:pids:/kubepods/pod-1/container-1
+4:cpu,devices:/kubepods/pod-1/container-1
+1:name=systemd:/kubepods/pod-1/container-1
+0::/system.slice/containerd.service
+`
+	unifiedMountInfo = `24 30 0:22 / /sys rw,nosuid,nodev,noexec,relatime shared:7 - sysfs sysfs rw
+33 24 0:28 / /sys/fs/cgroup rw,nosuid,nodev,noexec,relatime shared:9 - cgroup2 cgroup2 rw,nsdelegate
+`
+	unifiedCgroupInfo = `0::/kubepods.slice/pod-1.slice/container-1.scope
+`
+)
+
+func TestDirPathFrom(t *testing.T) {
+	for _, sample := range []struct {
+		name       string
+		cgroupInfo string
+		mountInfo  string
+		expected   string
+	}{
+		{
+			name:       "Hybrid",
+			cgroupInfo: hybridCgroupInfo,
+			mountInfo:  hybridMountInfo,
+			expected:   "kubepods/pod-1/*",
+		},
+		{
+			name:       "Unified",
+			cgroupInfo: unifiedCgroupInfo,
+			mountInfo:  unifiedMountInfo,
+			expected:   "kubepods.slice/pod-1.slice/*",
+		},
+		{
+			name:       "Legacy",
+			cgroupInfo: "5:devices:/kubepods/pod-1/container-1\n",
+			mountInfo:  "41 33 0:36 / /sys/fs/cgroup/devices rw,relatime shared:17 - cgroup cgroup rw,devices\n",
+			expected:   "kubepods/pod-1/*",
+		},
+	} {
+		t.Run(sample.name, func(t *testing.T) {
+			dirPath, err := cgroup.DirPathFrom(strings.NewReader(sample.cgroupInfo), strings.NewReader(sample.mountInfo))
+			require.NoError(t, err)
+			require.Equal(t, sample.expected, dirPath)
+		})
+	}
+}
+
+func TestDirPathFrom_Error(t *testing.T) {
+	for _, sample := range []struct {
+		name       string
+		cgroupInfo string
+		mountInfo  string
+	}{
+		{
+			name:       "NoCgroupMount",
+			cgroupInfo: hybridCgroupInfo,
+			mountInfo:  "24 30 0:22 / /sys rw,nosuid,nodev,noexec,relatime shared:7 - sysfs sysfs rw\n",
+		},
+		{
+			name:       "NoDevicesHierarchy",
+			cgroupInfo: "12:pids:/kubepods/pod-1/container-1\n0::/kubepods/pod-1/container-1\n",
+			mountInfo:  hybridMountInfo,
+		},
+		{
+			name:       "NoUnifiedHierarchy",
+			cgroupInfo: "4:cpu,devices:/kubepods/pod-1/container-1\n",
+			mountInfo:  unifiedMountInfo,
+		},
+		{
+			name:       "RootCgroup",
+			cgroupInfo: "0::/\n",
+			mountInfo:  unifiedMountInfo,
+		},
+	} {
+		t.Run(sample.name, func(t *testing.T) {
+			_, err := cgroup.DirPathFrom(strings.NewReader(sample.cgroupInfo), strings.NewReader(sample.mountInfo))
+			require.Error(t, err)
+		})
+	}
+}
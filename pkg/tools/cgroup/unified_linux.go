@@ -0,0 +1,451 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package cgroup
+
+import (
+	"reflect"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sys/unix"
+)
+
+// eBPF instruction codes
+const (
+	bpfALU64 = 0x07
+	bpfJMP   = 0x05
+	bpfLDX   = 0x01
+	bpfMEM   = 0x60
+	bpfW     = 0x00
+	bpfK     = 0x00
+	bpfX     = 0x08
+	bpfMOV   = 0xb0
+	bpfAND   = 0x50
+	bpfRSH   = 0x70
+	bpfJNE   = 0x50
+	bpfEXIT  = 0x90
+)
+
+// eBPF registers
+const (
+	r0 = iota
+	r1
+	r2
+	r3
+	r4
+	r5
+)
+
+// Device program prefix allows the devices before the original cgroup device program is run:
+//
+//	header:
+//	  r0 = devicesPrefixMagic
+//	  r0 = <rules count>
+//	  r2 = ctx->access_type; r3 = r2 & 0xffff (device type); r2 >>= 16 (access)
+//	  r4 = ctx->major; r5 = ctx->minor
+//	rule (devicesRuleLen instructions each):
+//	  if r3 != <device type> goto next rule  (r0 = devicesPrefixWildcard for "a")
+//	  if r4 != <major> goto next rule        (r0 = devicesPrefixWildcard for "*")
+//	  if r5 != <minor> goto next rule        (r0 = devicesPrefixWildcard for "*")
+//	  r0 = r2 & ^<access>; if r0 != 0 goto next rule
+//	  return 1
+//
+// The prefix doesn't touch r1 and the stack, so the original program runs after it the same way as it was run before.
+// Wildcards are encoded with the "r0 = imm" instructions instead of the "goto +0" ones, since the latter are removed
+// by the verifier and would break the prefix layout.
+const (
+	devicesPrefixMagic     = 0x4e534d44 // "NSMD"
+	devicesPrefixWildcard  = -1
+	devicesPrefixHeaderLen = 8
+	devicesRuleLen         = 8
+)
+
+func movImm(dst uint8, imm int32) bpfInsn {
+	return bpfInsn{code: bpfALU64 | bpfMOV | bpfK, dst: dst, imm: imm}
+}
+
+func movReg(dst, src uint8) bpfInsn {
+	return bpfInsn{code: bpfALU64 | bpfMOV | bpfX, dst: dst, src: src}
+}
+
+func andImm(dst uint8, imm int32) bpfInsn {
+	return bpfInsn{code: bpfALU64 | bpfAND | bpfK, dst: dst, imm: imm}
+}
+
+func rshImm(dst uint8, imm int32) bpfInsn {
+	return bpfInsn{code: bpfALU64 | bpfRSH | bpfK, dst: dst, imm: imm}
+}
+
+func ldxW(dst, src uint8, off int16) bpfInsn {
+	return bpfInsn{code: bpfLDX | bpfMEM | bpfW, dst: dst, src: src, off: off}
+}
+
+func jneImm(dst uint8, imm int32, off int16) bpfInsn {
+	return bpfInsn{code: bpfJMP | bpfJNE | bpfK, dst: dst, off: off, imm: imm}
+}
+
+func exit() bpfInsn {
+	return bpfInsn{code: bpfJMP | bpfEXIT}
+}
+
+var deviceTypes = map[string]int32{
+	"b": unix.BPF_DEVCG_DEV_BLOCK,
+	"c": unix.BPF_DEVCG_DEV_CHAR,
+}
+
+var deviceAccess = map[rune]int32{
+	'm': unix.BPF_DEVCG_ACC_MKNOD,
+	'r': unix.BPF_DEVCG_ACC_READ,
+	'w': unix.BPF_DEVCG_ACC_WRITE,
+}
+
+func devicesPrefix(rules []*device) ([]bpfInsn, error) {
+	insns := []bpfInsn{
+		movImm(r0, devicesPrefixMagic),
+		movImm(r0, int32(len(rules))),
+		ldxW(r2, r1, 0),
+		movReg(r3, r2),
+		andImm(r3, 0xffff),
+		rshImm(r2, 16),
+		ldxW(r4, r1, 4),
+		ldxW(r5, r1, 8),
+	}
+
+	for _, rule := range rules {
+		typeInsn := movImm(r0, devicesPrefixWildcard)
+		if rule.Type != "a" {
+			typeInsn = jneImm(r3, deviceTypes[rule.Type], devicesRuleLen-1)
+		}
+		majorInsn, err := deviceNumberInsn(r4, rule.Major, devicesRuleLen-2)
+		if err != nil {
+			return nil, err
+		}
+		minorInsn, err := deviceNumberInsn(r5, rule.Minor, devicesRuleLen-3)
+		if err != nil {
+			return nil, err
+		}
+
+		var access int32
+		for mode := range rule.Modes {
+			access |= deviceAccess[mode]
+		}
+
+		insns = append(insns,
+			typeInsn,
+			majorInsn,
+			minorInsn,
+			movReg(r0, r2),
+			andImm(r0, ^access),
+			jneImm(r0, 0, 2),
+			movImm(r0, 1),
+			exit(),
+		)
+	}
+
+	return insns, nil
+}
+
+func deviceNumberInsn(dst uint8, number string, off int16) (bpfInsn, error) {
+	if number == "*" {
+		return movImm(r0, devicesPrefixWildcard), nil
+	}
+	n, err := strconv.ParseInt(number, 10, 32)
+	if err != nil {
+		return bpfInsn{}, errors.Wrapf(err, "invalid device number: %s", number)
+	}
+	return jneImm(dst, int32(n), off), nil
+}
+
+// parseDevicesPrefix returns the devices allowed by the program prefix and the original program instructions, if the
+// program has no prefix it returns no devices and all the program instructions
+func parseDevicesPrefix(insns []bpfInsn) (rules []*device, original []bpfInsn) {
+	if len(insns) < devicesPrefixHeaderLen || insns[0] != movImm(r0, devicesPrefixMagic) {
+		return nil, insns
+	}
+
+	count := int(insns[1].imm)
+	prefixLen := devicesPrefixHeaderLen + count*devicesRuleLen
+	if count < 0 || len(insns) < prefixLen {
+		return nil, insns
+	}
+
+	for i := devicesPrefixHeaderLen; i < prefixLen; i += devicesRuleLen {
+		rule := &device{
+			Type:  "a",
+			Major: "*",
+			Minor: "*",
+			Modes: map[rune]struct{}{},
+		}
+		if typeInsn := insns[i]; typeInsn.code == bpfJMP|bpfJNE|bpfK {
+			for t, devType := range deviceTypes {
+				if devType == typeInsn.imm {
+					rule.Type = t
+				}
+			}
+		}
+		if majorInsn := insns[i+1]; majorInsn.code == bpfJMP|bpfJNE|bpfK {
+			rule.Major = strconv.FormatInt(int64(majorInsn.imm), 10)
+		}
+		if minorInsn := insns[i+2]; minorInsn.code == bpfJMP|bpfJNE|bpfK {
+			rule.Minor = strconv.FormatInt(int64(minorInsn.imm), 10)
+		}
+		access := ^insns[i+4].imm
+		for mode, modeAccess := range deviceAccess {
+			if access&modeAccess != 0 {
+				rule.Modes[mode] = struct{}{}
+			}
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, insns[prefixLen:]
+}
+
+// updateDevicePrograms replaces each device program attached to the cgroup with the program with the devices prefix
+// updated by update, the original programs are kept running after the prefix
+func (c *Cgroup) updateDevicePrograms(update func(rules []*device) []*device) error {
+	cgroupFD, err := unix.Open(c.Path, unix.O_RDONLY|unix.O_DIRECTORY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open cgroup: %s", c.Path)
+	}
+	defer func() { _ = unix.Close(cgroupFD) }()
+
+	progIDs, attachFlags, err := queryDevicePrograms(cgroupFD, 0)
+	if err != nil {
+		return errors.Wrapf(err, "cgroup: %s", c.Path)
+	}
+
+	for _, progID := range progIDs {
+		if err := c.updateDeviceProgram(cgroupFD, progID, attachFlags, update); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *Cgroup) updateDeviceProgram(cgroupFD int, progID, attachFlags uint32, update func(rules []*device) []*device) error {
+	progFD, err := progFDByID(progID)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = unix.Close(progFD) }()
+
+	insns, err := progInsns(progFD)
+	if err != nil {
+		return err
+	}
+
+	rules, original := parseDevicesPrefix(insns)
+	newRules := update(rules)
+	if reflect.DeepEqual(newRules, rules) {
+		return nil
+	}
+
+	newInsns := original
+	if len(newRules) > 0 {
+		prefix, err := devicesPrefix(newRules)
+		if err != nil {
+			return err
+		}
+		newInsns = append(prefix, original...)
+	}
+
+	newProgFD, err := loadDeviceProgram(newInsns)
+	if err != nil {
+		return errors.Wrapf(err, "cgroup: %s", c.Path)
+	}
+	defer func() { _ = unix.Close(newProgFD) }()
+
+	// without BPF_F_ALLOW_MULTI the attached program is replaced, otherwise the old one should be detached
+	if err := attachDeviceProgram(cgroupFD, newProgFD, attachFlags); err != nil {
+		return errors.Wrapf(err, "cgroup: %s", c.Path)
+	}
+	if attachFlags&unix.BPF_F_ALLOW_MULTI != 0 {
+		if err := detachDeviceProgram(cgroupFD, progFD); err != nil {
+			_ = detachDeviceProgram(cgroupFD, newProgFD)
+			return errors.Wrapf(err, "cgroup: %s", c.Path)
+		}
+	}
+
+	return nil
+}
+
+// devicePrograms returns the devices allowed by each device program attached to the cgroup
+func (c *Cgroup) devicePrograms() ([][]*device, error) {
+	cgroupFD, err := unix.Open(c.Path, unix.O_RDONLY|unix.O_DIRECTORY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open cgroup: %s", c.Path)
+	}
+	defer func() { _ = unix.Close(cgroupFD) }()
+
+	progIDs, _, err := queryDevicePrograms(cgroupFD, 0)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cgroup: %s", c.Path)
+	}
+
+	var programs [][]*device
+	for _, progID := range progIDs {
+		rules, err := deviceProgramRules(progID)
+		if err != nil {
+			return nil, err
+		}
+		programs = append(programs, rules)
+	}
+	return programs, nil
+}
+
+// hasEffectiveDevicePrograms returns true if any device program is run for the cgroup, including the ones attached to
+// the ancestor cgroups
+func (c *Cgroup) hasEffectiveDevicePrograms() (bool, error) {
+	cgroupFD, err := unix.Open(c.Path, unix.O_RDONLY|unix.O_DIRECTORY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to open cgroup: %s", c.Path)
+	}
+	defer func() { _ = unix.Close(cgroupFD) }()
+
+	progIDs, _, err := queryDevicePrograms(cgroupFD, unix.BPF_F_QUERY_EFFECTIVE)
+	if err != nil {
+		return false, errors.Wrapf(err, "cgroup: %s", c.Path)
+	}
+	return len(progIDs) > 0, nil
+}
+
+func deviceProgramRules(progID uint32) ([]*device, error) {
+	progFD, err := progFDByID(progID)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = unix.Close(progFD) }()
+
+	insns, err := progInsns(progFD)
+	if err != nil {
+		return nil, err
+	}
+
+	rules, _ := parseDevicesPrefix(insns)
+	return rules, nil
+}
+
+func (c *Cgroup) allowUnified(devs ...*device) error {
+	programs, err := c.devicePrograms()
+	if err != nil {
+		return err
+	}
+	if len(programs) == 0 {
+		// only the programs attached directly to the cgroup are updated
+		hasEffective, effectiveErr := c.hasEffectiveDevicePrograms()
+		if effectiveErr != nil {
+			return effectiveErr
+		}
+		if hasEffective {
+			return errors.Errorf("devices are filtered by the ancestor cgroup device programs: %s", c.Path)
+		}
+		return nil
+	}
+
+	return c.updateDevicePrograms(func(rules []*device) []*device {
+		newRules := rules[:len(rules):len(rules)]
+		for _, dev := range devs {
+			if !isAllowedByRules(newRules, dev) {
+				newRules = append(newRules, dev)
+			}
+		}
+		return newRules
+	})
+}
+
+func isAllowedByRules(rules []*device, dev *device) bool {
+	for _, rule := range rules {
+		if reflect.DeepEqual(rule, dev) || rule.isWiderThan(dev) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Cgroup) denyUnified(devs ...*device) error {
+	return c.updateDevicePrograms(func(rules []*device) []*device {
+		for _, dev := range devs {
+			rules = denyRule(rules, dev)
+		}
+		return rules
+	})
+}
+
+// denyRule returns a copy of rules with the device modes removed from the rules of the same device
+func denyRule(rules []*device, dev *device) []*device {
+	var newRules []*device
+	for _, rule := range rules {
+		if rule.Type == dev.Type && rule.Major == dev.Major && rule.Minor == dev.Minor {
+			newRule := &device{
+				Type:  rule.Type,
+				Major: rule.Major,
+				Minor: rule.Minor,
+				Modes: map[rune]struct{}{},
+			}
+			for mode := range rule.Modes {
+				if _, ok := dev.Modes[mode]; !ok {
+					newRule.Modes[mode] = struct{}{}
+				}
+			}
+			if len(newRule.Modes) == 0 {
+				continue
+			}
+			rule = newRule
+		}
+		newRules = append(newRules, rule)
+	}
+	return newRules
+}
+
+// compareToUnified returns if the device is allowed or a wider device group is allowed by the devices prefixes of all
+// the cgroup device programs, devices allowed by the original programs are not known. Cgroup with no device programs
+// allows all devices only if there are no device programs attached to the ancestor cgroups, otherwise the devices
+// allowed by them are not known.
+func (c *Cgroup) compareToUnified(dev *device) (isAllowed, isWider bool, err error) {
+	programs, err := c.devicePrograms()
+	if err != nil {
+		return false, false, err
+	}
+	if len(programs) == 0 {
+		hasEffective, effectiveErr := c.hasEffectiveDevicePrograms()
+		if effectiveErr != nil {
+			return false, false, effectiveErr
+		}
+		return !hasEffective, !hasEffective, nil
+	}
+
+	isAllowed, isWider = true, true
+	for _, rules := range programs {
+		var progAllowed, progWider bool
+		for _, rule := range rules {
+			switch {
+			case rule.isWiderThan(dev):
+				progAllowed, progWider = true, true
+			case reflect.DeepEqual(rule, dev):
+				progAllowed = true
+			}
+		}
+		isAllowed = isAllowed && progAllowed
+		isWider = isWider && progWider
+	}
+	return isAllowed, isWider, nil
+}
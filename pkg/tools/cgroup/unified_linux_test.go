@@ -0,0 +1,200 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux && perm
+// +build linux,perm
+
+package cgroup_test
+
+import (
+	"bufio"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+
+	"github.com/networkservicemesh/sdk-sriov/pkg/tools/cgroup"
+)
+
+const (
+	devNullMajor = 1
+	devNullMinor = 3
+)
+
+func unifiedMountPoint(t *testing.T) string {
+	mountInfo, err := os.Open("/proc/self/mountinfo")
+	require.NoError(t, err)
+	defer func() { _ = mountInfo.Close() }()
+
+	for scanner := bufio.NewScanner(mountInfo); scanner.Scan(); {
+		// 36 35 0:30 / /sys/fs/cgroup rw,nosuid - cgroup2 cgroup2 rw
+		fields := strings.Fields(scanner.Text())
+		for i, field := range fields {
+			if field == "-" && i+1 < len(fields) && fields[i+1] == "cgroup2" {
+				return fields[4]
+			}
+		}
+	}
+
+	t.Skip("no cgroup v2 mount")
+	return ""
+}
+
+// attachDenyAllProgram attaches "return 0" device program to the cgroup the same way as the container runtime does
+func attachDenyAllProgram(t *testing.T, path string) {
+	insns := []byte{
+		0xb7, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // r0 = 0
+		0x95, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // exit
+	}
+	license := []byte("Apache-2.0\x00")
+
+	loadAttr := struct {
+		progType uint32
+		insnCnt  uint32
+		insns    uint64
+		license  uint64
+	}{
+		progType: unix.BPF_PROG_TYPE_CGROUP_DEVICE,
+		insnCnt:  uint32(len(insns) / 8),
+		insns:    uint64(uintptr(unsafe.Pointer(&insns[0]))),
+		license:  uint64(uintptr(unsafe.Pointer(&license[0]))),
+	}
+	progFD, _, errno := unix.Syscall(unix.SYS_BPF, unix.BPF_PROG_LOAD, uintptr(unsafe.Pointer(&loadAttr)), unsafe.Sizeof(loadAttr))
+	runtime.KeepAlive(insns)
+	runtime.KeepAlive(license)
+	require.Zero(t, errno)
+	defer func() { _ = unix.Close(int(progFD)) }()
+
+	cgroupFD, err := unix.Open(path, unix.O_RDONLY|unix.O_DIRECTORY, 0)
+	require.NoError(t, err)
+	defer func() { _ = unix.Close(cgroupFD) }()
+
+	attachAttr := struct {
+		targetFD    uint32
+		attachBPFFD uint32
+		attachType  uint32
+		attachFlags uint32
+	}{
+		targetFD:    uint32(cgroupFD),
+		attachBPFFD: uint32(progFD),
+		attachType:  unix.BPF_CGROUP_DEVICE,
+		attachFlags: unix.BPF_F_ALLOW_MULTI,
+	}
+	_, _, errno = unix.Syscall(unix.SYS_BPF, unix.BPF_PROG_ATTACH, uintptr(unsafe.Pointer(&attachAttr)), unsafe.Sizeof(attachAttr))
+	require.Zero(t, errno)
+}
+
+// canReadDevNull returns true if the process moved to the cgroup can read /dev/null
+func canReadDevNull(path string) bool {
+	cmd := exec.Command("sh", "-c", `echo $$ > "$0/cgroup.procs" && exec cat /dev/null`, path)
+	return cmd.Run() == nil
+}
+
+func TestCgroup_Unified(t *testing.T) {
+	path := filepath.Join(unifiedMountPoint(t), "nsm-"+strings.ReplaceAll(t.Name(), "/", "-"))
+	require.NoError(t, os.Mkdir(path, 0o750))
+	defer func() { _ = os.Remove(path) }()
+
+	cgroups, err := cgroup.NewCgroups(path)
+	require.NoError(t, err)
+	require.Len(t, cgroups, 1)
+
+	cg := cgroups[0]
+	require.True(t, cg.IsUnified())
+
+	// 1. No device programs - all devices are allowed
+
+	isWider, err := cg.IsWiderThan(devNullMajor, devNullMinor)
+	require.NoError(t, err)
+	require.True(t, isWider)
+	require.True(t, canReadDevNull(path))
+
+	// 2. Devices are denied by the runtime program
+
+	attachDenyAllProgram(t, path)
+
+	isAllowed, err := cg.IsAllowed(devNullMajor, devNullMinor)
+	require.NoError(t, err)
+	require.False(t, isAllowed)
+	require.False(t, canReadDevNull(path))
+
+	// 3. Allow
+
+	require.NoError(t, cg.AllowModes(devNullMajor, devNullMinor, "r"))
+	require.NoError(t, cg.Allow(1, 5))
+
+	isAllowed, err = cg.IsAllowedModes(devNullMajor, devNullMinor, "r")
+	require.NoError(t, err)
+	require.True(t, isAllowed)
+	isAllowed, err = cg.IsAllowed(1, 5)
+	require.NoError(t, err)
+	require.True(t, isAllowed)
+	require.True(t, canReadDevNull(path))
+
+	// 4. Deny
+
+	require.NoError(t, cg.DenyModes(devNullMajor, devNullMinor, "r"))
+
+	isAllowed, err = cg.IsAllowedModes(devNullMajor, devNullMinor, "r")
+	require.NoError(t, err)
+	require.False(t, isAllowed)
+	isAllowed, err = cg.IsAllowed(1, 5)
+	require.NoError(t, err)
+	require.True(t, isAllowed)
+	require.False(t, canReadDevNull(path))
+
+	require.NoError(t, cg.Deny(1, 5))
+
+	isAllowed, err = cg.IsAllowed(1, 5)
+	require.NoError(t, err)
+	require.False(t, isAllowed)
+}
+
+func TestCgroup_Unified_AncestorPrograms(t *testing.T) {
+	parentPath := filepath.Join(unifiedMountPoint(t), "nsm-"+strings.ReplaceAll(t.Name(), "/", "-"))
+	require.NoError(t, os.Mkdir(parentPath, 0o750))
+	defer func() { _ = os.Remove(parentPath) }()
+
+	path := filepath.Join(parentPath, "child")
+	require.NoError(t, os.Mkdir(path, 0o750))
+	defer func() { _ = os.Remove(path) }()
+
+	attachDenyAllProgram(t, parentPath)
+
+	cgroups, err := cgroup.NewCgroups(path)
+	require.NoError(t, err)
+	require.Len(t, cgroups, 1)
+
+	cg := cgroups[0]
+
+	// No device programs attached to the cgroup, but the devices are denied by the parent one
+
+	isWider, err := cg.IsWiderThan(devNullMajor, devNullMinor)
+	require.NoError(t, err)
+	require.False(t, isWider)
+	isAllowed, err := cg.IsAllowed(devNullMajor, devNullMinor)
+	require.NoError(t, err)
+	require.False(t, isAllowed)
+
+	require.Error(t, cg.Allow(devNullMajor, devNullMinor))
+	require.False(t, canReadDevNull(path))
+}
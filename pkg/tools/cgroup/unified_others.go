@@ -0,0 +1,36 @@
+// Copyright (c) 2026 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !linux && !windows
+// +build !linux,!windows
+
+package cgroup
+
+import "github.com/pkg/errors"
+
+var errUnifiedNotSupported = errors.New("cgroup v2 devices are supported only on linux")
+
+func (c *Cgroup) allowUnified(_ ...*device) error {
+	return errUnifiedNotSupported
+}
+
+func (c *Cgroup) denyUnified(_ ...*device) error {
+	return errUnifiedNotSupported
+}
+
+func (c *Cgroup) compareToUnified(_ *device) (isAllowed, isWider bool, err error) {
+	return false, false, errUnifiedNotSupported
+}
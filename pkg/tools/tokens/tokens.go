@@ -21,17 +21,44 @@ package tokens
 
 import (
 	"fmt"
+	"path"
 	"strings"
+	"sync/atomic"
 
 	"github.com/google/uuid"
 )
 
 const (
 	// EnvPrefix sriov token env name prefix
-	EnvPrefix   = "NSM_SRIOV_TOKENS_"
-	sriovPrevix = "sriov-"
+	EnvPrefix = "NSM_SRIOV_TOKENS_"
+	// DefaultTokenPrefix is a default SR-IOV token ID prefix
+	DefaultTokenPrefix = "sriov-"
 )
 
+var (
+	tokenPrefix atomic.Value
+	uuidLen     = len(uuid.New().String())
+)
+
+func init() {
+	tokenPrefix.Store(DefaultTokenPrefix)
+}
+
+// SetTokenPrefix sets SR-IOV token ID prefix used by NewTokenID, IsTokenID, empty prefix resets it to the
+// DefaultTokenPrefix. It should be set on startup before any token ID is created, so the token ID doesn't collide
+// with other device plugins token IDs.
+func SetTokenPrefix(prefix string) {
+	if prefix == "" {
+		prefix = DefaultTokenPrefix
+	}
+	tokenPrefix.Store(prefix)
+}
+
+// TokenPrefix returns SR-IOV token ID prefix
+func TokenPrefix() string {
+	return tokenPrefix.Load().(string)
+}
+
 // ToEnv returns a (name, value) pair to store given tokens into the environment variable
 func ToEnv(tokenName string, tokenIDs []string) (name, value string) {
 	return fmt.Sprintf("%s%s", EnvPrefix, tokenName), strings.Join(tokenIDs, ",")
@@ -50,14 +77,42 @@ func FromEnv(envs []string) map[string][]string {
 	return tokens
 }
 
+// Names returns all token names for the given service domains and capabilities. Capabilities are hierarchical:
+// "net/10G" capability produces both "serviceDomain/net/10G" and "serviceDomain/net" token names, so a token with
+// a more specific name can satisfy a request for any of its parents.
+func Names(serviceDomains, capabilities []string) []string {
+	var names []string
+	visited := map[string]struct{}{}
+	for _, serviceDomain := range serviceDomains {
+		for _, capability := range capabilities {
+			for name := path.Join(serviceDomain, capability); name != serviceDomain && name != "."; name = path.Dir(name) {
+				if _, ok := visited[name]; ok {
+					continue
+				}
+				visited[name] = struct{}{}
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// QoSClassName returns the name of the token backed by the virtual functions of the given QoS class, tokens of the
+// default (empty) QoS class keep the name unchanged
+func QoSClassName(name, qosClass string) string {
+	if qosClass == "" {
+		return name
+	}
+	return name + "." + qosClass
+}
+
 // NewTokenID returns a new SR-IOV token ID
 func NewTokenID() string {
-	return sriovPrevix + uuid.New().String()
+	return TokenPrefix() + uuid.New().String()
 }
 
-var tokenIDLen = len(NewTokenID())
-
 // IsTokenID returns if given string is a SR-IOV token ID
 func IsTokenID(s string) bool {
-	return strings.HasPrefix(s, sriovPrevix) && len(s) == tokenIDLen
+	prefix := TokenPrefix()
+	return strings.HasPrefix(s, prefix) && len(s) == len(prefix)+uuidLen
 }
@@ -17,6 +17,7 @@
 package tokens_test
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -44,3 +45,44 @@ func TestFromEnv(t *testing.T) {
 		"name-2": {"4"},
 	}, toks)
 }
+
+func TestNames(t *testing.T) {
+	names := tokens.Names([]string{"domain-1", "domain-2"}, []string{"intel", "net/10G", "net/20G"})
+	require.ElementsMatch(t, []string{
+		"domain-1/intel",
+		"domain-1/net/10G",
+		"domain-1/net",
+		"domain-1/net/20G",
+		"domain-2/intel",
+		"domain-2/net/10G",
+		"domain-2/net",
+		"domain-2/net/20G",
+	}, names)
+}
+
+func TestQoSClassName(t *testing.T) {
+	require.Equal(t, "domain-1/net/10G", tokens.QoSClassName("domain-1/net/10G", ""))
+	require.Equal(t, "domain-1/net/10G.guaranteed", tokens.QoSClassName("domain-1/net/10G", "guaranteed"))
+}
+
+func TestIsTokenID(t *testing.T) {
+	require.True(t, tokens.IsTokenID(tokens.NewTokenID()))
+	require.True(t, tokens.IsTokenID("sriov-xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx"))
+	require.False(t, tokens.IsTokenID("sriov-xxxxxxxx"))
+	require.False(t, tokens.IsTokenID("other-xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx"))
+}
+
+func TestSetTokenPrefix(t *testing.T) {
+	tokens.SetTokenPrefix("nic-")
+	defer tokens.SetTokenPrefix("")
+
+	tokenID := tokens.NewTokenID()
+	require.True(t, strings.HasPrefix(tokenID, "nic-"))
+	require.True(t, tokens.IsTokenID(tokenID))
+	require.True(t, tokens.IsTokenID("nic-xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx"))
+	require.False(t, tokens.IsTokenID("sriov-xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx"))
+
+	tokens.SetTokenPrefix("")
+	require.Equal(t, tokens.DefaultTokenPrefix, tokens.TokenPrefix())
+	require.True(t, tokens.IsTokenID("sriov-xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx"))
+}
@@ -20,6 +20,7 @@ package yamlhelper
 import (
 	"os"
 	"path"
+	"path/filepath"
 
 	"github.com/ghodss/yaml"
 	"github.com/pkg/errors"
@@ -38,3 +39,52 @@ func UnmarshalFile(fileName string, o interface{}) error {
 
 	return nil
 }
+
+// MarshalFile marshals the object into YAML file, the file is replaced atomically
+func MarshalFile(fileName string, o interface{}) error {
+	bytes, err := yaml.Marshal(o)
+	if err != nil {
+		return errors.Wrapf(err, "error marshalling yaml: %+v", o)
+	}
+
+	return WriteFileAtomic(fileName, bytes)
+}
+
+// WriteFileAtomic writes the data into the file, the file is replaced atomically and synced to the disk together with
+// its directory, so the written data survives the node crash
+func WriteFileAtomic(fileName string, bytes []byte) error {
+	tmpFile, err := os.CreateTemp(filepath.Dir(fileName), filepath.Base(fileName)+".*.tmp")
+	if err != nil {
+		return errors.Wrapf(err, "error creating temporary file for: %v", fileName)
+	}
+	defer func() { _ = os.Remove(tmpFile.Name()) }()
+
+	if _, err = tmpFile.Write(bytes); err != nil {
+		_ = tmpFile.Close()
+		return errors.Wrapf(err, "error writing file: %v", tmpFile.Name())
+	}
+	if err = tmpFile.Sync(); err != nil {
+		_ = tmpFile.Close()
+		return errors.Wrapf(err, "error syncing file: %v", tmpFile.Name())
+	}
+	if err = tmpFile.Close(); err != nil {
+		return errors.Wrapf(err, "error closing file: %v", tmpFile.Name())
+	}
+
+	if err = os.Rename(tmpFile.Name(), fileName); err != nil {
+		return errors.Wrapf(err, "error replacing file: %v", fileName)
+	}
+
+	return syncDir(filepath.Dir(fileName))
+}
+
+// syncDir syncs the directory, so the file rename in it is persisted
+func syncDir(dirName string) error {
+	dir, err := os.Open(filepath.Clean(dirName))
+	if err != nil {
+		return errors.Wrapf(err, "error opening directory: %v", dirName)
+	}
+	defer func() { _ = dir.Close() }()
+
+	return errors.Wrapf(dir.Sync(), "error syncing directory: %v", dirName)
+}